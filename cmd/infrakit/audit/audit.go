@@ -0,0 +1,130 @@
+package audit // import "github.com/docker/infrakit/cmd/infrakit/audit"
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/infrakit/cmd/infrakit/base"
+
+	"github.com/docker/infrakit/pkg/cli"
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/client"
+	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/spf13/cobra"
+)
+
+var log = logutil.New("module", "cli/audit")
+
+func init() {
+	base.Register(Command)
+}
+
+// Command is the entrypoint
+func Command(scope scope.Scope) *cobra.Command {
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Audit the mutating calls to plugins",
+	}
+
+	ls := &cobra.Command{
+		Use:   "ls [plugin ...]",
+		Short: "List the audit records of the plugins. Args are the plugin names, all plugins if none",
+	}
+	since := ls.Flags().String("since", "", "Records since the duration ago, e.g. 1h, or the RFC3339 time")
+	kind := ls.Flags().String("kind", "", "Records of the kind, e.g. SetSize, or the method, e.g. Group.SetSize")
+	quiet := ls.Flags().BoolP("quiet", "q", false, "Print rows without column headers")
+	outputFlags, output := cli.Output()
+	ls.Flags().AddFlagSet(outputFlags)
+	ls.RunE = func(c *cobra.Command, args []string) error {
+
+		from, err := parseSince(*since, time.Now())
+		if err != nil {
+			return err
+		}
+
+		entries, err := scope.Plugins().List()
+		if err != nil {
+			return err
+		}
+
+		names := args
+		if len(names) == 0 {
+			for name := range entries {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+
+		records := []rpc.AuditRecord{}
+		seen := map[string]bool{}
+		for _, name := range names {
+			entry, has := entries[name]
+			if !has {
+				return fmt.Errorf("plugin not found: %v", name)
+			}
+			if seen[entry.Address] {
+				continue
+			}
+			seen[entry.Address] = true
+
+			infoClient, err := client.NewPluginInfoClient(entry.Address)
+			if err != nil {
+				log.Warn("cannot connect", "err", err, "addr", entry.Address)
+				continue
+			}
+			found, err := infoClient.GetAudit(from, *kind)
+			if err != nil {
+				log.Warn("cannot get audit records", "err", err, "addr", entry.Address)
+				continue
+			}
+			records = append(records, found...)
+		}
+		sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+
+		return output(os.Stdout, records,
+			func(w io.Writer, v interface{}) error {
+				if !*quiet {
+					fmt.Fprintf(w, "%-32s%-20s%-24s%-20s%-14s%-34s%s\n",
+						"TIME", "PLUGIN", "METHOD", "CALLER", "DURATION", "FINGERPRINT", "RESULT")
+				}
+				for _, r := range records {
+					caller := r.Caller
+					if caller == "" {
+						caller = r.Remote
+					}
+					result := "ok"
+					if r.Error != "" {
+						result = r.Error
+					}
+					fmt.Fprintf(w, "%-32s%-20s%-24s%-20s%-14s%-34s%s\n",
+						r.Time.Format(time.RFC3339Nano), r.Plugin, r.Method, caller,
+						r.Duration.Round(time.Microsecond), r.Fingerprint, result)
+				}
+				return nil
+			})
+	}
+
+	cmd.AddCommand(ls)
+	return cmd
+}
+
+// parseSince returns the time of the since flag, which is either a duration before now or a RFC3339 time.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(since); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(since))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad since %v: not a duration or RFC3339 time", since)
+	}
+	return t, nil
+}
@@ -13,6 +13,7 @@ import (
 	_ "github.com/docker/infrakit/pkg/run/v0/inventory"
 	_ "github.com/docker/infrakit/pkg/run/v0/manager"
 	_ "github.com/docker/infrakit/pkg/run/v0/pool"
+	_ "github.com/docker/infrakit/pkg/run/v0/proxy"
 	_ "github.com/docker/infrakit/pkg/run/v0/resource"
 	_ "github.com/docker/infrakit/pkg/run/v0/selector"
 	_ "github.com/docker/infrakit/pkg/run/v0/simulator"
@@ -24,6 +24,7 @@ import (
 	"github.com/spf13/cobra"
 
 	// CLI commands
+	_ "github.com/docker/infrakit/cmd/infrakit/audit"
 	_ "github.com/docker/infrakit/cmd/infrakit/manager"
 	_ "github.com/docker/infrakit/cmd/infrakit/playbook"
 	_ "github.com/docker/infrakit/cmd/infrakit/plugin"
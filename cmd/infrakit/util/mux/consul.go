@@ -0,0 +1,42 @@
+package mux // import "github.com/docker/infrakit/cmd/infrakit/util/mux"
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/docker/infrakit/pkg/leader/consul"
+	"github.com/spf13/cobra"
+)
+
+func consulEnvironment(cfg *config) *cobra.Command {
+
+	hostname, _ := os.Hostname()
+
+	cmd := &cobra.Command{
+		Use:   "consul",
+		Short: "consul session lock for leader detection and storage",
+	}
+
+	address := cmd.Flags().String("address", consul.DefaultAddress, "Address of the Consul agent")
+	token := cmd.Flags().String("token", os.Getenv("CONSUL_HTTP_TOKEN"), "ACL token")
+	key := cmd.Flags().String("key", consul.DefaultKey, "Key of the lock")
+	id := cmd.Flags().String("name", hostname, "Name of this node, as the value of the lock")
+	ttl := cmd.Flags().Duration("ttl", consul.DefaultTTL, "TTL of the session holding the lock")
+
+	cmd.RunE = func(c *cobra.Command, args []string) error {
+
+		options := consul.Options{
+			Address:  *address,
+			Token:    *token,
+			Key:      *key,
+			Identity: *id,
+			TTL:      *ttl,
+		}
+		cfg.poller = consul.NewDetector(*cfg.pollInterval, http.DefaultClient, options)
+		cfg.store = consul.NewStore(http.DefaultClient, options)
+
+		return runMux(cfg)
+	}
+
+	return cmd
+}
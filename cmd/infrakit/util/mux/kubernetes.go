@@ -0,0 +1,49 @@
+package mux // import "github.com/docker/infrakit/cmd/infrakit/util/mux"
+
+import (
+	"os"
+	"time"
+
+	"github.com/docker/infrakit/pkg/leader/kubernetes"
+	k8s "github.com/docker/infrakit/pkg/util/kubernetes"
+	"github.com/spf13/cobra"
+)
+
+func kubernetesEnvironment(cfg *config) *cobra.Command {
+
+	hostname, _ := os.Hostname()
+
+	cmd := &cobra.Command{
+		Use:   "kubernetes",
+		Short: "kubernetes lease for leader detection and storage",
+	}
+
+	master := cmd.Flags().String("master", "", "Address of the API server, if not in the kubeconfig")
+	kubeconfig := cmd.Flags().String("kubeconfig", "", "Path to the kubeconfig file. In-cluster config if not set")
+	namespace := cmd.Flags().String("namespace", "default", "Namespace of the lease")
+	name := cmd.Flags().String("lease", kubernetes.DefaultName, "Name of the lease")
+	id := cmd.Flags().String("name", hostname, "Name of this node, as the holder of the lease")
+	leaseDuration := cmd.Flags().Duration("lease-duration", 15*time.Second, "Duration of the lease")
+
+	cmd.RunE = func(c *cobra.Command, args []string) error {
+
+		host, client, err := k8s.NewHTTPClient(k8s.ConnectInfo{Master: *master, Kubeconfig: *kubeconfig})
+		logger.Info("Connect to kubernetes", "host", host, "err", err)
+		if err != nil {
+			return err
+		}
+
+		options := kubernetes.Options{
+			Namespace:     *namespace,
+			Name:          *name,
+			Identity:      *id,
+			LeaseDuration: *leaseDuration,
+		}
+		cfg.poller = kubernetes.NewDetector(*cfg.pollInterval, host, client, options)
+		cfg.store = kubernetes.NewStore(host, client, options)
+
+		return runMux(cfg)
+	}
+
+	return cmd
+}
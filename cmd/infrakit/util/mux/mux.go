@@ -7,6 +7,7 @@ import (
 	"github.com/docker/infrakit/pkg/discovery"
 	"github.com/docker/infrakit/pkg/leader"
 	"github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	"github.com/docker/infrakit/pkg/rpc/mux"
 	"github.com/docker/infrakit/pkg/run/scope"
 	"github.com/spf13/cobra"
@@ -21,7 +22,7 @@ type config struct {
 	pollInterval *time.Duration
 	location     *string
 	plugins      func() discovery.Plugins
-	poller       *leader.Poller
+	poller       leader.Detector
 	store        leader.Store
 }
 
@@ -57,6 +58,8 @@ func Command(scp scope.Scope) *cobra.Command {
 		osEnvironment(config),
 		swarmEnvironment(config),
 		etcdEnvironment(config),
+		kubernetesEnvironment(config),
+		consulEnvironment(config),
 	)
 
 	return cmd
@@ -80,11 +83,16 @@ func runMux(config *config) error {
 	if err != nil {
 		return err
 	}
+	authz, err := auth.Default()
+	if err != nil {
+		return err
+	}
 	logger.Info("Starting mux server", "listen", *config.listen)
 	server, err := mux.NewServer(*config.listen, advertise.Host, config.plugins,
 		mux.Options{
 			Leadership: leadership,
 			Registry:   config.store,
+			Auth:       authz,
 		})
 	if err != nil {
 		return err
@@ -0,0 +1,73 @@
+package util // import "github.com/docker/infrakit/cmd/infrakit/util"
+
+import (
+	"fmt"
+
+	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/docker/infrakit/pkg/util/raft"
+	"github.com/spf13/cobra"
+)
+
+func raftCommand(scp scope.Scope) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "raft",
+		Short: "Membership of the Raft group of the managers",
+	}
+
+	url := cmd.PersistentFlags().String("admin", "http://localhost:24866", "URL of the admin endpoint of a member")
+	admin := func() *raft.Admin {
+		return &raft.Admin{URL: *url}
+	}
+
+	ls := &cobra.Command{
+		Use:   "ls",
+		Short: "List the members",
+	}
+	quiet := ls.Flags().BoolP("quiet", "q", false, "Print rows without column headers")
+	ls.RunE = func(c *cobra.Command, args []string) error {
+		members, err := admin().Members()
+		if err != nil {
+			return err
+		}
+		if !*quiet {
+			fmt.Printf("%-20s%-30s%s\n", "ID", "ADDRESS", "LEADER")
+		}
+		for _, m := range members {
+			leader := ""
+			if m.Leader {
+				leader = "*"
+			}
+			fmt.Printf("%-20s%-30s%s\n", m.ID, m.Address, leader)
+		}
+		return nil
+	}
+
+	join := &cobra.Command{
+		Use:   "join <id> <address>",
+		Short: "Add the node at the address of its transport, on the leader",
+	}
+	join.RunE = func(c *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			c.Usage()
+			return nil
+		}
+		logger.Info("Adding member", "id", args[0], "address", args[1])
+		return admin().Join(args[0], args[1])
+	}
+
+	remove := &cobra.Command{
+		Use:   "remove <id>",
+		Short: "Remove the member, on the leader",
+	}
+	remove.RunE = func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			c.Usage()
+			return nil
+		}
+		logger.Info("Removing member", "id", args[0])
+		return admin().Remove(args[0])
+	}
+
+	cmd.AddCommand(ls, join, remove)
+	return cmd
+}
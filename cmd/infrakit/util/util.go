@@ -29,6 +29,7 @@ func Command(scp scope.Scope) *cobra.Command {
 		init_cmd.Command(scp),
 		fileServerCommand(scp),
 		trackCommand(scp),
+		raftCommand(scp),
 	)
 
 	return util
@@ -11,6 +11,7 @@ import (
 	"path"
 
 	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/run/local"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -77,10 +78,20 @@ func httpClient(urlString string, opt Options) (*url.URL, *http.Client, *http.Tr
 	switch u.Scheme {
 
 	case "http", "https":
-		tsport := http.DefaultTransport
+		tsport := http.DefaultTransport.(*http.Transport)
+		tlsConfig, err := local.ClientTLS()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if tlsConfig != nil {
+			tsport = &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			}
+		}
 		return u, &http.Client{
 			Transport: tsport,
-		}, tsport.(*http.Transport), nil
+		}, tsport, nil
 	case "unix":
 		// unix: will look for a socket that matches the host name at a
 		// directory path set by environment variable.
@@ -131,6 +142,9 @@ func Subscribe(url, topic string, opt Options) (
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Connection", "keep-alive")
+	if token := local.AuthToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	streamCh := make(chan *types.Any)
 	doneCh := make(chan struct{})
@@ -28,6 +28,10 @@ type Interceptor struct {
 
 	// Post is called when the client disconnects.  This is optional.
 	Post func(topic string)
+
+	// Authorize is called before Pre to check that the caller of the request can subscribe to the topic.
+	// This is optional.
+	Authorize func(req *http.Request, topic string) error
 }
 
 // ServeHTTP calls the before and after subscribe methods.
@@ -40,6 +44,14 @@ func (i *Interceptor) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		topic = topic[1:]
 	}
 
+	if i.Authorize != nil {
+		if err := i.Authorize(req, topic); err != nil {
+			log.Warn("not authorized", "topic", topic, "err", err)
+			http.Error(rw, err.Error(), AuthorizeStatusCode(err))
+			return
+		}
+	}
+
 	err := i.Pre(topic, req.Header)
 	if err != nil {
 		log.Warn("error", "err", err)
@@ -65,6 +77,21 @@ func getStatusCode(e error) int {
 	}
 }
 
+// StatusCoder is implemented by the errors of Authorize that have their own status code, e.g. 403 Forbidden
+// for a caller that is authenticated but not allowed.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// AuthorizeStatusCode returns the status code of the error of Authorize.  It's 401 Unauthorized unless
+// the error is a StatusCoder.
+func AuthorizeStatusCode(e error) int {
+	if coder, is := e.(StatusCoder); is {
+		return coder.StatusCode()
+	}
+	return http.StatusUnauthorized
+}
+
 // ErrInvalidTopic is the error raised when topic is invalid
 type ErrInvalidTopic string
 
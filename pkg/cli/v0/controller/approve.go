@@ -0,0 +1,124 @@
+package controller // import "github.com/docker/infrakit/pkg/cli/v0/controller"
+
+import (
+	"fmt"
+	"io"
+	"os"
+	gopath "path"
+
+	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// Approve returns the approve command
+func Approve(name string, services *cli.Services) *cobra.Command {
+	approve := &cobra.Command{
+		Use:   "approve <name> [<id>...] | approve <id>",
+		Short: "Approve the halted changes of an object, e.g. a large number of removals, or the destroy of quarantined resources",
+	}
+	approve.Flags().AddFlagSet(services.OutputFlags)
+
+	approve.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) < 1 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		c, err := services.Scope.Controller(name)
+		if err != nil {
+			return nil
+		}
+		cli.MustNotNil(c, "controller not found", "name", name)
+
+		// quarantined returns true if the resource of the id is in the quarantine of the object
+		quarantined := func(object, id string) bool {
+			call, err := services.Scope.Metadata(gopath.Join(name, object, "quarantine", id))
+			if err != nil || call == nil {
+				return false
+			}
+			any, err := call.Plugin.Get(call.Key)
+			return err == nil && any != nil && string(any.Bytes()) != "null"
+		}
+
+		objects, err := c.Describe(&types.Metadata{Name: args[0]})
+		if err != nil {
+			return err
+		}
+
+		ids := args[1:]
+		if len(objects) == 0 && len(args) == 1 {
+			// look for the object with the id in quarantine
+			all, err := c.Describe(nil)
+			if err != nil {
+				return err
+			}
+			for _, object := range all {
+				if quarantined(object.Spec.Metadata.Name, args[0]) {
+					objects = []types.Object{object}
+					ids = args
+					break
+				}
+			}
+		}
+		if len(objects) == 0 {
+			return fmt.Errorf("not found: %v", args[0])
+		}
+
+		object := objects[0]
+		spec := object.Spec
+
+		if len(ids) > 0 {
+			for _, id := range ids {
+				if !quarantined(spec.Metadata.Name, id) {
+					return fmt.Errorf("not in quarantine: %v", id)
+				}
+			}
+
+			// the approval is only for this call and not stored with the spec
+			result, err := c.Commit(controller.Approve, types.Spec{
+				Kind:       spec.Kind,
+				Metadata:   spec.Metadata,
+				Properties: types.AnyValueMust(map[string][]string{"IDs": ids}),
+			})
+			if err != nil {
+				return err
+			}
+			return services.Output(os.Stdout, result,
+				func(w io.Writer, v interface{}) error {
+					fmt.Fprintf(w, "approved %v: %v\n", spec.Metadata.Name, ids)
+					return nil
+				})
+		}
+
+		state := struct {
+			Approval string
+		}{}
+		if object.State != nil {
+			// not all objects have the approval in the state
+			object.State.Decode(&state)
+		}
+		if state.Approval == "" {
+			return fmt.Errorf("nothing to approve: %v", args[0])
+		}
+
+		// the approval is only for this call and not stored with the spec
+		result, err := c.Commit(controller.Approve, types.Spec{
+			Kind:       spec.Kind,
+			Metadata:   spec.Metadata,
+			Properties: types.AnyValueMust(map[string]string{"Token": state.Approval}),
+		})
+		if err != nil {
+			return err
+		}
+
+		return services.Output(os.Stdout, result,
+			func(w io.Writer, v interface{}) error {
+				fmt.Fprintf(w, "approved %v: %v\n", spec.Metadata.Name, state.Approval)
+				return nil
+			})
+	}
+	return approve
+}
@@ -15,6 +15,10 @@ func init() {
 			//Controller,
 			Describe,
 			Commit,
+			Plan,
+			Drift,
+			Approve,
+			History,
 			Free,
 		})
 }
@@ -29,6 +33,10 @@ func Controller(name string, services *cli.Services) *cobra.Command {
 	controller.AddCommand(
 		Describe(name, services),
 		Commit(name, services),
+		Plan(name, services),
+		Drift(name, services),
+		Approve(name, services),
+		History(name, services),
 		Free(name, services),
 	)
 
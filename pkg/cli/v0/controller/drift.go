@@ -0,0 +1,103 @@
+package controller // import "github.com/docker/infrakit/pkg/cli/v0/controller"
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// Drift returns the drift command
+func Drift(name string, services *cli.Services) *cobra.Command {
+	drift := &cobra.Command{
+		Use:   "drift",
+		Short: "List the differences of managed objects from their specs",
+	}
+	drift.Flags().AddFlagSet(services.OutputFlags)
+
+	tags := drift.Flags().StringSlice("tags", []string{}, "Tags to filter")
+
+	drift.RunE = func(cmd *cobra.Command, args []string) error {
+
+		pluginName := plugin.Name(name)
+
+		controller, err := services.Scope.Controller(pluginName.String())
+		if err != nil {
+			return nil
+		}
+		cli.MustNotNil(controller, "controller not found", "name", name)
+
+		var q *types.Metadata
+
+		if len(args) == 1 {
+			s := (types.Metadata{
+				Name: args[0],
+			}).AddTagsFromStringSlice(*tags)
+			q = &s
+		}
+
+		collections, err := controller.Describe(q)
+		if err != nil {
+			return err
+		}
+
+		// structured form -- controller/internal/Item with the differences under drift
+		type difference struct {
+			Path     string
+			Desired  interface{}
+			Observed interface{}
+		}
+		type fsm struct {
+			Key  string
+			Data struct {
+				Drift []difference `json:"drift"`
+			}
+		}
+
+		type row struct {
+			Collection string
+			Key        string
+			difference
+		}
+
+		drifted := []row{}
+		for _, c := range collections {
+			if c.State == nil {
+				continue
+			}
+			list := []fsm{}
+			if err := c.State.Decode(&list); err != nil {
+				return err
+			}
+			for _, l := range list {
+				for _, d := range l.Data.Drift {
+					drifted = append(drifted, row{Collection: c.Spec.Metadata.Name, Key: l.Key, difference: d})
+				}
+			}
+		}
+
+		sort.SliceStable(drifted, func(i, j int) bool {
+			if drifted[i].Collection != drifted[j].Collection {
+				return drifted[i].Collection < drifted[j].Collection
+			}
+			return drifted[i].Key < drifted[j].Key
+		})
+
+		return services.Output(os.Stdout, drifted,
+			func(w io.Writer, v interface{}) error {
+
+				format := "%-20s  %-20s  %-25s  %-20v  %-20v\n"
+				fmt.Fprintf(w, format, "COLLECTION", "KEY", "PATH", "DESIRED", "OBSERVED")
+				for _, d := range drifted {
+					fmt.Fprintf(w, format, d.Collection, d.Key, d.Path, d.Desired, d.Observed)
+				}
+				return nil
+			})
+	}
+	return drift
+}
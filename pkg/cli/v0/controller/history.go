@@ -0,0 +1,139 @@
+package controller // import "github.com/docker/infrakit/pkg/cli/v0/controller"
+
+import (
+	"fmt"
+	"io"
+	"os"
+	gopath "path"
+	"sort"
+	"time"
+
+	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// History returns the history command
+func History(name string, services *cli.Services) *cobra.Command {
+	history := &cobra.Command{
+		Use:   "history <collection>",
+		Short: "Show the recorded changes of a collection, its contents at a time or when an instance was in it",
+	}
+	history.Flags().AddFlagSet(services.OutputFlags)
+
+	at := history.Flags().String("at", "", "Time in RFC3339 format (e.g. 2018-01-02T15:04:05Z) to show the contents at")
+	id := history.Flags().String("instance", "", "ID of the instance to show the first and last appearance of")
+
+	history.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		get := func(p string, v interface{}) error {
+			path := gopath.Join(name, args[0], "history", p)
+			call, err := services.Scope.Metadata(path)
+			if err != nil {
+				return err
+			}
+			if call == nil {
+				return fmt.Errorf("no history at %v", path)
+			}
+			any, err := call.Plugin.Get(call.Key)
+			if err != nil {
+				return err
+			}
+			if any == nil {
+				return fmt.Errorf("no history at %v", path)
+			}
+			return any.Decode(v)
+		}
+
+		switch {
+
+		case *id != "":
+			appearance := struct {
+				ID    string
+				Key   string
+				First time.Time
+				Last  time.Time
+				Gone  *time.Time
+			}{}
+			if err := get("instances/"+*id, &appearance); err != nil {
+				return err
+			}
+			return services.Output(os.Stdout, appearance,
+				func(w io.Writer, v interface{}) error {
+					gone := "-"
+					if appearance.Gone != nil {
+						gone = appearance.Gone.Format(time.RFC3339)
+					}
+					format := "%-20s  %-30s  %-25s  %-25s  %-25s\n"
+					fmt.Fprintf(w, format, "ID", "KEY", "FIRST", "LAST", "GONE")
+					fmt.Fprintf(w, format, appearance.ID, appearance.Key,
+						appearance.First.Format(time.RFC3339), appearance.Last.Format(time.RFC3339), gone)
+					return nil
+				})
+
+		case *at != "":
+			t, err := time.Parse(time.RFC3339Nano, *at)
+			if err != nil {
+				return err
+			}
+			contents := map[string]interface{}{}
+			if err := get("at/"+t.Format(time.RFC3339Nano), &contents); err != nil {
+				return err
+			}
+			return services.Output(os.Stdout, contents,
+				func(w io.Writer, v interface{}) error {
+					rows := [][2]string{}
+					var visit func(types.Path, interface{})
+					visit = func(p types.Path, v interface{}) {
+						m, is := v.(map[string]interface{})
+						if !is {
+							return
+						}
+						if id, has := m["ID"]; has {
+							rows = append(rows, [2]string{p.String(), fmt.Sprintf("%v", id)})
+							return
+						}
+						for k, c := range m {
+							visit(p.JoinString(k), c)
+						}
+					}
+					visit(types.Path{}, contents)
+					sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+					format := "%-30s  %-30s\n"
+					fmt.Fprintf(w, format, "KEY", "ID")
+					for _, r := range rows {
+						fmt.Fprintf(w, format, r[0], r[1])
+					}
+					return nil
+				})
+		}
+
+		events := []struct {
+			Time     time.Time
+			Key      string
+			Change   string
+			Instance struct {
+				ID string
+			}
+		}{}
+		if err := get("events", &events); err != nil {
+			return err
+		}
+		return services.Output(os.Stdout, events,
+			func(w io.Writer, v interface{}) error {
+				format := "%-25s  %-8s  %-30s  %-30s\n"
+				fmt.Fprintf(w, format, "TIME", "CHANGE", "KEY", "ID")
+				for _, e := range events {
+					fmt.Fprintf(w, format, e.Time.Format(time.RFC3339), e.Change, e.Key, e.Instance.ID)
+				}
+				return nil
+			})
+	}
+	return history
+}
@@ -0,0 +1,70 @@
+package controller // import "github.com/docker/infrakit/pkg/cli/v0/controller"
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// Plan returns the plan command
+func Plan(name string, services *cli.Services) *cobra.Command {
+	plan := &cobra.Command{
+		Use:   "plan <configuration url>",
+		Short: "Show the plan for committing a configuration without committing. Read from stdin if url is '-'",
+	}
+	plan.Flags().AddFlagSet(services.ProcessTemplateFlags)
+
+	destroy := plan.Flags().Bool("destroy", false, "Plan for destroying all resources under management.")
+
+	plan.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		c, err := services.Scope.Controller(name)
+		if err != nil {
+			return nil
+		}
+		cli.MustNotNil(c, "controller not found", "name", name)
+
+		view, err := services.ReadFromStdinIfElse(
+			func() bool { return args[0] == "-" },
+			func() (string, error) { return services.ProcessTemplate(args[0]) },
+			services.ToJSON,
+		)
+		if err != nil {
+			return err
+		}
+
+		spec := types.Spec{}
+		if err := types.AnyString(view).Decode(&spec); err != nil {
+			return err
+		}
+
+		op := controller.Enforce
+		if *destroy {
+			op = controller.Destroy
+		}
+
+		_, p, err := c.Plan(op, spec)
+		if err != nil {
+			return err
+		}
+
+		return services.Output(os.Stdout, p,
+			func(w io.Writer, v interface{}) error {
+				for _, line := range p.Message {
+					fmt.Fprintln(w, line)
+				}
+				return nil
+			})
+	}
+	return plan
+}
@@ -18,6 +18,11 @@ func init() {
 			Inspect,
 			Specs,
 			Terminate,
+			History,
+			Diff,
+			Rollback,
+			Freeze,
+			Unfreeze,
 		})
 }
 
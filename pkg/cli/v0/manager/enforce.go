@@ -2,9 +2,12 @@ package manager // import "github.com/docker/infrakit/pkg/cli/v0/manager"
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/spi/stack"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/spf13/cobra"
 )
@@ -12,13 +15,15 @@ import (
 // Enforce returns the enforce command
 func Enforce(name string, services *cli.Services) *cobra.Command {
 
-	pretend := true
+	plan := true
 	enforce := &cobra.Command{
 		Use:   "enforce <global specs url>",
 		Short: "Enforce global stack specification. Read from stdin if url is '-'",
 	}
 	enforce.Flags().AddFlagSet(services.ProcessTemplateFlags)
-	enforce.Flags().BoolVar(&pretend, "pretend", pretend, "Don't actually commit, only explain where appropriate")
+	enforce.Flags().BoolVar(&plan, "plan", plan, "Don't actually commit, only show the changes planned by the groups and controllers")
+	enforce.Flags().BoolVar(&plan, "pretend", plan, "Same as --plan")
+	enforce.Flags().MarkDeprecated("pretend", "use --plan")
 
 	enforce.RunE = func(cmd *cobra.Command, args []string) error {
 
@@ -47,37 +52,38 @@ func Enforce(name string, services *cli.Services) *cobra.Command {
 			return err
 		}
 
-		if pretend {
-
-			// TODO - we should implement this on the server-side, but this requires
-			// a change to the stack SPI which is too large for this PR.
-
-			before, err := stack.Specs()
+		if plan {
+			p, err := stack.Plan(specs)
 			if err != nil {
 				return err
 			}
-
-			changes := types.Specs(before).Changes(specs)
-
-			for action, specs := range map[string]interface{}{
-				"ADD":    changes.Add,
-				"REMOVE": changes.Remove,
-				"CHANGE": changes.Changes,
-			} {
-				fmt.Printf("\n%v\n", action)
-				any := types.AnyValueMust(specs)
-				buff, err := any.MarshalYAML()
-				if err != nil {
-					return err
-				}
-				fmt.Print(string(buff))
-				fmt.Println()
-			}
-
-			return nil
+			return services.Output(os.Stdout, p, printPlan)
 		}
 
 		return stack.Enforce(specs)
 	}
 	return enforce
 }
+
+func printPlan(w io.Writer, v interface{}) error {
+	p := v.(stack.Plan)
+	counts := map[stack.ChangeType]int{}
+	for _, change := range p.Changes {
+		counts[change.Type]++
+		fmt.Fprintf(w, "%-10s %s/%s\n", strings.ToUpper(string(change.Type)), change.Kind, change.Name)
+		for _, line := range change.Message {
+			fmt.Fprintf(w, "           %s\n", line)
+		}
+		if change.Error != "" {
+			fmt.Fprintf(w, "           error: %s\n", change.Error)
+		}
+		if change.Diff != "" {
+			fmt.Fprintln(w)
+			fmt.Fprint(w, change.Diff)
+			fmt.Fprintln(w)
+		}
+	}
+	fmt.Fprintf(w, "\n%d added, %d changed, %d removed, %d unchanged\n",
+		counts[stack.Added], counts[stack.Changed], counts[stack.Removed], counts[stack.Unchanged])
+	return nil
+}
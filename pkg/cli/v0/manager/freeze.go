@@ -0,0 +1,65 @@
+package manager // import "github.com/docker/infrakit/pkg/cli/v0/manager"
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/infrakit/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+// Freeze returns the freeze command
+func Freeze(name string, services *cli.Services) *cobra.Command {
+	freeze := &cobra.Command{
+		Use:   "freeze",
+		Short: "Freeze stops all automated changes.  The groups and controllers report drift but do not provision or destroy",
+	}
+
+	reason := freeze.Flags().String("reason", "", "Reason of the freeze")
+	freeze.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 0 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		if *reason == "" {
+			return fmt.Errorf("--reason is required")
+		}
+
+		stack, err := services.Scope.Stack(name)
+		if err != nil {
+			return err
+		}
+		cli.MustNotNil(stack, "stack plugin not found", "name", name)
+
+		return stack.Freeze(*reason)
+	}
+	return freeze
+}
+
+// Unfreeze returns the unfreeze command
+func Unfreeze(name string, services *cli.Services) *cobra.Command {
+	unfreeze := &cobra.Command{
+		Use:   "unfreeze",
+		Short: "Unfreeze resumes the automated changes",
+	}
+
+	reason := unfreeze.Flags().String("reason", "", "Reason of lifting the freeze")
+	unfreeze.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 0 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		stack, err := services.Scope.Stack(name)
+		if err != nil {
+			return err
+		}
+		cli.MustNotNil(stack, "stack plugin not found", "name", name)
+
+		return stack.Unfreeze(*reason)
+	}
+	return unfreeze
+}
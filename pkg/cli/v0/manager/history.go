@@ -0,0 +1,152 @@
+package manager // import "github.com/docker/infrakit/pkg/cli/v0/manager"
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/spi/stack"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+)
+
+// History returns the history command
+func History(name string, services *cli.Services) *cobra.Command {
+	history := &cobra.Command{
+		Use:   "history",
+		Short: "History lists the revisions of the specs of the stack",
+	}
+	history.Flags().AddFlagSet(services.OutputFlags)
+
+	history.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 0 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		stack, err := services.Scope.Stack(name)
+		if err != nil {
+			return err
+		}
+		cli.MustNotNil(stack, "stack plugin not found", "name", name)
+
+		revisions, err := stack.History()
+		if err != nil {
+			return err
+		}
+
+		return services.Output(os.Stdout, revisions,
+			func(w io.Writer, v interface{}) error {
+				format := "%-8s  %-25s  %-15s  %-32s  %s\n"
+				fmt.Fprintf(w, format, "REVISION", "TIME", "AUTHOR", "FINGERPRINT", "MESSAGE")
+				for _, r := range revisions {
+					fmt.Fprintf(w, format, strconv.Itoa(r.Number), r.Time.Format(time.RFC3339), r.Author,
+						r.Fingerprint, r.Message)
+				}
+				return nil
+			})
+	}
+	return history
+}
+
+func findRevision(revisions []stack.Revision, arg string) (stack.Revision, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return stack.Revision{}, err
+	}
+	for _, r := range revisions {
+		if r.Number == n {
+			return r, nil
+		}
+	}
+	return stack.Revision{}, fmt.Errorf("no revision %v", n)
+}
+
+// Diff returns the diff command
+func Diff(name string, services *cli.Services) *cobra.Command {
+	diff := &cobra.Command{
+		Use:   "diff <rev1> <rev2>",
+		Short: "Diff shows the changes of the specs from one revision to another",
+	}
+
+	diff.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 2 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		stack, err := services.Scope.Stack(name)
+		if err != nil {
+			return err
+		}
+		cli.MustNotNil(stack, "stack plugin not found", "name", name)
+
+		revisions, err := stack.History()
+		if err != nil {
+			return err
+		}
+
+		text := []string{}
+		for _, arg := range args {
+			r, err := findRevision(revisions, arg)
+			if err != nil {
+				return err
+			}
+			buff, err := types.AnyValueMust(r.Specs).MarshalYAML()
+			if err != nil {
+				return err
+			}
+			text = append(text, string(buff))
+		}
+
+		out, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(text[0]),
+			B:        difflib.SplitLines(text[1]),
+			FromFile: "revision " + args[0],
+			ToFile:   "revision " + args[1],
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	}
+	return diff
+}
+
+// Rollback returns the rollback command
+func Rollback(name string, services *cli.Services) *cobra.Command {
+	rollback := &cobra.Command{
+		Use:   "rollback <rev>",
+		Short: "Rollback enforces the specs of a revision.  The objects committed after the revision are freed",
+	}
+
+	rollback.RunE = func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		stack, err := services.Scope.Stack(name)
+		if err != nil {
+			return err
+		}
+		cli.MustNotNil(stack, "stack plugin not found", "name", name)
+
+		return stack.Rollback(n)
+	}
+	return rollback
+}
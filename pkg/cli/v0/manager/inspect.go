@@ -2,9 +2,11 @@ package manager // import "github.com/docker/infrakit/pkg/cli/v0/manager"
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -12,9 +14,11 @@ import (
 func Inspect(name string, services *cli.Services) *cobra.Command {
 	inspect := &cobra.Command{
 		Use:   "inspect",
-		Short: "Inspect returns the specs for the entire stack",
+		Short: "Inspect returns the objects of the entire stack, as described by the groups and controllers",
 	}
 
+	inspect.Flags().AddFlagSet(services.OutputFlags)
+	namespace := inspect.Flags().String("namespace", "", "Show only the objects of the namespace. Empty is the default namespace")
 	inspect.RunE = func(cmd *cobra.Command, args []string) error {
 
 		if len(args) != 0 {
@@ -22,8 +26,40 @@ func Inspect(name string, services *cli.Services) *cobra.Command {
 			os.Exit(1)
 		}
 
-		fmt.Println("coming soon")
-		return nil
+		stack, err := services.Scope.Stack(name)
+		if err != nil {
+			return err
+		}
+		cli.MustNotNil(stack, "stack plugin not found", "name", name)
+
+		objects, err := stack.Inspect()
+		if err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("namespace") {
+			filtered := []types.Object{}
+			for _, object := range objects {
+				if object.Metadata.Namespace == *namespace {
+					filtered = append(filtered, object)
+				}
+			}
+			objects = filtered
+		}
+
+		return services.Output(os.Stdout, objects,
+			func(w io.Writer, v interface{}) error {
+				format := "%-15s  %-15s  %-30s  %s\n"
+				fmt.Fprintf(w, format, "NAMESPACE", "KIND", "NAME", "ID")
+				for _, object := range objects {
+					id := ""
+					if object.Metadata.Identity != nil {
+						id = object.Metadata.Identity.ID
+					}
+					fmt.Fprintf(w, format, object.Metadata.Namespace, object.Kind, object.Metadata.Name, id)
+				}
+				return nil
+			})
 	}
 	return inspect
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +16,7 @@ func Specs(name string, services *cli.Services) *cobra.Command {
 	}
 
 	specs.Flags().AddFlagSet(services.OutputFlags)
+	namespace := specs.Flags().String("namespace", "", "Show only the specs of the namespace. Empty is the default namespace")
 	specs.RunE = func(cmd *cobra.Command, args []string) error {
 
 		if len(args) != 0 {
@@ -32,7 +34,22 @@ func Specs(name string, services *cli.Services) *cobra.Command {
 			return err
 		}
 
+		if cmd.Flags().Changed("namespace") {
+			specs = inNamespace(specs, *namespace)
+		}
+
 		return services.Output(os.Stdout, specs, nil)
 	}
 	return specs
 }
+
+// inNamespace returns the specs in the namespace
+func inNamespace(specs []types.Spec, namespace string) []types.Spec {
+	out := []types.Spec{}
+	for _, spec := range specs {
+		if spec.Metadata.Namespace == namespace {
+			out = append(out, spec)
+		}
+	}
+	return out
+}
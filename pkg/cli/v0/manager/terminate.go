@@ -5,24 +5,64 @@ import (
 	"os"
 
 	"github.com/docker/infrakit/pkg/cli"
+	"github.com/docker/infrakit/pkg/types"
 	"github.com/spf13/cobra"
 )
 
 // Terminate returns the terminate command
 func Terminate(name string, services *cli.Services) *cobra.Command {
 	terminate := &cobra.Command{
-		Use:   "terminate",
-		Short: "Terminate returns the specs for the entire stack",
+		Use:   "terminate [<specs url>]",
+		Short: "Terminate destroys the resources of the specs and removes them. Read from stdin if url is '-'",
 	}
+	terminate.Flags().AddFlagSet(services.ProcessTemplateFlags)
+	namespace := terminate.Flags().String("namespace", "",
+		"Terminate all the specs of the namespace, if no url is given. Empty is the default namespace")
 
 	terminate.RunE = func(cmd *cobra.Command, args []string) error {
 
-		if len(args) != 0 {
+		if len(args) > 1 || (len(args) == 0 && !cmd.Flags().Changed("namespace")) {
 			cmd.Usage()
 			os.Exit(1)
 		}
 
-		fmt.Println("coming soon")
+		stack, err := services.Scope.Stack(name)
+		if err != nil {
+			return err
+		}
+		cli.MustNotNil(stack, "stack plugin not found", "name", name)
+
+		specs := []types.Spec{}
+		if len(args) == 1 {
+			view, err := services.ReadFromStdinIfElse(
+				func() bool { return args[0] == "-" },
+				func() (string, error) { return services.ProcessTemplate(args[0]) },
+				services.ToJSON,
+			)
+			if err != nil {
+				return err
+			}
+			if err := types.AnyString(view).Decode(&specs); err != nil {
+				return err
+			}
+		} else {
+			all, err := stack.Specs()
+			if err != nil {
+				return err
+			}
+			specs = inNamespace(all, *namespace)
+		}
+
+		if err := stack.Terminate(specs); err != nil {
+			return err
+		}
+		for _, spec := range specs {
+			if spec.Metadata.Namespace != "" {
+				fmt.Printf("terminated %v:%v/%v\n", spec.Metadata.Namespace, spec.Kind, spec.Metadata.Name)
+				continue
+			}
+			fmt.Printf("terminated %v/%v\n", spec.Kind, spec.Metadata.Name)
+		}
 		return nil
 	}
 	return terminate
@@ -56,6 +56,19 @@ func NewTypedControllers(scope scope.Scope,
 	)).Controllers
 }
 
+// Components contains a set of components in this controller.
+type Components = internal.Components
+
+// NewComponents returns the controllers and the events of the enrollments
+func NewComponents(scope scope.Scope, options enrollment.Options) *Components {
+	return internal.NewComponents(
+		func(spec types.Spec) (internal.Managed, error) {
+			log.Debug("Creating managed object", "spec", spec)
+			return newEnroller(scope, options)
+		},
+	)
+}
+
 func (l *enroller) started() bool {
 	l.lock.RLock()
 	defer l.lock.RUnlock()
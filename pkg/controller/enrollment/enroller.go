@@ -36,13 +36,25 @@ type enroller struct {
 
 	scope scope.Scope
 
-	poller *internal.Poller
-	ticker <-chan time.Time
-	lock   sync.RWMutex
-
-	groupPlugin    group.Plugin    // source -- where members are to be enrolled
-	instancePlugin instance.Plugin // sink -- where enrollments are made
-	running        bool
+	poller   *internal.Poller
+	triggers chan time.Time // a sync is run for each value, sent at intervals or on source events
+	done     chan struct{}
+	lock     sync.RWMutex
+
+	groupPlugin      group.Plugin          // source -- where members are to be enrolled
+	controllerPlugin controller.Controller // source -- resource collections
+	metadataPlugin   metadata.Plugin       // source -- lists at metadata paths
+	instancePlugin   instance.Plugin       // sink -- where enrollments are made
+	running          bool
+
+	halted   *halt               // the halted sync, if any
+	approved string              // the approval token of the halted sync, which is not stored
+	events   chan<- *event.Event // where events are published
+
+	// subscriptions to the event topics of the sources, by topic
+	subscriptions     map[string]chan<- struct{}
+	subscriptionsLock sync.Mutex
+	subscribeFunc     func(types.Path) (<-chan *event.Event, chan<- struct{}, error)
 
 	// template that we use to render with a source instance.Description to get the link Key
 	sourceKeySelectorTemplate *template.Template
@@ -54,13 +66,14 @@ type enroller struct {
 
 func newEnroller(scope scope.Scope, options enrollment.Options) (*enroller, error) {
 	l := &enroller{
-		scope:   scope,
-		options: options,
+		scope:         scope,
+		options:       options,
+		triggers:      make(chan time.Time, 1),
+		subscriptions: map[string]chan<- struct{}{},
 	}
 	if err := l.options.Validate(enrollment.PluginInit); err != nil {
 		return nil, err
 	}
-	l.ticker = time.Tick(l.options.SyncInterval.Duration())
 
 	l.poller = internal.Poll(
 		// This determines if the action should be taken when time is up
@@ -71,7 +84,7 @@ func newEnroller(scope scope.Scope, options enrollment.Options) (*enroller, erro
 		func() (err error) {
 			return l.sync()
 		},
-		l.ticker)
+		l.triggers)
 
 	return l, nil
 }
@@ -100,15 +113,20 @@ func (l *enroller) Metadata() metadata.Plugin {
 
 // Events returns events plugin implementation. Optional; ok to be nil
 func (l *enroller) Events() event.Plugin {
-	return nil
+	return l
 }
 
 // object returns the state
 func (l *enroller) object() (*types.Object, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
 	object := types.Object{
 		Spec: l.spec,
 	}
-	// TODO build the current state
+	if l.halted != nil {
+		object.State = types.AnyValueMust(l.halted)
+	}
 	return &object, nil
 }
 
@@ -123,17 +141,48 @@ func (l *enroller) Plan(operation controller.Operation, spec types.Spec) (*types
 	if spec.Properties == nil {
 		return nil, nil, fmt.Errorf("missing properties")
 	}
-	properties := enrollment.Properties{}
-	err := spec.Properties.Decode(&properties)
+	// plan with a copy that has the spec but shares the plugins and the approval
+	l.lock.RLock()
+	approved := l.approved
+	l.lock.RUnlock()
+	p := &enroller{
+		approved:         approved,
+		scope:            l.scope,
+		options:          l.options,
+		groupPlugin:      l.groupPlugin,
+		controllerPlugin: l.controllerPlugin,
+		metadataPlugin:   l.metadataPlugin,
+		instancePlugin:   l.instancePlugin,
+	}
+	if err := p.updateSpec(spec); err != nil {
+		return nil, nil, err
+	}
+
+	add, remove, enrolled, err := p.delta()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// TODO - build a plan
-	return &types.Object{
-		Spec: spec,
-	}, &controller.Plan{}, nil
+	plan := controller.Plan{Message: []string{}}
+	for _, n := range add {
+		plan.Message = append(plan.Message, fmt.Sprintf("enroll %v", n.ID))
+	}
+	for _, n := range remove {
+		plan.Message = append(plan.Message, fmt.Sprintf("remove %v", n.ID))
+	}
 
+	halted, err := p.checkRemovals(remove, len(enrolled))
+	if err != nil {
+		return nil, nil, err
+	}
+	object, _ := p.object()
+	if halted != nil {
+		object.State = types.AnyValueMust(halted)
+		plan.Message = append(plan.Message,
+			fmt.Sprintf("halt: %d removals exceed the max of %d; approve with %v",
+				len(remove), halted.Limit, halted.Approval))
+	}
+	return object, &plan, nil
 }
 
 func (l *enroller) updateSpec(spec types.Spec) error {
@@ -230,6 +279,10 @@ func (l *enroller) Start() {
 	defer l.lock.Unlock()
 
 	if l.poller != nil {
+		if l.done == nil {
+			l.done = make(chan struct{})
+			go l.tick(l.options.SyncInterval.Duration(), l.done)
+		}
 		go l.poller.Run(context.Background())
 		l.running = true
 	}
@@ -243,6 +296,11 @@ func (l *enroller) Stop() error {
 	if l.poller != nil {
 		l.poller.Stop()
 	}
+	if l.done != nil {
+		close(l.done)
+		l.done = nil
+	}
+	l.unsubscribe()
 	return nil
 }
 
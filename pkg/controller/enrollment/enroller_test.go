@@ -10,11 +10,15 @@ import (
 	"github.com/docker/infrakit/pkg/discovery"
 	"github.com/docker/infrakit/pkg/plugin"
 	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
 	"github.com/docker/infrakit/pkg/spi/stack"
+	controller_test "github.com/docker/infrakit/pkg/testing/controller"
 	group_test "github.com/docker/infrakit/pkg/testing/group"
 	instance_test "github.com/docker/infrakit/pkg/testing/instance"
+	metadata_test "github.com/docker/infrakit/pkg/testing/metadata"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/stretchr/testify/require"
 )
@@ -734,3 +738,212 @@ options:
 	require.Equal(t, enrollment.SourceParseErrorDisableDestroy, enroller.options.SourceParseErrPolicy)
 	require.Equal(t, enrollment.EnrolledParseErrorDisableProvision, enroller.options.EnrollmentParseErrPolicy)
 }
+
+func TestEnrollerSources(t *testing.T) {
+
+	enroller, err := newEnroller(
+		scope.DefaultScope(func() discovery.Plugins {
+			return fakePlugins{
+				"test": &plugin.Endpoint{},
+			}
+		}),
+		DefaultOptions)
+	require.NoError(t, err)
+	enroller.groupPlugin = &group_test.Plugin{
+		DoDescribeGroup: func(gid group.ID) (group.Description, error) {
+			return group.Description{Instances: []instance.Description{
+				{ID: instance.ID("h1")},
+				{ID: instance.ID("h2")},
+			}}, nil
+		},
+	}
+	enroller.controllerPlugin = &controller_test.Controller{
+		DoDescribe: func(search *types.Metadata) ([]types.Object, error) {
+			require.Equal(t, "workers", search.Name)
+			return []types.Object{
+				{
+					State: types.AnyValueMust([]interface{}{
+						map[string]interface{}{"Key": "w1", "Data": map[string]interface{}{
+							"instance": instance.Description{
+								ID: instance.ID("h2"), Tags: map[string]string{"from": "resource"}},
+						}},
+						map[string]interface{}{"Key": "w2", "Data": map[string]interface{}{
+							"instance": instance.Description{ID: instance.ID("h3")},
+						}},
+						map[string]interface{}{"Key": "w3", "Data": map[string]interface{}{}},
+					}),
+				},
+			}, nil
+		},
+	}
+	enroller.metadataPlugin = &metadata_test.Plugin{
+		DoGet: func(path types.Path) (*types.Any, error) {
+			require.Equal(t, types.PathFromString("hosts/list"), path)
+			return types.AnyValueMust([]instance.Description{
+				{ID: instance.ID("h3"), Tags: map[string]string{"from": "metadata"}},
+				{ID: instance.ID("h4")},
+			}), nil
+		},
+	}
+
+	streams := make(chan chan *event.Event, 1)
+	enroller.subscribeFunc = func(topic types.Path) (<-chan *event.Event, chan<- struct{}, error) {
+		require.Equal(t, types.PathFromString("resource/ready"), topic)
+		stream := make(chan *event.Event)
+		streams <- stream
+		return stream, make(chan struct{}), nil
+	}
+
+	spec := types.Spec{}
+	require.NoError(t, types.AnyYAMLMust([]byte(`
+kind: enrollment
+metadata:
+  name: nfs
+properties:
+  List: group/workers
+  Sources:
+    - Resource: resource/workers
+      Topic: resource/ready
+    - Metadata: vars/hosts/list
+  Instance:
+    Plugin: nfs/authorization
+`)).Decode(&spec))
+	require.NoError(t, enroller.updateSpec(spec))
+
+	s, err := enroller.getSourceInstances()
+	require.NoError(t, err)
+	require.Equal(t, []instance.Description{
+		{ID: instance.ID("h1")},
+		{ID: instance.ID("h2")},
+		{ID: instance.ID("h3")},
+		{ID: instance.ID("h4")},
+	}, s)
+
+	// any source failing means no list
+	enroller.metadataPlugin = &metadata_test.Plugin{
+		DoGet: func(path types.Path) (*types.Any, error) {
+			return nil, fmt.Errorf("boom")
+		},
+	}
+	_, err = enroller.getSourceInstances()
+	require.Error(t, err)
+
+	// events on the topic of a source trigger a sync
+	enroller.subscribe()
+	stream := <-streams
+	stream <- &event.Event{}
+	select {
+	case <-enroller.triggers:
+	case <-time.After(1 * time.Second):
+		require.Fail(t, "no sync triggered")
+	}
+
+	// subscriptions are made once
+	enroller.subscribe()
+	require.Equal(t, 0, len(streams))
+
+	enroller.unsubscribe()
+	require.Equal(t, 0, len(enroller.subscriptions))
+}
+
+func TestEnrollerMaxRemovals(t *testing.T) {
+
+	enrolled := []instance.Description{
+		{ID: instance.ID("nfs1"), Tags: map[string]string{"infrakit.enrollment.sourceID": "h1"}},
+		{ID: instance.ID("nfs2"), Tags: map[string]string{"infrakit.enrollment.sourceID": "h2"}},
+		{ID: instance.ID("nfs3"), Tags: map[string]string{"infrakit.enrollment.sourceID": "h3"}},
+		{ID: instance.ID("nfs4"), Tags: map[string]string{"infrakit.enrollment.sourceID": "h4"}},
+	}
+
+	seen := make(chan []interface{}, 10)
+
+	enroller, err := newEnroller(
+		scope.DefaultScope(func() discovery.Plugins {
+			return fakePlugins{
+				"test": &plugin.Endpoint{},
+			}
+		}),
+		DefaultOptions)
+	require.NoError(t, err)
+	enroller.groupPlugin = &group_test.Plugin{
+		DoDescribeGroup: func(gid group.ID) (group.Description, error) {
+			return group.Description{Instances: []instance.Description{
+				{ID: instance.ID("h1")},
+				{ID: instance.ID("h5")},
+			}}, nil
+		},
+	}
+	enroller.instancePlugin = &instance_test.Plugin{
+		DoDescribeInstances: func(t map[string]string, p bool) ([]instance.Description, error) {
+			return enrolled, nil
+		},
+		DoProvision: func(spec instance.Spec) (*instance.ID, error) {
+			seen <- []interface{}{spec.Tags["infrakit.enrollment.sourceID"], "Provision"}
+			return nil, nil
+		},
+		DoDestroy: func(id instance.ID, ctx instance.Context) error {
+			seen <- []interface{}{id, "Destroy"}
+			return nil
+		},
+	}
+	events := make(chan *event.Event, 10)
+	enroller.PublishOn(events)
+
+	spec := types.Spec{}
+	require.NoError(t, types.AnyYAMLMust([]byte(`
+kind: enrollment
+metadata:
+  name: nfs
+properties:
+  List: group/workers
+  Instance:
+    Plugin: nfs/authorization
+options:
+  MaxRemovals: 50%
+`)).Decode(&spec))
+
+	_, plan, err := enroller.Plan(controller.Enforce, spec)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(plan.Message))
+	require.Equal(t, []string{"enroll h5", "remove nfs2", "remove nfs3", "remove nfs4"}, plan.Message[0:4])
+
+	require.NoError(t, enroller.updateSpec(spec))
+	require.NoError(t, enroller.sync())
+
+	// nothing is done and the sync is halted
+	require.Equal(t, 0, len(seen))
+	evt := <-events
+	require.Equal(t, types.PathFromString("nfs/halted"), evt.Topic)
+
+	object, err := enroller.Inspect()
+	require.NoError(t, err)
+	halted := halt{}
+	require.NoError(t, object.State.Decode(&halted))
+	require.Equal(t, []string{"nfs2", "nfs3", "nfs4"}, halted.Removals)
+	require.Equal(t, 2, halted.Limit)
+	require.Equal(t, fmt.Sprintf("halt: 3 removals exceed the max of 2; approve with %v", halted.Approval), plan.Message[4])
+
+	// approve the removals, without changing the spec
+	_, err = enroller.Approve(types.Spec{
+		Properties: types.AnyValueMust(enrollment.Approval{Token: "bad"}),
+	})
+	require.Error(t, err)
+
+	_, err = enroller.Approve(types.Spec{
+		Properties: types.AnyValueMust(enrollment.Approval{Token: halted.Approval}),
+	})
+	require.NoError(t, err)
+	options := map[string]interface{}{}
+	require.NoError(t, enroller.spec.Options.Decode(&options))
+	require.Equal(t, map[string]interface{}{"MaxRemovals": "50%"}, options)
+	require.NoError(t, enroller.sync())
+
+	require.Equal(t, []interface{}{"h5", "Provision"}, <-seen)
+	require.Equal(t, []interface{}{instance.ID("nfs2"), "Destroy"}, <-seen)
+	require.Equal(t, []interface{}{instance.ID("nfs3"), "Destroy"}, <-seen)
+	require.Equal(t, []interface{}{instance.ID("nfs4"), "Destroy"}, <-seen)
+
+	object, err = enroller.Inspect()
+	require.NoError(t, err)
+	require.Nil(t, object.State)
+}
@@ -0,0 +1,117 @@
+package enrollment // import "github.com/docker/infrakit/pkg/controller/enrollment"
+
+import (
+	"fmt"
+	"sort"
+
+	enrollment "github.com/docker/infrakit/pkg/controller/enrollment/types"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+var (
+	// TopicHalted is the topic for syncs halted because of too many removals
+	TopicHalted = types.PathFromString("halted")
+)
+
+// halt describes a sync that is halted because it would remove more entries than allowed.
+// It is the state of the enrollment until the removals are approved or no longer needed.
+type halt struct {
+
+	// Removals are the IDs of the enrolled instances to remove
+	Removals []string
+
+	// Limit is the max number of removals allowed
+	Limit int
+
+	// Approval is the token to approve with, to let the removals proceed
+	Approval string
+}
+
+// checkRemovals returns a halt if the removals exceed the max allowed and haven't been approved
+func (l *enroller) checkRemovals(remove []instance.Description, enrolled int) (*halt, error) {
+	limit, set, err := l.options.MaxRemovals.Limit(enrolled)
+	if err != nil || !set || len(remove) <= limit {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, n := range remove {
+		ids = append(ids, string(n.ID))
+	}
+	sort.Strings(ids)
+
+	approval := types.Fingerprint(types.AnyValueMust(ids))
+	l.lock.RLock()
+	approved := l.approved
+	l.lock.RUnlock()
+	if approved == approval {
+		log.Info("Removals approved", "removals", ids, "approval", approval)
+		return nil, nil
+	}
+	return &halt{
+		Removals: ids,
+		Limit:    limit,
+		Approval: approval,
+	}, nil
+}
+
+// Approve implements internal.Approver.  The approval lets the next sync proceed with the halted removals.
+// It's kept in memory and not stored with the spec.
+func (l *enroller) Approve(spec types.Spec) (*types.Object, error) {
+	if spec.Properties == nil {
+		return nil, fmt.Errorf("missing properties")
+	}
+	approval := enrollment.Approval{}
+	if err := spec.Properties.Decode(&approval); err != nil {
+		return nil, err
+	}
+
+	l.lock.Lock()
+	if l.halted == nil || l.halted.Approval != approval.Token {
+		l.lock.Unlock()
+		return nil, fmt.Errorf("no halted sync with approval %v", approval.Token)
+	}
+	l.approved = approval.Token
+	l.lock.Unlock()
+
+	log.Info("Approved halted removals", "name", l.spec.Metadata.Name, "approval", approval.Token)
+	return l.object()
+}
+
+func (l *enroller) setHalted(h *halt) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.halted = h
+}
+
+// topic returns the topic scoped by the name of the enrollment
+func (l *enroller) topic(p types.Path) types.Path {
+	return types.PathFromString(l.spec.Metadata.Name).Join(p)
+}
+
+// publish publishes the event, if there is a subscriber
+func (l *enroller) publish(evt *event.Event) {
+	l.lock.RLock()
+	events := l.events
+	l.lock.RUnlock()
+
+	if events != nil {
+		events <- evt
+	}
+}
+
+// List implements event.List
+func (l *enroller) List(topic types.Path) ([]string, error) {
+	return types.List(topic, map[string]interface{}{
+		TopicHalted.String(): nil,
+	}), nil
+}
+
+// PublishOn sets the channel to publish on
+func (l *enroller) PublishOn(events chan<- *event.Event) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = events
+}
@@ -0,0 +1,284 @@
+package enrollment // import "github.com/docker/infrakit/pkg/controller/enrollment"
+
+import (
+	"fmt"
+	"time"
+
+	enrollment "github.com/docker/infrakit/pkg/controller/enrollment/types"
+	"github.com/docker/infrakit/pkg/plugin"
+	event_rpc "github.com/docker/infrakit/pkg/rpc/event"
+	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// sources returns the sources of instances, in the order of precedence
+func (l *enroller) sources() []enrollment.Source {
+	sources := []enrollment.Source{}
+	if l.properties.List != nil {
+		sources = append(sources, enrollment.Source{List: l.properties.List})
+	}
+	return append(sources, l.properties.Sources...)
+}
+
+// getSourceInstances returns the instances of all the sources.  When the same key is in
+// more than one source, the instance of the source listed first is kept.  If any source
+// cannot be read, an error is returned so that a partial list doesn't cause removals.
+func (l *enroller) getSourceInstances() ([]instance.Description, error) {
+	sources := l.sources()
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no list source specified")
+	}
+	if len(sources) == 1 {
+		return l.getInstances(sources[0])
+	}
+
+	merged := []instance.Description{}
+	seen := map[string]bool{}
+	for _, source := range sources {
+		list, err := l.getInstances(source)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range list {
+			key, err := l.sourceKey(d)
+			if err == nil {
+				if seen[key] {
+					log.Debug("Ignoring instance from source of lower precedence", "key", key, "V", debugV2)
+					continue
+				}
+				seen[key] = true
+			}
+			merged = append(merged, d)
+		}
+	}
+	return merged, nil
+}
+
+// getInstances returns the instances of a source
+func (l *enroller) getInstances(source enrollment.Source) ([]instance.Description, error) {
+	switch {
+	case source.List != nil:
+		return l.getListInstances(source.List)
+	case source.Resource != "":
+		return l.getResourceInstances(source.Resource)
+	case source.Metadata != "":
+		return l.getMetadataInstances(source.Metadata)
+	}
+	return nil, fmt.Errorf("no list source specified")
+}
+
+func (l *enroller) getListInstances(u *enrollment.ListSourceUnion) ([]instance.Description, error) {
+	list, err := u.InstanceDescriptions()
+	if err != nil {
+
+		pn, err := u.GroupPlugin()
+		if err != nil {
+			return nil, fmt.Errorf("no list source specified")
+		}
+
+		log.Debug("no instances specified statically. querying group", "pluginName", pn)
+		gp, err := l.getGroupPlugin(pn)
+		if err != nil {
+			log.Error("cannot contact group", "group", pn)
+			return nil, fmt.Errorf("cannot connect to group %v", pn)
+		}
+
+		desc, err := gp.DescribeGroup(group.ID(pn.Type()))
+		if err != nil {
+			return nil, err
+		}
+
+		return desc.Instances, nil
+	}
+	return list, err
+}
+
+// getResourceInstances returns the instances observed by a collection of a resource controller
+func (l *enroller) getResourceInstances(name plugin.Name) ([]instance.Description, error) {
+	c, err := l.getController(name)
+	if err != nil {
+		log.Error("cannot contact controller", "controller", name)
+		return nil, fmt.Errorf("cannot connect to controller %v", name)
+	}
+
+	var search *types.Metadata
+	if t := name.Type(); t != "" {
+		search = &types.Metadata{Name: t}
+	}
+	objects, err := c.Describe(search)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []instance.Description{}
+	for _, object := range objects {
+		if object.State == nil {
+			continue
+		}
+		// structured form -- controller/internal/Item
+		items := []struct {
+			Data struct {
+				Instance *instance.Description `json:"instance"`
+			}
+		}{}
+		if err := object.State.Decode(&items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if item.Data.Instance != nil {
+				list = append(list, *item.Data.Instance)
+			}
+		}
+	}
+	return list, nil
+}
+
+// getMetadataInstances returns the list of instances at a metadata path
+func (l *enroller) getMetadataInstances(path string) ([]instance.Description, error) {
+	call, err := l.getMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	if call == nil {
+		return nil, fmt.Errorf("no metadata plugin for %v", path)
+	}
+	any, err := call.Plugin.Get(call.Key)
+	if err != nil {
+		return nil, err
+	}
+	if any == nil {
+		return nil, fmt.Errorf("no metadata at %v", path)
+	}
+	list := []instance.Description{}
+	return list, any.Decode(&list)
+}
+
+func (l *enroller) getController(name plugin.Name) (controller.Controller, error) {
+	if l.controllerPlugin != nil {
+		return l.controllerPlugin, nil
+	}
+	return l.scope.Controller(name.String())
+}
+
+func (l *enroller) getMetadata(path string) (*scope.MetadataCall, error) {
+	if l.metadataPlugin != nil {
+		return &scope.MetadataCall{
+			Plugin: l.metadataPlugin,
+			Key:    types.PathFromString(path).Shift(1),
+		}, nil
+	}
+	return l.scope.Metadata(path)
+}
+
+// trigger requests a sync without waiting for the next interval
+func (l *enroller) trigger() {
+	select {
+	case l.triggers <- time.Now():
+	default: // a sync is already pending
+	}
+}
+
+// tick triggers a sync at every interval until done is closed
+func (l *enroller) tick(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			l.trigger()
+		}
+	}
+}
+
+// subscribe makes sure there are subscriptions to the topics of the sources, so that
+// events on the topics trigger a sync.  Subscriptions that fail are retried on the next sync.
+func (l *enroller) subscribe() {
+	topics := map[string]bool{}
+	for _, source := range l.sources() {
+		if source.Topic != "" {
+			topics[source.Topic] = true
+		}
+	}
+
+	l.subscriptionsLock.Lock()
+	defer l.subscriptionsLock.Unlock()
+
+	for topic, stop := range l.subscriptions {
+		if !topics[topic] {
+			close(stop)
+			delete(l.subscriptions, topic)
+		}
+	}
+
+	for topic := range topics {
+		if _, has := l.subscriptions[topic]; has {
+			continue
+		}
+		stream, stop, err := l.subscribeOn(types.PathFromString(topic).Clean())
+		if err != nil {
+			log.Warn("Cannot subscribe to source topic", "topic", topic, "err", err)
+			continue
+		}
+		l.subscriptions[topic] = stop
+
+		go func(topic string) {
+			for range stream {
+				log.Debug("Source event; triggering sync", "topic", topic, "V", debugV)
+				l.trigger()
+			}
+			log.Info("Source topic disconnected", "topic", topic)
+
+			l.subscriptionsLock.Lock()
+			defer l.subscriptionsLock.Unlock()
+			if l.subscriptions[topic] == stop {
+				delete(l.subscriptions, topic)
+			}
+		}(topic)
+	}
+}
+
+// unsubscribe stops all subscriptions to the topics of the sources
+func (l *enroller) unsubscribe() {
+	l.subscriptionsLock.Lock()
+	defer l.subscriptionsLock.Unlock()
+
+	for topic, stop := range l.subscriptions {
+		close(stop)
+		delete(l.subscriptions, topic)
+	}
+}
+
+// subscribeOn subscribes to a topic, where the first element of the topic is the plugin
+func (l *enroller) subscribeOn(topic types.Path) (<-chan *event.Event, chan<- struct{}, error) {
+	if l.subscribeFunc != nil {
+		return l.subscribeFunc(topic)
+	}
+
+	first := topic.Index(0)
+	if first == nil {
+		return nil, nil, fmt.Errorf("bad topic %v", topic)
+	}
+	endpoint, err := l.scope.Plugins().Find(plugin.Name(*first))
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := event_rpc.NewClient(endpoint.Address)
+	if err != nil {
+		return nil, nil, err
+	}
+	subscriber, is := client.(event.Subscriber)
+	if !is {
+		return nil, nil, fmt.Errorf("not a subscriber: %v", *first)
+	}
+	sub := topic.Shift(1)
+	if sub.Len() == 0 {
+		sub = types.PathFromString(".")
+	}
+	return subscriber.SubscribeOn(sub)
+}
@@ -4,37 +4,31 @@ import (
 	"fmt"
 
 	enrollment "github.com/docker/infrakit/pkg/controller/enrollment/types"
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
 	"github.com/docker/infrakit/pkg/template"
 	"github.com/docker/infrakit/pkg/types"
 )
 
-func (l *enroller) getSourceInstances() ([]instance.Description, error) {
-	list, err := l.properties.List.InstanceDescriptions()
-	if err != nil {
-
-		pn, err := l.properties.List.GroupPlugin()
-		if err != nil {
-			return nil, fmt.Errorf("no list source specified")
-		}
-
-		log.Debug("no instances specified statically. querying group", "pluginName", pn)
-		gp, err := l.getGroupPlugin(pn)
-		if err != nil {
-			log.Error("cannot contact group", "group", pn)
-			return nil, fmt.Errorf("cannot connect to group %v", pn)
-		}
+// sourceKey returns the join key of a source instance
+func (l *enroller) sourceKey(d instance.Description) (string, error) {
 
-		desc, err := gp.DescribeGroup(group.ID(pn.Type()))
+	t, err := l.getSourceKeySelectorTemplate()
+	if err != nil {
+		return "", err
+	}
+	if t != nil {
+		view, err := t.Render(d)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
-
-		return desc.Instances, nil
+		return view, nil
 	}
-	return list, err
+
+	return string(d.ID), nil
 }
 
 func (l *enroller) getEnrolledInstances() ([]instance.Description, error) {
@@ -101,68 +95,14 @@ func (l *enroller) getEnrollmentPropertiesTemplate() (*template.Template, error)
 // run one synchronization round
 func (l *enroller) sync() error {
 
-	source, err := l.getSourceInstances()
-	if err != nil {
-		log.Error("Error getting sources. No action", "err", err)
-		return nil
-	}
+	l.subscribe()
 
-	enrolled, err := l.getEnrolledInstances()
+	add, remove, enrolled, err := l.delta()
 	if err != nil {
-		log.Error("Error getting enrollment. No action", "err", err)
+		log.Error("No action", "err", err)
 		return nil
 	}
 
-	// We need to compute a projection for each one of the vectors and compare
-	// them.  This is because instance IDs from the respective lists are likely
-	// to be different.  Instead there's a join key / common attribute somewhere
-	// embedded in the Description.Properties.
-	sourceKeyFunc := func(d instance.Description) (string, error) {
-
-		t, err := l.getSourceKeySelectorTemplate()
-		if err != nil {
-			return "", err
-		}
-		if t != nil {
-			view, err := t.Render(d)
-			if err != nil {
-				return "", err
-			}
-			return view, nil
-		}
-
-		return string(d.ID), nil
-	}
-
-	// If specified, use the given enrollment selectior to get the index key;
-	// else check for the labels so that we can even support 'importing'
-	// out-of-band created enrollment records
-	enrolledKeyFunc := func(d instance.Description) (string, error) {
-
-		t, err := l.getEnrollmentKeySelectorTemplate()
-		if err != nil {
-			return "", err
-		}
-		if t == nil {
-			if v, has := d.Tags["infrakit.enrollment.sourceID"]; has {
-				return v, nil
-			}
-			return "", fmt.Errorf("not-matched:%v", d.ID)
-		}
-		view, err := t.Render(d)
-		if err != nil {
-			return "", err
-		}
-		return view, nil
-
-	}
-
-	// compute the delta required to make enrolled look like source
-	add, remove := Delta(
-		instance.Descriptions(source), sourceKeyFunc, l.options.SourceParseErrPolicy,
-		instance.Descriptions(enrolled), enrolledKeyFunc, l.options.EnrollmentParseErrPolicy,
-	)
-
 	log.Debug("Computed delta", "add", add, "remove", remove, "debug", debugV)
 	// Use Info logging only when making deltas, log the ID:LogicalID for each delta
 	if len(add) > 0 || len(remove) > 0 {
@@ -185,6 +125,29 @@ func (l *enroller) sync() error {
 		log.Info("Computed delta", "add", addIDs, "remove", removeIDS)
 	}
 
+	// Too many removals may be from a transient problem with the sources, so we halt
+	// until the removals are approved.
+	halted, err := l.checkRemovals(remove, len(enrolled))
+	if err != nil {
+		return err
+	}
+	l.setHalted(halted)
+	if halted != nil {
+		log.Warn("Sync halted", "removals", len(remove), "limit", halted.Limit, "approval", halted.Approval)
+		l.publish(event.Event{
+			Topic:   l.topic(TopicHalted),
+			Type:    event.Type("Halted"),
+			ID:      l.spec.Metadata.Name,
+			Message: fmt.Sprintf("halted %d removals, exceeding the max of %d", len(remove), halted.Limit),
+		}.Init().WithDataMust(halted))
+		return nil
+	}
+
+	if err := freeze.Check(); err != nil {
+		log.Warn("Sync frozen", "add", len(add), "remove", len(remove), "err", err)
+		return nil
+	}
+
 	instancePlugin, err := l.getInstancePlugin(l.properties.Instance.Plugin)
 	if err != nil {
 		log.Error("cannot get instance plugin", "err", err)
@@ -218,6 +181,60 @@ func (l *enroller) sync() error {
 	return nil
 }
 
+// delta returns the source instances to enroll and the enrolled instances to remove, along
+// with all the enrolled instances.
+func (l *enroller) delta() (add, remove, enrolled []instance.Description, err error) {
+
+	source, err := l.getSourceInstances()
+	if err != nil {
+		err = fmt.Errorf("error getting sources: %v", err)
+		return
+	}
+
+	enrolled, err = l.getEnrolledInstances()
+	if err != nil {
+		err = fmt.Errorf("error getting enrollment: %v", err)
+		return
+	}
+
+	// We need to compute a projection for each one of the vectors and compare
+	// them.  This is because instance IDs from the respective lists are likely
+	// to be different.  Instead there's a join key / common attribute somewhere
+	// embedded in the Description.Properties.
+	sourceKeyFunc := l.sourceKey
+
+	// If specified, use the given enrollment selectior to get the index key;
+	// else check for the labels so that we can even support 'importing'
+	// out-of-band created enrollment records
+	enrolledKeyFunc := func(d instance.Description) (string, error) {
+
+		t, err := l.getEnrollmentKeySelectorTemplate()
+		if err != nil {
+			return "", err
+		}
+		if t == nil {
+			if v, has := d.Tags["infrakit.enrollment.sourceID"]; has {
+				return v, nil
+			}
+			return "", fmt.Errorf("not-matched:%v", d.ID)
+		}
+		view, err := t.Render(d)
+		if err != nil {
+			return "", err
+		}
+		return view, nil
+
+	}
+
+	// compute the delta required to make enrolled look like source
+	add, remove = Delta(
+		instance.Descriptions(source), sourceKeyFunc, l.options.SourceParseErrPolicy,
+		instance.Descriptions(enrolled), enrolledKeyFunc, l.options.EnrollmentParseErrPolicy,
+	)
+
+	return
+}
+
 // buildProperties for calling enrollment / Provision
 func (l *enroller) buildProperties(d instance.Description) (*types.Any, error) {
 	t, err := l.getEnrollmentPropertiesTemplate()
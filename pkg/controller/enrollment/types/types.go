@@ -2,6 +2,8 @@ package types // import "github.com/docker/infrakit/pkg/controller/enrollment/ty
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/plugin"
@@ -120,12 +122,33 @@ type PluginSpec struct {
 	Properties *types.Any `json:",omitempty" yaml:",omitempty"`
 }
 
+// Source is a source of instances to enroll.  Only one of List, Resource, or Metadata is set.
+type Source struct {
+
+	// List is a list of instance descriptions or the name of a group plugin
+	List *ListSourceUnion `json:",omitempty" yaml:",omitempty"`
+
+	// Resource is the name of a collection of a resource controller, e.g. resource/workers
+	Resource plugin.Name `json:",omitempty" yaml:",omitempty"`
+
+	// Metadata is the path of a metadata value that is a list of instance descriptions
+	Metadata string `json:",omitempty" yaml:",omitempty"`
+
+	// Topic is the event topic, e.g. resource/ready, that triggers a sync when an event is
+	// published, without waiting for the next SyncInterval.
+	Topic string `json:",omitempty" yaml:",omitempty"`
+}
+
 // Properties is the schema of the configuration in the types.Spec.Properties
 type Properties struct {
 
 	// List is a list of instance descriptions to sync
 	List *ListSourceUnion `json:",omitempty" yaml:",omitempty"`
 
+	// Sources are additional sources of instances, merged after the List.  When the same
+	// key is in more than one source, the entry of the source listed first is enrolled.
+	Sources []Source `json:",omitempty" yaml:",omitempty"`
+
 	// Instance is the name of the instance plugin which will receive the
 	// synchronization messages of provision / destroy based on the
 	// changes in the List
@@ -162,6 +185,40 @@ type Options struct {
 	// depending on use cases the controller may not *own* the data in the
 	// downstream instance.  The controller merely reconciles it.
 	DestroyOnTerminate bool
+
+	// MaxRemovals is the max number, e.g. 5, or percentage of the enrolled, e.g. 10%, of
+	// entries removed in a sync.  A sync exceeding it is halted until approved.  Default is no limit.
+	MaxRemovals Threshold `json:",omitempty" yaml:",omitempty"`
+}
+
+// Approval is the Properties of the spec committed to approve a halted sync.  It's not stored.
+type Approval struct {
+	// Token is the approval token of the halted sync, which lets the sync proceed with
+	// exactly the removals that were halted.
+	Token string
+}
+
+// Threshold is a number, e.g. 5, or a percentage, e.g. 10%
+type Threshold string
+
+// Limit returns the limit given the total, and false if there is no limit
+func (t Threshold) Limit(total int) (int, bool, error) {
+	s := strings.TrimSpace(string(t))
+	if s == "" {
+		return 0, false, nil
+	}
+	if strings.HasSuffix(s, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || pct < 0 {
+			return 0, false, fmt.Errorf("bad threshold %v", t)
+		}
+		return int(pct * float64(total) / 100), true, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, false, fmt.Errorf("bad threshold %v", t)
+	}
+	return n, true, nil
 }
 
 // TemplateFrom returns a template after it has un-escaped any escape sequences
@@ -182,6 +239,9 @@ func (o Options) Validate(phase PluginPhase) error {
 			return fmt.Errorf("SyncInterval must be greater than 0")
 		}
 	}
+	if _, _, err := o.MaxRemovals.Limit(0); err != nil {
+		return err
+	}
 	srcParseErrorPolicy := o.SourceParseErrPolicy
 	switch srcParseErrorPolicy {
 	case SourceParseErrorEnableDestroy:
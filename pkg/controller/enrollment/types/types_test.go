@@ -214,3 +214,30 @@ func TestValidate(t *testing.T) {
 			err)
 	}
 }
+
+func TestThreshold(t *testing.T) {
+	limit, set, err := Threshold("").Limit(10)
+	require.NoError(t, err)
+	require.False(t, set)
+
+	limit, set, err = Threshold("3").Limit(10)
+	require.NoError(t, err)
+	require.True(t, set)
+	require.Equal(t, 3, limit)
+
+	limit, set, err = Threshold("25%").Limit(10)
+	require.NoError(t, err)
+	require.True(t, set)
+	require.Equal(t, 2, limit)
+
+	_, _, err = Threshold("x%").Limit(10)
+	require.Error(t, err)
+
+	o := Options{
+		SyncInterval:             types.FromDuration(time.Duration(10 * time.Second)),
+		SourceParseErrPolicy:     SourceParseErrorDisableDestroy,
+		EnrollmentParseErrPolicy: EnrolledParseErrorDisableProvision,
+		MaxRemovals:              "-1",
+	}
+	require.Error(t, o.Validate(PluginCommit))
+}
@@ -1,6 +1,7 @@
 package gc // import "github.com/docker/infrakit/pkg/controller/gc"
 
 import (
+	"sync"
 	"time"
 
 	gc "github.com/docker/infrakit/pkg/controller/gc/types"
@@ -25,10 +26,22 @@ var (
 // NewController returns a controller implementation
 func NewController(scope scope.Scope, options gc.Options) func() (map[string]controller.Controller, error) {
 
+	// the quarantines are kept here so they survive new commits of the specs
+	quarantines := map[string]*quarantine{}
+	var lock sync.Mutex
+
 	return (internal.NewController(
 		// the constructor
 		func(spec types.Spec) (internal.Managed, error) {
-			return newReaper(scope, options)
+			lock.Lock()
+			defer lock.Unlock()
+
+			q, has := quarantines[spec.Metadata.Name]
+			if !has {
+				q = newQuarantine()
+				quarantines[spec.Metadata.Name] = q
+			}
+			return newReaper(scope, options, q)
 		},
 		// the key function
 		func(metadata types.Metadata) string {
@@ -6,6 +6,7 @@ import (
 
 	gc "github.com/docker/infrakit/pkg/controller/gc/types"
 	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/run/scope"
 	"github.com/docker/infrakit/pkg/spi/instance"
 )
 
@@ -49,4 +50,18 @@ type Model interface {
 	LostInstance(fsm.FSM)
 	GCNode() <-chan fsm.FSM
 	GCInstance() <-chan fsm.FSM
+	Metrics() *fsm.Metrics
+}
+
+// NodeProvider is implemented by models that bring their own plugin for the node side, like the
+// nodes of a cluster that isn't exposed as an instance plugin.  The provided plugin is used in place of
+// the plugin named in the NodeObserver.
+type NodeProvider interface {
+	NodePlugin(scope.Scope) (instance.Plugin, error)
+}
+
+// NodeChecker is implemented by models that can tell whether a node found is ready.  The quarantined
+// resources of a link are released when its node is found ready with its instance.
+type NodeChecker interface {
+	NodeReady(instance.Description) bool
 }
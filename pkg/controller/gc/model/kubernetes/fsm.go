@@ -0,0 +1,93 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/controller/gc/model/kubernetes"
+
+import (
+	"fmt"
+
+	"github.com/docker/infrakit/pkg/controller/gc"
+	"github.com/docker/infrakit/pkg/controller/gc/model/workflow"
+	gc_types "github.com/docker/infrakit/pkg/controller/gc/types"
+	kubernetes_flavor "github.com/docker/infrakit/pkg/plugin/flavor/kubernetes"
+	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func init() {
+	gc.Register("kubernetes", BuildModel)
+}
+
+// ModelProperties are the properties of the kubernetes model
+type ModelProperties struct {
+	workflow.Properties
+
+	// Kubeconfig is the path of the kubeconfig file of the cluster.  If Kubeconfig is set, the nodes of
+	// the cluster are read from the API server directly and NodeObserver doesn't need to name a plugin.
+	Kubeconfig string
+
+	// Master is the address of the API server, overriding the one in the kubeconfig
+	Master string
+}
+
+// NodeFromDescription returns a kubernetes node that is assumed to be attached as a Properties
+func NodeFromDescription(desc instance.Description) (v1.Node, error) {
+	node := v1.Node{}
+	if desc.Properties == nil {
+		return node, fmt.Errorf("no kubernetes node information %v", desc)
+	}
+	return node, desc.Properties.Decode(&node)
+}
+
+// nodeState returns NodeReady when the Ready condition of the node is true
+func nodeState(desc instance.Description) (workflow.NodeState, error) {
+	node, err := NodeFromDescription(desc)
+	if err != nil {
+		return workflow.NodeDown, err
+	}
+	for _, c := range node.Status.Conditions {
+		if c.Type == v1.NodeReady && c.Status == v1.ConditionTrue {
+			return workflow.NodeReady, nil
+		}
+	}
+	return workflow.NodeDown, nil
+}
+
+type model struct {
+	*workflow.Model
+
+	properties ModelProperties
+}
+
+// NodePlugin implements gc.NodeProvider
+func (m *model) NodePlugin(scope scope.Scope) (instance.Plugin, error) {
+	config, err := clientcmd.BuildConfigFromFlags(m.properties.Master, m.properties.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes_flavor.NewNodePlugin(clientset.CoreV1().Nodes()), nil
+}
+
+// BuildModel constructs a workflow model given the configuration blob provided by user in the Properties
+func BuildModel(properties gc_types.Properties) (gc.Model, error) {
+
+	modelProperties := ModelProperties{Properties: workflow.DefaultProperties}
+	if properties.ModelProperties != nil {
+		if err := properties.ModelProperties.Decode(&modelProperties); err != nil {
+			return nil, err
+		}
+	}
+
+	m, err := workflow.Build("kubernetes", &properties, modelProperties.Properties, nodeState)
+	if err != nil {
+		return nil, err
+	}
+	if modelProperties.Kubeconfig == "" && modelProperties.Master == "" {
+		return m, nil
+	}
+	return &model{Model: m, properties: modelProperties}, nil
+}
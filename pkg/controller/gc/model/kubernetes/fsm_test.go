@@ -0,0 +1,79 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/controller/gc/model/kubernetes"
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller/gc"
+	"github.com/docker/infrakit/pkg/controller/gc/model/workflow"
+	gc_types "github.com/docker/infrakit/pkg/controller/gc/types"
+	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestKubernetesModel(t *testing.T) {
+
+	gcProperties := new(gc_types.Properties)
+	require.NoError(t, types.Decode([]byte(`
+Model : kubernetes
+ModelProperties:
+  TickUnit: 1s
+  NoData: 20
+NodeObserver:
+  ObserveInterval: 10s
+InstanceObserver:
+  ObserveInterval: 5s
+`), gcProperties))
+
+	m, err := BuildModel(*gcProperties)
+	require.NoError(t, err)
+
+	model := m.(*workflow.Model)
+	require.Equal(t, fsm.Tick(20), model.NoData)
+	require.Equal(t, workflow.DefaultProperties.NodeJoin, model.NodeJoin)
+
+	_, is := m.(gc.NodeProvider)
+	require.False(t, is)
+
+	gcProperties.ModelProperties = types.AnyValueMust(map[string]interface{}{"Kubeconfig": "/etc/kubernetes/admin.conf"})
+	m, err = BuildModel(*gcProperties)
+	require.NoError(t, err)
+
+	_, is = m.(gc.NodeProvider)
+	require.True(t, is)
+
+	m.Start()
+	<-time.After(100 * time.Millisecond)
+	m.Stop()
+}
+
+func TestNodeState(t *testing.T) {
+
+	describe := func(conditions ...v1.NodeCondition) instance.Description {
+		node := v1.Node{}
+		node.Name = "node1"
+		node.Status.Conditions = conditions
+		return instance.Description{ID: "node1", Properties: types.AnyValueMust(node)}
+	}
+
+	state, err := nodeState(describe(
+		v1.NodeCondition{Type: v1.NodeOutOfDisk, Status: v1.ConditionFalse},
+		v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionTrue},
+	))
+	require.NoError(t, err)
+	require.Equal(t, workflow.NodeReady, state)
+
+	state, err = nodeState(describe(v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionUnknown}))
+	require.NoError(t, err)
+	require.Equal(t, workflow.NodeDown, state)
+
+	state, err = nodeState(describe())
+	require.NoError(t, err)
+	require.Equal(t, workflow.NodeDown, state)
+
+	_, err = nodeState(instance.Description{ID: "node1"})
+	require.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package membership // import "github.com/docker/infrakit/pkg/controller/gc/model/membership"
+
+import (
+	"fmt"
+
+	"github.com/docker/infrakit/pkg/controller/gc"
+	"github.com/docker/infrakit/pkg/controller/gc/model/workflow"
+	gc_types "github.com/docker/infrakit/pkg/controller/gc/types"
+	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/docker/infrakit/pkg/spi/instance"
+)
+
+func init() {
+	gc.Register("membership", BuildModel)
+}
+
+// ModelProperties are the properties of the membership model
+type ModelProperties struct {
+	workflow.Properties
+
+	// Path is the metadata path of the member list, e.g. mystore/cluster/members.  The value at the
+	// path is a list of objects, one for each member.
+	Path string
+
+	// IDField is the field of a member that has its id
+	IDField string
+
+	// StateField is the field of a member that has its state.  If not set, all members are ready.
+	StateField string
+
+	// ReadyValue is the value of the StateField of a member that is ready
+	ReadyValue string
+}
+
+// DefaultModelProperties are the default properties of the model
+var DefaultModelProperties = ModelProperties{
+	Properties: workflow.DefaultProperties,
+	IDField:    "ID",
+	ReadyValue: "ready",
+}
+
+type model struct {
+	*workflow.Model
+
+	properties ModelProperties
+}
+
+// NodePlugin implements gc.NodeProvider
+func (m *model) NodePlugin(scope scope.Scope) (instance.Plugin, error) {
+	return NewPlugin(scope, m.properties.Path, m.properties.IDField), nil
+}
+
+// nodeState returns the state of the member by the value of its state field
+func (m *model) nodeState(desc instance.Description) (workflow.NodeState, error) {
+	if m.properties.StateField == "" {
+		return workflow.NodeReady, nil
+	}
+	if desc.Properties == nil {
+		return workflow.NodeDown, fmt.Errorf("no member information %v", desc)
+	}
+	member := map[string]interface{}{}
+	if err := desc.Properties.Decode(&member); err != nil {
+		return workflow.NodeDown, err
+	}
+	if fmt.Sprintf("%v", member[m.properties.StateField]) == m.properties.ReadyValue {
+		return workflow.NodeReady, nil
+	}
+	return workflow.NodeDown, nil
+}
+
+// BuildModel constructs a workflow model given the configuration blob provided by user in the Properties
+func BuildModel(properties gc_types.Properties) (gc.Model, error) {
+
+	modelProperties := DefaultModelProperties
+	if properties.ModelProperties != nil {
+		if err := properties.ModelProperties.Decode(&modelProperties); err != nil {
+			return nil, err
+		}
+	}
+	if modelProperties.Path == "" {
+		return nil, fmt.Errorf("no path of the member list")
+	}
+
+	m := &model{properties: modelProperties}
+	base, err := workflow.Build("membership", &properties, modelProperties.Properties, m.nodeState)
+	if err != nil {
+		return nil, err
+	}
+	m.Model = base
+	return m, nil
+}
@@ -0,0 +1,154 @@
+package membership // import "github.com/docker/infrakit/pkg/controller/gc/model/membership"
+
+import (
+	"fmt"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/spi/metadata"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+var log = logutil.New("module", "controller/gc/membership")
+
+// NewPlugin returns the instance plugin of the members in the list at the metadata path
+func NewPlugin(scope scope.Scope, path string, idField string) instance.Plugin {
+	return &plugin{scope: scope, path: path, idField: idField}
+}
+
+type plugin struct {
+	scope   scope.Scope
+	path    string
+	idField string
+}
+
+func (p *plugin) members() (*scope.MetadataCall, []map[string]interface{}, error) {
+	call, err := p.scope.Metadata(p.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if call == nil {
+		return nil, nil, fmt.Errorf("no metadata at %v", p.path)
+	}
+	any, err := call.Plugin.Get(call.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	members := []map[string]interface{}{}
+	if any == nil {
+		return call, members, nil
+	}
+	return call, members, any.Decode(&members)
+}
+
+func (p *plugin) id(member map[string]interface{}) instance.ID {
+	v, has := member[p.idField]
+	if !has || v == nil {
+		return ""
+	}
+	return instance.ID(fmt.Sprintf("%v", v))
+}
+
+// DescribeInstances returns the members matching the labels, each having:
+// - Value of the IDField as ID
+// - Scalar fields of the member as Tags
+// - Tag infrakit-link as the LogicalID (if set)
+// - Member object as Properties
+func (p *plugin) DescribeInstances(labels map[string]string, properties bool) ([]instance.Description, error) {
+	_, members, err := p.members()
+	if err != nil {
+		return nil, err
+	}
+	result := []instance.Description{}
+scan:
+	for _, member := range members {
+		id := p.id(member)
+		if id == "" {
+			log.Warn("Member without id", "path", p.path, "field", p.idField, "member", member)
+			continue
+		}
+		tags := map[string]string{}
+		for k, v := range member {
+			switch v.(type) {
+			case string, bool, float64:
+				tags[k] = fmt.Sprintf("%v", v)
+			}
+		}
+		for k, v := range labels {
+			if tags[k] != v {
+				continue scan
+			}
+		}
+		var logicalID *instance.LogicalID
+		if link, has := tags[types.LinkLabel]; has {
+			v := instance.LogicalID(link)
+			logicalID = &v
+		}
+		var propsAny *types.Any
+		if properties {
+			if propsAny, err = types.AnyValue(member); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, instance.Description{
+			ID:         id,
+			LogicalID:  logicalID,
+			Properties: propsAny,
+			Tags:       tags,
+		})
+	}
+	return result, nil
+}
+
+// Destroy removes the member from the list.  The metadata plugin at the path must be updatable.
+func (p *plugin) Destroy(id instance.ID, ctx instance.Context) error {
+	call, members, err := p.members()
+	if err != nil {
+		return err
+	}
+	updatable, is := call.Plugin.(metadata.Updatable)
+	if !is {
+		return fmt.Errorf("metadata at %v is readonly", p.path)
+	}
+
+	remain := []map[string]interface{}{}
+	for _, member := range members {
+		if p.id(member) != id {
+			remain = append(remain, member)
+		}
+	}
+	if len(remain) == len(members) {
+		log.Warn("Unable to remove member - not found", "path", p.path, "id", id)
+		return nil
+	}
+
+	value, err := types.AnyValue(remain)
+	if err != nil {
+		return err
+	}
+	_, proposed, cas, err := updatable.Changes([]metadata.Change{{Path: call.Key, Value: value}})
+	if err != nil {
+		return err
+	}
+	if err := updatable.Commit(proposed, cas); err != nil {
+		return err
+	}
+	log.Info("Removed member", "path", p.path, "id", id)
+	return nil
+}
+
+// Validate is not supported
+func (p *plugin) Validate(req *types.Any) error {
+	return fmt.Errorf("Validate not supported for members")
+}
+
+// Provision is not supported
+func (p *plugin) Provision(spec instance.Spec) (*instance.ID, error) {
+	return nil, fmt.Errorf("Provision not supported for members")
+}
+
+// Label is not supported
+func (p *plugin) Label(id instance.ID, labels map[string]string) error {
+	return fmt.Errorf("Label not supported for members")
+}
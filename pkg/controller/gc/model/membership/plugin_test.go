@@ -0,0 +1,120 @@
+package membership // import "github.com/docker/infrakit/pkg/controller/gc/model/membership"
+
+import (
+	"testing"
+
+	"github.com/docker/infrakit/pkg/controller/gc"
+	"github.com/docker/infrakit/pkg/controller/gc/model/workflow"
+	gc_types "github.com/docker/infrakit/pkg/controller/gc/types"
+	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/spi/metadata"
+	testing_metadata "github.com/docker/infrakit/pkg/testing/metadata"
+	testing_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func testScope(t *testing.T, members *[]map[string]interface{}) *testing_scope.Scope {
+	s := testing_scope.DefaultScope()
+	s.ResolveMetadata = func(p string) (*scope.MetadataCall, error) {
+		require.Equal(t, "vars/cluster/members", p)
+		return &scope.MetadataCall{
+			Plugin: &testing_metadata.Updatable{
+				Plugin: testing_metadata.Plugin{
+					DoGet: func(path types.Path) (*types.Any, error) {
+						return types.AnyValue(*members)
+					},
+				},
+				DoChanges: func(changes []metadata.Change) (original, proposed *types.Any, cas string, err error) {
+					require.Equal(t, 1, len(changes))
+					require.Equal(t, types.PathFromString("cluster/members"), changes[0].Path)
+					return nil, changes[0].Value, "cas", nil
+				},
+				DoCommit: func(proposed *types.Any, cas string) error {
+					require.Equal(t, "cas", cas)
+					*members = []map[string]interface{}{}
+					return proposed.Decode(members)
+				},
+			},
+			Name: "vars",
+			Key:  types.PathFromString("cluster/members"),
+		}, nil
+	}
+	return s
+}
+
+func TestMembers(t *testing.T) {
+
+	members := []map[string]interface{}{
+		{"ID": "m1", "State": "ready", "Role": "manager", types.LinkLabel: "10.0.0.1"},
+		{"ID": "m2", "State": "down", "Role": "worker"},
+		{"State": "ready"},
+	}
+
+	p := NewPlugin(testScope(t, &members), "vars/cluster/members", "ID")
+
+	described, err := p.DescribeInstances(nil, true)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(described))
+	require.Equal(t, instance.ID("m1"), described[0].ID)
+	require.Equal(t, instance.LogicalID("10.0.0.1"), *described[0].LogicalID)
+	require.Equal(t, "manager", described[0].Tags["Role"])
+	require.Nil(t, described[1].LogicalID)
+
+	described, err = p.DescribeInstances(map[string]string{"Role": "worker"}, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(described))
+	require.Equal(t, instance.ID("m2"), described[0].ID)
+	require.Nil(t, described[0].Properties)
+
+	require.NoError(t, p.Destroy("m2", instance.Termination))
+	require.Equal(t, 2, len(members))
+
+	described, err = p.DescribeInstances(nil, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(described))
+
+	// not a member
+	require.NoError(t, p.Destroy("m2", instance.Termination))
+}
+
+func TestMembershipModel(t *testing.T) {
+
+	gcProperties := new(gc_types.Properties)
+	require.NoError(t, types.Decode([]byte(`
+Model : membership
+ModelProperties:
+  Path: vars/cluster/members
+  StateField: State
+`), gcProperties))
+
+	m, err := BuildModel(*gcProperties)
+	require.NoError(t, err)
+
+	members := []map[string]interface{}{
+		{"ID": "m1", "State": "ready"},
+		{"ID": "m2", "State": "down"},
+	}
+
+	provider, is := m.(gc.NodeProvider)
+	require.True(t, is)
+	nodes, err := provider.NodePlugin(testScope(t, &members))
+	require.NoError(t, err)
+
+	described, err := nodes.DescribeInstances(nil, true)
+	require.NoError(t, err)
+
+	model := m.(*model)
+	state, err := model.nodeState(described[0])
+	require.NoError(t, err)
+	require.Equal(t, workflow.NodeReady, state)
+
+	state, err = model.nodeState(described[1])
+	require.NoError(t, err)
+	require.Equal(t, workflow.NodeDown, state)
+
+	gcProperties.ModelProperties = nil
+	_, err = BuildModel(*gcProperties)
+	require.Error(t, err)
+}
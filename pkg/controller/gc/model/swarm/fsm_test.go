@@ -4,8 +4,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/infrakit/pkg/controller/gc/model/workflow"
 	gc_types "github.com/docker/infrakit/pkg/controller/gc/types"
 	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/spi/instance"
 	"github.com/docker/infrakit/pkg/types"
 
 	"github.com/stretchr/testify/require"
@@ -20,6 +23,7 @@ Model : swarm
 ModelProperties:
   TickUnit: 1s
   NoData: 20
+  DockerNodeJoin: 7
   RmNodeBufferSize: 20
 NodeObserver:
   ObserveInterval: 10s
@@ -32,11 +36,12 @@ InstanceObserver:
 	m, err := BuildModel(*gcProperties)
 	require.NoError(t, err)
 
-	model := m.(*model)
+	model := m.(*workflow.Model)
 
 	require.Equal(t, fsm.Tick(20), model.NoData)
+	require.Equal(t, fsm.Tick(7), model.NodeJoin)
 	require.Equal(t, 1*time.Second, model.TickUnit.Duration())
-	require.Equal(t, 10*time.Second, model.tickSize) // must take the slower of the two durations of 10s vs 1s
+	require.Equal(t, 10*time.Second, model.TickSize()) // must take the slower of the two durations of 10s vs 1s
 
 	m.Start()
 	<-time.After(1 * time.Second)
@@ -53,3 +58,29 @@ InstanceObserver:
 	}
 
 }
+
+func TestSwarmNodeState(t *testing.T) {
+
+	state := func(node swarm.Node) workflow.NodeState {
+		s, err := nodeState(instance.Description{Properties: types.AnyValueMust(node)})
+		require.NoError(t, err)
+		return s
+	}
+
+	node := swarm.Node{}
+	node.Status.State = swarm.NodeStateReady
+	require.Equal(t, workflow.NodeReady, state(node))
+
+	node.Spec.Role = swarm.NodeRoleManager
+	node.ManagerStatus = &swarm.ManagerStatus{Reachability: swarm.ReachabilityUnreachable}
+	require.Equal(t, workflow.NodeDown, state(node))
+
+	node.ManagerStatus.Reachability = swarm.ReachabilityReachable
+	require.Equal(t, workflow.NodeReady, state(node))
+
+	node.Status.State = swarm.NodeStateDown
+	require.Equal(t, workflow.NodeDown, state(node))
+
+	_, err := nodeState(instance.Description{})
+	require.Error(t, err)
+}
@@ -0,0 +1,360 @@
+package workflow // import "github.com/docker/infrakit/pkg/controller/gc/model/workflow"
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller/gc"
+	gc_types "github.com/docker/infrakit/pkg/controller/gc/types"
+	"github.com/docker/infrakit/pkg/fsm"
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+var (
+	log    = logutil.New("module", "controller/gc/workflow")
+	debugV = logutil.V(300)
+)
+
+// NodeState is the state of a node as reported by the cluster
+type NodeState int
+
+const (
+	// NodeReady is the state of a node that is healthy in the cluster
+	NodeReady NodeState = iota
+
+	// NodeDown is the state of a node that is known to the cluster but unavailable
+	NodeDown
+)
+
+const (
+	nodeReady fsm.Signal = iota
+	nodeDown
+	nodeGone
+	instanceOK
+	instanceGone
+	timeout
+	reap
+)
+
+const (
+	start                  fsm.Index = iota
+	matchedInstance                  // has vm information, waiting to match to node
+	matchedNode                      // has node information, waiting to match to vm
+	clusterNode                      // has matching node and vm information
+	clusterNodeReady                 // ready as cluster node
+	clusterNodeDown                  // unavailable as cluster node
+	pendingInstanceDestroy           // vm needs to be removed (instance destroy)
+	removedInstance                  // instance is deleted
+	done                             // terminal
+)
+
+// Properties are the tuning parameters of the workflow, in the ModelProperties of the gc spec
+type Properties struct {
+	TickUnit                  types.Duration
+	NoData                    fsm.Tick
+	NodeJoin                  fsm.Tick
+	WaitDescribeInstances     fsm.Tick
+	WaitBeforeInstanceDestroy fsm.Tick
+	WaitBeforeReprovision     fsm.Tick
+	WaitBeforeCleanup         fsm.Tick
+	RmNodeBufferSize          int
+	RmInstanceBufferSize      int
+}
+
+// DefaultProperties are the default tuning parameters
+var DefaultProperties = Properties{
+	TickUnit:                  types.FromDuration(1 * time.Second),
+	NoData:                    fsm.Tick(10),
+	NodeJoin:                  fsm.Tick(5),
+	WaitDescribeInstances:     fsm.Tick(5),
+	WaitBeforeInstanceDestroy: fsm.Tick(3),
+	WaitBeforeReprovision:     fsm.Tick(10), // wait before we reprovision a new instance to fix a Down node
+	WaitBeforeCleanup:         fsm.Tick(10),
+	RmNodeBufferSize:          10,
+	RmInstanceBufferSize:      10,
+}
+
+// Model is the workflow of garbage collecting nodes and the instances they run on, for clusters
+// where the state of a node can be read from its description.
+type Model struct {
+	Properties
+
+	name      string
+	nodeState func(instance.Description) (NodeState, error)
+
+	spec     *fsm.Spec
+	set      fsm.Instances
+	clock    *fsm.Clock
+	tickSize time.Duration
+
+	nodeRmChan          chan fsm.FSM
+	instanceDestroyChan chan fsm.FSM
+
+	lock sync.RWMutex
+}
+
+// TickSize returns the duration of a tick of the clock of the model
+func (m *Model) TickSize() time.Duration {
+	return m.tickSize
+}
+
+// GCNode implements gc.Model
+func (m *Model) GCNode() <-chan fsm.FSM {
+	return m.nodeRmChan
+}
+
+// GCInstance implements gc.Model
+func (m *Model) GCInstance() <-chan fsm.FSM {
+	return m.instanceDestroyChan
+}
+
+// New implements gc.Model
+func (m *Model) New() fsm.FSM {
+	return m.set.Add(start)
+}
+
+// FoundNode implements gc.Model
+func (m *Model) FoundNode(fsm fsm.FSM, desc instance.Description) error {
+	state, err := m.nodeState(desc)
+	if err != nil {
+		return err
+	}
+	switch state {
+	case NodeReady:
+		fsm.Signal(nodeReady)
+	case NodeDown:
+		log.Warn("node down", "model", m.name, "node", desc.ID)
+		fsm.Signal(nodeDown)
+	default:
+		return fmt.Errorf("unknown state of node %v, no signals triggered", desc.ID)
+	}
+	return nil
+}
+
+// NodeReady implements gc.NodeChecker
+func (m *Model) NodeReady(desc instance.Description) bool {
+	state, err := m.nodeState(desc)
+	return err == nil && state == NodeReady
+}
+
+// LostNode implements gc.Model
+func (m *Model) LostNode(fsm fsm.FSM) {
+	fsm.Signal(nodeGone)
+}
+
+// FoundInstance implements gc.Model
+func (m *Model) FoundInstance(fsm fsm.FSM, desc instance.Description) error {
+	fsm.Signal(instanceOK)
+	return nil
+}
+
+// LostInstance implements gc.Model
+func (m *Model) LostInstance(fsm fsm.FSM) {
+	fsm.Signal(instanceGone)
+}
+
+// Spec implements gc.Model
+func (m *Model) Spec() *fsm.Spec {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.spec
+}
+
+// Metrics implements gc.Model
+func (m *Model) Metrics() *fsm.Metrics {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.set == nil {
+		return nil
+	}
+	metrics := m.set.Metrics()
+	return &metrics
+}
+
+// Start implements gc.Model
+func (m *Model) Start() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.clock.Start()
+	m.set = fsm.NewInstances(m.spec, m.clock, fsm.DefaultOptions(m.name))
+}
+
+// Stop implements gc.Model
+func (m *Model) Stop() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.set.Stop()
+	m.clock.Stop()
+	m.set = nil
+
+	close(m.nodeRmChan)
+	close(m.instanceDestroyChan)
+}
+
+func (m *Model) instanceDestroy(i fsm.FSM) error {
+	log.Debug("instance destroy", "model", m.name, "id", i.ID(), "V", debugV)
+	m.instanceDestroyChan <- i
+	return nil
+}
+
+func (m *Model) nodeRm(i fsm.FSM) error {
+	log.Debug("node remove", "model", m.name, "id", i.ID(), "V", debugV)
+	m.nodeRmChan <- i
+	return nil
+}
+
+func longer(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Build constructs the workflow model with the given name and tuning parameters.  The model ticks at
+// the slowest of the tick unit and the observe intervals.  The nodeState function returns the state of a node found.
+func Build(name string, properties *gc_types.Properties, tuning Properties,
+	nodeState func(instance.Description) (NodeState, error)) (*Model, error) {
+
+	model := &Model{
+		Properties:          tuning,
+		name:                name,
+		nodeState:           nodeState,
+		nodeRmChan:          make(chan fsm.FSM, tuning.RmNodeBufferSize),
+		instanceDestroyChan: make(chan fsm.FSM, tuning.RmInstanceBufferSize),
+	}
+
+	d := longer(tuning.TickUnit.Duration(), longer(
+		properties.NodeObserver.ObserveInterval.Duration(),
+		properties.InstanceObserver.ObserveInterval.Duration(),
+	))
+
+	model.tickSize = d
+	model.clock = fsm.Wall(time.Tick(d))
+
+	spec, err := fsm.Define(
+		fsm.State{
+			Index: start,
+			TTL:   fsm.Expiry{TTL: tuning.NoData, Raise: timeout},
+			Transitions: map[fsm.Signal]fsm.Index{
+				nodeReady:  matchedNode,
+				nodeDown:   matchedNode,
+				instanceOK: matchedInstance,
+				timeout:    removedInstance, // nothing happened... cleanup
+			},
+		},
+		fsm.State{
+			Index: matchedInstance,
+			TTL:   fsm.Expiry{TTL: tuning.NodeJoin, Raise: nodeGone},
+			Transitions: map[fsm.Signal]fsm.Index{
+				nodeReady:    clusterNode,
+				nodeDown:     clusterNode,
+				nodeGone:     pendingInstanceDestroy,
+				instanceGone: removedInstance,
+			},
+			Actions: map[fsm.Signal]fsm.Action{
+				instanceGone: model.instanceDestroy,
+			},
+		},
+		fsm.State{
+			Index: pendingInstanceDestroy,
+			TTL:   fsm.Expiry{TTL: tuning.WaitBeforeInstanceDestroy, Raise: reap},
+			Transitions: map[fsm.Signal]fsm.Index{
+				nodeReady:    clusterNode, // late joiner
+				instanceGone: removedInstance,
+				reap:         removedInstance,
+			},
+			Actions: map[fsm.Signal]fsm.Action{
+				instanceGone: model.instanceDestroy,
+				reap:         model.instanceDestroy,
+			},
+		},
+		fsm.State{
+			Index: matchedNode,
+			TTL:   fsm.Expiry{TTL: tuning.WaitDescribeInstances, Raise: instanceGone},
+			Transitions: map[fsm.Signal]fsm.Index{
+				instanceOK:   clusterNode,
+				instanceGone: removedInstance,
+				nodeGone:     removedInstance, // could be removed out of band
+			},
+			Actions: map[fsm.Signal]fsm.Action{
+				instanceGone: model.nodeRm,
+			},
+		},
+		fsm.State{
+			Index: clusterNode,
+			Transitions: map[fsm.Signal]fsm.Index{
+				nodeReady:    clusterNodeReady,
+				nodeDown:     clusterNodeDown,
+				nodeGone:     matchedInstance,
+				instanceGone: matchedNode,
+			},
+		},
+		fsm.State{
+			Index: clusterNodeReady,
+			Transitions: map[fsm.Signal]fsm.Index{
+				nodeDown:     clusterNodeDown,
+				nodeGone:     matchedInstance,
+				instanceGone: matchedNode,
+			},
+		},
+		fsm.State{
+			Index: clusterNodeDown,
+			TTL:   fsm.Expiry{TTL: tuning.WaitBeforeReprovision, Raise: nodeGone},
+			Transitions: map[fsm.Signal]fsm.Index{
+				nodeReady:    clusterNodeReady,
+				nodeGone:     pendingInstanceDestroy,
+				instanceGone: matchedNode,
+			},
+		},
+		fsm.State{
+			Index: removedInstance, // after we removed the instance, we can still have unmatched node
+			TTL:   fsm.Expiry{TTL: tuning.WaitBeforeCleanup, Raise: timeout},
+			Transitions: map[fsm.Signal]fsm.Index{
+				nodeDown: done,
+				timeout:  done,
+			},
+			Actions: map[fsm.Signal]fsm.Action{
+				nodeDown: model.nodeRm,
+			},
+		},
+		fsm.State{
+			Index: done, // deleted state is terminal. this will be garbage collected
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	spec.SetStateNames(map[fsm.Index]string{
+		start:                  "START",
+		matchedInstance:        "FOUND_INSTANCE",
+		matchedNode:            "FOUND_NODE",
+		clusterNode:            "NODE",
+		clusterNodeReady:       "NODE_READY",
+		clusterNodeDown:        "NODE_DOWN",
+		pendingInstanceDestroy: "PENDING_INSTANCE_DESTROY",
+		removedInstance:        "INSTANCE_REMOVED",
+		done:                   "DONE",
+	}).SetSignalNames(map[fsm.Signal]string{
+		nodeReady:    "node_ready",
+		nodeDown:     "node_down",
+		nodeGone:     "node_gone",
+		instanceOK:   "instance_ok",
+		instanceGone: "instance_gone",
+		timeout:      "timeout",
+		reap:         "reap",
+	})
+	model.spec = spec
+	return model, nil
+}
+
+// check that the model implements the interface
+var _ gc.Model = &Model{}
@@ -0,0 +1,42 @@
+package workflow // import "github.com/docker/infrakit/pkg/controller/gc/model/workflow"
+
+import (
+	"testing"
+	"time"
+
+	gc_types "github.com/docker/infrakit/pkg/controller/gc/types"
+	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceWithoutNodeDestroyed(t *testing.T) {
+
+	tuning := DefaultProperties
+	tuning.TickUnit = types.FromDuration(10 * time.Millisecond)
+	tuning.NodeJoin = fsm.Tick(2)
+	tuning.WaitBeforeInstanceDestroy = fsm.Tick(2)
+
+	m, err := Build("test", &gc_types.Properties{}, tuning,
+		func(instance.Description) (NodeState, error) { return NodeReady, nil })
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Millisecond, m.tickSize)
+
+	m.Start()
+
+	f := m.New()
+	require.NoError(t, m.FoundInstance(f, instance.Description{ID: "i-1"}))
+
+	select {
+	case gone := <-m.GCInstance():
+		require.Equal(t, f.ID(), gone.ID())
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "instance not collected")
+	}
+
+	m.Stop()
+
+	_, ok := <-m.GCNode()
+	require.False(t, ok)
+}
@@ -0,0 +1,144 @@
+package gc // import "github.com/docker/infrakit/pkg/controller/gc"
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	gc "github.com/docker/infrakit/pkg/controller/gc/types"
+	"github.com/docker/infrakit/pkg/spi/instance"
+)
+
+const (
+	// QuarantineLabel is the label set on a quarantined resource, with the time it was quarantined
+	QuarantineLabel = "infrakit-gc-quarantine"
+
+	// QuarantineKey is the key in the metadata of the gc where the quarantined resources are found
+	QuarantineKey = "quarantine"
+
+	// PausedKey is the key in the metadata of the gc that is true while the kill switch pauses all destroys
+	PausedKey = "paused"
+)
+
+// Drainer is implemented by node plugins that can drain a node before it's destroyed, e.g. by cordoning it
+type Drainer interface {
+	Drain(instance.ID) error
+}
+
+// Side is the side of a quarantined resource
+type Side string
+
+const (
+	// SideNode is the node side, e.g. the Docker engine
+	SideNode Side = "node"
+
+	// SideInstance is the instance side, e.g. the vm
+	SideInstance Side = "instance"
+)
+
+// Quarantined is a resource in quarantine
+type Quarantined struct {
+	ID          instance.ID
+	Side        Side
+	Key         string
+	Since       time.Time
+	Approved    bool
+	Description instance.Description
+}
+
+// quarantine is the set of quarantined resources of a gc.  It outlives the reaper so that the
+// quarantine survives new commits of the spec.
+type quarantine struct {
+	entries map[instance.ID]*Quarantined
+	lock    sync.Mutex
+}
+
+func newQuarantine() *quarantine {
+	return &quarantine{entries: map[instance.ID]*Quarantined{}}
+}
+
+// add puts the resource in quarantine.  It returns false if the resource is already in quarantine.
+func (q *quarantine) add(side Side, key string, desc instance.Description, now time.Time) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if _, has := q.entries[desc.ID]; has {
+		return false
+	}
+	q.entries[desc.ID] = &Quarantined{
+		ID:          desc.ID,
+		Side:        side,
+		Key:         key,
+		Since:       now,
+		Description: desc,
+	}
+	return true
+}
+
+// approve approves the destroy of the resources and returns the ones in quarantine
+func (q *quarantine) approve(ids []string) []instance.ID {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	approved := []instance.ID{}
+	for _, id := range ids {
+		if entry, has := q.entries[instance.ID(id)]; has && !entry.Approved {
+			entry.Approved = true
+			approved = append(approved, entry.ID)
+		}
+	}
+	return approved
+}
+
+// release takes the resources of the key out of quarantine and returns their ids
+func (q *quarantine) release(key string) []instance.ID {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	released := []instance.ID{}
+	for id, entry := range q.entries {
+		if entry.Key == key {
+			delete(q.entries, id)
+			released = append(released, id)
+		}
+	}
+	return released
+}
+
+// remove takes the resource out of quarantine
+func (q *quarantine) remove(id instance.ID) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	delete(q.entries, id)
+}
+
+// due returns the resources to destroy now, in the order they were quarantined.  Nothing is due while
+// the kill switch is on, in which case paused is true.
+func (q *quarantine) due(config gc.Quarantine, now time.Time) (due []Quarantined, paused bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if config.MaxOrphans > 0 && len(q.entries) > config.MaxOrphans {
+		return nil, true
+	}
+	for _, entry := range q.entries {
+		if entry.Approved || (config.Approval != gc.ApproveManual && now.Sub(entry.Since) >= config.TTL.Duration()) {
+			due = append(due, *entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Since.Before(due[j].Since) })
+	return
+}
+
+// view returns the resources in quarantine by id
+func (q *quarantine) view() map[string]Quarantined {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	view := map[string]Quarantined{}
+	for id, entry := range q.entries {
+		view[string(id)] = *entry
+	}
+	return view
+}
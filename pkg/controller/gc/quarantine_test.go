@@ -0,0 +1,232 @@
+package gc // import "github.com/docker/infrakit/pkg/controller/gc"
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	gc "github.com/docker/infrakit/pkg/controller/gc/types"
+	"github.com/docker/infrakit/pkg/controller/internal"
+	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	testing_instance "github.com/docker/infrakit/pkg/testing/instance"
+	testing_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuarantineDue(t *testing.T) {
+
+	t0 := time.Now()
+	q := newQuarantine()
+	require.True(t, q.add(SideInstance, "k1", instance.Description{ID: "i-1"}, t0))
+	require.False(t, q.add(SideInstance, "k1", instance.Description{ID: "i-1"}, t0.Add(time.Second)))
+	require.True(t, q.add(SideNode, "k2", instance.Description{ID: "n-2"}, t0.Add(time.Second)))
+
+	auto := gc.Quarantine{Approval: gc.ApproveAuto, TTL: types.FromDuration(time.Minute)}
+
+	due, paused := q.due(auto, t0.Add(30*time.Second))
+	require.False(t, paused)
+	require.Equal(t, 0, len(due))
+
+	due, _ = q.due(auto, t0.Add(time.Minute))
+	require.Equal(t, []instance.ID{"i-1"}, []instance.ID{due[0].ID})
+
+	due, _ = q.due(auto, t0.Add(2*time.Minute))
+	require.Equal(t, 2, len(due))
+	require.Equal(t, instance.ID("i-1"), due[0].ID)
+	require.Equal(t, instance.ID("n-2"), due[1].ID)
+
+	manual := gc.Quarantine{Approval: gc.ApproveManual}
+	due, _ = q.due(manual, t0.Add(time.Hour))
+	require.Equal(t, 0, len(due))
+
+	require.Equal(t, []instance.ID{"n-2"}, q.approve([]string{"n-2", "unknown"}))
+	require.Equal(t, []instance.ID{}, q.approve([]string{"n-2"}))
+	due, _ = q.due(manual, t0.Add(time.Hour))
+	require.Equal(t, 1, len(due))
+	require.Equal(t, instance.ID("n-2"), due[0].ID)
+
+	// kill switch
+	manual.MaxOrphans = 1
+	due, paused = q.due(manual, t0.Add(time.Hour))
+	require.True(t, paused)
+	require.Equal(t, 0, len(due))
+
+	q.remove("i-1")
+	due, paused = q.due(manual, t0.Add(time.Hour))
+	require.False(t, paused)
+	require.Equal(t, 1, len(due))
+
+	require.Equal(t, 1, len(q.view()))
+
+	require.True(t, q.add(SideNode, "k2", instance.Description{ID: "n-3"}, t0))
+	require.Equal(t, []instance.ID{}, q.release("k1"))
+	require.Equal(t, 2, len(q.release("k2")))
+	require.Equal(t, 0, len(q.view()))
+}
+
+type drainer struct {
+	testing_instance.Plugin
+	drained []instance.ID
+}
+
+func (d *drainer) Drain(id instance.ID) error {
+	d.drained = append(d.drained, id)
+	return nil
+}
+
+func TestReapQuarantined(t *testing.T) {
+
+	var lock sync.Mutex
+	labeled := map[instance.ID]map[string]string{}
+	destroyed := []instance.ID{}
+
+	instances := &testing_instance.Plugin{
+		DoLabel: func(id instance.ID, labels map[string]string) error {
+			lock.Lock()
+			defer lock.Unlock()
+			labeled[id] = labels
+			return nil
+		},
+		DoDestroy: func(id instance.ID, ctx instance.Context) error {
+			lock.Lock()
+			defer lock.Unlock()
+			destroyed = append(destroyed, id)
+			return nil
+		},
+	}
+	nodes := &drainer{}
+
+	testScope := testing_scope.DefaultScope()
+	testScope.ResolveInstance = func(n string) (instance.Plugin, error) {
+		return nodes, nil
+	}
+
+	q := newQuarantine()
+	managed, err := newReaper(testScope, DefaultOptions, q)
+	require.NoError(t, err)
+	r := managed.(*reaper)
+
+	r.properties.Quarantine = &gc.Quarantine{Approval: gc.ApproveManual, MaxOrphans: 1}
+	r.instances = instances
+	r.nodes = nodes
+	r.nodeScope = testScope
+	r.nodeObserver = &internal.InstanceObserver{}
+
+	events := make(chan *event.Event)
+	r.PublishOn(events)
+	go func() {
+		for range events {
+		}
+	}()
+
+	spec, err := fsm.Define(fsm.State{Index: 0})
+	require.NoError(t, err)
+	set := fsm.NewSet(spec, fsm.Wall(time.Tick(time.Second)), fsm.DefaultOptions("test"))
+	defer set.Stop()
+
+	f1 := set.Add(0)
+	r.Collection.Put("link1", f1, spec, map[string]interface{}{
+		"node":     instance.Description{ID: "n-1"},
+		"instance": instance.Description{ID: "i-1"},
+	})
+	r.reap(SideInstance, f1, instance.Description{ID: "i-1"})
+
+	require.Contains(t, labeled["i-1"], QuarantineLabel)
+	require.Equal(t, []instance.ID{"n-1"}, nodes.drained)
+	require.Equal(t, "link1", q.view()["i-1"].Key)
+
+	// manual approval
+	r.reapQuarantined(time.Now().Add(time.Hour))
+	require.Equal(t, 0, len(destroyed))
+
+	r.reap(SideInstance, set.Add(0), instance.Description{ID: "i-2"})
+
+	// kill switch
+	_, err = r.Approve(types.Spec{Properties: types.AnyValueMust(gc.Approve{IDs: []string{"i-1", "i-2"}})})
+	require.NoError(t, err)
+	_, err = r.Approve(types.Spec{Properties: types.AnyValueMust(gc.Approve{IDs: []string{"unknown"}})})
+	require.Error(t, err)
+	r.reapQuarantined(time.Now())
+	require.True(t, r.paused)
+	require.Equal(t, 0, len(destroyed))
+
+	q.remove("i-2")
+	r.reapQuarantined(time.Now())
+	require.False(t, r.paused)
+	require.Equal(t, []instance.ID{"i-1"}, destroyed)
+	require.Equal(t, 0, len(q.view()))
+
+	var view map[string]interface{}
+	for i := 0; i < 10; i++ {
+		any, err := r.Metadata().Get(types.PathFromString(QuarantineKey))
+		require.NoError(t, err)
+		require.NoError(t, any.Decode(&view))
+		if len(view) == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 0, len(view))
+}
+
+type readyModel struct {
+	Model
+	ready bool
+}
+
+func (m *readyModel) NodeReady(instance.Description) bool {
+	return m.ready
+}
+
+func TestReleaseQuarantined(t *testing.T) {
+
+	q := newQuarantine()
+	managed, err := newReaper(testing_scope.DefaultScope(), DefaultOptions, q)
+	require.NoError(t, err)
+	r := managed.(*reaper)
+
+	model := &readyModel{}
+	r.model = model
+
+	events := make(chan *event.Event)
+	r.PublishOn(events)
+	go func() {
+		for range events {
+		}
+	}()
+
+	spec, err := fsm.Define(fsm.State{Index: 0})
+	require.NoError(t, err)
+	set := fsm.NewSet(spec, fsm.Wall(time.Tick(time.Second)), fsm.DefaultOptions("test"))
+	defer set.Stop()
+
+	item := r.Collection.Put("link1", set.Add(0), spec, map[string]interface{}{
+		"node": instance.Description{ID: "n-1"},
+	})
+	q.add(SideInstance, "link1", instance.Description{ID: "i-1"}, time.Now())
+	q.add(SideNode, "link1", instance.Description{ID: "n-1"}, time.Now())
+	q.add(SideInstance, "link2", instance.Description{ID: "i-2"}, time.Now())
+
+	// not ready
+	item.Data["instance"] = instance.Description{ID: "i-1"}
+	r.release(item)
+	require.Equal(t, 3, len(q.view()))
+
+	// ready but without its instance
+	model.ready = true
+	delete(item.Data, "instance")
+	r.release(item)
+	require.Equal(t, 3, len(q.view()))
+
+	item.Data["instance"] = instance.Description{ID: "i-1"}
+	r.release(item)
+	require.Equal(t, []string{"i-2"}, func() (ids []string) {
+		for id := range q.view() {
+			ids = append(ids, id)
+		}
+		return
+	}())
+}
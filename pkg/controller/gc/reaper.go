@@ -2,11 +2,13 @@ package gc // import "github.com/docker/infrakit/pkg/controller/gc"
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	gc "github.com/docker/infrakit/pkg/controller/gc/types"
 	"github.com/docker/infrakit/pkg/controller/internal"
 	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	instance_plugin "github.com/docker/infrakit/pkg/plugin/instance"
 	"github.com/docker/infrakit/pkg/run/scope"
 	"github.com/docker/infrakit/pkg/spi/event"
@@ -27,11 +29,15 @@ type reaper struct {
 	instanceObserver *internal.InstanceObserver
 
 	scope     scope.Scope
+	nodeScope scope.Scope
 	nodes     instance.Plugin
 	instances instance.Plugin
+
+	quarantine *quarantine
+	paused     bool
 }
 
-func newReaper(scope scope.Scope, options gc.Options) (internal.Managed, error) {
+func newReaper(scope scope.Scope, options gc.Options, quarantine *quarantine) (internal.Managed, error) {
 	if err := options.Validate(context.Background()); err != nil {
 		return nil, err
 	}
@@ -45,18 +51,28 @@ func newReaper(scope scope.Scope, options gc.Options) (internal.Managed, error)
 		Collection: base,
 		scope:      scope,
 		options:    options,
+		quarantine: quarantine,
 	}
 
 	base.StartFunc = r.run
 	base.StopFunc = r.stop
 	base.UpdateSpecFunc = r.updateSpec
+	base.MetricsFunc = r.metrics
 
 	return r, nil
 }
 
 // Metadata returns an optional metadata.Plugin implementation
 func (r *reaper) Metadata() metadata.Plugin {
-	return nil
+	return r.Collection.Metadata()
+}
+
+// metrics is called with the lock on the collection held
+func (r *reaper) metrics() *fsm.Metrics {
+	if r.model == nil {
+		return nil
+	}
+	return r.model.Metrics()
 }
 
 // Events returns an optional event.Plugin implementation
@@ -92,6 +108,9 @@ var (
 	defaultInstanceObserver = &internal.InstanceObserver{
 		ObserveInterval: types.Duration(1 * time.Second),
 	}
+
+	// quarantineCheckInterval is how often the quarantine is checked for resources to destroy
+	quarantineCheckInterval = 1 * time.Second
 )
 
 func (r *reaper) updateSpec(spec types.Spec, prev *types.Spec) error {
@@ -125,7 +144,16 @@ func (r *reaper) updateSpec(spec types.Spec, prev *types.Spec) error {
 	if err := nodeObserver.Validate(defaultInstanceObserver); err != nil {
 		return err
 	}
-	if err := nodeObserver.Init(r.scope, r.options.PluginRetryInterval.Duration()); err != nil {
+
+	nodeScope := r.scope
+	if provider, is := model.(NodeProvider); is {
+		nodes, err := provider.NodePlugin(r.scope)
+		if err != nil {
+			return err
+		}
+		nodeScope = &providedNodes{Scope: r.scope, name: nodeObserver.Name.String(), nodes: nodes}
+	}
+	if err := nodeObserver.Init(nodeScope, r.options.PluginRetryInterval.Duration()); err != nil {
 		return err
 	}
 
@@ -139,15 +167,51 @@ func (r *reaper) updateSpec(spec types.Spec, prev *types.Spec) error {
 		r.options.PluginRetryInterval.Duration())
 	r.nodes = instance_plugin.LazyConnect(
 		func() (instance.Plugin, error) {
-			return r.scope.Instance(r.nodeObserver.Name.String())
+			return nodeScope.Instance(r.nodeObserver.Name.String())
 		},
 		r.options.PluginRetryInterval.Duration())
 
+	r.nodeScope = nodeScope
 	r.properties = properties
 	r.model = model
 	return nil
 }
 
+// Approve implements internal.Approver.  It approves the destroy of the quarantined resources in the Properties.
+func (r *reaper) Approve(spec types.Spec) (*types.Object, error) {
+	if spec.Properties == nil {
+		return nil, fmt.Errorf("missing properties")
+	}
+	approve := gc.Approve{}
+	if err := spec.Properties.Decode(&approve); err != nil {
+		return nil, err
+	}
+
+	approved := r.quarantine.approve(approve.IDs)
+	if len(approved) == 0 {
+		return nil, fmt.Errorf("not in quarantine: %v", approve.IDs)
+	}
+	log.Info("Approved destroy of quarantined", "ids", approved)
+	r.reportQuarantine()
+
+	return r.Inspect()
+}
+
+// providedNodes is the scope where the node plugin is provided by the model
+type providedNodes struct {
+	scope.Scope
+	name  string
+	nodes instance.Plugin
+}
+
+// Instance returns the provided plugin for the name of the node observer
+func (s *providedNodes) Instance(name string) (instance.Plugin, error) {
+	if name == s.name {
+		return s.nodes, nil
+	}
+	return s.Scope.Instance(name)
+}
+
 func (r *reaper) getNodeDescription(i fsm.FSM) (desc *instance.Description) {
 	r.Collection.Visit(func(item internal.Item) bool {
 		if item.State.ID() == i.ID() {
@@ -177,6 +241,13 @@ func (r *reaper) gc(ctx context.Context) {
 	nodeInput := r.model.GCNode()
 	instanceInput := r.model.GCInstance()
 
+	var check <-chan time.Time
+	if r.properties.Quarantine != nil {
+		ticker := time.NewTicker(quarantineCheckInterval)
+		defer ticker.Stop()
+		check = ticker.C
+	}
+
 	for {
 		select {
 
@@ -188,13 +259,7 @@ func (r *reaper) gc(ctx context.Context) {
 
 			t := r.getNodeDescription(m)
 			if t != nil {
-				err := r.nodes.Destroy(t.ID, instance.Termination)
-
-				log.Debug("nodeDestroy", "id", t.ID, "node", t, "V", debugV)
-
-				if err != nil {
-					log.Error("error destroying node", "err", err, "id", t.ID)
-				}
+				r.reap(SideNode, m, *t)
 			}
 
 		case m, ok := <-instanceInput:
@@ -205,16 +270,133 @@ func (r *reaper) gc(ctx context.Context) {
 
 			t := r.getInstanceDescription(m)
 			if t != nil {
-				err := r.instances.Destroy(t.ID, instance.Termination)
+				r.reap(SideInstance, m, *t)
+			}
 
-				log.Debug("instanceDestroy", "id", t.ID, "instance", t, "V", debugV)
+		case now := <-check:
+			r.reapQuarantined(now)
+		}
+	}
+}
 
-				if err != nil {
-					log.Error("error destroying instance", "err", err, "id", t.ID)
-				}
-			}
+func (r *reaper) plugin(side Side) instance.Plugin {
+	if side == SideNode {
+		return r.nodes
+	}
+	return r.instances
+}
+
+func (r *reaper) destroy(side Side, id instance.ID) error {
+	if err := freeze.Check(); err != nil {
+		log.Warn("Not destroying", "side", side, "id", id, "err", err)
+		return err
+	}
+
+	err := r.plugin(side).Destroy(id, instance.Termination)
+
+	log.Debug("destroy", "side", side, "id", id, "V", debugV)
+
+	if err != nil {
+		log.Error("error destroying", "side", side, "err", err, "id", id)
+	}
+	return err
+}
+
+// reap destroys the resource the model gave up on, or puts it in quarantine if configured
+func (r *reaper) reap(side Side, m fsm.FSM, desc instance.Description) {
+	if r.properties.Quarantine == nil {
+		r.destroy(side, desc.ID)
+		return
+	}
+
+	key := ""
+	var node *instance.Description
+	if item := r.Collection.GetByFSM(m); item != nil {
+		key = item.Key
+		if n, has := item.Data["node"].(instance.Description); has {
+			node = &n
+		}
+	}
+
+	now := time.Now()
+	if !r.quarantine.add(side, key, desc, now) {
+		return
+	}
+	log.Warn("Quarantined", "side", side, "id", desc.ID, "key", key, "approval", r.properties.Quarantine.Approval)
+
+	err := r.plugin(side).Label(desc.ID, map[string]string{QuarantineLabel: now.Format(time.RFC3339)})
+	if err != nil {
+		log.Warn("Cannot label quarantined", "side", side, "id", desc.ID, "err", err)
+	}
+
+	if node != nil {
+		r.drain(node.ID)
+	}
+	r.reportQuarantine()
+}
+
+// drain drains the node if the node plugin supports it
+func (r *reaper) drain(id instance.ID) {
+	p, err := r.nodeScope.Instance(r.nodeObserver.Name.String())
+	if err != nil {
+		log.Warn("Cannot drain node", "id", id, "err", err)
+		return
+	}
+	drainer, is := p.(Drainer)
+	if !is {
+		return
+	}
+	if err := drainer.Drain(id); err != nil {
+		log.Warn("Cannot drain node", "id", id, "err", err)
+		return
+	}
+	log.Info("Drained node", "id", id)
+}
+
+// reapQuarantined destroys the quarantined resources that are due, unless paused by the kill switch
+func (r *reaper) reapQuarantined(now time.Time) {
+	due, paused := r.quarantine.due(*r.properties.Quarantine, now)
+	if paused != r.paused {
+		r.paused = paused
+		if paused {
+			log.Warn("Too many orphans, all destroys paused", "max", r.properties.Quarantine.MaxOrphans)
+		} else {
+			log.Info("Destroys resumed")
 		}
+		r.reportQuarantine()
 	}
+
+	for _, q := range due {
+		if err := r.destroy(q.Side, q.ID); err != nil {
+			continue
+		}
+		r.quarantine.remove(q.ID)
+		r.reportQuarantine()
+	}
+}
+
+// release takes the resources of the item out of quarantine once its node is found ready with its instance
+func (r *reaper) release(item *internal.Item) {
+	checker, is := r.model.(NodeChecker)
+	if !is {
+		return
+	}
+	node, has := item.Data["node"].(instance.Description)
+	if !has {
+		return
+	}
+	if _, has := item.Data["instance"].(instance.Description); !has || !checker.NodeReady(node) {
+		return
+	}
+	if released := r.quarantine.release(item.Key); len(released) > 0 {
+		log.Info("Released from quarantine", "key", item.Key, "ids", released)
+		r.reportQuarantine()
+	}
+}
+
+func (r *reaper) reportQuarantine() {
+	r.Collection.MetadataPut(types.PathFromString(QuarantineKey), r.quarantine.view())
+	r.Collection.MetadataPut(types.PathFromString(PausedKey), r.paused)
 }
 
 func (r *reaper) processObservations(ctx context.Context) {
@@ -281,6 +463,7 @@ func (r *reaper) processObservations(ctx context.Context) {
 				item.Data["node"] = found // update the node
 
 				r.model.FoundNode(item.State, found) // signal the fsm
+				r.release(item)
 
 				log.Debug("foundNode", "node", found, "V", debugV)
 			}
@@ -304,6 +487,7 @@ func (r *reaper) processObservations(ctx context.Context) {
 				item.Data["instance"] = found // update the instance
 
 				r.model.FoundInstance(item.State, found) // signal the fsm
+				r.release(item)
 
 				log.Debug("foundInstance", "instance", found, "V", debugV)
 			}
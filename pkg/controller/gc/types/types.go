@@ -2,6 +2,7 @@ package types // import "github.com/docker/infrakit/pkg/controller/gc/types"
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/docker/infrakit/pkg/controller/internal"
 	logutil "github.com/docker/infrakit/pkg/log"
@@ -31,7 +32,7 @@ func ResolveDependencies(spec types.Spec) (depends.Runnables, error) {
 		return nil, err
 	}
 
-	return depends.Runnables{
+	runnables := depends.Runnables{
 		depends.AsRunnable(
 			types.Spec{
 				Kind: properties.InstanceObserver.Name.Lookup(),
@@ -40,15 +41,19 @@ func ResolveDependencies(spec types.Spec) (depends.Runnables, error) {
 				},
 			},
 		),
-		depends.AsRunnable(
+	}
+	// Models that provide their own node plugin don't need the node observer to name one.
+	if properties.NodeObserver.Name != "" {
+		runnables = append(runnables, depends.AsRunnable(
 			types.Spec{
 				Kind: properties.NodeObserver.Name.Lookup(),
 				Metadata: types.Metadata{
 					Name: properties.NodeObserver.Name.String(),
 				},
 			},
-		),
-	}, nil
+		))
+	}
+	return runnables, nil
 }
 
 // Properties is the schema of the configuration in the types.Spec.Properties
@@ -65,13 +70,67 @@ type Properties struct {
 
 	// NodeObserver is the observer of the 'node' side
 	NodeObserver internal.InstanceObserver
+
+	// Quarantine holds the resources to destroy until approved.  If not set, resources are destroyed
+	// as soon as the model says so.
+	Quarantine *Quarantine `json:",omitempty" yaml:",omitempty"`
 }
 
 // Validate validates the input properties
 func (p Properties) Validate(ctx context.Context) error {
+	if p.Quarantine != nil {
+		return p.Quarantine.Validate()
+	}
 	return nil
 }
 
+// Approval is how the destroy of a quarantined resource is approved
+type Approval string
+
+const (
+	// ApproveAuto approves the destroy once the resource has been in quarantine for the TTL
+	ApproveAuto Approval = "auto"
+
+	// ApproveManual requires the destroy of each resource to be approved, with infrakit gc approve <id>
+	ApproveManual Approval = "manual"
+)
+
+// Quarantine is the configuration of the quarantine of the resources to destroy.  A quarantined resource
+// is labeled, drained if the node plugin supports it, and reported in the metadata at quarantine/<id>.
+type Quarantine struct {
+
+	// Approval is the approval mode, auto (default) or manual
+	Approval Approval
+
+	// TTL is how long a resource is in quarantine before it's destroyed with auto approval
+	TTL types.Duration
+
+	// MaxOrphans is the kill switch: all destroys are paused while more than MaxOrphans resources
+	// are in quarantine.  0 means no limit.
+	MaxOrphans int
+}
+
+// Validate validates the quarantine
+func (q Quarantine) Validate() error {
+	switch q.Approval {
+	case "", ApproveAuto, ApproveManual:
+	default:
+		return fmt.Errorf("unknown approval %v", q.Approval)
+	}
+	if q.MaxOrphans < 0 {
+		return fmt.Errorf("bad max orphans %v", q.MaxOrphans)
+	}
+	return nil
+}
+
+// Approve is the Properties of the spec committed to approve the destroy of quarantined resources.
+// It's not stored.
+type Approve struct {
+
+	// IDs are the ids of the quarantined resources approved to be destroyed
+	IDs []string
+}
+
 // Options is the controller options that is used at start up of the process.  It's one-time
 type Options struct {
 
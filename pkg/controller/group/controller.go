@@ -42,7 +42,7 @@ func (c *adapter) translateSpec(spec types.Spec) (group.Spec, error) {
 		if addressable.Instance() == "" {
 			return gSpec, fmt.Errorf("no group name")
 		}
-		gSpec.ID = group.ID(addressable.Instance())
+		gSpec.ID = group.ID(types.Namespaced(spec.Metadata.Namespace, addressable.Instance()))
 		return gSpec, nil
 	}
 	if addressable.Instance() != string(*c.scope) {
@@ -158,11 +158,16 @@ func (c *adapter) Commit(operation controller.Operation, spec types.Spec) (objec
 		return
 	}
 
+	// the commit or destroy carries the fencing token of the leader, if any, to the group plugin
+	token, err := controller.TokenOf(spec)
+	if err != nil {
+		return
+	}
 	switch operation {
 	case controller.Enforce:
-		_, err = c.plugin.CommitGroup(gSpec, false)
+		_, err = group.CommitWithToken(c.plugin, gSpec, false, token)
 	case controller.Destroy:
-		err = c.plugin.DestroyGroup(group.ID(spec.Metadata.Name))
+		err = group.DestroyWithToken(c.plugin, group.ID(types.Namespaced(spec.Metadata.Namespace, spec.Metadata.Name)), token)
 	}
 	return
 }
@@ -179,7 +184,7 @@ func (c *adapter) Describe(search *types.Metadata) (objects []types.Object, err
 			return true
 		}
 		query := plugin.NewAddressableFromMetadata(c.Kind(), *search)
-		return query.Instance() == string(gid)
+		return types.Namespaced(search.Namespace, query.Instance()) == string(gid)
 	}
 
 	objects = []types.Object{}
@@ -215,7 +220,7 @@ func (c *adapter) Specs(search *types.Metadata) (specs []types.Spec, err error)
 			return true
 		}
 		query := plugin.NewAddressableFromMetadata(c.Kind(), *search)
-		return query.Instance() == string(gid)
+		return types.Namespaced(search.Namespace, query.Instance()) == string(gid)
 	}
 
 	specs = []types.Spec{}
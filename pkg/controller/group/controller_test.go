@@ -9,6 +9,7 @@ import (
 	"github.com/docker/infrakit/pkg/spi/controller"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/store"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -293,3 +294,52 @@ properties:
 	_, err = types.AnyValueMust(objects).MarshalYAML()
 	require.NoError(t, err)
 }
+
+// fencedGroup is a group plugin whose commits and destroys carry the fencing token
+type fencedGroup struct {
+	group.Plugin
+	tokens []store.Token
+}
+
+func (g *fencedGroup) CommitGroupWithToken(grp group.Spec, pretend bool, token store.Token) (string, error) {
+	g.tokens = append(g.tokens, token)
+	return g.Plugin.CommitGroup(grp, pretend)
+}
+
+func (g *fencedGroup) DestroyGroupWithToken(id group.ID, token store.Token) error {
+	g.tokens = append(g.tokens, token)
+	return g.Plugin.DestroyGroup(id)
+}
+
+func TestAsControllerCommitWithToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	spec, err := types.SpecFromString(`
+kind: group
+metadata:
+  name: workers
+properties:
+  Instance:
+    Plugin: aws/ec2-instance
+`)
+	require.NoError(t, err)
+
+	g := group_mock.NewMockPlugin(ctrl)
+	g.EXPECT().InspectGroups().AnyTimes().Return([]group.Spec{}, nil)
+	g.EXPECT().CommitGroup(gomock.Any(), false).Times(2).Return("ok", nil)
+
+	fenced := &fencedGroup{Plugin: g}
+	c := AsController(plugin.NewAddressable("group", plugin.Name("group-stateless/"), ""), fenced)
+
+	_, err = c.Commit(controller.Enforce, controller.WithToken(spec, 3))
+	require.NoError(t, err)
+	_, err = c.Commit(controller.Enforce, spec)
+	require.NoError(t, err)
+
+	g.EXPECT().DestroyGroup(group.ID("workers")).Return(nil)
+	_, err = c.Commit(controller.Destroy, controller.WithToken(spec, 4))
+	require.NoError(t, err)
+
+	require.Equal(t, []store.Token{3, 0, 4}, fenced.tokens)
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
 )
@@ -116,7 +117,12 @@ func (q *quorum) converge() {
 
 	grp := sync.WaitGroup{}
 
+	frozen := freeze.Check()
 	for _, ip := range unknownIPs {
+		if frozen != nil {
+			log.Warn("Not destroying instance with unknown IP address", "instance", ip, "err", frozen)
+			continue
+		}
 		unknownInstance := ip
 		log.Warn("Destroying instances with unknown IP address", "instance", unknownInstance)
 
@@ -145,6 +151,10 @@ func (q *quorum) converge() {
 	}
 
 	for _, missingID := range missingIDs {
+		if frozen != nil {
+			log.Warn("Not provisioning missing logical ID", "instance", missingID, "err", frozen)
+			continue
+		}
 		log.Info("Logical ID is missing, provisioning new instance", "instance", missingID)
 		id := missingID
 
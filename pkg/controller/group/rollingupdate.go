@@ -6,6 +6,7 @@ import (
 	"time"
 
 	group_types "github.com/docker/infrakit/pkg/controller/group/types"
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	"github.com/docker/infrakit/pkg/spi/flavor"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
@@ -208,6 +209,10 @@ func (r *rollingupdate) Run(pollInterval time.Duration, updating group_types.Upd
 			return err
 		}
 
+		if err := r.waitWhileFrozen(pollInterval); err != nil {
+			return err
+		}
+
 		instances, err := labelAndList(r.scaled)
 		if err != nil {
 			return err
@@ -243,6 +248,22 @@ func (r *rollingupdate) Run(pollInterval time.Duration, updating group_types.Upd
 	return nil
 }
 
+// waitWhileFrozen blocks while the manager is frozen, so the update resumes where it left off when unfrozen.
+func (r *rollingupdate) waitWhileFrozen(pollInterval time.Duration) error {
+	for {
+		err := freeze.Check()
+		if err == nil {
+			return nil
+		}
+		log.Warn("RollingUpdate paused", "err", err)
+		select {
+		case <-time.After(pollInterval):
+		case <-r.stop:
+			return errors.New("Update halted by user")
+		}
+	}
+}
+
 func (r *rollingupdate) Stop() {
 	close(r.stop)
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	group_types "github.com/docker/infrakit/pkg/controller/group/types"
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
 )
@@ -229,6 +230,12 @@ func (s *scaler) converge() {
 
 	actualSize := uint(len(descriptions))
 	desiredSize := s.getSize()
+	if actualSize != desiredSize {
+		if err := freeze.Check(); err != nil {
+			log.Warn("Not scaling", "id", s.id, "actualSize", actualSize, "desired", desiredSize, "err", err)
+			return
+		}
+	}
 	switch {
 	case actualSize == desiredSize:
 		log.Debug("No action - Group has enough instances", "desired", desiredSize)
@@ -6,10 +6,12 @@ import (
 	"time"
 
 	group_types "github.com/docker/infrakit/pkg/controller/group/types"
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	mock_group "github.com/docker/infrakit/pkg/mock/plugin/group"
 	mock_instance "github.com/docker/infrakit/pkg/mock/spi/instance"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/spi/stack"
 	testutil "github.com/docker/infrakit/pkg/testing"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -52,6 +54,30 @@ func TestScaleUp(t *testing.T) {
 	scaler.Run()
 }
 
+func TestScaleFrozen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	freeze.Set(&stack.Freeze{Reason: "incident"})
+	defer freeze.Set(nil)
+
+	groupID := group.ID("scaler")
+
+	scaled := mock_group.NewMockScaled(ctrl)
+	scaler := NewScalingGroup(groupID, scaled, 3, 1*time.Millisecond, 0)
+
+	// no instances are created while frozen
+	gomock.InOrder(
+		scaled.EXPECT().List().Return([]instance.Description{a, b}, nil),
+		scaled.EXPECT().List().Do(func() {
+			go scaler.Stop()
+		}).Return([]instance.Description{a, b}, nil),
+		scaled.EXPECT().List().Return([]instance.Description{a, b}, nil).AnyTimes(),
+	)
+
+	scaler.Run()
+}
+
 func TestBufferScaleUp(t *testing.T) {
 
 	if testutil.SkipTests("flaky") {
@@ -89,6 +89,10 @@ func (c *managed) defaultBehaviors(spec types.Spec) {
 		c.groups = properties.Groups
 	}
 
+	if c.weighted == nil {
+		c.weighted = properties.Weighted
+	}
+
 	if c.instanceIDs == nil {
 		c.instanceIDs = properties.InstanceIDs
 	}
@@ -0,0 +1,125 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/controller/ingress/kubernetes"
+
+import (
+	ingress "github.com/docker/infrakit/pkg/controller/ingress/types"
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/spi/loadbalancer"
+	"github.com/docker/infrakit/pkg/types"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var log = logutil.New("module", "controller/ingress/kubernetes")
+
+const (
+	debugV = logutil.V(300)
+
+	// VhostAnnotation is the annotation on a service for the vhost of its routes.  The default is "default".
+	VhostAnnotation = "infrakit.io/ingress-vhost"
+
+	// ProtocolAnnotation is the annotation on a service for the loadbalancer protocol of its routes, e.g. HTTPS.
+	// The default is the protocol of the service port.
+	ProtocolAnnotation = "infrakit.io/ingress-protocol"
+
+	// DefaultCertificateAnnotation is the default annotation on a service for the certificate id
+	DefaultCertificateAnnotation = "infrakit.io/ingress-certificate"
+
+	// DefaultHealthMonitorPathAnnotation is the default annotation on a service for the url path of a health monitor
+	DefaultHealthMonitorPathAnnotation = "infrakit.io/ingress-health-path"
+)
+
+func init() {
+
+	// Register the kubernetes based ingress route finder.  This will be included when the package is imported
+	// in the main or wherever kubernetes is to be supported.
+	ingress.RegisterRouteHandler(
+		"kubernetes",
+		RoutesFromKubernetesServices,
+	)
+}
+
+// Spec is the struct that captures the configuration of the kubernetes-based ingress route finder
+type Spec struct {
+
+	// Kubeconfig is the path of the kubeconfig file.  If not set, the in-cluster config is used.
+	Kubeconfig string
+
+	// Master is the address of the API server, overriding the one in the kubeconfig
+	Master string
+
+	// Namespace is the namespace of the services.  The default is all namespaces.
+	Namespace string
+
+	// Selector is the label selector of the services, e.g. app=web
+	Selector string
+
+	// CertificateAnnotation is the annotation on services that we look for to get the certificate id.
+	CertificateAnnotation *string
+
+	// HealthMonitorPathAnnotation is the annotation on services that we look for to get the url path
+	// for a health monitor.
+	HealthMonitorPathAnnotation *string
+}
+
+type handler struct {
+	// services returns the client of the services in the namespace
+	services func(spec Spec) (core_v1.ServiceInterface, error)
+}
+
+// Close implements io.Closer
+func (h *handler) Close() error {
+	return nil
+}
+
+func services(spec Spec) (core_v1.ServiceInterface, error) {
+	config, err := clientcmd.BuildConfigFromFlags(spec.Master, spec.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return clientset.CoreV1().Services(spec.Namespace), nil
+}
+
+// Routes implements ingress/types/RouteHandler
+func (h *handler) Routes(properties *types.Any,
+	options ingress.Options) (map[ingress.Vhost][]loadbalancer.Route, error) {
+
+	spec := Spec{}
+	if properties != nil {
+		if err := properties.Decode(&spec); err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := h.services(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := client.List(meta_v1.ListOptions{LabelSelector: spec.Selector})
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Found services", "count", len(list.Items), "selector", spec.Selector, "V", debugV)
+
+	certAnnotation := DefaultCertificateAnnotation
+	if spec.CertificateAnnotation != nil {
+		certAnnotation = *spec.CertificateAnnotation
+	}
+	healthAnnotation := DefaultHealthMonitorPathAnnotation
+	if spec.HealthMonitorPathAnnotation != nil {
+		healthAnnotation = *spec.HealthMonitorPathAnnotation
+	}
+	return routesFromServices(list.Items, certAnnotation, healthAnnotation), nil
+}
+
+// RoutesFromKubernetesServices determines the routes based on the NodePort and LoadBalancer services
+// in the kubernetes cluster
+func RoutesFromKubernetesServices() (ingress.RouteHandler, error) {
+	return &handler{services: services}, nil
+}
@@ -0,0 +1,64 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/controller/ingress/kubernetes"
+
+import (
+	"testing"
+
+	ingress "github.com/docker/infrakit/pkg/controller/ingress/types"
+	mock_core_v1 "github.com/docker/infrakit/pkg/mock/kubernetes/typed/core/v1"
+	"github.com/docker/infrakit/pkg/spi/loadbalancer"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	services := mock_core_v1.NewMockServiceInterface(ctrl)
+	services.EXPECT().List(meta_v1.ListOptions{LabelSelector: "app=web"}).Return(&v1.ServiceList{
+		Items: []v1.Service{
+			service("web", v1.ServiceTypeNodePort,
+				map[string]string{
+					"example.com/cert": "cert",
+					VhostAnnotation:    "web.example.com",
+					ProtocolAnnotation: "https",
+				},
+				v1.ServicePort{Port: 443, NodePort: 30443, Protocol: v1.ProtocolTCP},
+			),
+		},
+	}, nil)
+
+	var namespace string
+	h := &handler{
+		services: func(spec Spec) (core_v1.ServiceInterface, error) {
+			namespace = spec.Namespace
+			return services, nil
+		},
+	}
+
+	certAnnotation := "example.com/cert"
+	routes, err := h.Routes(types.AnyValueMust(Spec{
+		Namespace:             "prod",
+		Selector:              "app=web",
+		CertificateAnnotation: &certAnnotation,
+	}), ingress.Options{})
+	require.NoError(t, err)
+	require.Equal(t, "prod", namespace)
+
+	cert := "cert"
+	require.Equal(t, map[ingress.Vhost][]loadbalancer.Route{
+		ingress.Vhost("web.example.com"): {
+			{
+				Port:                 30443,
+				Protocol:             loadbalancer.TCP,
+				LoadBalancerPort:     443,
+				LoadBalancerProtocol: loadbalancer.HTTPS,
+				Certificate:          &cert,
+			},
+		},
+	}, routes)
+}
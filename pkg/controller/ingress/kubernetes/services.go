@@ -0,0 +1,66 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/controller/ingress/kubernetes"
+
+import (
+	ingress "github.com/docker/infrakit/pkg/controller/ingress/types"
+	"github.com/docker/infrakit/pkg/spi/loadbalancer"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// routesFromServices returns the routes of the services of type NodePort or LoadBalancer, by vhost.  Each
+// port of a service is a route from the service port on the loadbalancer to the node port on the nodes.
+func routesFromServices(services []v1.Service, certAnnotation, healthAnnotation string) map[ingress.Vhost][]loadbalancer.Route {
+
+	result := map[ingress.Vhost][]loadbalancer.Route{}
+	for _, s := range services {
+
+		switch s.Spec.Type {
+		case v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer:
+		default:
+			continue
+		}
+
+		vhost := ingress.Vhost("default")
+		if v, has := s.Annotations[VhostAnnotation]; has && v != "" {
+			vhost = ingress.Vhost(v)
+		}
+
+		var cert, healthPath *string
+		if v, has := s.Annotations[certAnnotation]; has && v != "" {
+			cert = &v
+		}
+		if v, has := s.Annotations[healthAnnotation]; has && v != "" {
+			healthPath = &v
+		}
+
+		for _, p := range s.Spec.Ports {
+			if p.NodePort == 0 {
+				log.Warn("No node port", "service", s.Name, "namespace", s.Namespace, "port", p.Port)
+				continue
+			}
+
+			protocol := loadbalancer.ProtocolFromString(string(p.Protocol))
+			if p.Protocol == "" {
+				protocol = loadbalancer.TCP
+			}
+			lbProtocol := protocol
+			if v, has := s.Annotations[ProtocolAnnotation]; has {
+				lbProtocol = loadbalancer.ProtocolFromString(v)
+			}
+
+			route := loadbalancer.Route{
+				Port:                 int(p.NodePort),
+				Protocol:             protocol,
+				LoadBalancerPort:     int(p.Port),
+				LoadBalancerProtocol: lbProtocol,
+				Certificate:          cert,
+				HealthMonitorPath:    healthPath,
+			}
+			if err := route.Validate(); err != nil {
+				log.Warn("Bad route", "service", s.Name, "namespace", s.Namespace, "route", route, "err", err)
+				continue
+			}
+			result[vhost] = append(result[vhost], route)
+		}
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/controller/ingress/kubernetes"
+
+import (
+	"testing"
+
+	ingress "github.com/docker/infrakit/pkg/controller/ingress/types"
+	"github.com/docker/infrakit/pkg/spi/loadbalancer"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func service(name string, t v1.ServiceType, annotations map[string]string, ports ...v1.ServicePort) v1.Service {
+	s := v1.Service{}
+	s.Name = name
+	s.Namespace = "default"
+	s.Annotations = annotations
+	s.Spec.Type = t
+	s.Spec.Ports = ports
+	return s
+}
+
+func TestRoutesFromServices(t *testing.T) {
+
+	cert := "cert"
+	health := "/healthz"
+
+	routes := routesFromServices([]v1.Service{
+		service("web", v1.ServiceTypeNodePort,
+			map[string]string{
+				VhostAnnotation:                    "web.example.com",
+				ProtocolAnnotation:                 "https",
+				DefaultCertificateAnnotation:       cert,
+				DefaultHealthMonitorPathAnnotation: health,
+			},
+			v1.ServicePort{Port: 443, NodePort: 30443, Protocol: v1.ProtocolTCP},
+		),
+		service("dns", v1.ServiceTypeLoadBalancer, nil,
+			v1.ServicePort{Port: 53, NodePort: 30053, Protocol: v1.ProtocolUDP},
+			v1.ServicePort{Port: 54}, // no node port
+		),
+		service("internal", v1.ServiceTypeClusterIP, nil,
+			v1.ServicePort{Port: 80, Protocol: v1.ProtocolTCP},
+		),
+		service("bad", v1.ServiceTypeNodePort,
+			map[string]string{
+				ProtocolAnnotation: "https", // no certificate
+			},
+			v1.ServicePort{Port: 8443, NodePort: 31443, Protocol: v1.ProtocolTCP},
+		),
+	}, DefaultCertificateAnnotation, DefaultHealthMonitorPathAnnotation)
+
+	require.Equal(t, map[ingress.Vhost][]loadbalancer.Route{
+		ingress.Vhost("web.example.com"): {
+			{
+				Port:                 30443,
+				Protocol:             loadbalancer.TCP,
+				LoadBalancerPort:     443,
+				LoadBalancerProtocol: loadbalancer.HTTPS,
+				Certificate:          &cert,
+				HealthMonitorPath:    &health,
+			},
+		},
+		ingress.Vhost("default"): {
+			{
+				Port:                 30053,
+				Protocol:             loadbalancer.UDP,
+				LoadBalancerPort:     53,
+				LoadBalancerProtocol: loadbalancer.UDP,
+			},
+		},
+	}, routes)
+}
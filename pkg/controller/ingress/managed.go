@@ -39,8 +39,8 @@ type managed struct {
 	// Leader controls whether this ingress is active or not
 	leader func() stack.Leadership
 
-	// l4s is a function that get retrieve a map of L4 loadbalancers by name
-	l4s func() (map[ingress.Vhost]loadbalancer.L4, error)
+	// l4s is a function that get retrieve a map of L4 loadbalancers by vhost
+	l4s func() (map[ingress.Vhost][]loadbalancer.L4, error)
 
 	// routes is a function returning the desired state of routes by vhosts
 	routes func() (map[ingress.Vhost][]loadbalancer.Route, error)
@@ -51,6 +51,9 @@ type managed struct {
 	// groups is a function that looks up an association of vhost to lists of group ids
 	groups func() (map[ingress.Vhost][]ingress.Group, error)
 
+	// weighted is a function that looks up an association of vhost to lists of weighted groups
+	weighted func() (map[ingress.Vhost][]ingress.WeightedGroup, error)
+
 	// list of instance ids by vhost
 	instanceIDs func() (map[ingress.Vhost][]instance.ID, error)
 
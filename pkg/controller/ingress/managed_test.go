@@ -40,7 +40,7 @@ func TestManagedStartStop(t *testing.T) {
 		ticker:       ticker,
 		healthChecks: func() (map[ingress.Vhost][]loadbalancer.HealthCheck, error) { return nil, nil },
 		groups:       func() (map[ingress.Vhost][]ingress.Group, error) { return nil, nil },
-		l4s:          func() (map[ingress.Vhost]loadbalancer.L4, error) { return nil, nil },
+		l4s:          func() (map[ingress.Vhost][]loadbalancer.L4, error) { return nil, nil },
 
 		routes: func() (map[ingress.Vhost][]loadbalancer.Route, error) {
 			// if this function is called then we know we've done work in the state transition
@@ -1,6 +1,9 @@
 package ingress // import "github.com/docker/infrakit/pkg/controller/ingress"
 
 import (
+	"math"
+	"sort"
+
 	"github.com/deckarep/golang-set"
 	"github.com/docker/infrakit/pkg/controller/ingress/types"
 	"github.com/docker/infrakit/pkg/spi/instance"
@@ -23,11 +26,13 @@ func (c *managed) syncRoutesL4() error {
 	if err != nil {
 		return err
 	}
-	for vhost, elb := range elbs {
+	for vhost, l4s := range elbs {
 		// If a given elb does not have any vhosts and thus routes associated with it, it will have
 		// an entry in the targets map, but with no routes.  Then the empty routes slice will be sent
 		// to the elb to effectively remove the routes/ listeners.
-		targets[elb] = append(targets[elb], routesByVhost[vhost]...)
+		for _, elb := range l4s {
+			targets[elb] = append(targets[elb], routesByVhost[vhost]...)
+		}
 	}
 
 	log.Debug("expose l4", "targets", len(targets), "targets", targets, "meta", c.spec.Metadata)
@@ -60,6 +65,149 @@ func (c *managed) getSourceKeySelectorTemplate() (*template.Template, error) {
 	return c.sourceKeySelectorTemplate, nil
 }
 
+// members returns the backends of the group, by instance ID or by the key selected with the SourceKeySelector
+func (c *managed) members(g types.Group) ([]instance.ID, error) {
+	gid := g.ID()
+	groupPlugin, err := c.groupPlugin(g)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := groupPlugin.DescribeGroup(gid)
+	if err != nil {
+		// Failed to describe the group, since we do not know the members we do not want to proceed
+		log.Warn("error describing group, not syncing backends", "id", gid, "err", err, "meta", c.spec.Metadata)
+		return nil, err
+	}
+
+	log.Debug("found backends", "groupID", gid, "desc", desc, "meta", c.spec.Metadata)
+
+	members := []instance.ID{}
+	for _, inst := range desc.Instances {
+		t, err := c.getSourceKeySelectorTemplate()
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			members = append(members, inst.ID)
+		} else {
+			view, err := t.Render(inst)
+			if err != nil {
+				log.Error("cannot index entry", "instance.ID", inst.ID, "instance.tags", inst.Tags, "err", err, "meta", c.spec.Metadata)
+				continue
+			}
+			members = append(members, instance.ID(view))
+		}
+	}
+	return members, nil
+}
+
+// weigh returns how many members of each group to register so that the shares of the groups are
+// as close as possible to the ratio of their weights, with the most members registered.  Groups without
+// members are left out.
+func weigh(weighted []types.WeightedGroup, members map[types.Group][]instance.ID) map[types.Group]int {
+
+	total := 0
+	for _, w := range weighted {
+		if w.Weight > 0 && len(members[w.Group]) > 0 {
+			total += w.Weight
+		}
+	}
+
+	counts := func(scale float64) (map[types.Group]int, int) {
+		out := map[types.Group]int{}
+		sum := 0
+		for _, w := range weighted {
+			n := 0
+			if size := len(members[w.Group]); w.Weight > 0 && size > 0 {
+				n = int(math.Floor(scale*float64(w.Weight) + 0.5))
+				if n > size {
+					n = size
+				}
+			}
+			out[w.Group] = n
+			sum += n
+		}
+		return out, sum
+	}
+
+	deviation := func(c map[types.Group]int, sum int) float64 {
+		d := 0.
+		for _, w := range weighted {
+			if w.Weight > 0 && len(members[w.Group]) > 0 {
+				d += math.Abs(float64(c[w.Group])/float64(sum) - float64(w.Weight)/float64(total))
+			}
+		}
+		return d
+	}
+
+	// Try the scales that give each group an exact count of members and keep the best
+	best, bestSum := counts(0)
+	bestDeviation := math.MaxFloat64
+	for _, w := range weighted {
+		if w.Weight <= 0 {
+			continue
+		}
+		for m := 1; m <= len(members[w.Group]); m++ {
+			c, sum := counts(float64(m) / float64(w.Weight))
+			if sum == 0 {
+				continue
+			}
+			d := deviation(c, sum)
+			if d < bestDeviation-1e-9 || (math.Abs(d-bestDeviation) <= 1e-9 && sum > bestSum) {
+				best, bestSum, bestDeviation = c, sum, d
+			}
+		}
+	}
+	return best
+}
+
+// backends returns the desired backends of the vhost
+func (c *managed) backends(vhost types.Vhost, groups []types.Group, weighted []types.WeightedGroup) (mapset.Set, error) {
+
+	// all the nodes from all the groups and nodes
+	nodes := mapset.NewSet()
+
+	instanceIDs, _ := c.instanceIDs()
+	for _, id := range instanceIDs[vhost] {
+		nodes.Add(id)
+	}
+
+	log.Debug("backend groups", "groups", groups, "weighted", weighted, "meta", c.spec.Metadata)
+	for _, g := range groups {
+		members, err := c.members(g)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			nodes.Add(m)
+		}
+	}
+
+	if len(weighted) == 0 {
+		return nodes, nil
+	}
+
+	members := map[types.Group][]instance.ID{}
+	for _, w := range weighted {
+		list, err := c.members(w.Group)
+		if err != nil {
+			return nil, err
+		}
+		// sorted so the same members are picked each time
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+		members[w.Group] = list
+	}
+	counts := weigh(weighted, members)
+	log.Debug("weighted backends", "vhost", vhost, "counts", counts, "meta", c.spec.Metadata)
+	for g, n := range counts {
+		for _, m := range members[g][:n] {
+			nodes.Add(m)
+		}
+	}
+	return nodes, nil
+}
+
 func (c *managed) syncBackends() error {
 	groupsByVhost, err := c.groups()
 	log.Debug("Groups by vhost", "groups", groupsByVhost, "meta", c.spec.Metadata, "fsm", c.stateMachine.ID())
@@ -68,6 +216,11 @@ func (c *managed) syncBackends() error {
 		return err
 	}
 
+	weightedByVhost, err := c.weighted()
+	if err != nil {
+		return err
+	}
+
 	loadbalancersByVhost, err := c.l4s()
 	log.Debug("L4s by vhost", "l4s", loadbalancersByVhost)
 	if err != nil {
@@ -78,7 +231,7 @@ func (c *managed) syncBackends() error {
 	// for which we do not have any backends
 
 	unresolved := []types.Vhost{}
-	for vhost, l4 := range loadbalancersByVhost {
+	for vhost, l4s := range loadbalancersByVhost {
 
 		groups, has := groupsByVhost[vhost]
 		if !has {
@@ -86,104 +239,72 @@ func (c *managed) syncBackends() error {
 			continue
 		}
 
-		// we have backends and loadbalancers
-		registered := mapset.NewSet()
-		if backends, err := l4.Backends(); err != nil {
-			log.Warn("error getting backends", "err", err, "meta", c.spec.Metadata)
-			continue
-		} else {
-			for _, b := range backends {
-				registered.Add(b)
-			}
-		}
-		log.Debug("Registered backends", "backends", registered, "meta", c.spec.Metadata)
-
-		// all the nodes from all the groups and nodes
-		nodes := mapset.NewSet()
-
-		instanceIDs, _ := c.instanceIDs()
-		for _, id := range instanceIDs[vhost] {
-			nodes.Add(id)
-		}
-
-		log.Debug("backend groups", "groups", groups, "meta", c.spec.Metadata)
-		for _, g := range groups {
-
-			gid := g.ID()
-			groupPlugin, err := c.groupPlugin(g)
-			if err != nil {
-				return err
-			}
-
-			desc, err := groupPlugin.DescribeGroup(gid)
-			if err != nil {
-				// Failed to describe the group, since we do not know the members we do not want to proceed
-				log.Warn("error describing group, not syncing backends", "id", gid, "err", err, "meta", c.spec.Metadata)
-				return err
-			}
-
-			log.Debug("found backends", "groupID", gid, "desc", desc, "vhost", vhost, "L4", l4.Name(), "meta", c.spec.Metadata)
-
-			for _, inst := range desc.Instances {
-				t, err := c.getSourceKeySelectorTemplate()
-				if err != nil {
-					return err
-				}
-				if t == nil {
-					nodes.Add(inst.ID)
-				} else {
-					view, err := t.Render(inst)
-					if err != nil {
-						log.Error("cannot index entry", "instance.ID", inst.ID, "instance.tags", inst.Tags, "err", err, "meta", c.spec.Metadata)
-						continue
-					}
-					nodes.Add(instance.ID(view))
-				}
-			}
+		nodes, err := c.backends(vhost, groups, weightedByVhost[vhost])
+		if err != nil {
+			return err
 		}
 
 		log.Debug("Group data", "nodes", nodes)
 
-		// compute the difference between registered and nodes
-		toRemove := []instance.ID{}
-		for n := range registered.Difference(nodes).Iter() {
-			toRemove = append(toRemove, n.(instance.ID))
+		for _, l4 := range l4s {
+			c.syncL4Backends(vhost, l4, nodes)
 		}
+	}
 
-		// Use Info logging only when making deltas
-		logFn := log.Debug
-		if len(toRemove) > 0 {
-			logFn = log.Info
-		}
-		logFn("De-register backends", "instances", toRemove, "vhost", vhost, "L4", l4.Name(), "meta", c.spec.Metadata)
+	return nil
 
-		if result, err := l4.DeregisterBackends(toRemove); err != nil {
-			log.Warn("error deregistering backends", "toRemove", toRemove, "err", err, "meta", c.spec.Metadata)
-		} else {
-			logFn("deregistered backends", "vhost", vhost, "result", result, "meta", c.spec.Metadata)
-		}
+}
 
-		toAdd := []instance.ID{}
-		for n := range nodes.Difference(registered).Iter() {
-			toAdd = append(toAdd, n.(instance.ID))
+// syncL4Backends registers and deregisters the backends of the L4 to match the nodes
+func (c *managed) syncL4Backends(vhost types.Vhost, l4 loadbalancer.L4, nodes mapset.Set) {
+
+	// we have backends and loadbalancers
+	registered := mapset.NewSet()
+	if backends, err := l4.Backends(); err != nil {
+		log.Warn("error getting backends", "err", err, "L4", l4.Name(), "meta", c.spec.Metadata)
+		return
+	} else {
+		for _, b := range backends {
+			registered.Add(b)
 		}
+	}
+	log.Debug("Registered backends", "backends", registered, "L4", l4.Name(), "meta", c.spec.Metadata)
 
-		logFn = log.Debug
-		if len(toAdd) > 0 {
-			logFn = log.Info
-		}
+	// compute the difference between registered and nodes
+	toRemove := []instance.ID{}
+	for n := range registered.Difference(nodes).Iter() {
+		toRemove = append(toRemove, n.(instance.ID))
+	}
 
-		logFn("Register backends", "instances", toAdd, "vhost", vhost, "L4", l4.Name(), "meta", c.spec.Metadata)
-		if result, err := l4.RegisterBackends(toAdd); err != nil {
-			log.Warn("error registering backends", "toAdd", toAdd, "err", err, "meta", c.spec.Metadata)
-		} else {
-			logFn("registered backends", "vhost", vhost, "result", result, "meta", c.spec.Metadata)
-		}
+	// Use Info logging only when making deltas
+	logFn := log.Debug
+	if len(toRemove) > 0 {
+		logFn = log.Info
+	}
+	logFn("De-register backends", "instances", toRemove, "vhost", vhost, "L4", l4.Name(), "meta", c.spec.Metadata)
 
+	if result, err := l4.DeregisterBackends(toRemove); err != nil {
+		log.Warn("error deregistering backends", "toRemove", toRemove, "err", err, "meta", c.spec.Metadata)
+	} else {
+		logFn("deregistered backends", "vhost", vhost, "result", result, "meta", c.spec.Metadata)
 	}
 
-	return nil
+	toAdd := []instance.ID{}
+	for n := range nodes.Difference(registered).Iter() {
+		toAdd = append(toAdd, n.(instance.ID))
+	}
+
+	logFn = log.Debug
+	if len(toAdd) > 0 {
+		logFn = log.Info
+	}
 
+	logFn("Register backends", "instances", toAdd, "vhost", vhost, "L4", l4.Name(), "meta", c.spec.Metadata)
+	if result, err := l4.RegisterBackends(toAdd); err != nil {
+		log.Warn("error registering backends", "toAdd", toAdd, "err", err, "meta", c.spec.Metadata)
+	} else {
+		logFn("registered backends", "vhost", vhost, "result", result, "meta", c.spec.Metadata)
+	}
 }
 
 func (c *managed) syncHealthChecks() error {
@@ -199,8 +320,10 @@ func (c *managed) syncHealthChecks() error {
 	if err != nil {
 		return err
 	}
-	for vhost, elb := range elbs {
-		targets[elb] = append(targets[elb], healthChecksByVhost[vhost]...)
+	for vhost, l4s := range elbs {
+		for _, elb := range l4s {
+			targets[elb] = append(targets[elb], healthChecksByVhost[vhost]...)
+		}
 	}
 
 	log.Debug("configure healthchecks", "targets", targets, "meta", c.spec.Metadata)
@@ -1,11 +1,141 @@
 package ingress // import "github.com/docker/infrakit/pkg/controller/ingress"
 
 import (
+	"fmt"
+	"sort"
 	"testing"
 
-	_ "github.com/stretchr/testify/require"
+	ingress "github.com/docker/infrakit/pkg/controller/ingress/types"
+	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/spi/loadbalancer"
+	testing_group "github.com/docker/infrakit/pkg/testing/group"
+	testing_loadbalancer "github.com/docker/infrakit/pkg/testing/loadbalancer"
+	testing_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSyncRoutes(t *testing.T) {
 
 }
+
+func ids(prefix string, n int) []instance.ID {
+	out := []instance.ID{}
+	for i := 0; i < n; i++ {
+		out = append(out, instance.ID(fmt.Sprintf("%s-%d", prefix, i)))
+	}
+	return out
+}
+
+func TestWeigh(t *testing.T) {
+
+	old, new := ingress.Group("group/old"), ingress.Group("group/new")
+	members := map[ingress.Group][]instance.ID{old: ids("old", 10), new: ids("new", 10)}
+
+	weights := func(o, n int) []ingress.WeightedGroup {
+		return []ingress.WeightedGroup{{Group: old, Weight: o}, {Group: new, Weight: n}}
+	}
+
+	require.Equal(t, map[ingress.Group]int{old: 10, new: 10}, weigh(weights(50, 50), members))
+	require.Equal(t, map[ingress.Group]int{old: 9, new: 1}, weigh(weights(90, 10), members))
+	require.Equal(t, map[ingress.Group]int{old: 9, new: 3}, weigh(weights(75, 25), members))
+	require.Equal(t, map[ingress.Group]int{old: 5, new: 10}, weigh(weights(1, 2), members))
+	require.Equal(t, map[ingress.Group]int{old: 0, new: 10}, weigh(weights(0, 100), members))
+	require.Equal(t, map[ingress.Group]int{old: 0, new: 0}, weigh(weights(0, 0), members))
+
+	// a group without members takes no traffic and doesn't limit the others
+	members[new] = nil
+	require.Equal(t, map[ingress.Group]int{old: 10, new: 0}, weigh(weights(10, 90), members))
+}
+
+type fakeL4 struct {
+	testing_loadbalancer.L4
+	backends []instance.ID
+}
+
+func newFakeL4(name string) *fakeL4 {
+	l := &fakeL4{}
+	l.DoName = func() string { return name }
+	l.DoBackends = func() ([]instance.ID, error) { return l.backends, nil }
+	l.DoRegisterBackends = func(ids []instance.ID) (loadbalancer.Result, error) {
+		l.backends = append(l.backends, ids...)
+		return nil, nil
+	}
+	l.DoDeregisterBackends = func(ids []instance.ID) (loadbalancer.Result, error) {
+		remove := map[instance.ID]bool{}
+		for _, id := range ids {
+			remove[id] = true
+		}
+		keep := []instance.ID{}
+		for _, id := range l.backends {
+			if !remove[id] {
+				keep = append(keep, id)
+			}
+		}
+		l.backends = keep
+		return nil, nil
+	}
+	return l
+}
+
+func (l *fakeL4) sorted() []instance.ID {
+	sort.Slice(l.backends, func(i, j int) bool { return l.backends[i] < l.backends[j] })
+	return l.backends
+}
+
+func TestSyncBackendsWeighted(t *testing.T) {
+
+	vhost := ingress.Vhost("default")
+	old, new := ingress.Group("group/old"), ingress.Group("group/new")
+
+	groups := &testing_group.Plugin{
+		DoDescribeGroup: func(id group.ID) (group.Description, error) {
+			desc := group.Description{}
+			for _, id := range ids(string(id), 4) {
+				desc.Instances = append(desc.Instances, instance.Description{ID: id})
+			}
+			return desc, nil
+		},
+	}
+	testScope := testing_scope.DefaultScope()
+	testScope.ResolveGroup = func(n string) (group.Plugin, error) {
+		return groups, nil
+	}
+
+	region1, region2 := newFakeL4("region1"), newFakeL4("region2")
+	region2.backends = []instance.ID{"stale"}
+
+	weights := []ingress.WeightedGroup{{Group: old, Weight: 3}, {Group: new, Weight: 1}}
+
+	c := &managed{
+		scope: testScope,
+		groups: func() (map[ingress.Vhost][]ingress.Group, error) {
+			return map[ingress.Vhost][]ingress.Group{vhost: nil}, nil
+		},
+		instanceIDs: func() (map[ingress.Vhost][]instance.ID, error) { return nil, nil },
+		weighted: func() (map[ingress.Vhost][]ingress.WeightedGroup, error) {
+			return map[ingress.Vhost][]ingress.WeightedGroup{vhost: weights}, nil
+		},
+		l4s: func() (map[ingress.Vhost][]loadbalancer.L4, error) {
+			return map[ingress.Vhost][]loadbalancer.L4{vhost: {region1, region2}}, nil
+		},
+	}
+	set := fsm.NewSet(stateMachineSpec, fsm.NewClock())
+	defer set.Stop()
+	c.stateMachine = set.Add(waiting)
+
+	require.NoError(t, c.syncBackends())
+
+	expect := []instance.ID{"new-0", "old-0", "old-1", "old-2"} // 3:1
+	require.Equal(t, expect, region1.sorted())
+	require.Equal(t, expect, region2.sorted())
+
+	// shift all traffic to the new group
+	weights[0].Weight, weights[1].Weight = 0, 1
+	require.NoError(t, c.syncBackends())
+
+	expect = ids("new", 4)
+	require.Equal(t, expect, region1.sorted())
+	require.Equal(t, expect, region2.sorted())
+}
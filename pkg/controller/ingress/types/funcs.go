@@ -36,21 +36,27 @@ func RegisterRouteHandler(key string, f func() (RouteHandler, error)) {
 }
 
 // L4Func returns a function that can return a map of vhost and L4 objects, with the help of plugin lookup.
-func (p Properties) L4Func(findL4 func(spec Spec) (loadbalancer.L4, error)) func() (map[Vhost]loadbalancer.L4, error) {
+// The lookup is called for each L4 plugin of a vhost with the spec's L4Plugin set to the plugin.
+func (p Properties) L4Func(findL4 func(spec Spec) (loadbalancer.L4, error)) func() (map[Vhost][]loadbalancer.L4, error) {
 
-	return func() (result map[Vhost]loadbalancer.L4, err error) {
-		result = map[Vhost]loadbalancer.L4{}
+	return func() (result map[Vhost][]loadbalancer.L4, err error) {
+		result = map[Vhost][]loadbalancer.L4{}
 		for _, spec := range p {
 
 			vhost := spec.Vhost
 
-			l4, err := findL4(spec)
-			if err != nil || l4 == nil {
-				log.Warn("cannot locate L4 plugin", "vhost", vhost, "spec", spec, "err", err)
-				continue
-			}
+			for _, name := range spec.L4PluginNames() {
+				find := spec
+				find.L4Plugin = name
+
+				l4, err := findL4(find)
+				if err != nil || l4 == nil {
+					log.Warn("cannot locate L4 plugin", "vhost", vhost, "spec", spec, "name", name, "err", err)
+					continue
+				}
 
-			result[vhost] = l4
+				result[vhost] = append(result[vhost], l4)
+			}
 		}
 		return
 	}
@@ -78,6 +84,15 @@ func (p Properties) Groups() (result map[Vhost][]Group, err error) {
 	return
 }
 
+// Weighted returns a list of weighted groups by vhost
+func (p Properties) Weighted() (result map[Vhost][]WeightedGroup, err error) {
+	result = map[Vhost][]WeightedGroup{}
+	for _, spec := range p {
+		result[spec.Vhost] = append(result[spec.Vhost], spec.Backends.Weighted...)
+	}
+	return
+}
+
 // InstanceIDs returns a map of static instance ids by vhost
 func (p Properties) InstanceIDs() (result map[Vhost][]instance.ID, err error) {
 	result = map[Vhost][]instance.ID{}
@@ -34,8 +34,8 @@ func TestL4(t *testing.T) {
 
 	fakeL4 := &fake.L4{}
 
-	expect := map[Vhost]loadbalancer.L4{
-		vhost: fakeL4,
+	expect := map[Vhost][]loadbalancer.L4{
+		vhost: {fakeL4},
 	}
 
 	calledFind := make(chan struct{})
@@ -245,3 +245,35 @@ func TestRegisterRouteHandler(t *testing.T) {
 	close(calledChan)
 	close(routesChan)
 }
+
+func TestMultipleL4(t *testing.T) {
+	vhost := Vhost("test.com")
+
+	properties := Properties{
+		{
+			Vhost:     vhost,
+			L4Plugin:  plugin.Name("us-east/elb"),
+			L4Plugins: []plugin.Name{"us-west/elb", "us-east/elb"},
+		},
+	}
+	require.Equal(t, []plugin.Name{"us-east/elb", "us-west/elb"}, properties[0].L4PluginNames())
+
+	l4s := map[string]loadbalancer.L4{
+		"us-east/elb": &fake.L4{},
+		"us-west/elb": &fake.L4{},
+	}
+	m, err := properties.L4Func(
+		func(spec Spec) (loadbalancer.L4, error) {
+			require.Equal(t, vhost, spec.Vhost)
+			return l4s[string(spec.L4Plugin)], nil
+		},
+	)()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(m[vhost]))
+	require.True(t, m[vhost][0] == l4s["us-east/elb"])
+	require.True(t, m[vhost][1] == l4s["us-west/elb"])
+
+	runnables, err := ResolveDependencies(types.Spec{Properties: types.AnyValueMust(properties)})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(runnables))
+}
@@ -1,6 +1,7 @@
 package types // import "github.com/docker/infrakit/pkg/controller/ingress/types"
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/docker/infrakit/pkg/plugin"
@@ -31,7 +32,9 @@ func ResolveDependencies(spec types.Spec) (depends.Runnables, error) {
 
 	out := depends.Runnables{}
 	for _, p := range properties {
-		out = append(out, depends.RunnableFrom(p.L4Plugin))
+		for _, name := range p.L4PluginNames() {
+			out = append(out, depends.RunnableFrom(name))
+		}
 	}
 	return out, nil
 }
@@ -49,6 +52,10 @@ type Spec struct {
 	// L4Plugin is the name of the L4Plugin to lookup
 	L4Plugin plugin.Name
 
+	// L4Plugins are more L4 plugins the vhost fans out to, e.g. the loadbalancers of other regions
+	// or the passive loadbalancer of an active/passive pair.  They are configured the same as L4Plugin.
+	L4Plugins []plugin.Name `json:",omitempty" yaml:",omitempty"`
+
 	// RouteSources allows the specification of routes based on some specialized handlers.
 	// The routes are keyed by the 'handler' name and the configuration blob are specific to the keyed
 	// handler.  For example, a 'swarm' handler will dynamically generate the required routes based
@@ -65,6 +72,20 @@ type Spec struct {
 	HealthChecks []loadbalancer.HealthCheck
 }
 
+// L4PluginNames returns the names of all the L4 plugins of the vhost
+func (s Spec) L4PluginNames() []plugin.Name {
+	names := []plugin.Name{}
+	seen := map[plugin.Name]bool{}
+	for _, name := range append([]plugin.Name{s.L4Plugin}, s.L4Plugins...) {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
 // Validate validates the struct and can mutate the fields as necessary.
 func (s *Spec) Validate() error {
 	for _, r := range s.Routes {
@@ -72,6 +93,11 @@ func (s *Spec) Validate() error {
 			return err
 		}
 	}
+	for _, w := range s.Backends.Weighted {
+		if w.Weight < 0 {
+			return fmt.Errorf("negative weight %v for group %v", w.Weight, w.Group)
+		}
+	}
 	return nil
 }
 
@@ -99,6 +125,21 @@ type BackendSpec struct {
 
 	// Instances are static instance ids
 	Instances []instance.ID
+
+	// Weighted are groups that share the traffic by weight, e.g. to shift traffic gradually from
+	// an old group to a new one.  Since the backends of a loadbalancer take equal shares of the traffic,
+	// each group has as many of its instances registered as to make its share match its weight.
+	Weighted []WeightedGroup `json:",omitempty" yaml:",omitempty"`
+}
+
+// WeightedGroup is a group with a weight
+type WeightedGroup struct {
+
+	// Group is the group
+	Group Group
+
+	// Weight is the relative weight of the group.  A group with weight 0 takes no traffic.
+	Weight int
 }
 
 // Vhost is the virtual host / domain
@@ -88,6 +88,15 @@ type Collection struct {
 	// This is not the same as Stop, which stops monitoring.
 	TerminateFunc func() error `json:"-"`
 
+	// TerminateWaitFunc is called instead of TerminateFunc, if set.  It starts the termination with the lock on
+	// the collection held, and returns the function that waits for the termination to complete.  The wait is
+	// without the lock, so that the collection is inspected and observed meanwhile.
+	TerminateWaitFunc func() (wait func() error, err error) `json:"-"`
+
+	// MetricsFunc returns the metrics of the state machines of the collection. Optional; the
+	// metrics are exported in the metadata and to Prometheus when set.
+	MetricsFunc func() *fsm.Metrics `json:"-"`
+
 	types.Spec
 
 	previous *types.Spec
@@ -183,7 +192,7 @@ func (c *Collection) Render(any *types.Any, item Item) (*types.Any, error) {
 
 // Metadata returns a metadata plugin implementation. Optional; ok to be nil
 func (c *Collection) Metadata() metadata.Plugin {
-	return c.metadata
+	return &metricsView{Plugin: c.metadata, metrics: c.Metrics}
 }
 
 // Events returns events plugin implementation. Optional; ok to be nil
@@ -203,9 +212,10 @@ func (c *Collection) List(topic types.Path) ([]string, error) {
 
 // PublishOn sets the channel to publish on
 func (c *Collection) PublishOn(events chan<- *event.Event) {
+	in := c.events
 	go func() {
 		for {
-			evt, ok := <-c.events
+			evt, ok := <-in
 			if !ok {
 				close(events)
 				return
@@ -253,6 +263,13 @@ func (c *Collection) MetadataGone(key func(instance.Description) (string, error)
 	}
 }
 
+// MetadataPut sets the value at the path in the metadata plugin interface
+func (c *Collection) MetadataPut(path types.Path, value interface{}) {
+	c.metadataUpdates <- func(view map[string]interface{}) {
+		types.Put(path, value, view)
+	}
+}
+
 // MetadataExport exports the objects in the metadata plugin interface. A keyfunc is required to compute
 // the key based on the instance.
 func (c *Collection) MetadataExport(key func(instance.Description) (string, error), v []instance.Description) error {
@@ -616,12 +633,20 @@ func (c *Collection) Terminate() (object *types.Object, err error) {
 	if err != nil {
 		return
 	}
-	err = c.writeTxn(func() error {
+	var wait func() error
+	err = c.writeTxn(func() (e error) {
+		if c.TerminateWaitFunc != nil {
+			wait, e = c.TerminateWaitFunc()
+			return
+		}
 		if c.TerminateFunc != nil {
 			return c.TerminateFunc()
 		}
 		return fmt.Errorf("not supported")
 	})
+	if err == nil && wait != nil {
+		err = wait()
+	}
 	return
 }
 
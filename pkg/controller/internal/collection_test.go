@@ -0,0 +1,43 @@
+package internal // import "github.com/docker/infrakit/pkg/controller/internal"
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionTerminateWait(t *testing.T) {
+
+	c, err := NewCollection(nil)
+	require.NoError(t, err)
+	c.Spec = types.Spec{Metadata: types.Metadata{Name: "workers"}}
+
+	_, err = c.Terminate()
+	require.Error(t, err)
+
+	waiting := make(chan struct{})
+	release := make(chan error)
+	c.TerminateWaitFunc = func() (func() error, error) {
+		return func() error {
+			close(waiting)
+			return <-release
+		}, nil
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := c.Terminate()
+		done <- err
+	}()
+
+	// the collection is inspected while the termination is awaited
+	<-waiting
+	object, err := c.Inspect()
+	require.NoError(t, err)
+	require.Equal(t, "workers", object.Spec.Metadata.Name)
+
+	release <- fmt.Errorf("timeout terminating db")
+	require.Equal(t, fmt.Errorf("timeout terminating db"), <-done)
+}
@@ -0,0 +1,48 @@
+package internal // import "github.com/docker/infrakit/pkg/controller/internal"
+
+import (
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/metadata"
+	"github.com/docker/infrakit/pkg/spi/stack"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// Components contains a set of components of a controller.
+type Components struct {
+	Controllers func() (map[string]controller.Controller, error)
+	Metadata    func() (map[string]metadata.Plugin, error)
+	Events      event.Plugin
+}
+
+// NewComponents returns the components of a controller whose managed objects are allocated
+// by the given function and keyed by name.
+func NewComponents(alloc func(types.Spec) (Managed, error)) *Components {
+
+	controller := NewController(
+		alloc,
+		// the key function
+		func(metadata types.Metadata) string {
+			return metadata.Name
+		},
+	)
+
+	return &Components{
+		Controllers: controller.Controllers,
+		Metadata:    controller.Metadata,
+		Events:      controller,
+	}
+}
+
+// Singletons returns the controllers, each of which processes calls only when running as the leader.
+func (c *Components) Singletons(leader func() stack.Leadership) func() (map[string]controller.Controller, error) {
+	return func() (map[string]controller.Controller, error) {
+		singletons := map[string]controller.Controller{}
+		if controllers, err := c.Controllers(); err == nil {
+			for k, v := range controllers {
+				singletons[k] = controller.Singleton(v, leader)
+			}
+		}
+		return singletons, nil
+	}
+}
@@ -2,6 +2,7 @@ package internal // import "github.com/docker/infrakit/pkg/controller/internal"
 
 import (
 	"fmt"
+	"net/http"
 	"sync"
 
 	"github.com/docker/infrakit/pkg/spi/controller"
@@ -166,6 +167,24 @@ func (c *Controller) Controllers() (map[string]controller.Controller, error) {
 	return out, nil
 }
 
+// Exports returns the exports of all the managed objects, with the paths prefixed by the keys of the objects
+func (c *Controller) Exports() map[string]http.Handler {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	out := map[string]http.Handler{}
+	for k, m := range c.managed {
+		exporter, is := (*m).(Exporter)
+		if !is {
+			continue
+		}
+		for p, h := range exporter.Exports() {
+			out[k+"/"+p] = h
+		}
+	}
+	return out
+}
+
 // Plan is a commit without actually making the changes.  The controller returns a proposed object state
 // after commit, with a Plan, or error.
 func (c *Controller) Plan(operation controller.Operation,
@@ -173,7 +192,11 @@ func (c *Controller) Plan(operation controller.Operation,
 
 	m := []**Managed{}
 	copy := spec
-	m, err = c.getManaged(&spec.Metadata, &copy)
+	alloc := &copy
+	if operation == controller.Claim || operation == controller.Approve {
+		alloc = nil // claims and approvals are only on existing objects
+	}
+	m, err = c.getManaged(&spec.Metadata, alloc)
 	if err != nil {
 		return
 	}
@@ -208,7 +231,11 @@ func (c *Controller) Commit(operation controller.Operation, spec types.Spec) (ob
 
 	m := []**Managed{}
 	copy := spec
-	m, err = c.getManaged(&spec.Metadata, &copy)
+	alloc := &copy
+	if operation == controller.Claim || operation == controller.Approve {
+		alloc = nil // claims and approvals are only on existing objects
+	}
+	m, err = c.getManaged(&spec.Metadata, alloc)
 	if err != nil {
 		log.Error("err", "err", err)
 		return
@@ -276,6 +303,33 @@ func (c *Controller) Commit(operation controller.Operation, spec types.Spec) (ob
 		}
 		err = e
 		return
+
+	case controller.Claim:
+		claimer, is := (**m[0]).(Claimer)
+		if !is {
+			err = fmt.Errorf("claim not supported: %v", spec.Metadata.Name)
+			return
+		}
+		o, e := claimer.Claim(spec)
+		if o != nil {
+			object = *o
+		}
+		err = e
+		return
+
+	case controller.Approve:
+		approver, is := (**m[0]).(Approver)
+		if !is {
+			err = fmt.Errorf("approve not supported: %v", spec.Metadata.Name)
+			return
+		}
+		o, e := approver.Approve(spec)
+		if o != nil {
+			object = *o
+		}
+		err = e
+		return
+
 	default:
 		err = fmt.Errorf("unknown operation: %v", operation)
 		return
@@ -0,0 +1,33 @@
+package internal // import "github.com/docker/infrakit/pkg/controller/internal"
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrDeadlineExceeded is returned when a call doesn't return before its deadline
+var ErrDeadlineExceeded = fmt.Errorf("deadline exceeded")
+
+// CallWithDeadline calls the plugin in the background and waits for it up to the deadline, so that a slow
+// plugin doesn't hold up the caller.  A call past the deadline is left to finish on its own.
+func CallWithDeadline(deadline time.Duration, call func() error) error {
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				result <- fmt.Errorf("recovered: %v", e)
+			}
+		}()
+		result <- call()
+	}()
+
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return ErrDeadlineExceeded
+	}
+}
@@ -0,0 +1,23 @@
+package internal // import "github.com/docker/infrakit/pkg/controller/internal"
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallWithDeadline(t *testing.T) {
+
+	require.NoError(t, CallWithDeadline(1*time.Second, func() error { return nil }))
+	require.Equal(t, "boom", CallWithDeadline(1*time.Second, func() error { return fmt.Errorf("boom") }).Error())
+	require.Error(t, CallWithDeadline(1*time.Second, func() error { panic("boom") }))
+
+	block := make(chan struct{})
+	defer close(block)
+	require.Equal(t, ErrDeadlineExceeded, CallWithDeadline(10*time.Millisecond, func() error {
+		<-block
+		return nil
+	}))
+}
@@ -0,0 +1,111 @@
+package internal // import "github.com/docker/infrakit/pkg/controller/internal"
+
+import (
+	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/spi/metadata"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+const (
+	// MetricsKey is the key in the metadata of a collection where the metrics of its state machines are found
+	MetricsKey = "metrics"
+
+	// CollectionMetricsLabel is the label attached to exported metrics to identify the collection
+	CollectionMetricsLabel = "collection"
+)
+
+// Measured is implemented by managed objects that maintain metrics of their state machines
+type Measured interface {
+	// Metrics returns the metrics or nil if not available
+	Metrics() *fsm.Metrics
+}
+
+// Metrics returns a snapshot of the metrics of the state machines of the collection, or nil
+// if the collection doesn't track them or is not running.
+func (c *Collection) Metrics() (metrics *fsm.Metrics) {
+	if c.MetricsFunc == nil {
+		return nil
+	}
+	c.readTxn(func() error {
+		metrics = c.MetricsFunc()
+		if metrics == nil {
+			return nil
+		}
+		if metrics.Labels == nil {
+			metrics.Labels = map[string]string{}
+		}
+		metrics.Labels[CollectionMetricsLabel] = c.Spec.Metadata.Name
+		return nil
+	})
+	return
+}
+
+// Metrics returns the metrics of all the managed objects
+func (c *Controller) Metrics() []fsm.Metrics {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	out := []fsm.Metrics{}
+	for _, m := range c.managed {
+		measured, is := (*m).(Measured)
+		if !is {
+			continue
+		}
+		if metrics := measured.Metrics(); metrics != nil {
+			out = append(out, *metrics)
+		}
+	}
+	return out
+}
+
+// metricsView is a metadata plugin that adds a view of the metrics of the collection to the
+// metadata at the MetricsKey.
+type metricsView struct {
+	metadata.Plugin
+	metrics func() *fsm.Metrics
+}
+
+func (v *metricsView) view() (interface{}, error) {
+	m := v.metrics()
+	if m == nil {
+		return nil, nil
+	}
+	var view interface{}
+	any, err := types.AnyValue(m)
+	if err != nil {
+		return nil, err
+	}
+	err = any.Decode(&view)
+	return view, err
+}
+
+// Keys returns a list of *child nodes* given a path
+func (v *metricsView) Keys(path types.Path) ([]string, error) {
+	if path.Len() == 0 || path.Dot() {
+		keys, err := v.Plugin.Keys(path)
+		if err != nil {
+			return nil, err
+		}
+		return append(keys, MetricsKey), nil
+	}
+	if *path.Index(0) != MetricsKey {
+		return v.Plugin.Keys(path)
+	}
+	view, err := v.view()
+	if err != nil {
+		return nil, err
+	}
+	return types.List(path.Shift(1), view), nil
+}
+
+// Get retrieves the value at path given.
+func (v *metricsView) Get(path types.Path) (*types.Any, error) {
+	if path.Len() == 0 || *path.Index(0) != MetricsKey {
+		return v.Plugin.Get(path)
+	}
+	view, err := v.view()
+	if err != nil {
+		return nil, err
+	}
+	return types.AnyValue(types.Get(path.Shift(1), view))
+}
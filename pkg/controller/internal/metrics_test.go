@@ -0,0 +1,53 @@
+package internal // import "github.com/docker/infrakit/pkg/controller/internal"
+
+import (
+	"testing"
+
+	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionMetrics(t *testing.T) {
+
+	c, err := NewCollection(nil)
+	require.NoError(t, err)
+	c.Spec = types.Spec{Metadata: types.Metadata{Name: "workers"}}
+
+	require.Nil(t, c.Metrics())
+
+	keys, err := c.Metadata().Keys(types.PathFromString("metrics"))
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	c.MetricsFunc = func() *fsm.Metrics {
+		return &fsm.Metrics{
+			Name:   "pool",
+			States: map[string]int{"ready": 3},
+		}
+	}
+
+	m := c.Metrics()
+	require.NotNil(t, m)
+	require.Equal(t, map[string]string{CollectionMetricsLabel: "workers"}, m.Labels)
+
+	keys, err = c.Metadata().Keys(types.Path{})
+	require.NoError(t, err)
+	require.Contains(t, keys, MetricsKey)
+
+	keys, err = c.Metadata().Keys(types.PathFromString("metrics/States"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"ready"}, keys)
+
+	v, err := c.Metadata().Get(types.PathFromString("metrics/States/ready"))
+	require.NoError(t, err)
+	require.Equal(t, "3", v.String())
+
+	controller := NewController(nil, func(m types.Metadata) string { return m.Name })
+	var managed Managed = c
+	controller.managed["workers"] = &managed
+
+	all := controller.Metrics()
+	require.Equal(t, 1, len(all))
+	require.Equal(t, "pool", all[0].Name)
+}
@@ -0,0 +1,413 @@
+package internal // import "github.com/docker/infrakit/pkg/controller/internal"
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// Sources are the named sources of observations of the instances of a reconciler.
+type Sources map[string]*InstanceAccess
+
+// Model is the workflow of the state machines of a reconciler.
+type Model struct {
+	// Spec is the fsm spec
+	Spec *fsm.Spec
+
+	// Clock is the clock that drives the state machines
+	Clock *fsm.Clock
+
+	// Options are the options of the fsm set
+	Options fsm.Options
+
+	// BufferSize is the size of the buffers of observations and actions. Defaults to Options.BufferSize.
+	BufferSize int
+}
+
+// Reconcile specifies a reconcile loop.  The controller author supplies the sources of the
+// observed state, the desired state and the workflow model; the Reconciler does the rest.
+type Reconcile struct {
+
+	// Configure is called with each new spec, with the lock on the collection held.  It returns
+	// the sources of observations.  Sources that are in the previous spec but not the current one
+	// should be included so that their instances continue to be observed.
+	Configure func(spec types.Spec, previous *types.Spec) (Sources, error)
+
+	// Model is called after Configure and returns the workflow model.
+	Model func() (Model, error)
+
+	// Desired returns the keys of the instances desired by the current spec. Optional.
+	Desired func() []string
+
+	// Requested is the initial state of the desired instances
+	Requested fsm.Index
+
+	// Unmatched is the initial state of the instances observed but not desired
+	Unmatched fsm.Index
+
+	// Found is the signal raised when an instance is observed
+	Found fsm.Signal
+
+	// Lost is the signal raised when an instance is no longer observed
+	Lost fsm.Signal
+
+	// QualifyKeys qualifies the keys in the metadata with the names of the sources
+	QualifyKeys bool
+
+	// Changed is called in the reconcile loop after observations add, change or remove
+	// instances. Optional.
+	Changed func()
+}
+
+// Reconciler is a collection that runs a reconcile loop of the observed state of the
+// instances toward the desired state.
+type Reconciler struct {
+	*Collection
+	Reconcile
+
+	model    Model
+	set      fsm.Instances
+	sources  Sources
+	observed map[string]instance.Description
+	actions  chan action
+	calls    chan func()
+	cancel   func()
+	done     <-chan struct{}
+
+	lock sync.RWMutex
+}
+
+type action struct {
+	fsm     fsm.FSM
+	handler func(*Item)
+}
+
+type observation struct {
+	name      string
+	instances []instance.Description
+}
+
+// NewReconciler returns a collection that runs the reconcile loop.  The behaviors of the
+// collection for start, stop, updates of spec and metrics are set.  The others, like
+// TerminateFunc, are left to the caller.
+func NewReconciler(scope scope.Scope, reconcile Reconcile, topics ...types.Path) (*Reconciler, error) {
+	base, err := NewCollection(scope, topics...)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reconciler{
+		Collection: base,
+		Reconcile:  reconcile,
+		observed:   map[string]instance.Description{},
+		calls:      make(chan func()),
+	}
+	base.StartFunc = r.run
+	base.StopFunc = r.stop
+	base.UpdateSpecFunc = r.updateSpec
+	base.MetricsFunc = r.metrics
+	return r, nil
+}
+
+// Async returns an fsm action that calls the handler with the item of the state machine.  The
+// handlers are called one at a time by the reconcile loop and not by the state machine.
+func (r *Reconciler) Async(handler func(*Item)) fsm.Action {
+	return func(f fsm.FSM) error {
+		r.actions <- action{fsm: f, handler: handler}
+		return nil
+	}
+}
+
+// FSMSpec returns the spec of the state machines
+func (r *Reconciler) FSMSpec() *fsm.Spec {
+	return r.model.Spec
+}
+
+// Source returns the source by name
+func (r *Reconciler) Source(name string) *InstanceAccess {
+	return r.sources[name]
+}
+
+// Observed returns the instances currently observed, by key.  This is updated by the reconcile
+// loop and is safe to access only in the handlers of Async actions.
+func (r *Reconciler) Observed() map[string]instance.Description {
+	return r.observed
+}
+
+// Add adds a state machine in the given state for the key
+func (r *Reconciler) Add(k string, initial fsm.Index, data map[string]interface{}) *Item {
+	r.lock.RLock()
+	set := r.set
+	r.lock.RUnlock()
+
+	if set == nil {
+		return nil
+	}
+	return r.Put(k, set.Add(initial), r.model.Spec, data)
+}
+
+// Do calls the function in the reconcile loop, one at a time with the handlers, and waits for
+// it to return.  This is for operations that access the items or the observed instances.
+func (r *Reconciler) Do(f func()) error {
+	r.lock.RLock()
+	done := r.done
+	r.lock.RUnlock()
+
+	if done == nil {
+		return fmt.Errorf("not running")
+	}
+
+	returned := make(chan struct{})
+	select {
+	case r.calls <- func() { defer close(returned); f() }:
+	case <-done:
+		return fmt.Errorf("not running")
+	}
+	<-returned
+	return nil
+}
+
+// metrics is called with the lock on the collection held
+func (r *Reconciler) metrics() *fsm.Metrics {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.set == nil {
+		return nil
+	}
+	metrics := r.set.Metrics()
+	return &metrics
+}
+
+func (r *Reconciler) updateSpec(spec types.Spec, previous *types.Spec) error {
+	sources, err := r.Configure(spec, previous)
+	if err != nil {
+		return err
+	}
+	model, err := r.Model()
+	if err != nil {
+		return err
+	}
+	if model.BufferSize == 0 {
+		model.BufferSize = model.Options.BufferSize
+	}
+	r.sources = sources
+	r.model = model
+	r.actions = make(chan action, model.BufferSize)
+	return nil
+}
+
+func (r *Reconciler) run(ctx context.Context) {
+
+	r.lock.Lock()
+	r.model.Clock.Start()
+	r.set = fsm.NewInstances(r.model.Spec, r.model.Clock, r.model.Options)
+	r.lock.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.lock.Lock()
+	r.cancel = cancel
+	r.done = ctx.Done()
+	r.lock.Unlock()
+
+	// Start all the sources and wire up the observations.
+	lostInstances := make(chan *observation, r.model.BufferSize)  // ch to aggregate all lost observations
+	foundInstances := make(chan *observation, r.model.BufferSize) // ch to aggregate all found observations
+
+	for k, a := range r.sources {
+
+		log.Debug("Set up events from instance accessor", "name", k, "V", debugV)
+		go func(name string, accessor *InstanceAccess) {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case list, ok := <-accessor.Observations():
+					if !ok {
+						log.Debug("found observations done", "name", name, "V", debugV2)
+						return
+					}
+					if len(list) > 0 {
+						foundInstances <- &observation{name: name, instances: list}
+						log.Debug("accessor found instances", "name", name, "count", len(list), "V", debugV2)
+					}
+				case list, ok := <-accessor.Lost():
+					if !ok {
+						log.Debug("lost events done", "name", name, "V", debugV2)
+						return
+					}
+					if len(list) > 0 {
+						lostInstances <- &observation{name: name, instances: list}
+						log.Debug("accessor lost instances", "name", name, "count", len(list), "V", debugV2)
+					}
+				}
+			}
+		}(k, a)
+
+		a.Start()
+		log.Debug("accessor started", "name", k, "observeInterval", a.ObserveInterval)
+	}
+
+	go func() {
+		for {
+			select {
+
+			case <-ctx.Done():
+				return
+
+			case a := <-r.actions:
+				if item := r.GetByFSM(a.fsm); item != nil {
+					a.handler(item)
+				}
+
+			case f := <-r.calls:
+				f()
+
+			case lost := <-lostInstances:
+				r.lost(lost)
+
+			case found := <-foundInstances:
+				r.found(found)
+			}
+		}
+	}()
+
+	if r.Desired == nil {
+		return
+	}
+
+	// Seed the state machines for the desired instances
+	for i, k := range r.Desired() {
+		item := r.Add(k, r.Requested, nil)
+		if item == nil {
+			return
+		}
+		item.Ordinal = i
+		log.Debug("requested", "key", item.Key, "ordinal", item.Ordinal, "V", debugV)
+	}
+}
+
+// keyOf returns the function for the key in the metadata of the instances of the named source
+func (r *Reconciler) keyOf(name string, accessor *InstanceAccess) func(instance.Description) (string, error) {
+	if !r.QualifyKeys {
+		return accessor.KeyOf
+	}
+	return func(i instance.Description) (string, error) {
+		k, err := accessor.KeyOf(i)
+		if err != nil {
+			return k, err
+		}
+		return types.PathFromString(name).JoinString(k).String(), nil
+	}
+}
+
+func (r *Reconciler) lost(lost *observation) {
+	accessor, has := r.sources[lost.name]
+	if !has {
+		log.Warn("cannot find accessor for lost instance", "name", lost.name)
+		return
+	}
+
+	// Update the view in the metadata plugin
+	r.MetadataGone(r.keyOf(lost.name, accessor), lost.instances)
+
+	for _, n := range lost.instances {
+		k, err := accessor.KeyOf(n)
+		if err != nil {
+			log.Error("error getting key", "err", err, "instance", n)
+			break
+		}
+		if item := r.Get(k); item != nil {
+			log.Warn("lost", "instance", n, "name", lost.name, "key", k)
+			item.State.Signal(r.Lost)
+		}
+		delete(r.observed, k)
+	}
+
+	if r.Changed != nil && len(lost.instances) > 0 {
+		r.Changed()
+	}
+}
+
+func (r *Reconciler) found(found *observation) {
+	accessor, has := r.sources[found.name]
+	if !has {
+		log.Warn("cannot find accessor for found instance", "name", found.name)
+		return
+	}
+
+	// Update the view in the metadata plugin
+	export := []instance.Description{}
+
+	for _, n := range found.instances {
+		k, err := accessor.KeyOf(n)
+		if err != nil {
+			log.Error("error getting key", "err", err, "instance", n)
+			break
+		}
+		item := r.Get(k)
+		if item == nil {
+
+			// In this case, the fsm isn't requested.. it's something we get out of band
+			// that somehow shows up (or from previous runs but now the user has
+			// removed it from the spec and performed a commit.
+			item = r.Add(k, r.Unmatched, map[string]interface{}{
+				"instance": n,
+			})
+			if item == nil {
+				return // stopped
+			}
+			export = append(export, n) // export to metadata
+
+		} else {
+
+			// if we already have entries stored, then see if the data changed
+			prev := item.Data["instance"]
+			if prev == nil {
+				export = append(export, n)
+			} else if dd, is := prev.(instance.Description); is {
+				if dd.Fingerprint() != n.Fingerprint() {
+					export = append(export, n)
+				}
+			}
+		}
+
+		r.observed[k] = n
+
+		log.Debug("found", "instance", n, "name", found.name, "key", k, "V", debugV2)
+		item.State.Signal(r.Found)
+		item.Data["instance"] = n
+		item.Data["source"] = found.name
+		item.Error(nil) // clear any previous error if this is from a retry
+	}
+
+	r.MetadataExport(r.keyOf(found.name, accessor), export)
+
+	if r.Changed != nil && len(export) > 0 {
+		r.Changed()
+	}
+}
+
+func (r *Reconciler) stop() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.set == nil {
+		return nil
+	}
+
+	r.cancel()
+	r.done = nil
+	for k, accessor := range r.sources {
+		log.Debug("Stopping", "name", k, "V", debugV)
+		accessor.Stop()
+	}
+	r.set.Stop()
+	r.model.Clock.Stop()
+	r.set = nil
+	return nil
+}
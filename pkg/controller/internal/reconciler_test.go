@@ -0,0 +1,138 @@
+package internal // import "github.com/docker/infrakit/pkg/controller/internal"
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/template"
+	testutil_instance "github.com/docker/infrakit/pkg/testing/instance"
+	testutil_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconciler(t *testing.T) {
+
+	const (
+		requested fsm.Index = iota
+		ready
+		unmatched
+
+		found fsm.Signal = iota
+		lost
+	)
+
+	observed := []instance.Description{
+		{ID: "id1", Properties: types.AnyValueMust(map[string]string{"link": "a"})},
+		{ID: "id2", Properties: types.AnyValueMust(map[string]string{"link": "c"})},
+	}
+
+	testScope := testutil_scope.DefaultScope()
+	testScope.ResolveInstance = func(n string) (instance.Plugin, error) {
+		return &testutil_instance.Plugin{
+			DoDescribeInstances: func(tags map[string]string, details bool) ([]instance.Description, error) {
+				return observed, nil
+			},
+		}, nil
+	}
+
+	readyItems := make(chan string, 10)
+
+	var r *Reconciler
+	r, err := NewReconciler(testScope, Reconcile{
+		Configure: func(spec types.Spec, previous *types.Spec) (Sources, error) {
+			access := &InstanceAccess{
+				InstanceObserver: &InstanceObserver{
+					Name:            plugin.Name("simulator/compute"),
+					ObserveInterval: types.FromDuration(1 * time.Second),
+					KeySelector:     template.EscapeString(`{{.Properties.link}}`),
+				},
+			}
+			return Sources{"compute": access}, access.Init(testScope, 1*time.Second)
+		},
+		Model: func() (Model, error) {
+			spec, err := fsm.Define(
+				fsm.State{
+					Index: requested,
+					Transitions: map[fsm.Signal]fsm.Index{
+						found: ready,
+					},
+					Actions: map[fsm.Signal]fsm.Action{
+						found: r.Async(func(item *Item) {
+							readyItems <- item.Key
+						}),
+					},
+				},
+				fsm.State{
+					Index: ready,
+					Transitions: map[fsm.Signal]fsm.Index{
+						found: ready,
+						lost:  requested,
+					},
+				},
+				fsm.State{
+					Index: unmatched,
+					Transitions: map[fsm.Signal]fsm.Index{
+						found: unmatched,
+					},
+				},
+			)
+			return Model{
+				Spec:    spec,
+				Clock:   fsm.NewClock(),
+				Options: fsm.DefaultOptions("test"),
+			}, err
+		},
+		Desired: func() []string {
+			return []string{"a", "b"}
+		},
+		Requested:   requested,
+		Unmatched:   unmatched,
+		Found:       found,
+		Lost:        lost,
+		QualifyKeys: true,
+	})
+	require.NoError(t, err)
+
+	events := make(chan *event.Event)
+	r.PublishOn(events)
+	go func() {
+		for range events {
+		}
+	}()
+
+	_, err = r.Enforce(types.Spec{Metadata: types.Metadata{Name: "workers"}})
+	require.NoError(t, err)
+
+	require.Equal(t, "a", <-readyItems)
+
+	// the action is called before the transition completes
+	for i := 0; i < 10 && r.GetCountByState(ready) == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	require.NotNil(t, r.Metrics())
+	require.Equal(t, 1, r.GetCountByState(ready))
+	require.Equal(t, 1, r.GetCountByState(requested))
+	require.Equal(t, 1, r.GetCountByState(unmatched))
+	require.Equal(t, 1, r.Get("b").Ordinal)
+	require.Equal(t, instance.ID("id2"), r.Get("c").Data["instance"].(instance.Description).ID)
+
+	var keys []string
+	for i := 0; i < 10; i++ {
+		keys, err = r.Metadata().Keys(types.PathFromString("compute"))
+		require.NoError(t, err)
+		if len(keys) == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.Equal(t, []string{"a", "c"}, keys)
+
+	require.NoError(t, r.Stop())
+	require.Nil(t, r.Metrics())
+}
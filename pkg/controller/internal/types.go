@@ -1,6 +1,8 @@
 package internal // import "github.com/docker/infrakit/pkg/controller/internal"
 
 import (
+	"net/http"
+
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/spi/controller"
 	"github.com/docker/infrakit/pkg/spi/event"
@@ -40,3 +42,19 @@ type Managed interface {
 	Free() (*types.Object, error)
 	Terminate() (*types.Object, error)
 }
+
+// Claimer is implemented by managed objects that hold resources that can be claimed
+type Claimer interface {
+	Claim(types.Spec) (*types.Object, error)
+}
+
+// Approver is implemented by managed objects that halt changes until they are approved
+type Approver interface {
+	Approve(types.Spec) (*types.Object, error)
+}
+
+// Exporter is implemented by managed objects that render their state in the formats of external systems.
+// The handlers are keyed by path.
+type Exporter interface {
+	Exports() map[string]http.Handler
+}
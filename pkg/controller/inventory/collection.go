@@ -2,31 +2,30 @@ package inventory // import "github.com/docker/infrakit/pkg/controller/inventory
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/docker/infrakit/pkg/controller/internal"
 	inventory "github.com/docker/infrakit/pkg/controller/inventory/types"
 	"github.com/docker/infrakit/pkg/run/scope"
 	"github.com/docker/infrakit/pkg/spi/event"
-	"github.com/docker/infrakit/pkg/spi/instance"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/imdario/mergo"
 )
 
-type resources map[string]instance.Description
-
 type collection struct {
-	*internal.Collection
-
-	options inventory.Options
-	model   *Model
+	*internal.Reconciler
 
-	resources resources
+	properties inventory.Properties
+	options    inventory.Options
 
-	accessors map[string]*internal.InstanceAccess
-	deleted   map[string]*internal.InstanceAccess
+	exports     map[string]*rendered
+	exportsLock sync.RWMutex
 
-	cancel func()
+	name     string
+	history  *history
+	recorded map[string]Record
+	reported map[string]bool // the sources that have reported instances
 }
 
 var (
@@ -43,7 +42,7 @@ var (
 	TopicErr = types.PathFromString("error")
 )
 
-func newCollection(scope scope.Scope, options inventory.Options) (internal.Managed, error) {
+func newCollection(scope scope.Scope, options inventory.Options, history *history) (internal.Managed, error) {
 
 	if err := mergo.Merge(&options, DefaultOptions); err != nil {
 		return nil, err
@@ -53,7 +52,20 @@ func newCollection(scope scope.Scope, options inventory.Options) (internal.Manag
 		return nil, err
 	}
 
-	base, err := internal.NewCollection(scope,
+	c := &collection{
+		options: options,
+		history: history,
+	}
+	base, err := internal.NewReconciler(scope,
+		internal.Reconcile{
+			Configure:   c.configure,
+			Model:       c.model,
+			Unmatched:   found,
+			Found:       resourceFound,
+			Lost:        resourceLost,
+			QualifyKeys: true,
+			Changed:     c.changed,
+		},
 		TopicFound,
 		TopicLost,
 		TopicErr,
@@ -61,21 +73,13 @@ func newCollection(scope scope.Scope, options inventory.Options) (internal.Manag
 	if err != nil {
 		return nil, err
 	}
-	c := &collection{
-		Collection: base,
-		options:    options,
-		resources:  resources{},
-		deleted:    map[string]*internal.InstanceAccess{},
-	}
-	// set the behaviors
-	base.StartFunc = c.run
-	base.StopFunc = c.stop
-	base.UpdateSpecFunc = c.updateSpec
+	c.Reconciler = base
 
 	return c, nil
 }
 
-func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err error) {
+// configure is called with the lock on the collection held
+func (c *collection) configure(spec types.Spec, previous *types.Spec) (sources internal.Sources, err error) {
 
 	prev := spec
 	if previous != nil {
@@ -116,7 +120,7 @@ func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err erro
 	// NOTE - we are using one client per instance accessor.  This is not the most efficient
 	// if there are resources sharing the same backends.
 
-	accessors := map[string]*internal.InstanceAccess{}
+	sources = internal.Sources{}
 
 	for name, accessList := range properties {
 
@@ -126,19 +130,17 @@ func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err erro
 
 			err = c.configureAccessor(spec, name, &copy)
 			if err != nil {
-				return err
+				return
 			}
 
 			key := types.Path([]string{name, copy.InstanceObserver.Name.String()})
-			accessors[key.String()] = &copy
+			sources[key.String()] = &copy
 
 			log.Debug("Initialized INCLUDED accessor", "name", name, "key", key,
 				"spec", spec, "access", access, "V", debugV2)
 		}
 	}
 
-	deleted := map[string]*internal.InstanceAccess{}
-
 	// For each in the previous spec that's not in the new spec, we need to start up the observation
 	// so that we can detect whether there are real instances that needs to be terminated to match
 	// the deletion in the new spec.
@@ -152,12 +154,12 @@ func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err erro
 
 				copy := access
 
-				if err := c.configureAccessor(prev, name, &copy); err != nil {
-					return err
+				if err = c.configureAccessor(prev, name, &copy); err != nil {
+					return
 				}
 
 				key := types.Path([]string{name, copy.InstanceObserver.Name.String()})
-				deleted[key.String()] = &copy
+				sources[key.String()] = &copy
 
 				log.Debug("Initialize DELETED accessor", "name", name, "key", key,
 					"spec", spec, "access", access, "V", debugV2)
@@ -165,241 +167,34 @@ func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err erro
 		}
 	}
 
-	c.deleted = deleted
-
-	// build the fsm model
-	var model *Model
-	model, err = BuildModel(properties, options)
-	if err != nil {
-		return
-	}
-
-	c.model = model
-	c.accessors = accessors
+	c.properties = properties
 	c.options = options
+	c.name = spec.Metadata.Name
 	return
 }
 
-func (c *collection) run(ctx context.Context) {
-
-	// Start the model
-	c.model.Start()
-
-	// channels that aggregate from all the instance accessors
-	type observation struct {
-		name      string
-		instances []instance.Description
-	}
-
-	accessors := map[string]*internal.InstanceAccess{}
-
-	for n, a := range c.accessors {
-		accessors[n] = a
-	}
-	for n, a := range c.deleted {
-		accessors[n] = a
-	}
-
-	log.Info("starting up accessors", "len", len(accessors))
-
-	ctx, cancel := context.WithCancel(context.Background())
-	c.cancel = cancel
-
-	// Start all the instance accessors and wire up the observations.
-	lostInstances := make(chan *observation, c.options.ChannelBufferSize)  // ch to aggregate all lost observations
-	foundInstances := make(chan *observation, c.options.ChannelBufferSize) // ch to aggregate all found observations
-
-	for k, a := range accessors {
-
-		log.Debug("Set up events from instance accessor", "name", k, "V", debugV)
-		go func(name string, accessor *internal.InstanceAccess) {
-
-			for {
-				select {
-				case list, ok := <-accessor.Observations():
-					if !ok {
-						log.Debug("found observations done", "name", name, "V", debugV2)
-						return
-					}
-					if len(list) > 0 {
-						foundInstances <- &observation{name: name, instances: list}
-						log.Debug("accessor found instances", "name", name, "count", len(list), "V", debugV)
-					}
-				case list, ok := <-accessor.Lost():
-					if !ok {
-						log.Debug("lost events done", "name", name, "V", debugV2)
-						return
-					}
-					if len(list) > 0 {
-						lostInstances <- &observation{name: name, instances: list}
-						log.Debug("accessor lost instances", "name", name, "count", len(list), "V", debugV)
-					}
-				}
-			}
-		}(k, a)
-
-		a.Start()
-		log.Debug("accessor started", "key", k, "observeInterval", a.ObserveInterval)
-	}
-
-	go func() {
-
-		for {
-
-			select {
-
-			case f, ok := <-c.model.Found():
-				if !ok {
-					return
-				}
-				item := c.Collection.GetByFSM(f)
-				if item != nil {
-					c.EventCh() <- event.Event{
-						Topic:   c.Topic(TopicFound),
-						Type:    event.Type("Found"),
-						ID:      c.EventID(item.Key),
-						Message: "resource found",
-					}.Init()
-				}
-
-			case f, ok := <-c.model.Lost():
-				if !ok {
-					return
-				}
-				item := c.Collection.GetByFSM(f)
-				if item != nil {
-					c.EventCh() <- event.Event{
-						Topic:   c.Topic(TopicLost),
-						Type:    event.Type("Lost"),
-						ID:      c.EventID(item.Key),
-						Message: "resource lost",
-					}.Init()
-				}
-
-			case lost, ok := <-lostInstances:
-				if !ok {
-					log.Info("Lost aggregator done")
-					return
-				}
-
-				accessor, has := accessors[lost.name]
-				if !has {
-					log.Warn("cannot find accessor for lost instance", "name", lost.name)
-					break
-				}
-
-				// Update the view in the metadata plugin
-				c.MetadataGone(keyOf(lost.name, accessor.KeyOf), lost.instances)
-
-				for _, n := range lost.instances {
-					k, err := accessor.KeyOf(n)
-					if err != nil {
-						log.Error("error getting key", "err", err, "instance", n)
-						break
-					}
-
-					if item := c.Collection.Get(k); item != nil {
-						log.Error("lost", "instance", n, "name", lost.name, "key", k)
-						item.State.Signal(resourceLost)
-					}
-					delete(c.resources, k)
-				}
-
-			case found, ok := <-foundInstances:
-				if !ok {
-					log.Info("Found aggregator done")
-					return
-				}
-
-				accessor, has := accessors[found.name]
-				if !has {
-					log.Warn("cannot find accessor for found instance", "name", found.name)
-					break
-				}
-
-				// Update the view in the metadata plugin
-				export := []instance.Description{}
-
-				for _, n := range found.instances {
-					k, err := accessor.KeyOf(n)
-					if err != nil {
-						log.Error("error getting key", "err", err, "instance", n)
-						break
-					}
-					item := c.Collection.Get(k)
-					if item == nil {
-
-						// In this case, the fsm isn't requested.. it's something we get out of band
-						// that somehow shows up (or from previous runs but now the user has
-						// removed it from the spec and performed a commit.
-						f := c.model.New()
-						item = c.Put(k, f, c.model.Spec(), map[string]interface{}{
-							"instance": n,
-						})
-
-						log.Debug("New instance", "fsm", f, "instance", n, "V", debugV)
-
-						export = append(export, n) // export to metadata
-
-					} else {
-						// if we already have entries stored, then see if the data changed
-						prev := item.Data["instance"]
-						if prev == nil {
-							export = append(export, n)
-						} else if dd, is := prev.(instance.Description); is {
-							if dd.Fingerprint() != n.Fingerprint() {
-								export = append(export, n)
-							}
-						}
-					}
-
-					c.resources[k] = n
-
-					log.Debug("found", "instance", n, "name", found.name, "key", k, "V", debugV2)
-					item.State.Signal(resourceFound)
-					item.Data["instance"] = n
-				}
-
-				c.MetadataExport(keyOf(found.name, accessor.KeyOf), export)
-			}
-		}
-	}()
-
+// changed is called in the reconcile loop on each change of the inventory
+func (c *collection) changed() {
+	c.recordChanges()
+	c.refresh()
 }
 
-type keyofFunc func(instance.Description) (string, error)
-
-func keyOf(n string, keyof keyofFunc) keyofFunc {
-	return func(i instance.Description) (string, error) {
-		k, err := keyof(i)
-		if err != nil {
-			return k, err
-		}
-		return types.PathFromString(n).JoinString(k).String(), nil
-	}
+func (c *collection) foundItem(item *internal.Item) {
+	c.EventCh() <- event.Event{
+		Topic:   c.Topic(TopicFound),
+		Type:    event.Type("Found"),
+		ID:      c.EventID(item.Key),
+		Message: "resource found",
+	}.Init()
 }
 
-func (c *collection) stop() error {
-	log.Info("stop")
-
-	if c.model != nil {
-
-		c.cancel()
-
-		for k, accessor := range c.accessors {
-			log.Debug("Stopping", "name", k, "V", debugV)
-			accessor.Stop()
-		}
-
-		for k, accessor := range c.deleted {
-			log.Debug("Stopping", "name", k, "V", debugV)
-			accessor.Stop()
-		}
-
-		c.model.Stop()
-		c.model = nil
-	}
-	return nil
+func (c *collection) lostItem(item *internal.Item) {
+	c.EventCh() <- event.Event{
+		Topic:   c.Topic(TopicLost),
+		Type:    event.Type("Lost"),
+		ID:      c.EventID(item.Key),
+		Message: "resource lost",
+	}.Init()
 }
 
 func (c *collection) configureAccessor(spec types.Spec, name string, access *internal.InstanceAccess) error {
@@ -0,0 +1,288 @@
+package inventory // import "github.com/docker/infrakit/pkg/controller/inventory"
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	inventory "github.com/docker/infrakit/pkg/controller/inventory/types"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// host is an instance in the inventory
+type host struct {
+	Key       string
+	Inventory string
+	ID        instance.ID
+	LogicalID *instance.LogicalID `json:",omitempty"`
+	Address   string
+	Tags      map[string]string
+}
+
+// rendered is the content of an export
+type rendered struct {
+	contentType string
+	data        []byte
+}
+
+var invalidName = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// sanitize returns a name that is valid as an Ansible group or a Prometheus label
+func sanitize(s string) string {
+	s = invalidName.ReplaceAllString(s, "_")
+	if len(s) > 0 && s[0] >= '0' && s[0] <= '9' {
+		s = "_" + s
+	}
+	return s
+}
+
+// hosts returns the instances currently in the inventory, sorted by key.  It is called in the reconcile loop.
+func (c *collection) hosts() []host {
+	hosts := []host{}
+	for k, d := range c.Observed() {
+		h := host{
+			Key:       k,
+			ID:        d.ID,
+			LogicalID: d.LogicalID,
+			Tags:      d.Tags,
+		}
+		if item := c.Get(k); item != nil {
+			if source, is := item.Data["source"].(string); is {
+				if name := types.PathFromString(source).Index(0); name != nil {
+					h.Inventory = *name
+				}
+			}
+		}
+		hosts = append(hosts, h)
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Key < hosts[j].Key })
+	return hosts
+}
+
+// address returns the address of the host for the export
+func address(export inventory.Export, h host) string {
+	if export.AddressTag != "" {
+		if v, has := h.Tags[export.AddressTag]; has {
+			return v
+		}
+	}
+	if h.LogicalID != nil {
+		return string(*h.LogicalID)
+	}
+	return string(h.ID)
+}
+
+// render renders the hosts in the format of the export
+func render(export inventory.Export, hosts []host) (*rendered, error) {
+	addressed := make([]host, len(hosts))
+	for i, h := range hosts {
+		addressed[i] = h
+		addressed[i].Address = address(export, h)
+	}
+
+	switch export.Format {
+	case inventory.Ansible:
+		data, err := renderAnsible(export, addressed)
+		return &rendered{contentType: "application/json", data: data}, err
+	case inventory.Prometheus:
+		data, err := renderPrometheus(export, addressed)
+		return &rendered{contentType: "application/json", data: data}, err
+	case inventory.CSV:
+		data, err := renderCSV(addressed)
+		return &rendered{contentType: "text/csv", data: data}, err
+	case inventory.JSON:
+		data, err := json.MarshalIndent(addressed, "", "  ")
+		return &rendered{contentType: "application/json", data: data}, err
+	}
+	return nil, fmt.Errorf("unknown export format: %v", export.Format)
+}
+
+// groupVars returns the tags with the same values in all the hosts of a group
+func groupVars(members []host) map[string]string {
+	vars := map[string]string{}
+	if len(members) == 0 {
+		return vars
+	}
+	for k, v := range members[0].Tags {
+		vars[k] = v
+	}
+	for _, h := range members[1:] {
+		for k, v := range vars {
+			if h.Tags[k] != v {
+				delete(vars, k)
+			}
+		}
+	}
+	return vars
+}
+
+// renderAnsible renders the JSON of an Ansible dynamic inventory.  The hosts are grouped by the names of
+// the inventory and the values of the GroupBy tags; the vars of a group are the tags common to its hosts.
+func renderAnsible(export inventory.Export, hosts []host) ([]byte, error) {
+	groups := map[string][]host{}
+	hostvars := map[string]map[string]string{}
+	for _, h := range hosts {
+		if h.Inventory != "" {
+			groups[sanitize(h.Inventory)] = append(groups[sanitize(h.Inventory)], h)
+		}
+		for _, tag := range export.GroupBy {
+			if v, has := h.Tags[tag]; has {
+				name := sanitize(tag + "_" + v)
+				groups[name] = append(groups[name], h)
+			}
+		}
+		hostvars[h.Address] = h.Tags
+	}
+
+	out := map[string]interface{}{
+		"_meta": map[string]interface{}{
+			"hostvars": hostvars,
+		},
+	}
+	for name, members := range groups {
+		addresses := []string{}
+		for _, h := range members {
+			addresses = append(addresses, h.Address)
+		}
+		out[name] = map[string]interface{}{
+			"hosts": addresses,
+			"vars":  groupVars(members),
+		}
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// renderPrometheus renders a Prometheus file_sd target list, with one target per host labeled by its tags
+func renderPrometheus(export inventory.Export, hosts []host) ([]byte, error) {
+	type targetGroup struct {
+		Targets []string          `json:"targets"`
+		Labels  map[string]string `json:"labels"`
+	}
+	out := []targetGroup{}
+	for _, h := range hosts {
+		target := h.Address
+		if export.Port > 0 {
+			target = fmt.Sprintf("%s:%d", target, export.Port)
+		}
+		labels := map[string]string{}
+		for k, v := range h.Tags {
+			labels[sanitize(k)] = v
+		}
+		if h.Inventory != "" {
+			labels["inventory"] = h.Inventory
+		}
+		out = append(out, targetGroup{Targets: []string{target}, Labels: labels})
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// renderCSV renders one row per host, with a column for each of the tags found
+func renderCSV(hosts []host) ([]byte, error) {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, h := range hosts {
+		for k := range h.Tags {
+			if !seen[k] {
+				seen[k] = true
+				tags = append(tags, k)
+			}
+		}
+	}
+	sort.Strings(tags)
+
+	buff := &bytes.Buffer{}
+	w := csv.NewWriter(buff)
+	if err := w.Write(append([]string{"key", "inventory", "id", "logical_id", "address"}, tags...)); err != nil {
+		return nil, err
+	}
+	for _, h := range hosts {
+		logicalID := ""
+		if h.LogicalID != nil {
+			logicalID = string(*h.LogicalID)
+		}
+		row := []string{h.Key, h.Inventory, string(h.ID), logicalID, h.Address}
+		for _, k := range tags {
+			row = append(row, h.Tags[k])
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buff.Bytes(), w.Error()
+}
+
+// writeFile writes the file, if the content changed, by renaming a temp file so readers never see partial content
+func writeFile(path string, data []byte) error {
+	if current, err := ioutil.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// refresh renders the exports.  It is called in the reconcile loop on each change of the inventory.
+func (c *collection) refresh() {
+	if len(c.options.Exports) == 0 {
+		return
+	}
+
+	hosts := c.hosts()
+	served := map[string]*rendered{}
+	for _, export := range c.options.Exports {
+		r, err := render(export, hosts)
+		if err != nil {
+			log.Error("Cannot render export", "format", export.Format, "err", err)
+			continue
+		}
+		if export.File != "" {
+			if err := writeFile(export.File, r.data); err != nil {
+				log.Error("Cannot write export", "format", export.Format, "file", export.File, "err", err)
+			}
+		}
+		if export.Path != "" {
+			served[export.Path] = r
+		}
+	}
+	log.Debug("Refreshed exports", "hosts", len(hosts), "V", debugV)
+
+	c.exportsLock.Lock()
+	defer c.exportsLock.Unlock()
+	c.exports = served
+}
+
+// Exports implements internal.Exporter
+func (c *collection) Exports() map[string]http.Handler {
+	c.exportsLock.RLock()
+	defer c.exportsLock.RUnlock()
+
+	out := map[string]http.Handler{}
+	for p, r := range c.exports {
+		r := r
+		out[p] = http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			resp.Header().Set("Content-Type", r.contentType)
+			resp.Write(r.data)
+		})
+	}
+	return out
+}
@@ -0,0 +1,168 @@
+package inventory // import "github.com/docker/infrakit/pkg/controller/inventory"
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller/internal"
+	inventory "github.com/docker/infrakit/pkg/controller/inventory/types"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	testutil_instance "github.com/docker/infrakit/pkg/testing/instance"
+	testutil_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func testHosts() []host {
+	lid := instance.LogicalID("10.0.0.2")
+	return []host{
+		{
+			Key:       "workers/1",
+			Inventory: "az1",
+			ID:        "i-1",
+			Tags:      map[string]string{"role": "worker", "zone": "a", "ip": "10.0.0.1"},
+		},
+		{
+			Key:       "workers/2",
+			Inventory: "az1",
+			ID:        "i-2",
+			LogicalID: &lid,
+			Tags:      map[string]string{"role": "worker", "zone": "b"},
+		},
+	}
+}
+
+func TestRenderAnsible(t *testing.T) {
+	r, err := render(inventory.Export{
+		Format:     inventory.Ansible,
+		GroupBy:    []string{"zone"},
+		AddressTag: "ip",
+	}, testHosts())
+	require.NoError(t, err)
+
+	out := map[string]struct {
+		Hosts    []string
+		Vars     map[string]string
+		Hostvars map[string]map[string]string
+	}{}
+	require.NoError(t, json.Unmarshal(r.data, &out))
+
+	require.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, out["az1"].Hosts)
+	require.Equal(t, map[string]string{"role": "worker"}, out["az1"].Vars)
+	require.Equal(t, []string{"10.0.0.1"}, out["zone_a"].Hosts)
+	require.Equal(t, "a", out["zone_a"].Vars["zone"])
+	require.Equal(t, []string{"10.0.0.2"}, out["zone_b"].Hosts)
+	require.Equal(t, "b", out["_meta"].Hostvars["10.0.0.2"]["zone"])
+}
+
+func TestRenderPrometheus(t *testing.T) {
+	r, err := render(inventory.Export{
+		Format: inventory.Prometheus,
+		Port:   9100,
+	}, testHosts())
+	require.NoError(t, err)
+
+	out := []struct {
+		Targets []string
+		Labels  map[string]string
+	}{}
+	require.NoError(t, json.Unmarshal(r.data, &out))
+	require.Equal(t, 2, len(out))
+	require.Equal(t, []string{"i-1:9100"}, out[0].Targets)
+	require.Equal(t, "az1", out[0].Labels["inventory"])
+	require.Equal(t, []string{"10.0.0.2:9100"}, out[1].Targets)
+}
+
+func TestRenderCSV(t *testing.T) {
+	r, err := render(inventory.Export{Format: inventory.CSV}, testHosts())
+	require.NoError(t, err)
+	require.Equal(t, "text/csv", r.contentType)
+	require.Equal(t, `key,inventory,id,logical_id,address,ip,role,zone
+workers/1,az1,i-1,,i-1,10.0.0.1,worker,a
+workers/2,az1,i-2,10.0.0.2,10.0.0.2,,worker,b
+`, string(r.data))
+}
+
+func TestExportsRefreshed(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "inventory")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "hosts.json")
+
+	observed := []instance.Description{
+		{ID: "i-1", Tags: map[string]string{internal.CollectionLabel: "workers", internal.InstanceLabel: "1"}},
+		{ID: "i-2", Tags: map[string]string{internal.CollectionLabel: "workers", internal.InstanceLabel: "2"}},
+	}
+
+	testScope := testutil_scope.DefaultScope()
+	testScope.ResolveInstance = func(n string) (instance.Plugin, error) {
+		return &testutil_instance.Plugin{
+			DoDescribeInstances: func(tags map[string]string, details bool) ([]instance.Description, error) {
+				return observed, nil
+			},
+		}, nil
+	}
+
+	options := DefaultOptions
+	options.InstanceObserver = &internal.InstanceObserver{
+		ObserveInterval: types.FromDuration(100 * time.Millisecond),
+		KeySelector:     DefaultOptions.InstanceObserver.KeySelector,
+	}
+	options.Exports = []inventory.Export{
+		{Format: inventory.JSON, File: file, Path: "hosts.json"},
+	}
+
+	managed, err := newCollection(testScope, options, nil)
+	require.NoError(t, err)
+	c := managed.(*collection)
+
+	events := make(chan *event.Event)
+	c.PublishOn(events)
+	go func() {
+		for range events {
+		}
+	}()
+
+	spec := types.Spec{}
+	require.NoError(t, types.AnyYAMLMust([]byte(`
+kind: inventory
+metadata:
+  name: hosts
+properties:
+  az1:
+    - plugin: simulator/compute
+`)).Decode(&spec))
+
+	_, err = c.Enforce(spec)
+	require.NoError(t, err)
+
+	hosts := []host{}
+	for i := 0; i < 50; i++ {
+		if buff, err := ioutil.ReadFile(file); err == nil {
+			require.NoError(t, json.Unmarshal(buff, &hosts))
+			if len(hosts) == 2 {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.Equal(t, 2, len(hosts))
+	require.Equal(t, "az1", hosts[0].Inventory)
+	require.Equal(t, instance.ID("i-1"), hosts[0].ID)
+
+	handler, has := c.Exports()["hosts.json"]
+	require.True(t, has)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest("GET", "/exports/hosts/hosts.json", nil))
+	require.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &hosts))
+	require.Equal(t, 2, len(hosts))
+}
@@ -1,78 +1,12 @@
 package inventory // import "github.com/docker/infrakit/pkg/controller/inventory"
 
 import (
-	"sync"
 	"time"
 
-	inventory "github.com/docker/infrakit/pkg/controller/inventory/types"
+	"github.com/docker/infrakit/pkg/controller/internal"
 	"github.com/docker/infrakit/pkg/fsm"
 )
 
-// Model encapsulates the workflow / state machines for provisioning resources
-type Model struct {
-	spec     *fsm.Spec
-	set      *fsm.Set
-	clock    *fsm.Clock
-	tickSize time.Duration
-
-	inventory.Properties
-
-	instanceFoundChan chan fsm.FSM
-	instanceLostChan  chan fsm.FSM
-
-	lock sync.RWMutex
-}
-
-// Found is the channel to get signals of instances that are found
-func (m *Model) Found() <-chan fsm.FSM {
-	return m.instanceFoundChan
-}
-
-// Lost is the channel to get signals of instances that are lost
-func (m *Model) Lost() <-chan fsm.FSM {
-	return m.instanceLostChan
-}
-
-// New adds a new fsm in the found (initial) state
-func (m *Model) New() fsm.FSM {
-	return m.set.Add(found)
-}
-
-// Spec returns the model description
-func (m *Model) Spec() *fsm.Spec {
-	m.lock.RLock()
-	defer m.lock.RUnlock()
-
-	return m.spec
-}
-
-// Start starts the model
-func (m *Model) Start() {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-
-	if m.set == nil {
-		m.clock.Start()
-		m.set = fsm.NewSet(m.spec, m.clock, fsm.DefaultOptions("inventory"))
-	}
-}
-
-// Stop stops the model
-func (m *Model) Stop() {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-
-	if m.set != nil {
-		m.set.Stop()
-		m.clock.Stop()
-
-		close(m.instanceFoundChan)
-		close(m.instanceLostChan)
-		m.set = nil
-	}
-
-}
-
 const (
 
 	// States
@@ -96,22 +30,19 @@ var (
 	}
 )
 
-// BuildModel constructs a workflow model given the configuration blob provided by user in the Properties
-func BuildModel(properties inventory.Properties, options inventory.Options) (*Model, error) {
+// model constructs the workflow model given the configuration blob provided by user in the Properties
+func (c *collection) model() (internal.Model, error) {
+
+	properties, options := c.properties, c.options
 
 	log.Info("Build model", "properties", properties)
-	model := &Model{
-		Properties:        properties,
-		instanceFoundChan: make(chan fsm.FSM, options.ChannelBufferSize),
-		instanceLostChan:  make(chan fsm.FSM, options.ChannelBufferSize),
-		tickSize:          1 * time.Second,
-	}
+	tickSize := 1 * time.Second
 
 	// find the max observation interval and set the model tick to be that
 	for _, accessList := range properties {
 		for _, accessor := range accessList {
-			if model.tickSize < accessor.ObserveInterval.Duration() {
-				model.tickSize = accessor.ObserveInterval.Duration()
+			if tickSize < accessor.ObserveInterval.Duration() {
+				tickSize = accessor.ObserveInterval.Duration()
 			}
 		}
 	}
@@ -119,13 +50,10 @@ func BuildModel(properties inventory.Properties, options inventory.Options) (*Mo
 	// We must guarantee that the tick size is at least as large as the global
 	// setting.  This is so that we don't miss samples and instead advances state
 	// too quickly.
-	if options.InstanceObserver.ObserveInterval.Duration() > model.tickSize {
-		model.tickSize = options.InstanceObserver.ObserveInterval.Duration()
+	if options.InstanceObserver.ObserveInterval.Duration() > tickSize {
+		tickSize = options.InstanceObserver.ObserveInterval.Duration()
 	}
 
-	log.Info("model", "tickSize", model.tickSize)
-
-	model.clock = fsm.Wall(time.Tick(model.tickSize))
 	spec, err := fsm.With(stateNames, signalNames).Define(
 		fsm.State{
 			Index: found,
@@ -134,10 +62,7 @@ func BuildModel(properties inventory.Properties, options inventory.Options) (*Mo
 				resourceLost:  lost,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				resourceLost: func(n fsm.FSM) error {
-					model.instanceLostChan <- n
-					return nil
-				},
+				resourceLost: c.Async(c.lostItem),
 			},
 		},
 		fsm.State{
@@ -146,19 +71,20 @@ func BuildModel(properties inventory.Properties, options inventory.Options) (*Mo
 				resourceFound: found,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				resourceFound: func(n fsm.FSM) error {
-					model.instanceFoundChan <- n
-					return nil
-				},
+				resourceFound: c.Async(c.foundItem),
 			},
 		},
 	)
 
-	log.Info("model", "tickSize", model.tickSize, "err", err)
+	log.Info("model", "tickSize", tickSize, "err", err)
 	if err != nil {
 		panic(err) // Panic because there's a problem with the static / code definition of the model
 	}
 
-	model.spec = spec
-	return model, nil
+	return internal.Model{
+		Spec:       spec,
+		Clock:      fsm.Wall(time.Tick(tickSize)),
+		Options:    fsm.DefaultOptions("inventory"),
+		BufferSize: options.ChannelBufferSize,
+	}, nil
 }
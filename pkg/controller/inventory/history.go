@@ -0,0 +1,429 @@
+package inventory // import "github.com/docker/infrakit/pkg/controller/inventory"
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	inventory "github.com/docker/infrakit/pkg/controller/inventory/types"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/spi/metadata"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/store/file"
+	"github.com/docker/infrakit/pkg/store/mem"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+const (
+	// HistoryKey is the key in the metadata of a collection where the history of the inventory is found
+	HistoryKey = "history"
+
+	historyType = "inventory-history"
+)
+
+// Change is the type of change of an instance in the inventory
+type Change string
+
+const (
+	// Found is recorded when an instance shows up in the inventory
+	Found Change = "found"
+
+	// Lost is recorded when an instance is gone from the inventory
+	Lost Change = "lost"
+
+	// Changed is recorded when an instance in the inventory is updated
+	Changed Change = "changed"
+)
+
+// Record is a change of an instance in the inventory
+type Record struct {
+	Time       time.Time
+	Collection string
+	Source     string
+	Key        string
+	Change     Change
+	Instance   instance.Description
+
+	// Seq orders the records made at the same time
+	Seq uint64 `json:",omitempty"`
+}
+
+// Appearance is when an instance was in the inventory
+type Appearance struct {
+
+	// ID is the id of the instance
+	ID instance.ID
+
+	// Key is the key of the instance in the inventory
+	Key string
+
+	// First is when the instance was first found
+	First time.Time
+
+	// Last is when the instance was last found or changed
+	Last time.Time
+
+	// Gone is when the instance was lost, if it's no longer in the inventory
+	Gone *time.Time `json:",omitempty"`
+}
+
+// history is the record of the changes of the inventories.  It's shared by the collections, which are
+// replaced on each commit.  The records are cached by collection, so that queries don't read the store,
+// and the records past the retention are removed.
+type history struct {
+	kv        store.KV
+	retention time.Duration
+
+	lock    sync.Mutex
+	records map[string][]Record // by collection, in order of time.  Nil until loaded.
+	seq     uint64
+	pruned  time.Time
+}
+
+// newHistory returns the history, or nil if no history is recorded
+func newHistory(options inventory.History) *history {
+	var kv store.KV
+	switch options.Store {
+	case inventory.StoreFile:
+		kv = file.NewStore(historyType, options.Dir)
+	case inventory.StoreMem:
+		kv = mem.NewStore(historyType)
+	default:
+		return nil
+	}
+	return &history{kv: kv, retention: options.Retention.Duration()}
+}
+
+// historyKey returns the key of the record in the store
+func historyKey(r Record) string {
+	return fmt.Sprintf("%s-%d-%d", r.Collection, r.Time.UnixNano(), r.Seq)
+}
+
+// load reads the records from the store, once.  It's called with the lock held.
+func (h *history) load() error {
+	if h.records != nil {
+		return nil
+	}
+	entries, err := h.kv.Entries()
+	if err != nil {
+		return err
+	}
+	records := map[string][]Record{}
+	for entry := range entries {
+		r := Record{}
+		if err := json.Unmarshal(entry.Value, &r); err != nil {
+			return err
+		}
+		records[r.Collection] = append(records[r.Collection], r)
+		if r.Seq > h.seq {
+			h.seq = r.Seq
+		}
+	}
+	for _, list := range records {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Time.Before(list[j].Time) })
+	}
+	h.records = records
+	return nil
+}
+
+// list returns the records of the collection, in order of time
+func (h *history) list(collection string) ([]Record, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	return append([]Record{}, h.records[collection]...), nil
+}
+
+// add writes the record and removes the records past the retention
+func (h *history) add(r Record) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if err := h.load(); err != nil {
+		return err
+	}
+	h.seq++
+	r.Seq = h.seq
+	buff, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := h.kv.Write(historyKey(r), buff); err != nil {
+		return err
+	}
+	h.records[r.Collection] = append(h.records[r.Collection], r)
+	h.prune(r.Time)
+	return nil
+}
+
+// prune removes the records older than the retention, at most once every tenth of the retention.  The last
+// record of an instance still in the inventory is kept, so that the contents of the inventory are intact.
+// It's called with the lock held.
+func (h *history) prune(now time.Time) {
+	if h.retention == 0 || now.Sub(h.pruned) < h.retention/10 {
+		return
+	}
+	h.pruned = now
+	cutoff := now.Add(-h.retention)
+
+	for collection, list := range h.records {
+		last := map[string]int{}
+		for i, r := range list {
+			last[r.Key] = i
+		}
+		kept := []Record{}
+		for i, r := range list {
+			if !r.Time.Before(cutoff) || (last[r.Key] == i && r.Change != Lost) {
+				kept = append(kept, r)
+				continue
+			}
+			if err := h.kv.Delete(historyKey(r)); err != nil {
+				log.Warn("Cannot remove record", "collection", collection, "key", r.Key, "err", err)
+			}
+		}
+		h.records[collection] = kept
+	}
+}
+
+// contents returns the instances in the inventory at the given time, by key
+func contents(records []Record, t time.Time) map[string]Record {
+	out := map[string]Record{}
+	for _, r := range records {
+		if r.Time.After(t) {
+			break
+		}
+		if r.Change == Lost {
+			delete(out, r.Key)
+			continue
+		}
+		out[r.Key] = r
+	}
+	return out
+}
+
+// appearances returns when the instances were in the inventory, by instance id
+func appearances(records []Record) map[instance.ID]*Appearance {
+	out := map[instance.ID]*Appearance{}
+	for _, r := range records {
+		a, has := out[r.Instance.ID]
+		if !has {
+			a = &Appearance{ID: r.Instance.ID, Key: r.Key, First: r.Time}
+			out[r.Instance.ID] = a
+		}
+		if r.Change == Lost {
+			t := r.Time
+			a.Gone = &t
+			continue
+		}
+		a.Key, a.Last, a.Gone = r.Key, r.Time, nil
+	}
+	return out
+}
+
+// record writes the record of a change
+func (c *collection) record(change Change, key, source string, d instance.Description) {
+	err := c.history.add(Record{
+		Time:       time.Now(),
+		Collection: c.name,
+		Source:     source,
+		Key:        key,
+		Change:     change,
+		Instance:   d,
+	})
+	if err != nil {
+		log.Error("Cannot record change", "change", change, "key", key, "err", err)
+	}
+}
+
+// recordChanges records the differences of the observed instances from the last recorded.  It is called
+// in the reconcile loop on each change of the inventory.  On the first call, the last recorded state
+// is loaded from the history so that restarts don't record the instances as found again.  An instance
+// is recorded as lost only if its source has reported since, even if the source now has no instances.
+func (c *collection) recordChanges() {
+	if c.history == nil {
+		return
+	}
+
+	if c.recorded == nil {
+		c.recorded = map[string]Record{}
+		if list, err := c.history.list(c.name); err == nil {
+			c.recorded = contents(list, time.Now())
+		} else {
+			log.Error("Cannot load history", "collection", c.name, "err", err)
+		}
+	}
+
+	if c.reported == nil {
+		c.reported = map[string]bool{}
+	}
+
+	observed := c.Observed()
+	keys := []string{}
+	for k := range observed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		d := observed[k]
+		source := ""
+		if item := c.Get(k); item != nil {
+			source, _ = item.Data["source"].(string)
+		}
+		c.reported[source] = true
+
+		prev, has := c.recorded[k]
+		switch {
+		case !has:
+			c.record(Found, k, source, d)
+		case prev.Instance.Fingerprint() != d.Fingerprint():
+			c.record(Changed, k, source, d)
+		default:
+			continue
+		}
+		c.recorded[k] = Record{Source: source, Key: k, Instance: d}
+	}
+
+	keys = []string{}
+	for k := range c.recorded {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		prev := c.recorded[k]
+		// Only the sources that reported can tell an instance is gone.
+		if _, has := observed[k]; has || !c.reported[prev.Source] {
+			continue
+		}
+		c.record(Lost, k, prev.Source, prev.Instance)
+		delete(c.recorded, k)
+	}
+}
+
+// historyView is a metadata plugin that adds the queries of the history to the metadata at the HistoryKey:
+//
+//	history/events          - all the changes recorded
+//	history/at/<time>       - the instances in the inventory at the time, in RFC3339 format
+//	history/instances/<id>  - when the instance was in the inventory
+type historyView struct {
+	metadata.Plugin
+	history    *history
+	collection func() string
+}
+
+func (v *historyView) records() ([]Record, error) {
+	return v.history.list(v.collection())
+}
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// view returns the history viewed at the path, which is relative to the HistoryKey.  The view is
+// returned in generic form along with the rest of the path to navigate it.
+func (v *historyView) view(path types.Path) (interface{}, types.Path, error) {
+	raw, rest, err := v.raw(path)
+	if err != nil || raw == nil {
+		return nil, rest, err
+	}
+	any, err := types.AnyValue(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	var view interface{}
+	if err := any.Decode(&view); err != nil {
+		return nil, nil, err
+	}
+	return view, rest, nil
+}
+
+func (v *historyView) raw(path types.Path) (interface{}, types.Path, error) {
+	list, err := v.records()
+	if err != nil {
+		return nil, nil, err
+	}
+	if path.Len() == 0 {
+		return map[string]interface{}{"events": nil, "at": nil, "instances": nil}, path, nil
+	}
+
+	switch *path.Index(0) {
+	case "events":
+		return list, path.Shift(1), nil
+
+	case "at":
+		if path.Len() == 1 {
+			times := map[string]interface{}{}
+			for _, r := range list {
+				times[r.Time.Format(time.RFC3339Nano)] = nil
+			}
+			return times, path.Shift(1), nil
+		}
+		t, err := parseTime(*path.Index(1))
+		if err != nil {
+			return nil, nil, err
+		}
+		view := map[string]interface{}{}
+		for k, r := range contents(list, t) {
+			types.Put(types.PathFromString(k), r.Instance, view)
+		}
+		return view, path.Shift(2), nil
+
+	case "instances":
+		view := map[string]interface{}{}
+		for id, a := range appearances(list) {
+			view[string(id)] = a
+		}
+		return view, path.Shift(1), nil
+	}
+	return nil, path, nil
+}
+
+// Keys returns a list of *child nodes* given a path
+func (v *historyView) Keys(path types.Path) ([]string, error) {
+	if path.Len() == 0 || path.Dot() {
+		keys, err := v.Plugin.Keys(path)
+		if err != nil {
+			return nil, err
+		}
+		return append(keys, HistoryKey), nil
+	}
+	if *path.Index(0) != HistoryKey {
+		return v.Plugin.Keys(path)
+	}
+	view, rest, err := v.view(path.Shift(1))
+	if err != nil {
+		return nil, err
+	}
+	return types.List(rest, view), nil
+}
+
+// Get retrieves the value at path given.
+func (v *historyView) Get(path types.Path) (*types.Any, error) {
+	if path.Len() == 0 || *path.Index(0) != HistoryKey {
+		return v.Plugin.Get(path)
+	}
+	view, rest, err := v.view(path.Shift(1))
+	if err != nil {
+		return nil, err
+	}
+	return types.AnyValue(types.Get(rest, view))
+}
+
+// Metadata returns the metadata of the collection, with the history if it's recorded
+func (c *collection) Metadata() metadata.Plugin {
+	base := c.Reconciler.Metadata()
+	if c.history == nil {
+		return base
+	}
+	return &historyView{
+		Plugin:     base,
+		history:    c.history,
+		collection: func() string { return c.CurrentSpec().Metadata.Name },
+	}
+}
@@ -0,0 +1,199 @@
+package inventory // import "github.com/docker/infrakit/pkg/controller/inventory"
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller/internal"
+	inventory "github.com/docker/infrakit/pkg/controller/inventory/types"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	testutil_instance "github.com/docker/infrakit/pkg/testing/instance"
+	testutil_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryQueries(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(m int) time.Time { return t0.Add(time.Duration(m) * time.Minute) }
+
+	list := []Record{
+		{Time: at(0), Key: "workers/1", Change: Found, Instance: instance.Description{ID: "i-1"}},
+		{Time: at(1), Key: "workers/2", Change: Found, Instance: instance.Description{ID: "i-2"}},
+		{Time: at(2), Key: "workers/1", Change: Lost, Instance: instance.Description{ID: "i-1"}},
+		{Time: at(3), Key: "workers/1", Change: Found, Instance: instance.Description{ID: "i-3"}},
+		{Time: at(4), Key: "workers/2", Change: Changed, Instance: instance.Description{ID: "i-2"}},
+	}
+
+	require.Equal(t, 1, len(contents(list, at(0))))
+	require.Equal(t, 2, len(contents(list, at(1))))
+	require.Equal(t, 1, len(contents(list, at(2))))
+
+	c := contents(list, at(5))
+	require.Equal(t, 2, len(c))
+	require.Equal(t, instance.ID("i-3"), c["workers/1"].Instance.ID)
+
+	a := appearances(list)
+	require.Equal(t, at(0), a["i-1"].First)
+	require.Equal(t, at(0), a["i-1"].Last)
+	require.Equal(t, at(2), *a["i-1"].Gone)
+	require.Equal(t, at(1), a["i-2"].First)
+	require.Equal(t, at(4), a["i-2"].Last)
+	require.Nil(t, a["i-2"].Gone)
+}
+
+func TestHistoryRecorded(t *testing.T) {
+
+	var lock sync.Mutex
+	observed := []instance.Description{
+		{ID: "i-1", Tags: map[string]string{internal.CollectionLabel: "workers", internal.InstanceLabel: "1"}},
+		{ID: "i-2", Tags: map[string]string{internal.CollectionLabel: "workers", internal.InstanceLabel: "2"}},
+	}
+
+	testScope := testutil_scope.DefaultScope()
+	testScope.ResolveInstance = func(n string) (instance.Plugin, error) {
+		return &testutil_instance.Plugin{
+			DoDescribeInstances: func(tags map[string]string, details bool) ([]instance.Description, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				return observed, nil
+			},
+		}, nil
+	}
+
+	options := DefaultOptions
+	options.InstanceObserver = &internal.InstanceObserver{
+		ObserveInterval: types.FromDuration(100 * time.Millisecond),
+		KeySelector:     DefaultOptions.InstanceObserver.KeySelector,
+	}
+	history := newHistory(inventory.History{Store: inventory.StoreMem})
+
+	managed, err := newCollection(testScope, options, history)
+	require.NoError(t, err)
+	c := managed.(*collection)
+
+	events := make(chan *event.Event)
+	c.PublishOn(events)
+	go func() {
+		for range events {
+		}
+	}()
+
+	spec := types.Spec{}
+	require.NoError(t, types.AnyYAMLMust([]byte(`
+kind: inventory
+metadata:
+  name: hosts
+properties:
+  az1:
+    - plugin: simulator/compute
+`)).Decode(&spec))
+
+	_, err = c.Enforce(spec)
+	require.NoError(t, err)
+
+	waitFor := func(n int) []Record {
+		var list []Record
+		for i := 0; i < 50; i++ {
+			list, err = history.list("hosts")
+			require.NoError(t, err)
+			if len(list) == n {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		require.Equal(t, n, len(list))
+		return list
+	}
+
+	list := waitFor(2)
+	require.Equal(t, Found, list[0].Change)
+	require.Equal(t, "az1/simulator/compute", list[0].Source)
+
+	before := time.Now()
+
+	lock.Lock()
+	observed = observed[:1]
+	lock.Unlock()
+
+	list = waitFor(3)
+	require.Equal(t, Lost, list[2].Change)
+	require.Equal(t, instance.ID("i-2"), list[2].Instance.ID)
+
+	// The source has no instances left
+	lock.Lock()
+	observed = nil
+	lock.Unlock()
+
+	list = waitFor(4)
+	require.Equal(t, Lost, list[3].Change)
+	require.Equal(t, instance.ID("i-1"), list[3].Instance.ID)
+
+	m := c.Metadata()
+
+	keys, err := m.Keys(types.PathFromString(HistoryKey))
+	require.NoError(t, err)
+	require.Equal(t, []string{"at", "events", "instances"}, keys)
+
+	any, err := m.Get(types.PathFromString(HistoryKey + "/at/" + before.Format(time.RFC3339Nano)))
+	require.NoError(t, err)
+	at := map[string]map[string]interface{}{}
+	require.NoError(t, any.Decode(&at))
+	require.Equal(t, 2, len(at["workers"]))
+
+	any, err = m.Get(types.PathFromString(HistoryKey + "/instances/i-2"))
+	require.NoError(t, err)
+	a := Appearance{}
+	require.NoError(t, any.Decode(&a))
+	require.Equal(t, "workers/2", a.Key)
+	require.NotNil(t, a.Gone)
+
+	// A new collection for the same spec continues from the recorded state
+	managed, err = newCollection(testScope, options, history)
+	require.NoError(t, err)
+	c2 := managed.(*collection)
+	c2.PublishOn(events)
+	_, err = c2.Enforce(spec)
+	require.NoError(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+	waitFor(4)
+}
+
+func TestHistoryRetention(t *testing.T) {
+
+	h := newHistory(inventory.History{Store: inventory.StoreMem, Retention: types.FromDuration(1 * time.Hour)})
+	now := time.Now()
+	at := func(m int) time.Time { return now.Add(time.Duration(m) * time.Minute) }
+
+	for _, r := range []Record{
+		{Time: at(-180), Key: "workers/1", Change: Found, Instance: instance.Description{ID: "i-1"}},
+		{Time: at(-170), Key: "workers/2", Change: Found, Instance: instance.Description{ID: "i-2"}},
+		{Time: at(-160), Key: "workers/2", Change: Changed, Instance: instance.Description{ID: "i-2"}},
+		{Time: at(-150), Key: "workers/3", Change: Found, Instance: instance.Description{ID: "i-3"}},
+		{Time: at(-140), Key: "workers/3", Change: Lost, Instance: instance.Description{ID: "i-3"}},
+		{Time: at(0), Key: "workers/4", Change: Found, Instance: instance.Description{ID: "i-4"}},
+	} {
+		r.Collection = "hosts"
+		require.NoError(t, h.add(r))
+	}
+
+	// Only the last records of the instances in the inventory are kept past the retention
+	list, err := h.list("hosts")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(list))
+	require.Equal(t, instance.ID("i-1"), list[0].Instance.ID)
+	require.Equal(t, Changed, list[1].Change)
+	require.Equal(t, instance.ID("i-4"), list[2].Instance.ID)
+	require.Equal(t, 3, len(contents(list, now)))
+
+	entries, err := h.kv.Entries()
+	require.NoError(t, err)
+	count := 0
+	for range entries {
+		count++
+	}
+	require.Equal(t, 3, count)
+}
@@ -75,6 +75,104 @@ type Options struct {
 
 	// ModelProperties capture the config parameters of the workflow model
 	ModelProperties `json:",inline" yaml:",inline"`
+
+	// Exports are the renderings of the inventory for external systems, refreshed on each change
+	Exports []Export `json:",omitempty" yaml:",omitempty"`
+
+	// History configures the recording of the changes of the inventory.  This is read only at start up.
+	History History `json:",omitempty" yaml:",omitempty"`
+}
+
+const (
+	// StoreMem is the value for recording the history in memory
+	StoreMem = "mem"
+
+	// StoreFile is the value for recording the history in files
+	StoreFile = "file"
+)
+
+// History configures where the changes of the inventory are recorded
+type History struct {
+
+	// Store is the backend of the history, mem or file.  No history is recorded if not set.
+	Store string `json:",omitempty" yaml:",omitempty"`
+
+	// Dir is the directory of the file store
+	Dir string `json:",omitempty" yaml:",omitempty"`
+
+	// Retention is how long the records are kept, except the last record of each instance in the
+	// inventory.  Queries at times before the retention may be incomplete.  Default is to keep all.
+	Retention types.Duration `json:",omitempty" yaml:",omitempty"`
+}
+
+// Validate validates the history config
+func (h History) Validate() error {
+	switch h.Store {
+	case "", StoreMem:
+	case StoreFile:
+		if h.Dir == "" {
+			return fmt.Errorf("history in files needs a dir")
+		}
+	default:
+		return fmt.Errorf("unknown history store: %v", h.Store)
+	}
+	if h.Retention.Duration() < 0 {
+		return fmt.Errorf("bad retention: %v", h.Retention)
+	}
+	return nil
+}
+
+// Format is the format of an export of the inventory
+type Format string
+
+const (
+	// Ansible is the JSON of an Ansible dynamic inventory
+	Ansible Format = "ansible"
+
+	// Prometheus is the JSON of a Prometheus file_sd target list
+	Prometheus Format = "prometheus"
+
+	// CSV is a snapshot of the inventory in CSV, one row per instance
+	CSV Format = "csv"
+
+	// JSON is a snapshot of the inventory in JSON
+	JSON Format = "json"
+)
+
+// Export specifies a rendering of the inventory in the format of an external system.  The export is
+// written to a file, served at a path of the plugin's HTTP endpoint, or both.
+type Export struct {
+
+	// Format is the format of the export
+	Format Format
+
+	// File is the path of the file to write to. Optional.
+	File string `json:",omitempty" yaml:",omitempty"`
+
+	// Path is the path of the export under the exports endpoint of the plugin. Optional.
+	Path string `json:",omitempty" yaml:",omitempty"`
+
+	// GroupBy are the tags whose values group the hosts, in addition to the names of the inventory.
+	GroupBy []string `json:",omitempty" yaml:",omitempty"`
+
+	// AddressTag is the tag with the address of a host.  Defaults to the logical ID or the ID.
+	AddressTag string `json:",omitempty" yaml:",omitempty"`
+
+	// Port is appended to the addresses of Prometheus targets. Optional.
+	Port int `json:",omitempty" yaml:",omitempty"`
+}
+
+// Validate validates the export
+func (e Export) Validate() error {
+	switch e.Format {
+	case Ansible, Prometheus, CSV, JSON:
+	default:
+		return fmt.Errorf("unknown export format: %v", e.Format)
+	}
+	if e.File == "" && e.Path == "" {
+		return fmt.Errorf("export %v needs a file or a path", e.Format)
+	}
+	return nil
 }
 
 // Validate validates the controller's options
@@ -85,5 +183,10 @@ func (p Options) Validate(ctx context.Context) error {
 	if p.ChannelBufferSize < p.MinChannelBufferSize {
 		return fmt.Errorf("channel buffer size can't be less than %v", p.MinChannelBufferSize)
 	}
-	return nil
+	for _, e := range p.Exports {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+	return p.History.Validate()
 }
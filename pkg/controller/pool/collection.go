@@ -7,6 +7,7 @@ import (
 
 	"github.com/docker/infrakit/pkg/controller/internal"
 	pool "github.com/docker/infrakit/pkg/controller/pool/types"
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	"github.com/docker/infrakit/pkg/run/scope"
 	"github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/spi/instance"
@@ -14,10 +15,8 @@ import (
 	"github.com/imdario/mergo"
 )
 
-type resources map[string]instance.Description
-
 type collection struct {
-	*internal.Collection
+	*internal.Reconciler
 
 	accessor *internal.InstanceAccess // current version
 	last     *internal.InstanceAccess // last version
@@ -27,11 +26,12 @@ type collection struct {
 	properties pool.Properties
 	options    pool.Options
 
-	model *Model
-
-	resources resources
+	// warm pool: accessed only in the reconcile loop
+	size   int       // the number of unclaimed instances wanted
+	next   int       // the ordinal of the next key
+	claims []claimed // the instances claimed
 
-	cancel func()
+	stopAging chan struct{}
 }
 
 var (
@@ -64,32 +64,60 @@ func newCollection(scope scope.Scope, options pool.Options) (internal.Managed, e
 		return nil, err
 	}
 
-	base, err := internal.NewCollection(scope,
+	c := &collection{
+		options: options,
+	}
+	base, err := internal.NewReconciler(scope,
+		internal.Reconcile{
+			Configure: c.configure,
+			Model:     c.model,
+			Desired:   c.desired,
+			Requested: requested,
+			Unmatched: unmatched,
+			Found:     resourceFound,
+			Lost:      resourceLost,
+		},
 		TopicProvision,
 		TopicProvisionErr,
 		TopicDestroy,
 		TopicDestroyErr,
 		TopicPending,
 		TopicReady,
+		TopicClaim,
+		TopicAgedOut,
 	)
 	if err != nil {
 		return nil, err
 	}
-	c := &collection{
-		Collection: base,
-		options:    options,
-		resources:  resources{},
-	}
+	c.Reconciler = base
+
 	// set the behaviors
-	base.StartFunc = c.run
-	base.StopFunc = c.stop
-	base.UpdateSpecFunc = c.updateSpec
+	start := base.StartFunc
+	base.StartFunc = func(ctx context.Context) {
+		start(ctx)
+		if standby := c.properties.Standby; standby != nil && standby.TTL.Duration() > 0 {
+			c.stopAging = make(chan struct{})
+			go c.age(standby.TTL.Duration(), c.stopAging)
+		}
+	}
+	stop := base.StopFunc
+	base.StopFunc = func() error {
+		if c.stopAging != nil {
+			close(c.stopAging)
+			c.stopAging = nil
+		}
+		if c.last != nil {
+			c.last.Stop()
+		}
+		return stop()
+	}
 	base.TerminateFunc = c.terminate
 
 	return c, nil
 }
 
-func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err error) {
+// configure is called with the lock on the collection held
+func (c *collection) configure(spec types.Spec, previous *types.Spec) (sources internal.Sources, err error) {
 
 	prev := spec
 	if previous != nil {
@@ -130,7 +158,7 @@ func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err erro
 
 	err = c.configureAccessor(spec, &properties.InstanceAccess)
 	if err != nil {
-		return err
+		return
 	}
 
 	c.accessor = &properties.InstanceAccess
@@ -139,424 +167,263 @@ func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err erro
 
 	err = c.configureAccessor(spec, &prevProperties.InstanceAccess)
 	if err != nil {
-		return err
+		return
 	}
 	c.last = &prevProperties.InstanceAccess
 
 	log.Debug("Initialize last accessor", "previous", previous, "access", c.last, "V", debugV)
 
-	// build the fsm model
-	var model *Model
-	model, err = BuildModel(properties, options)
-	if err != nil {
-		return
-	}
-
-	c.model = model
 	c.spec = spec
 	c.properties = properties
 	c.options = options
 
 	log.Debug("Starting with state", "properties", c.properties, "V", debugV)
+
+	// Only the current accessor is observed; the last is kept for destroying instances.
+	sources = internal.Sources{"": c.accessor}
 	return
 }
 
-func (c *collection) run(ctx context.Context) {
-
-	// Start the model
-	c.model.Start()
-
-	// channels that aggregate from all the instance accessors
-	type observation struct {
-		instances []instance.Description
-	}
-
-	ctx, cancel := context.WithCancel(context.Background())
-	c.cancel = cancel
-
-	// Start all the instance accessors and wire up the observations.
-
-	lostInstances := make(chan *observation, c.options.ChannelBufferSize)  // ch to aggregate all lost observations
-	foundInstances := make(chan *observation, c.options.ChannelBufferSize) // ch to aggregate all found observations
-
-	log.Debug("Set up events from instance accessor", "V", debugV)
-	go func(accessor *internal.InstanceAccess) {
-		for {
-			select {
-			case list, ok := <-accessor.Observations():
-				if !ok {
-					log.Debug("found observations done", "V", debugV2)
-					return
-				}
-				if len(list) > 0 {
-					foundInstances <- &observation{instances: list}
-					log.Debug("accessor found instances", "count", len(list), "V", debugV2)
-				}
-			case list, ok := <-accessor.Lost():
-				if !ok {
-					log.Debug("lost events done", "V", debugV2)
-					return
-				}
-				if len(list) > 0 {
-					lostInstances <- &observation{instances: list}
-					log.Debug("accessor lost instances", "count", len(list), "V", debugV2)
-				}
-			}
-		}
-	}(c.accessor)
+// desired returns the keys of the instances for the size of the collection
+func (c *collection) desired() []string {
+	count := c.properties.Count
+	if standby := c.properties.Standby; standby != nil {
+		count = standby.Min
+	}
+	c.size, c.next = count, count
 
-	c.accessor.Start()
-	log.Debug("accessor started")
+	keys := []string{}
+	for i := 0; i < count; i++ {
+		keys = append(keys, c.key(i))
+	}
+	return keys
+}
 
-	go func() {
+// cleanupItem removes the item of a terminated instance from the collection
+func (c *collection) cleanupItem(item *internal.Item) {
+	c.Collection.Delete(item.Key)
+}
+
+func (c *collection) readyItem(item *internal.Item) {
+	item.Data[readySinceKey] = time.Now()
+
+	c.EventCh() <- event.Event{
+		Topic:   c.Topic(TopicReady),
+		Type:    event.Type("Ready"),
+		ID:      c.EventID(item.Key),
+		Message: "resource ready",
+	}.Init()
+}
+
+func (c *collection) pendingItem(item *internal.Item) {
+	msg := fmt.Sprintf("%v : resource blocked waiting on dependencies", item.State)
+	c.EventCh() <- event.Event{
+		Topic:   c.Topic(TopicPending),
+		Type:    event.Type("Pending"),
+		ID:      c.EventID(item.Key),
+		Message: msg,
+	}.Init()
+}
+
+func (c *collection) destroyItem(item *internal.Item) {
+
+	// We throttle provisioning based on the
+	// parallelism parameter and the number of inflight
+	// resources
+	inTerminating := c.Collection.GetCountByState(terminating)
+
+	if c.properties.Parallelism < inTerminating {
+		// we need to send this back to the requested state
+		item.State.Signal(throttle)
+		return
+	}
+
+	// while frozen, the item is throttled until unfrozen
+	if err := freeze.Check(); err != nil {
+		log.Debug("Not destroying", "item", item.Key, "err", err)
+		item.State.Signal(throttle)
+		return
+	}
+
+	accessor := c.accessor
+	log.Info("Destroy", "fsm", item.State.ID(), "item", item, "accessor", accessor)
+
+	if accessor == nil {
+		accessor = c.last
+	}
+
+	if accessor == nil {
+		log.Error("cannot find accessor for seq", "seq", item.Key)
+		return
+	}
+
+	d := item.Data["instance"]
+	if d == nil {
+		log.Error("cannot find instance", "item", item.Key)
+		return
+	}
 
-	loop:
-		for {
-
-			select {
-
-			case f, ok := <-c.model.Cleanup():
-				if !ok {
-					return
-				}
-				item := c.Collection.GetByFSM(f)
-				if item != nil {
-					c.Collection.Delete(item.Key)
-				}
-			case f, ok := <-c.model.Ready():
-				if !ok {
-					return
-				}
-				item := c.Collection.GetByFSM(f)
-				if item != nil {
-					c.EventCh() <- event.Event{
-						Topic:   c.Topic(TopicReady),
-						Type:    event.Type("Ready"),
-						ID:      c.EventID(item.Key),
-						Message: "resource ready",
-					}.Init()
-				}
-
-			case f, ok := <-c.model.Pending():
-				if !ok {
-					return
-				}
-				item := c.Collection.GetByFSM(f)
-				if item != nil {
-
-					msg := fmt.Sprintf("%v : resource blocked waiting on dependencies", item.State)
-					c.EventCh() <- event.Event{
-						Topic:   c.Topic(TopicPending),
-						Type:    event.Type("Pending"),
-						ID:      c.EventID(item.Key),
-						Message: msg,
-					}.Init()
-				}
-
-			case f, ok := <-c.model.Destroy():
-				if !ok {
-					return
-				}
-
-				item := c.Collection.GetByFSM(f)
-				if item != nil {
-
-					// We throttle provisioning based on the
-					// parallelism parameter and the number of inflight
-					// resources
-					inTerminating := c.Collection.GetCountByState(terminating)
-
-					if c.properties.Parallelism < inTerminating {
-						// we need to send this back to the requested state
-						item.State.Signal(throttle)
-						continue
-					}
-
-					accessor := c.accessor
-					log.Info("Destroy", "fsm", f.ID(), "item", item, "accessor", accessor)
-
-					if accessor == nil {
-						accessor = c.last
-					}
-
-					if accessor == nil {
-						log.Error("cannot find accessor for seq", "seq", item.Key)
-						continue loop
-					}
-
-					d := item.Data["instance"]
-					if d == nil {
-						log.Error("cannot find instance", "item", item.Key)
-						continue loop
-					}
-
-					if dd, is := d.(instance.Description); is {
-
-						// terminate asynchronously
-						timer := time.NewTimer(c.options.DestroyDeadline.Duration())
-						done := make(chan struct{})
-
-						go func() {
-							defer func() {
-								e := recover()
-								if e != nil {
-									log.Error("Recovered from error while terminating", "err", e,
-										"accessor", accessor,
-										"instanceID", dd.ID, "item", item)
-								}
-
-								close(done)
-							}()
-
-							log.Info("Destroy", "instanceID", dd.ID, "key", item.Key)
-							err := accessor.Destroy(dd.ID, instance.Termination)
-							log.Debug("destroy", "instanceID", dd.ID, "key", item.Key, "err", err)
-
-							if err != nil {
-
-								log.Error("Cannot destroy", "err", err)
-								item.State.Signal(terminateError)
-								item.Error(err)
-
-								c.EventCh() <- event.Event{
-									Topic:   c.Topic(TopicDestroyErr),
-									Type:    event.Type("DestroyErr"),
-									ID:      c.EventID(item.Key),
-									Message: "destroying resource error",
-								}.Init().WithError(err)
-
-							} else {
-
-								c.EventCh() <- event.Event{
-									Topic:   c.Topic(TopicDestroy),
-									Type:    event.Type("Destroy"),
-									ID:      c.EventID(item.Key),
-									Message: "destroying resource",
-								}.Init()
-
-							}
-						}()
-
-						// Wait for the destroy to complete or when deadline is exceeded.
-						select {
-						case <-timer.C:
-						case <-done:
-						}
-						timer.Stop()
-					}
-				}
-
-			case f, ok := <-c.model.Provision():
-				if !ok {
-					return
-				}
-
-				item := c.Collection.GetByFSM(f)
-				if item != nil {
-
-					// We throttle provisioning based on the
-					// parallelism parameter and the number of inflight
-					// resources
-					inProvisioning := c.Collection.GetCountByState(provisioning)
-
-					if c.properties.Parallelism < inProvisioning {
-						// we need to send this back to the requested state
-						item.State.Signal(throttle)
-						continue
-					}
-
-					accessor := c.accessor
-					accessorSpec := accessor.Spec
-					accessorSpec.Properties = types.AnyBytes(accessor.Spec.Properties.Bytes())
-
-					spec, err := c.buildSpec(item, accessorSpec)
-					if err != nil {
-
-						log.Error("Error building spec",
-							"fsm", f.ID(), "item", item,
-							"accessor", accessor, "spec", spec,
-							"err", err)
-
-						item.State.Signal(dependencyMissing)
-						continue
-					}
-
-					// provision asynchronously
-					timer := time.NewTimer(c.options.ProvisionDeadline.Duration())
-					done := make(chan struct{})
-
-					go func() {
-						defer func() {
-							e := recover()
-							if e != nil {
-								log.Error("Recovered from error while provisioning", "err", e,
-									"accessor", accessor,
-									"spec", spec, "item", item)
-							}
-
-							close(done)
-						}()
-						instanceID, err := accessor.Provision(spec)
-						if err != nil {
-
-							log.Error("Cannot provision", "err", err)
-							item.State.Signal(provisionError)
-							item.Error(err)
-
-							c.EventCh() <- event.Event{
-								Topic:   c.Topic(TopicProvisionErr),
-								Type:    event.Type("ProvisionErr"),
-								ID:      c.EventID(item.Key),
-								Message: "error when provision",
-							}.Init().WithError(err)
-
-						} else {
-
-							id := ""
-							if instanceID != nil {
-								id = string(*instanceID)
-							}
-
-							log.Info("Provisioned", "id", id, "spec", spec)
-
-							/// don't do anything. next sample will make sure it moves to ready
-
-							c.EventCh() <- event.Event{
-								Topic:   c.Topic(TopicProvision),
-								Type:    event.Type("Provision"),
-								ID:      c.EventID(item.Key),
-								Message: "provisioning resource",
-							}.Init().WithDataMust(spec)
-						}
-					}()
-
-					// Wait for the provision to complete or when deadline is exceeded.
-					select {
-					case <-timer.C:
-					case <-done:
-					}
-					timer.Stop()
-
-				}
-
-			case lost, ok := <-lostInstances:
-				if !ok {
-					log.Info("Lost aggregator done")
-					return
-				}
-
-				// Update the view in the metadata plugin
-				c.MetadataGone(c.accessor.KeyOf, lost.instances)
-
-				for _, n := range lost.instances {
-					k, err := c.accessor.KeyOf(n)
-					if err != nil {
-						log.Error("error getting key", "err", err, "instance", n)
-						break
-					}
-
-					if item := c.Collection.Get(k); item != nil {
-						item.State.Signal(resourceLost)
-						log.Warn("lost", "instance", n, "key", k)
-					}
-					delete(c.resources, k)
-				}
-
-			case found, ok := <-foundInstances:
-				if !ok {
-					log.Info("Found aggregator done")
-					return
-				}
-
-				// Update the view in the metadata plugin
-				export := []instance.Description{}
-
-				for _, n := range found.instances {
-					k, err := c.accessor.KeyOf(n)
-					if err != nil {
-						log.Error("error getting key", "err", err, "instance", n)
-						break
-					}
-					item := c.Collection.Get(k)
-					if item == nil {
-
-						// In this case, the fsm isn't requested.. it's something we get out of band
-						// that somehow shows up (or from previous runs but now the user has
-						// removed it from the spec and performed a commit.
-						f := c.model.Unmatched()
-						item = c.Put(k, f, c.model.Spec(), map[string]interface{}{
-							"instance": n,
-						})
-
-						export = append(export, n) // export to metadata
-
-					} else {
-
-						// if we already have entries stored, then see if the data changed
-						prev := item.Data["instance"]
-						if prev == nil {
-							export = append(export, n)
-						} else if dd, is := prev.(instance.Description); is {
-							if dd.Fingerprint() != n.Fingerprint() {
-								export = append(export, n)
-							}
-						}
-					}
-
-					c.resources[k] = n
-
-					log.Debug("found", "instance", n, "key", k, "V", debugV2)
-					item.State.Signal(resourceFound)
-					item.Data["instance"] = n
-					item.Error(nil) // clear any previous error if this is from a retry
-				}
-
-				c.MetadataExport(c.accessor.KeyOf, export)
+	dd, is := d.(instance.Description)
+	if !is {
+		return
+	}
+
+	// terminate asynchronously
+	timer := time.NewTimer(c.options.DestroyDeadline.Duration())
+	done := make(chan struct{})
+
+	go func() {
+		defer func() {
+			e := recover()
+			if e != nil {
+				log.Error("Recovered from error while terminating", "err", e,
+					"accessor", accessor,
+					"instanceID", dd.ID, "item", item)
 			}
+
+			close(done)
+		}()
+
+		log.Info("Destroy", "instanceID", dd.ID, "key", item.Key)
+		err := accessor.Destroy(dd.ID, instance.Termination)
+		log.Debug("destroy", "instanceID", dd.ID, "key", item.Key, "err", err)
+
+		if err != nil {
+
+			log.Error("Cannot destroy", "err", err)
+			item.State.Signal(terminateError)
+			item.Error(err)
+
+			c.EventCh() <- event.Event{
+				Topic:   c.Topic(TopicDestroyErr),
+				Type:    event.Type("DestroyErr"),
+				ID:      c.EventID(item.Key),
+				Message: "destroying resource error",
+			}.Init().WithError(err)
+
+		} else {
+
+			c.EventCh() <- event.Event{
+				Topic:   c.Topic(TopicDestroy),
+				Type:    event.Type("Destroy"),
+				ID:      c.EventID(item.Key),
+				Message: "destroying resource",
+			}.Init()
+
 		}
 	}()
 
-	log.Debug("Seeding instances", "count", c.properties.Count, "V", debugV)
+	// Wait for the destroy to complete or when deadline is exceeded.
+	select {
+	case <-timer.C:
+	case <-done:
+	}
+	timer.Stop()
+}
 
-	// Seed the initial fsm instances for the size of the collection
-	for i := 0; i < c.properties.Count; i++ {
+func (c *collection) provisionItem(item *internal.Item) {
 
-		f := c.model.Requested()
-		k := fmt.Sprintf("%s_%04d", c.spec.Metadata.Name, i)
+	// a claimed or aged out instance is replaced
+	delete(item.Data, claimedKey)
+	delete(item.Data, readySinceKey)
 
-		item := c.Put(k, f, c.model.Spec(), nil)
-		item.Ordinal = i
+	// We throttle provisioning based on the
+	// parallelism parameter and the number of inflight
+	// resources
+	inProvisioning := c.Collection.GetCountByState(provisioning)
 
-		log.Debug("requested", "id", f.ID(), "key", item.Key, "ordinal", item.Ordinal)
+	if c.properties.Parallelism < inProvisioning {
+		// we need to send this back to the requested state
+		item.State.Signal(throttle)
+		return
 	}
 
-	log.Debug("Seeded instances. Running.")
-}
+	// while frozen, the item is throttled until unfrozen
+	if err := freeze.Check(); err != nil {
+		log.Debug("Not provisioning", "item", item.Key, "err", err)
+		item.State.Signal(throttle)
+		return
+	}
 
-func (c *collection) terminate() error {
-	c.Visit(func(item internal.Item) bool {
-		item.State.Signal(terminate)
-		return true
-	})
+	accessor := c.accessor
+	accessorSpec := accessor.Spec
+	accessorSpec.Properties = types.AnyBytes(accessor.Spec.Properties.Bytes())
 
-	return nil
-}
+	spec, err := c.buildSpec(item, accessorSpec)
+	if err != nil {
 
-func (c *collection) stop() error {
-	log.Info("stop")
+		log.Error("Error building spec",
+			"fsm", item.State.ID(), "item", item,
+			"accessor", accessor, "spec", spec,
+			"err", err)
 
-	if c.model != nil {
+		item.State.Signal(dependencyMissing)
+		return
+	}
 
-		c.cancel()
+	// provision asynchronously
+	timer := time.NewTimer(c.options.ProvisionDeadline.Duration())
+	done := make(chan struct{})
 
-		log.Debug("Stopping", "V", debugV)
-		c.accessor.Stop()
-		if c.last != nil {
-			c.last.Stop()
+	go func() {
+		defer func() {
+			e := recover()
+			if e != nil {
+				log.Error("Recovered from error while provisioning", "err", e,
+					"accessor", accessor,
+					"spec", spec, "item", item)
+			}
+
+			close(done)
+		}()
+		instanceID, err := accessor.Provision(spec)
+		if err != nil {
+
+			log.Error("Cannot provision", "err", err)
+			item.State.Signal(provisionError)
+			item.Error(err)
+
+			c.EventCh() <- event.Event{
+				Topic:   c.Topic(TopicProvisionErr),
+				Type:    event.Type("ProvisionErr"),
+				ID:      c.EventID(item.Key),
+				Message: "error when provision",
+			}.Init().WithError(err)
+
+		} else {
+
+			id := ""
+			if instanceID != nil {
+				id = string(*instanceID)
+			}
+
+			log.Info("Provisioned", "id", id, "spec", spec)
+
+			/// don't do anything. next sample will make sure it moves to ready
+
+			c.EventCh() <- event.Event{
+				Topic:   c.Topic(TopicProvision),
+				Type:    event.Type("Provision"),
+				ID:      c.EventID(item.Key),
+				Message: "provisioning resource",
+			}.Init().WithDataMust(spec)
 		}
-		c.model.Stop()
-		c.model = nil
+	}()
+
+	// Wait for the provision to complete or when deadline is exceeded.
+	select {
+	case <-timer.C:
+	case <-done:
 	}
+	timer.Stop()
+}
+
+func (c *collection) terminate() error {
+	c.Visit(func(item internal.Item) bool {
+		item.State.Signal(terminate)
+		return true
+	})
+
 	return nil
 }
 
@@ -590,7 +457,7 @@ func (c *collection) buildSpec(item *internal.Item, spec instance.Spec) (instanc
 	// This is a placeholder.
 	evaled := types.EvalDepends(specAny,
 		func(p types.Path) (interface{}, error) {
-			v := types.Get(p, c.resources)
+			v := types.Get(p, c.Observed())
 			return v, nil
 		}) // should have all values populated
 
@@ -9,9 +9,6 @@ import (
 	"github.com/docker/infrakit/pkg/fsm"
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/run/scope"
-	"github.com/docker/infrakit/pkg/spi/controller"
-	"github.com/docker/infrakit/pkg/spi/event"
-	"github.com/docker/infrakit/pkg/spi/metadata"
 	"github.com/docker/infrakit/pkg/template"
 	"github.com/docker/infrakit/pkg/types"
 )
@@ -59,29 +56,14 @@ var (
 )
 
 // Components contains a set of components in this controller.
-type Components struct {
-	Controllers func() (map[string]controller.Controller, error)
-	Metadata    func() (map[string]metadata.Plugin, error)
-	Events      event.Plugin
-}
+type Components = internal.Components
 
 // NewComponents returns a controller implementation
 func NewComponents(scope scope.Scope, options pool.Options) *Components {
-
-	controller := internal.NewController(
+	return internal.NewComponents(
 		// the constructor
 		func(spec types.Spec) (internal.Managed, error) {
 			return newCollection(scope, options)
 		},
-		// the key function
-		func(metadata types.Metadata) string {
-			return metadata.Name
-		},
 	)
-
-	return &Components{
-		Controllers: controller.Controllers,
-		Metadata:    controller.Metadata,
-		Events:      controller,
-	}
 }
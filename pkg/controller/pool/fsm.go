@@ -1,106 +1,12 @@
 package pool // import "github.com/docker/infrakit/pkg/controller/pool"
 
 import (
-	"sync"
 	"time"
 
-	pool "github.com/docker/infrakit/pkg/controller/pool/types"
+	"github.com/docker/infrakit/pkg/controller/internal"
 	"github.com/docker/infrakit/pkg/fsm"
 )
 
-// Model encapsulates the workflow / state machines for provisioning resources
-type Model struct {
-	spec     *fsm.Spec
-	set      *fsm.Set
-	clock    *fsm.Clock
-	tickSize time.Duration
-
-	pool.Properties
-	pool.Options
-
-	instanceDestroyChan   chan fsm.FSM
-	instanceProvisionChan chan fsm.FSM
-	instancePendingChan   chan fsm.FSM
-	instanceReadyChan     chan fsm.FSM
-	cleanupChan           chan fsm.FSM
-
-	lock sync.RWMutex
-}
-
-// Cleanup is the channel to get signals to clean up
-func (m *Model) Cleanup() <-chan fsm.FSM {
-	return m.cleanupChan
-}
-
-// Destroy is the channel to get signals to destroy an instance
-func (m *Model) Destroy() <-chan fsm.FSM {
-	return m.instanceDestroyChan
-}
-
-// Pending is the channel to get signals that instances are in pending state
-func (m *Model) Pending() <-chan fsm.FSM {
-	return m.instancePendingChan
-}
-
-// Ready is the channel to get signals of instances that are ready
-func (m *Model) Ready() <-chan fsm.FSM {
-	return m.instanceReadyChan
-}
-
-// Provision is the channel to get signals to provision new instance
-func (m *Model) Provision() <-chan fsm.FSM {
-	return m.instanceProvisionChan
-}
-
-// Requested adds a new fsm in the requested state
-func (m *Model) Requested() fsm.FSM {
-	return m.set.Add(requested)
-}
-
-// Unmatched adds a new fsm in unmatched state
-func (m *Model) Unmatched() fsm.FSM {
-	return m.set.Add(unmatched)
-}
-
-// Spec returns the model description
-func (m *Model) Spec() *fsm.Spec {
-	m.lock.RLock()
-	defer m.lock.RUnlock()
-
-	return m.spec
-}
-
-// Start starts the model
-func (m *Model) Start() {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-
-	if m.set == nil {
-		m.clock.Start()
-
-		log.Info("model starting", "options", m.Options.Options)
-		m.set = fsm.NewSet(m.spec, m.clock, m.Options.Options)
-	}
-}
-
-// Stop stops the model
-func (m *Model) Stop() {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-
-	if m.set != nil {
-		m.set.Stop()
-		m.clock.Stop()
-
-		close(m.instanceDestroyChan)
-		close(m.instanceProvisionChan)
-		close(m.instancePendingChan)
-		close(m.instanceReadyChan)
-		close(m.cleanupChan)
-		m.set = nil
-	}
-}
-
 const (
 
 	// States
@@ -130,37 +36,36 @@ const (
 	throttle // when it's asked to go back to requested state
 )
 
-// BuildModel constructs a workflow model given the configuration blob provided by user in the Properties
-func BuildModel(properties pool.Properties, options pool.Options) (*Model, error) {
+// model constructs the workflow model given the configuration blob provided by user in the Properties
+func (c *collection) model() (internal.Model, error) {
+
+	properties, options := c.properties, c.options
 
 	log.Info("Build model", "properties", properties, "options", options)
-	model := &Model{
-		Options:               options,
-		Properties:            properties,
-		instanceDestroyChan:   make(chan fsm.FSM, options.ChannelBufferSize),
-		instanceProvisionChan: make(chan fsm.FSM, options.ChannelBufferSize),
-		instancePendingChan:   make(chan fsm.FSM, options.ChannelBufferSize),
-		instanceReadyChan:     make(chan fsm.FSM, options.ChannelBufferSize),
-		cleanupChan:           make(chan fsm.FSM, options.ChannelBufferSize),
-		tickSize:              1 * time.Second,
-	}
+	tickSize := 1 * time.Second
 
 	// find the max observation interval and set the model tick to be that
-	if model.tickSize < properties.ObserveInterval.Duration() {
-		model.tickSize = properties.ObserveInterval.Duration()
+	if tickSize < properties.ObserveInterval.Duration() {
+		tickSize = properties.ObserveInterval.Duration()
 	}
 
 	// We must guarantee that the tick size is at least as large as the global
 	// setting.  This is so that we don't miss samples and instead advances state
 	// too quickly.
-	if options.InstanceObserver.ObserveInterval.Duration() > model.tickSize {
-		model.tickSize = options.InstanceObserver.ObserveInterval.Duration()
+	if options.InstanceObserver.ObserveInterval.Duration() > tickSize {
+		tickSize = options.InstanceObserver.ObserveInterval.Duration()
 	}
 
-	log.Info("model", "tickSize", model.tickSize,
+	log.Info("model", "tickSize", tickSize,
 		"waitBeforeProvision", options.WaitBeforeProvision)
 
-	model.clock = fsm.Wall(time.Tick(model.tickSize))
+	var (
+		provisionItem = c.Async(c.provisionItem)
+		destroyItem   = c.Async(c.destroyItem)
+		pendingItem   = c.Async(c.pendingItem)
+		readyItem     = c.Async(c.readyItem)
+		cleanupItem   = c.Async(c.cleanupItem)
+	)
 
 	spec, err := fsm.Define(
 		fsm.State{
@@ -172,10 +77,7 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 				provision:     provisioning,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				provision: func(n fsm.FSM) error {
-					model.instanceProvisionChan <- n
-					return nil
-				},
+				provision: provisionItem,
 			},
 		},
 		fsm.State{
@@ -187,14 +89,8 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 				provisionError:    cannotProvision,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				dependencyMissing: func(n fsm.FSM) error {
-					model.instancePendingChan <- n
-					return nil
-				},
-				resourceFound: func(n fsm.FSM) error {
-					model.instanceReadyChan <- n
-					return nil
-				},
+				dependencyMissing: pendingItem,
+				resourceFound:     readyItem,
 			},
 		},
 		fsm.State{
@@ -206,10 +102,7 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 				provision:     provisioning,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				provision: func(n fsm.FSM) error {
-					model.instanceProvisionChan <- n
-					return nil
-				},
+				provision: provisionItem,
 			},
 		},
 		fsm.State{
@@ -220,10 +113,7 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 				terminate:    terminating,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				terminate: func(n fsm.FSM) error {
-					model.instanceDestroyChan <- n
-					return nil
-				},
+				terminate: destroyItem,
 			},
 		},
 		fsm.State{
@@ -235,10 +125,7 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 				throttle:          terminateThrottled,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				dependencyMissing: func(n fsm.FSM) error {
-					model.instancePendingChan <- n
-					return nil
-				},
+				dependencyMissing: pendingItem,
 			},
 		},
 		fsm.State{
@@ -248,10 +135,7 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 				dependencyReady:   provisioning,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				dependencyReady: func(n fsm.FSM) error {
-					model.instanceProvisionChan <- n
-					return nil
-				},
+				dependencyReady: provisionItem,
 			},
 		},
 		fsm.State{
@@ -262,10 +146,7 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 				resourceLost:      terminated,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				dependencyReady: func(n fsm.FSM) error {
-					model.instanceDestroyChan <- n
-					return nil
-				},
+				dependencyReady: destroyItem,
 			},
 		},
 		fsm.State{
@@ -276,14 +157,8 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 				terminate:     terminating,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				resourceLost: func(n fsm.FSM) error {
-					model.instanceProvisionChan <- n
-					return nil
-				},
-				terminate: func(n fsm.FSM) error {
-					model.instanceDestroyChan <- n
-					return nil
-				},
+				resourceLost: provisionItem,
+				terminate:    destroyItem,
 			},
 		},
 		fsm.State{
@@ -302,10 +177,7 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 				terminate: terminating,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				terminate: func(n fsm.FSM) error {
-					model.instanceDestroyChan <- n
-					return nil
-				},
+				terminate: destroyItem,
 			},
 		},
 		fsm.State{
@@ -315,21 +187,13 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 				cleanup: terminated, // This is really unnecessary, just here to trigger the cleanup action
 			},
 			Actions: map[fsm.Signal]fsm.Action{
-				cleanup: func(n fsm.FSM) error {
-					defer func() {
-						if err := recover(); err != nil {
-							log.Error("Error cleaning up", "err", err)
-						}
-					}()
-					model.cleanupChan <- n
-					return nil
-				},
+				cleanup: cleanupItem,
 			},
 		},
 	)
 
 	if err != nil {
-		return nil, err
+		return internal.Model{}, err
 	}
 
 	spec.SetStateNames(map[fsm.Index]string{
@@ -357,6 +221,10 @@ func BuildModel(properties pool.Properties, options pool.Options) (*Model, error
 		dependencyMissing: "dependency_missing",
 		dependencyReady:   "dependency_ready",
 	})
-	model.spec = spec
-	return model, nil
+	return internal.Model{
+		Spec:       spec,
+		Clock:      fsm.Wall(time.Tick(tickSize)),
+		Options:    options.Options,
+		BufferSize: options.ChannelBufferSize,
+	}, nil
 }
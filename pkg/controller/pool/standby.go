@@ -0,0 +1,280 @@
+package pool // import "github.com/docker/infrakit/pkg/controller/pool"
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller/internal"
+	pool "github.com/docker/infrakit/pkg/controller/pool/types"
+	"github.com/docker/infrakit/pkg/manager/freeze"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+const (
+	// ClaimedLabel is the label set on claimed instances with the name of the pool they came from
+	ClaimedLabel = "infrakit.pool.claimed"
+
+	// ClaimsKey is the key in the metadata of the pool where the claims are listed
+	ClaimsKey = "claims"
+
+	claimedKey    = "claimed"
+	readySinceKey = "readySince"
+)
+
+var (
+	// TopicClaim is the topic for claims of instances
+	TopicClaim = types.PathFromString("claim")
+
+	// TopicAgedOut is the topic for unclaimed instances aged out
+	TopicAgedOut = types.PathFromString("agedout")
+)
+
+// claimed is the record of an instance claimed from the pool
+type claimed struct {
+	ID       instance.ID
+	Key      string
+	Claimant string
+	Time     time.Time
+}
+
+func (c *collection) key(i int) string {
+	return fmt.Sprintf("%s_%04d", c.spec.Metadata.Name, i)
+}
+
+// unclaimed returns the keys of the ready instances that are not claimed, in order
+func (c *collection) unclaimed() []string {
+	keys := []string{}
+	c.Visit(func(item internal.Item) bool {
+		if item.State.State() == ready && item.Data[claimedKey] == nil && item.Data["instance"] != nil {
+			keys = append(keys, item.Key)
+		}
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Claim implements internal.Claimer.  Instances in the pool are relabeled with the claimant as
+// their collection and removed from the pool, which then refills in the background.
+func (c *collection) Claim(spec types.Spec) (*types.Object, error) {
+	if c.properties.Standby == nil {
+		return nil, fmt.Errorf("not a warm pool: %v", c.spec.Metadata.Name)
+	}
+	if spec.Properties == nil {
+		return nil, fmt.Errorf("missing properties")
+	}
+	claim := pool.Claim{}
+	if err := spec.Properties.Decode(&claim); err != nil {
+		return nil, err
+	}
+	if err := claim.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The instances are reserved in the reconcile loop and labeled outside of it, so that a slow
+	// plugin doesn't hold up the loop.
+	reserved := []reservation{}
+	var accessor *internal.InstanceAccess
+	if err := c.Do(func() { reserved, accessor = c.reserve(claim), c.accessor }); err != nil {
+		return nil, err
+	}
+
+	deadline := c.options.ProvisionDeadline.Duration()
+	for i, r := range reserved {
+		reserved[i].err = internal.CallWithDeadline(deadline, func() error {
+			return accessor.Label(r.instance.ID, r.labels)
+		})
+	}
+
+	result := []instance.Description{}
+	if err := c.Do(func() { result = c.claim(claim, reserved) }); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no instances available in %v", c.spec.Metadata.Name)
+	}
+	return &types.Object{
+		Spec:  spec,
+		State: types.AnyValueMust(result),
+	}, nil
+}
+
+// reservation is an instance reserved for a claim
+type reservation struct {
+	key      string
+	instance instance.Description
+	labels   map[string]string
+	err      error
+}
+
+// reserve is called in the reconcile loop.  It marks the unclaimed instances for the claim.
+func (c *collection) reserve(claim pool.Claim) []reservation {
+
+	reserved := []reservation{}
+	for _, k := range c.unclaimed() {
+		if len(reserved) == claim.Count {
+			break
+		}
+		item := c.Get(k)
+		if item == nil {
+			continue
+		}
+		d, is := item.Data["instance"].(instance.Description)
+		if !is {
+			continue
+		}
+
+		labels := map[string]string{}
+		for k, v := range claim.Labels {
+			labels[k] = v
+		}
+		labels[internal.CollectionLabel] = claim.Claimant
+		labels[ClaimedLabel] = c.spec.Metadata.Name
+
+		item.Data[claimedKey] = claim.Claimant
+		reserved = append(reserved, reservation{key: k, instance: d, labels: labels})
+	}
+	return reserved
+}
+
+// claim is called in the reconcile loop with the reserved instances, after they are labeled
+func (c *collection) claim(claim pool.Claim, reserved []reservation) []instance.Description {
+
+	result := []instance.Description{}
+	for _, r := range reserved {
+		k, d := r.key, r.instance
+
+		if r.err != nil {
+			// An instance labeled past the deadline leaves the pool when it's observed with the claimant.
+			log.Error("Cannot label claimed instance", "id", d.ID, "key", k, "err", r.err)
+			if item := c.Get(k); item != nil && r.err != internal.ErrDeadlineExceeded {
+				delete(item.Data, claimedKey)
+			}
+			continue
+		}
+
+		tags := map[string]string{}
+		for k, v := range d.Tags {
+			tags[k] = v
+		}
+		for k, v := range r.labels {
+			tags[k] = v
+		}
+		d.Tags = tags
+		result = append(result, d)
+
+		c.claims = append(c.claims, claimed{ID: d.ID, Key: k, Claimant: claim.Claimant, Time: time.Now()})
+
+		log.Info("Claimed", "id", d.ID, "key", k, "claimant", claim.Claimant)
+		c.EventCh() <- event.Event{
+			Topic:   c.Topic(TopicClaim),
+			Type:    event.Type("Claim"),
+			ID:      c.EventID(k),
+			Message: "instance claimed by " + claim.Claimant,
+		}.Init().WithDataMust(d)
+	}
+
+	// Grow the pool, up to the max, to anticipate more claims
+	standby := c.properties.Standby
+	for i := 0; i < len(result) && c.size < standby.Max; i++ {
+		k := c.key(c.next)
+		if c.Add(k, requested, nil) == nil {
+			break
+		}
+		c.next++
+		c.size++
+		log.Info("Grow pool", "key", k, "size", c.size)
+	}
+
+	claims := make([]claimed, len(c.claims))
+	copy(claims, c.claims)
+	c.MetadataPut(types.PathFromString(ClaimsKey), claims)
+
+	return result
+}
+
+// age ages out unclaimed instances at intervals until stop is closed
+func (c *collection) age(ttl time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(ttl / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			aged := map[string]instance.Description{}
+			var accessor *internal.InstanceAccess
+			if err := c.Do(func() { aged, accessor = c.ageOut(ttl), c.accessor }); err != nil {
+				return
+			}
+			c.replace(accessor, aged)
+		}
+	}
+}
+
+// ageOut is called in the reconcile loop.  Instances unclaimed past the ttl are terminated if the
+// pool is over its min size.  Otherwise they are returned by key, to be destroyed outside of the
+// loop so that they are replaced with fresh ones.
+func (c *collection) ageOut(ttl time.Duration) map[string]instance.Description {
+	aged := map[string]instance.Description{}
+	if err := freeze.Check(); err != nil {
+		log.Debug("Not aging out", "err", err)
+		return aged
+	}
+	now := time.Now()
+	for _, k := range c.unclaimed() {
+		item := c.Get(k)
+		if item == nil {
+			continue
+		}
+		since, has := item.Data[readySinceKey].(time.Time)
+		if !has {
+			item.Data[readySinceKey] = now
+			continue
+		}
+		if now.Sub(since) < ttl {
+			continue
+		}
+		delete(item.Data, readySinceKey)
+
+		if c.size > c.properties.Standby.Min {
+			c.size--
+			log.Info("Shrink pool", "key", k, "size", c.size)
+			item.State.Signal(terminate)
+			c.agedOut(k)
+			continue
+		}
+		if d, is := item.Data["instance"].(instance.Description); is {
+			aged[k] = d
+		}
+	}
+	return aged
+}
+
+// replace destroys the aged instances, each up to the destroy deadline.  The pool provisions
+// fresh ones when the instances are no longer observed.
+func (c *collection) replace(accessor *internal.InstanceAccess, aged map[string]instance.Description) {
+	for k, d := range aged {
+		log.Info("Replace aged instance", "key", k, "id", d.ID)
+		err := internal.CallWithDeadline(c.options.DestroyDeadline.Duration(), func() error {
+			return accessor.Destroy(d.ID, instance.Termination)
+		})
+		if err != nil {
+			log.Error("Cannot destroy aged instance", "key", k, "id", d.ID, "err", err)
+			continue
+		}
+		c.agedOut(k)
+	}
+}
+
+func (c *collection) agedOut(k string) {
+	c.EventCh() <- event.Event{
+		Topic:   c.Topic(TopicAgedOut),
+		Type:    event.Type("AgedOut"),
+		ID:      c.EventID(k),
+		Message: "unclaimed instance aged out",
+	}.Init()
+}
@@ -0,0 +1,168 @@
+package pool // import "github.com/docker/infrakit/pkg/controller/pool"
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller/internal"
+	pool "github.com/docker/infrakit/pkg/controller/pool/types"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/template"
+	testutil_instance "github.com/docker/infrakit/pkg/testing/instance"
+	testutil_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmPoolClaim(t *testing.T) {
+
+	var lock sync.Mutex
+	instances := map[instance.ID]instance.Description{}
+	count := 0
+
+	testScope := testutil_scope.DefaultScope()
+	testScope.ResolveInstance = func(n string) (instance.Plugin, error) {
+		return &testutil_instance.Plugin{
+			DoProvision: func(spec instance.Spec) (*instance.ID, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				count++
+				id := instance.ID(fmt.Sprintf("i%d", count))
+				tags := map[string]string{}
+				for k, v := range spec.Tags {
+					tags[k] = v
+				}
+				instances[id] = instance.Description{ID: id, Tags: tags}
+				return &id, nil
+			},
+			DoLabel: func(id instance.ID, labels map[string]string) error {
+				lock.Lock()
+				defer lock.Unlock()
+				for k, v := range labels {
+					instances[id].Tags[k] = v
+				}
+				return nil
+			},
+			DoDestroy: func(id instance.ID, ctx instance.Context) error {
+				lock.Lock()
+				defer lock.Unlock()
+				delete(instances, id)
+				return nil
+			},
+			DoDescribeInstances: func(tags map[string]string, details bool) ([]instance.Description, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				result := []instance.Description{}
+			scan:
+				for _, d := range instances {
+					for k, v := range tags {
+						if d.Tags[k] != v {
+							continue scan
+						}
+					}
+					copy := d
+					copy.Tags = map[string]string{}
+					for k, v := range d.Tags {
+						copy.Tags[k] = v
+					}
+					result = append(result, copy)
+				}
+				return result, nil
+			},
+		}, nil
+	}
+
+	options := DefaultOptions
+	options.InstanceObserver = &internal.InstanceObserver{
+		ObserveInterval: types.FromDuration(100 * time.Millisecond),
+		KeySelector:     template.EscapeString(fmt.Sprintf(`{{.Tags.%s}}`, internal.InstanceLabel)),
+	}
+
+	managed, err := newCollection(testScope, options)
+	require.NoError(t, err)
+	c := managed.(*collection)
+
+	events := make(chan *event.Event)
+	c.PublishOn(events)
+	go func() {
+		for range events {
+		}
+	}()
+
+	spec := types.Spec{}
+	require.NoError(t, types.AnyYAMLMust([]byte(`
+kind: pool
+metadata:
+  name: workers
+properties:
+  plugin: simulator/compute
+  Properties:
+    size: small
+  Parallelism: 3
+  Standby:
+    Min: 2
+    Max: 3
+`)).Decode(&spec))
+
+	_, err = c.Enforce(spec)
+	require.NoError(t, err)
+
+	unclaimed := func(n int) {
+		var keys []string
+		for i := 0; i < 100; i++ {
+			require.NoError(t, c.Do(func() { keys = c.unclaimed() }))
+			if len(keys) == n {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		require.Equal(t, n, len(keys))
+	}
+
+	unclaimed(2)
+
+	claim := types.Spec{
+		Metadata: types.Metadata{Name: "workers"},
+		Properties: types.AnyValueMust(pool.Claim{
+			Count:    1,
+			Claimant: "web",
+			Labels:   map[string]string{internal.InstanceLabel: "web-0"},
+		}),
+	}
+	object, err := c.Claim(claim)
+	require.NoError(t, err)
+
+	result := []instance.Description{}
+	require.NoError(t, object.State.Decode(&result))
+	require.Equal(t, 1, len(result))
+	require.Equal(t, "web", result[0].Tags[internal.CollectionLabel])
+	require.Equal(t, "web-0", result[0].Tags[internal.InstanceLabel])
+	require.Equal(t, "workers", result[0].Tags[ClaimedLabel])
+
+	// the pool grows by the claim and refills the claimed instance
+	require.NotNil(t, c.Get("workers_0002"))
+	unclaimed(3)
+
+	var claims *types.Any
+	for i := 0; i < 10 && claims == nil; i++ {
+		claims, err = c.Metadata().Get(types.PathFromString(ClaimsKey))
+		require.NoError(t, err)
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NotNil(t, claims)
+
+	// can't claim more than the pool has
+	claim.Properties = types.AnyValueMust(pool.Claim{Count: 5, Claimant: "web"})
+	object, err = c.Claim(claim)
+	require.NoError(t, err)
+	require.NoError(t, object.State.Decode(&result))
+	require.Equal(t, 3, len(result))
+
+	require.NoError(t, c.Stop())
+
+	_, err = c.Claim(claim)
+	require.Error(t, err)
+}
@@ -56,6 +56,51 @@ type Properties struct {
 
 	// Count is how many instances of the resource to provision
 	Count int
+
+	// Standby, if set, makes the pool a warm pool of instances that are claimed on demand.
+	// Count is then ignored.
+	Standby *Standby `json:",omitempty" yaml:",omitempty"`
+}
+
+// Standby configures a warm pool of instances that are provisioned ahead of being claimed.
+// Claimed instances are relabeled so they leave the pool, and the pool refills in the background.
+type Standby struct {
+
+	// Min is the number of unclaimed instances the pool keeps
+	Min int
+
+	// Max is the max number of unclaimed instances.  The pool grows by the number of instances
+	// claimed, up to Max, to anticipate demand.  0 means the pool doesn't grow beyond Min.
+	Max int
+
+	// TTL is how long an instance can stay unclaimed before it's aged out.  Aged out instances are
+	// destroyed, and replaced if the pool is not over Min.  0 means instances don't age out.
+	TTL types.Duration
+}
+
+// Claim is the schema of the Properties of a spec committed with the Claim operation
+type Claim struct {
+
+	// Count is the number of instances to claim
+	Count int
+
+	// Claimant is the name of the collection, e.g. a group or resource collection, claiming
+	// the instances.  Claimed instances are labeled with it as their collection.
+	Claimant string
+
+	// Labels are additional labels for the claimed instances
+	Labels map[string]string
+}
+
+// Validate validates the claim
+func (c Claim) Validate() error {
+	if c.Count <= 0 {
+		return fmt.Errorf("bad count: %v", c.Count)
+	}
+	if c.Claimant == "" {
+		return fmt.Errorf("missing claimant")
+	}
+	return nil
 }
 
 // ModelProperties contain fsm tuning parameters
@@ -71,6 +116,14 @@ type ModelProperties struct {
 
 // Validate validates the input properties
 func (p Properties) Validate(ctx context.Context) error {
+	if s := p.Standby; s != nil {
+		if s.Min < 0 {
+			return fmt.Errorf("bad standby min: %v", s.Min)
+		}
+		if s.Max != 0 && s.Max < s.Min {
+			return fmt.Errorf("standby max %v less than min %v", s.Max, s.Min)
+		}
+	}
 	return nil
 }
 
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/infrakit/pkg/controller/internal"
 	resource "github.com/docker/infrakit/pkg/controller/resource/types"
+	"github.com/docker/infrakit/pkg/fsm"
 	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/docker/infrakit/pkg/spi/controller"
 	"github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/spi/instance"
 	"github.com/docker/infrakit/pkg/types"
@@ -25,11 +28,21 @@ type collection struct {
 	properties resource.Properties
 	options    resource.Options
 	model      *Model
+	graph      *Graph
 
 	resources resources
 
 	deleted map[string]*internal.InstanceAccess
 
+	// remediated is the instance last destroyed to remediate the drift of each resource, and remediating the
+	// resources with a remediation in progress.  Guarded by remediationLock, since remediation is in the background.
+	remediationLock sync.Mutex
+	remediated      map[string]instance.ID
+	remediating     map[string]bool
+
+	// provisioned is when each resource was last provisioned, for throttling
+	provisioned map[string]time.Time
+
 	provisionWatch    *Watch
 	provisionWatching map[string]Watchers
 	destroyWatch      *Watch
@@ -74,6 +87,7 @@ func newCollection(scope scope.Scope, options resource.Options) (internal.Manage
 		TopicDestroyErr,
 		TopicPending,
 		TopicReady,
+		TopicDrift,
 	)
 	if err != nil {
 		return nil, err
@@ -87,16 +101,30 @@ func newCollection(scope scope.Scope, options resource.Options) (internal.Manage
 		destroyWatching:   map[string]Watchers{},
 		resources:         resources{},
 		deleted:           map[string]*internal.InstanceAccess{},
+		remediated:        map[string]instance.ID{},
+		remediating:       map[string]bool{},
+		provisioned:       map[string]time.Time{},
 	}
 	// set the behaviors
 	base.StartFunc = c.run
 	base.StopFunc = c.stop
 	base.UpdateSpecFunc = c.updateSpec
-	base.TerminateFunc = c.terminate
+	base.TerminateWaitFunc = c.terminate
+	base.PauseFunc = c.pause
+	base.PlanFunc = c.plan
+	base.MetricsFunc = c.metrics
 
 	return c, nil
 }
 
+// metrics is called with the lock on the collection held
+func (c *collection) metrics() *fsm.Metrics {
+	if c.model == nil {
+		return nil
+	}
+	return c.model.Metrics()
+}
+
 func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err error) {
 
 	prev := spec
@@ -136,6 +164,12 @@ func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err erro
 
 	log.Debug("Begin processing", "properties", properties, "previous", prevProperties, "options", options, "V", debugV2)
 
+	graph, err := BuildGraph(properties)
+	if err != nil {
+		return
+	}
+	log.Debug("dependency graph", "levels", graph.Levels(), "V", debugV)
+
 	// NOTE - we are using one client per instance accessor.  This is not the most efficient
 	// if there are resources sharing the same backends.  We assume there are only a small number
 	// of resources in a collection.  For large pools of the same thing, we will implement a dedicated
@@ -145,7 +179,7 @@ func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err erro
 
 	for name, access := range properties {
 
-		copy := access
+		copy := access.InstanceAccess
 
 		// merge defaults
 		mergo.Merge(&copy, internal.InstanceAccess{
@@ -176,7 +210,7 @@ func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err erro
 			// this is no longer in the newer version of the spec, so it's a deletion.
 			// we need to have this still.
 
-			copy := access
+			copy := access.InstanceAccess
 
 			// merge defaults
 			mergo.Merge(&copy, internal.InstanceAccess{
@@ -210,6 +244,7 @@ func (c *collection) updateSpec(spec types.Spec, previous *types.Spec) (err erro
 
 	c.accessors = accessors
 	c.model = model
+	c.graph = graph
 	c.properties = properties
 	c.options = options
 
@@ -326,6 +361,7 @@ func (c *collection) run(ctx context.Context) {
 				item := c.Collection.GetByFSM(f)
 				if item != nil {
 					c.Collection.Delete(item.Key)
+					delete(c.provisioned, item.Key)
 				}
 			case f, ok := <-c.model.Ready():
 				if !ok {
@@ -472,6 +508,14 @@ func (c *collection) run(ctx context.Context) {
 
 				item := c.Collection.GetByFSM(f)
 				if item != nil {
+
+					// We throttle provisioning based on the parallelism parameter and
+					// the number of resources of the same level in flight.
+					if c.options.Parallelism > 0 && c.inProvisioning(item.Key) >= c.options.Parallelism {
+						item.State.Signal(throttle)
+						continue
+					}
+
 					accessor := c.accessors[item.Key]
 
 					spec, err := c.populateDependencies(item, accessor.Spec)
@@ -487,6 +531,7 @@ func (c *collection) run(ctx context.Context) {
 					}
 
 					// provision asynchronously
+					c.provisioned[item.Key] = time.Now()
 					timer := time.NewTimer(c.options.ProvisionDeadline.Duration())
 					done := make(chan struct{})
 					go func() {
@@ -653,6 +698,7 @@ func (c *collection) run(ctx context.Context) {
 					c.provisionWatch.Notify(k)
 
 					log.Debug("found", "instance", n, "name", found.name, "key", k, "V", debugV2)
+					c.checkDrift(item, n, item.State.State() == ready)
 					item.State.Signal(resourceFound)
 					item.Data["instance"] = n
 				}
@@ -665,8 +711,8 @@ func (c *collection) run(ctx context.Context) {
 	log.Debug("Seeding instances")
 
 	// Seed the initial fsm instances for each named resource in the config
-	// For each accessor / resource we create one fsm
-	for k := range c.accessors {
+	// For each accessor / resource we create one fsm, in the order of the dependencies
+	for _, k := range c.graph.Order() {
 		log.Debug("requesting", "key", k)
 		f := c.model.Requested()
 		c.Put(k, f, c.model.Spec(), nil)
@@ -676,14 +722,154 @@ func (c *collection) run(ctx context.Context) {
 	log.Debug("Seeded instances. Running.")
 }
 
-func (c *collection) terminate() error {
+// inProvisioning returns the number of the other resources at the level of the given one that have been
+// provisioned and not yet observed.  A resource that is not observed within the provision deadline and a couple
+// of observations is no longer counted, so that the resources throttled behind it are not held back forever.
+func (c *collection) inProvisioning(key string) (count int) {
+	level := c.graph.Level(key)
+	expiry := time.Now().Add(-c.options.ProvisionDeadline.Duration() - 2*c.model.tickSize)
+	c.Visit(func(item internal.Item) bool {
+		if item.Key != key && item.State.State() == provisioning && c.graph.Level(item.Key) == level {
+			if started, has := c.provisioned[item.Key]; has && started.After(expiry) {
+				count++
+			}
+		}
+		return true
+	})
+	return
+}
+
+// plan computes the dependency graph of the resources in the spec and returns the order in
+// which the resources will be provisioned or destroyed.
+func (c *collection) plan(op controller.Operation, spec types.Spec) (plan controller.Plan, err error) {
+
+	properties := resource.Properties{}
+	if spec.Properties != nil {
+		if err = spec.Properties.Decode(&properties); err != nil {
+			return
+		}
+	}
+
+	graph, err := BuildGraph(properties)
+	if err != nil {
+		return
+	}
+
+	levels := graph.Levels()
+	verb := "provision"
+	if op == controller.Destroy {
+		verb = "destroy"
+		reversed := [][]string{}
+		for i := len(levels) - 1; i >= 0; i-- {
+			reversed = append(reversed, levels[i])
+		}
+		levels = reversed
+	}
+
+	for i, level := range levels {
+		plan.Message = append(plan.Message, fmt.Sprintf("%s %d: %s", verb, i, strings.Join(level, ", ")))
+	}
+	return
+}
+
+// terminate orders the termination of the resources in the reverse order of the dependencies, starting with
+// the resources that are not in the spec.  It's called with the lock on the collection held, and returns the
+// function that terminates each level before the next one is signaled, which is called without the lock.
+func (c *collection) terminate() (func() error, error) {
 
+	levels := [][]string{}
+	orphans := []string{}
 	c.Visit(func(item internal.Item) bool {
-		item.State.Signal(terminate)
+		if c.graph == nil || c.graph.Level(item.Key) < 0 {
+			orphans = append(orphans, item.Key)
+		}
 		return true
 	})
+	if len(orphans) > 0 {
+		levels = append(levels, orphans)
+	}
+	if c.graph != nil {
+		all := c.graph.Levels()
+		for i := len(all) - 1; i >= 0; i-- {
+			levels = append(levels, all[i])
+		}
+	}
 
-	return nil
+	tickSize := 1 * time.Second
+	if c.model != nil {
+		tickSize = c.model.tickSize
+	}
+	timeout := c.options.DestroyDeadline.Duration() + time.Duration(c.options.WaitBeforeDestroy+2)*tickSize
+
+	return func() error {
+		for _, level := range levels {
+			signaled := []string{}
+			for _, k := range level {
+				if item := c.Collection.Get(k); item != nil {
+					// only the ready and unmatched resources have anything to terminate
+					switch item.State.State() {
+					case ready, unmatched:
+						signaled = append(signaled, k)
+					}
+					item.State.Signal(terminate)
+				}
+			}
+			if err := c.awaitTerminated(signaled, tickSize, timeout); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// awaitTerminated waits for the resources to be terminated, up to the timeout.
+func (c *collection) awaitTerminated(keys []string, tickSize, timeout time.Duration) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		pending := []string{}
+		for _, k := range keys {
+			item := c.Collection.Get(k)
+			if item == nil {
+				continue
+			}
+			switch item.State.State() {
+			case terminated:
+			case cannotTerminate:
+				return fmt.Errorf("cannot terminate %v", k)
+			default:
+				pending = append(pending, k)
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout terminating %s", strings.Join(pending, ", "))
+		}
+		time.Sleep(tickSize / 10)
+	}
+}
+
+// pause pauses the observations of the resources in the reverse order of the dependencies, and
+// resumes them in order.
+func (c *collection) pause(paused bool) {
+	if c.graph == nil {
+		return
+	}
+
+	order := c.graph.Order()
+	if paused {
+		order = c.graph.Reverse()
+	}
+	for _, k := range order {
+		if accessor, has := c.accessors[k]; has {
+			accessor.Pause(paused)
+		}
+	}
 }
 
 func (c *collection) stop() error {
@@ -736,6 +922,18 @@ func keyFromPath(path types.Path) (key string, err error) {
 	return
 }
 
+// postProvision is the modifier of a dependency on the data of a resource after it's provisioned
+const postProvision = "post-provision"
+
+// splitModifier splits the key of a depended on resource into the modifier, like post-provision,
+// and the name of the resource.  The modifier is empty if there is none.
+func splitModifier(key string) (modifier, name string) {
+	if i := strings.Index(key, ":"); i > 0 {
+		return key[0:i], key[i+1:]
+	}
+	return "", key
+}
+
 func processProvisionWatches(properties resource.Properties) (watch *Watch, watching map[string]Watchers) {
 	watch = &Watch{}
 	watching = map[string]Watchers{}
@@ -762,7 +960,7 @@ func processProvisionWatches(properties resource.Properties) (watch *Watch, watc
 			// This is so that provisioning of a resource can be gated by the post-provisioning step / data of another.
 			// For all practical purposes, this is like a totally different key the object will watch.  If this is never
 			// fulfilled, the watcher will be blocked forever from being provisioned.
-			if i := strings.Index(dependedOnKey, ":"); i > 0 && dependedOnKey[0:i] != "post-provision" {
+			if modifier, _ := splitModifier(dependedOnKey); modifier != "" && modifier != postProvision {
 				continue
 			}
 
@@ -801,7 +999,7 @@ func processDestroyWatches(properties resource.Properties) (watch *Watch, watchi
 
 			// Disallow any modifiers because we don't care about modifiers like post-provision (which is
 			// not a valid dependency for termination of this resource.
-			if strings.Index(dependedOnKey, ":") > 0 {
+			if modifier, _ := splitModifier(dependedOnKey); modifier != "" {
 				continue
 			}
 
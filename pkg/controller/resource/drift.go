@@ -0,0 +1,187 @@
+package resource // import "github.com/docker/infrakit/pkg/controller/resource"
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller/internal"
+	resource "github.com/docker/infrakit/pkg/controller/resource/types"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+var (
+	// TopicDrift is the topic for resources drifted from their specs
+	TopicDrift = types.PathFromString("drift")
+)
+
+// DriftKey is the key in the data of a resource where the differences from its spec are recorded
+const DriftKey = "drift"
+
+// Difference is a value in the spec of a resource that differs from the observed instance
+type Difference struct {
+	Path     string
+	Desired  interface{}
+	Observed interface{}
+}
+
+// view returns the values of the spec or the instance that can be compared by path
+func view(properties *types.Any, tags map[string]string) (map[string]interface{}, error) {
+	var props interface{}
+	if properties != nil {
+		if err := properties.Decode(&props); err != nil {
+			return nil, err
+		}
+	}
+	t := map[string]interface{}{}
+	for k, v := range tags {
+		t[k] = v
+	}
+	return map[string]interface{}{
+		"Properties": props,
+		"Tags":       t,
+	}, nil
+}
+
+// differences compares the values at the given paths of the desired spec and the observed instance
+func differences(paths []string, desired instance.Spec, observed instance.Description) ([]Difference, error) {
+	want, err := view(desired.Properties, desired.Tags)
+	if err != nil {
+		return nil, err
+	}
+	have, err := view(observed.Properties, observed.Tags)
+	if err != nil {
+		return nil, err
+	}
+	diffs := []Difference{}
+	for _, p := range paths {
+		path := types.PathFromString(p)
+		d, o := types.Get(path, want), types.Get(path, have)
+		if !reflect.DeepEqual(d, o) {
+			diffs = append(diffs, Difference{Path: p, Desired: d, Observed: o})
+		}
+	}
+	return diffs, nil
+}
+
+// checkDrift compares the observed instance of the resource with its spec, records the differences
+// in the data of the item and, if the resource is ready, remediates as configured.
+func (c *collection) checkDrift(item *internal.Item, observed instance.Description, ready bool) {
+	drift := c.properties[item.Key].Drift
+	accessor := c.accessors[item.Key]
+	if drift == nil || accessor == nil {
+		return
+	}
+
+	desired, err := c.populateDependencies(item, accessor.Spec)
+	if err != nil {
+		log.Debug("cannot check drift", "key", item.Key, "err", err, "V", debugV)
+		return
+	}
+
+	diffs, err := differences(drift.Paths, desired, observed)
+	if err != nil {
+		log.Warn("cannot compute drift", "key", item.Key, "err", err)
+		return
+	}
+
+	prev := item.Data[DriftKey]
+	if len(diffs) == 0 {
+		delete(item.Data, DriftKey)
+		c.remediationLock.Lock()
+		delete(c.remediated, item.Key)
+		c.remediationLock.Unlock()
+		return
+	}
+	item.Data[DriftKey] = diffs
+
+	if prev == nil || types.Fingerprint(types.AnyValueMust(prev)) != types.Fingerprint(types.AnyValueMust(diffs)) {
+		c.EventCh() <- event.Event{
+			Topic:   c.Topic(TopicDrift),
+			Type:    event.Type("Drift"),
+			ID:      c.EventID(item.Key),
+			Message: "resource drifted from spec",
+		}.Init().WithDataMust(diffs)
+	}
+
+	if !ready {
+		return
+	}
+	c.remediate(item.Key, accessor, observed, drift.Remediation, diffs)
+}
+
+// remediate remediates the drift of the resource in the background, up to the plugin deadlines, so that a
+// slow plugin doesn't hold up the observations.  A resource has one remediation at a time.
+func (c *collection) remediate(key string, accessor *internal.InstanceAccess, observed instance.Description,
+	remediation resource.Remediation, diffs []Difference) {
+
+	var call func() error
+	var deadline time.Duration
+
+	switch remediation {
+
+	case resource.Reprovision:
+		deadline = c.options.DestroyDeadline.Duration()
+		call = func() error {
+			log.Info("Reprovisioning drifted resource", "key", key, "id", observed.ID, "diffs", diffs)
+			return accessor.Destroy(observed.ID, instance.Termination)
+		}
+
+	case resource.Relabel:
+		labels := map[string]string{}
+		for _, d := range diffs {
+			if !strings.HasPrefix(d.Path, "Tags/") {
+				continue
+			}
+			if v, is := d.Desired.(string); is {
+				labels[strings.TrimPrefix(d.Path, "Tags/")] = v
+			}
+		}
+		if len(labels) == 0 {
+			return
+		}
+		deadline = c.options.ProvisionDeadline.Duration()
+		call = func() error {
+			log.Info("Relabeling drifted resource", "key", key, "id", observed.ID, "labels", labels)
+			return accessor.Label(observed.ID, labels)
+		}
+
+	default:
+		return
+	}
+
+	c.remediationLock.Lock()
+	defer c.remediationLock.Unlock()
+
+	if c.remediating[key] {
+		return
+	}
+	if remediation == resource.Reprovision {
+		// Destroy only once per instance; the loss of the instance will trigger the provisioning.
+		if c.remediated[key] == observed.ID {
+			return
+		}
+		c.remediated[key] = observed.ID
+	}
+	c.remediating[key] = true
+
+	go func() {
+		err := internal.CallWithDeadline(deadline, call)
+
+		c.remediationLock.Lock()
+		defer c.remediationLock.Unlock()
+		delete(c.remediating, key)
+
+		switch err {
+		case nil:
+		case internal.ErrDeadlineExceeded:
+			// The call may still complete, so the instance is not destroyed again.
+			log.Warn("Remediation of drifted resource exceeded deadline", "key", key, "id", observed.ID)
+		default:
+			log.Error("Cannot remediate drifted resource", "key", key, "id", observed.ID, "err", err)
+			delete(c.remediated, key)
+		}
+	}()
+}
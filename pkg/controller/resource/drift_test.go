@@ -0,0 +1,125 @@
+package resource // import "github.com/docker/infrakit/pkg/controller/resource"
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/controller/internal"
+	resource "github.com/docker/infrakit/pkg/controller/resource/types"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	testing_instance "github.com/docker/infrakit/pkg/testing/instance"
+	"github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDifferences(t *testing.T) {
+
+	desired := instance.Spec{
+		Properties: types.AnyValueMust(map[string]interface{}{
+			"size": 10,
+			"fs":   "ext4",
+		}),
+		Tags: map[string]string{
+			"env": "prod",
+		},
+	}
+	observed := instance.Description{
+		ID: instance.ID("disk1"),
+		Properties: types.AnyValueMust(map[string]interface{}{
+			"size": 10,
+			"fs":   "xfs",
+		}),
+		Tags: map[string]string{
+			"env": "dev",
+		},
+	}
+
+	diffs, err := differences([]string{"Properties/size", "Properties/fs", "Tags/env", "Tags/missing"}, desired, observed)
+	require.NoError(t, err)
+	require.Equal(t, []Difference{
+		{Path: "Properties/fs", Desired: "ext4", Observed: "xfs"},
+		{Path: "Tags/env", Desired: "prod", Observed: "dev"},
+	}, diffs)
+
+	observed.Properties = desired.Properties
+	observed.Tags = desired.Tags
+	diffs, err = differences([]string{"Properties/size", "Properties/fs", "Tags/env"}, desired, observed)
+	require.NoError(t, err)
+	require.Equal(t, []Difference{}, diffs)
+}
+
+func TestDriftProperties(t *testing.T) {
+
+	properties := resource.Properties{}
+	require.NoError(t, types.AnyYAMLMust([]byte(`
+disk:
+  plugin: az1/disk
+  Properties:
+    size: 1TB
+  Drift:
+    Paths:
+      - Tags/env
+    Remediation: relabel
+net:
+  plugin: az1/net
+`)).Decode(&properties))
+	require.NoError(t, properties.Validate(context.Background()))
+
+	require.Equal(t, "az1/disk", properties["disk"].Name.String())
+	require.Equal(t, &resource.Drift{Paths: []string{"Tags/env"}, Remediation: resource.Relabel}, properties["disk"].Drift)
+	require.Nil(t, properties["net"].Drift)
+
+	properties["disk"] = resource.Resource{Drift: &resource.Drift{Remediation: "fix"}}
+	require.Error(t, properties.Validate(context.Background()))
+}
+
+func TestRemediate(t *testing.T) {
+
+	c, err := newCollection(scope.DefaultScope(), DefaultOptions)
+	require.NoError(t, err)
+	collection := c.(*collection)
+
+	labeled := make(chan map[string]string)
+	release := make(chan error)
+	destroyed := make(chan instance.ID, 2)
+	accessor := &internal.InstanceAccess{InstanceObserver: &internal.InstanceObserver{}}
+	accessor.Plugin = &testing_instance.Plugin{
+		DoLabel: func(id instance.ID, labels map[string]string) error {
+			labeled <- labels
+			return <-release
+		},
+		DoDestroy: func(id instance.ID, context instance.Context) error {
+			destroyed <- id
+			return nil
+		},
+	}
+
+	observed := instance.Description{ID: instance.ID("disk1")}
+	diffs := []Difference{{Path: "Tags/env", Desired: "prod", Observed: "dev"}}
+
+	// the relabeling is in the background, and not repeated while in progress
+	collection.remediate("disk", accessor, observed, resource.Relabel, diffs)
+	collection.remediate("disk", accessor, observed, resource.Relabel, diffs)
+	require.Equal(t, map[string]string{"env": "prod"}, <-labeled)
+	release <- nil
+
+	remediating := func() bool {
+		collection.remediationLock.Lock()
+		defer collection.remediationLock.Unlock()
+		return collection.remediating["disk"]
+	}
+	for remediating() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// an instance is destroyed once to reprovision it
+	collection.remediate("disk", accessor, observed, resource.Reprovision, diffs)
+	require.Equal(t, observed.ID, <-destroyed)
+	for remediating() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	collection.remediate("disk", accessor, observed, resource.Reprovision, diffs)
+	require.Equal(t, 0, len(destroyed))
+}
@@ -11,7 +11,7 @@ import (
 // Model encapsulates the workflow / state machines for provisioning resources
 type Model struct {
 	spec     *fsm.Spec
-	set      *fsm.Set
+	set      fsm.Instances
 	clock    *fsm.Clock
 	tickSize time.Duration
 
@@ -62,6 +62,18 @@ func (m *Model) Unmatched() fsm.FSM {
 	return m.set.Add(unmatched)
 }
 
+// Metrics returns the metrics of the state machines, or nil if the model is not running
+func (m *Model) Metrics() *fsm.Metrics {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.set == nil {
+		return nil
+	}
+	metrics := m.set.Metrics()
+	return &metrics
+}
+
 // Spec returns the model description
 func (m *Model) Spec() *fsm.Spec {
 	m.lock.RLock()
@@ -79,7 +91,7 @@ func (m *Model) Start() {
 		m.clock.Start()
 
 		log.Info("model starting", "options", m.Options.Options)
-		m.set = fsm.NewSet(m.spec, m.clock, m.Options.Options)
+		m.set = fsm.NewInstances(m.spec, m.clock, m.Options.Options)
 	}
 }
 
@@ -114,6 +126,7 @@ const (
 	unmatched
 	terminating
 	terminated
+	throttled
 
 	// Signals
 	resourceFound fsm.Signal = iota
@@ -125,6 +138,7 @@ const (
 	terminate
 	terminateError
 	cleanup
+	throttle // when there are too many resources of the same level being provisioned
 )
 
 // BuildModel constructs a workflow model given the configuration blob provided by user in the Properties
@@ -163,7 +177,7 @@ func BuildModel(properties resource.Properties, options resource.Options) (*Mode
 	spec, err := fsm.Define(
 		fsm.State{
 			Index: requested,
-			TTL:   fsm.Expiry{options.WaitBeforeProvision, provision},
+			TTL:   fsm.Expiry{TTL: options.WaitBeforeProvision, Raise: provision},
 			Transitions: map[fsm.Signal]fsm.Index{
 				resourceFound: ready,
 				resourceLost:  provisioning,
@@ -181,6 +195,7 @@ func BuildModel(properties resource.Properties, options resource.Options) (*Mode
 			Transitions: map[fsm.Signal]fsm.Index{
 				dependencyMissing: waiting,
 				resourceFound:     ready,
+				throttle:          throttled,
 				provisionError:    cannotProvision,
 			},
 			Actions: map[fsm.Signal]fsm.Action{
@@ -194,6 +209,21 @@ func BuildModel(properties resource.Properties, options resource.Options) (*Mode
 				},
 			},
 		},
+		fsm.State{
+			Index: throttled,
+			TTL:   fsm.Expiry{TTL: fsm.Tick(1), Raise: provision},
+			Transitions: map[fsm.Signal]fsm.Index{
+				resourceFound: ready,
+				resourceLost:  provisioning,
+				provision:     provisioning,
+			},
+			Actions: map[fsm.Signal]fsm.Action{
+				provision: func(n fsm.FSM) error {
+					model.instanceProvisionChan <- n
+					return nil
+				},
+			},
+		},
 		fsm.State{
 			Index: terminating,
 			Transitions: map[fsm.Signal]fsm.Index{
@@ -266,7 +296,7 @@ func BuildModel(properties resource.Properties, options resource.Options) (*Mode
 		},
 		fsm.State{
 			Index: unmatched,
-			TTL:   fsm.Expiry{options.WaitBeforeDestroy, terminate},
+			TTL:   fsm.Expiry{TTL: options.WaitBeforeDestroy, Raise: terminate},
 			Transitions: map[fsm.Signal]fsm.Index{
 				terminate: terminating,
 			},
@@ -279,7 +309,7 @@ func BuildModel(properties resource.Properties, options resource.Options) (*Mode
 		},
 		fsm.State{
 			Index: terminated,
-			TTL:   fsm.Expiry{options.WaitBeforeDestroy, cleanup},
+			TTL:   fsm.Expiry{TTL: options.WaitBeforeDestroy, Raise: cleanup},
 			Transitions: map[fsm.Signal]fsm.Index{
 				cleanup: terminated, // This is really unnecessary, just here to trigger the cleanup action
 			},
@@ -303,6 +333,7 @@ func BuildModel(properties resource.Properties, options resource.Options) (*Mode
 
 	spec.SetStateNames(map[fsm.Index]string{
 		requested:        "REQUESTED",
+		throttled:        "THROTTLED",
 		ready:            "READY",
 		provisioning:     "PROVISIONING",
 		waiting:          "WAITING_PROVISION",
@@ -315,6 +346,7 @@ func BuildModel(properties resource.Properties, options resource.Options) (*Mode
 	}).SetSignalNames(map[fsm.Signal]string{
 		resourceFound:     "resource_found",
 		resourceLost:      "resource_lost",
+		throttle:          "throttle",
 		provision:         "provision",
 		terminate:         "terminate",
 		cleanup:           "cleanup",
@@ -0,0 +1,172 @@
+package resource // import "github.com/docker/infrakit/pkg/controller/resource"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	resource "github.com/docker/infrakit/pkg/controller/resource/types"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// errCircularDependency is returned when the resources depend on one another in a cycle
+type errCircularDependency []string
+
+func (e errCircularDependency) Error() string {
+	return fmt.Sprintf("circular dependency: %s", strings.Join(e, " -> "))
+}
+
+// Graph is the graph of dependencies among the resources of a collection.  A resource depends on
+// another when its spec references the other's observed properties via @depend expressions.
+// The resources are partitioned into levels, where the resources in a level depend only on those
+// of the lower levels.
+type Graph struct {
+	dependsOn map[string][]string
+	levels    [][]string
+	level     map[string]int
+}
+
+// BuildGraph returns the graph of dependencies of the resources, or an error if there are cycles.
+// References to resources not in the properties are ignored.
+func BuildGraph(properties resource.Properties) (*Graph, error) {
+
+	g := &Graph{
+		dependsOn: map[string][]string{},
+		level:     map[string]int{},
+	}
+
+	for key, access := range properties {
+
+		any, err := types.AnyValue(access.Spec)
+		if err != nil {
+			return nil, err
+		}
+
+		seen := map[string]bool{}
+		deps := []string{}
+		for _, path := range types.ParseDepends(any) {
+
+			dependedOnKey, err := keyFromPath(path)
+			if err != nil {
+				return nil, err
+			}
+
+			// Only the post-provision: modifier is a dependency, as in processProvisionWatches.
+			// It still refers to the same resource.
+			modifier, name := splitModifier(dependedOnKey)
+			if modifier != "" && modifier != postProvision {
+				continue
+			}
+			dependedOnKey = name
+
+			if _, has := properties[dependedOnKey]; !has || seen[dependedOnKey] {
+				continue
+			}
+			if dependedOnKey == key {
+				return nil, errCircularDependency{key, key}
+			}
+			seen[dependedOnKey] = true
+			deps = append(deps, dependedOnKey)
+		}
+		sort.Strings(deps)
+		g.dependsOn[key] = deps
+	}
+
+	return g, g.sort()
+}
+
+// sort partitions the resources into levels
+func (g *Graph) sort() error {
+	remaining := map[string]int{}
+	for key, deps := range g.dependsOn {
+		remaining[key] = len(deps)
+	}
+
+	for len(remaining) > 0 {
+		level := []string{}
+		for key, count := range remaining {
+			if count == 0 {
+				level = append(level, key)
+			}
+		}
+		if len(level) == 0 {
+			return g.cycle(remaining)
+		}
+		sort.Strings(level)
+		for _, key := range level {
+			delete(remaining, key)
+			g.level[key] = len(g.levels)
+		}
+		for key := range remaining {
+			for _, dep := range g.dependsOn[key] {
+				if _, has := g.level[dep]; has && g.level[dep] == len(g.levels) {
+					remaining[key]--
+				}
+			}
+		}
+		g.levels = append(g.levels, level)
+	}
+	return nil
+}
+
+// cycle returns the error of a cycle among the resources that cannot be sorted
+func (g *Graph) cycle(remaining map[string]int) error {
+	keys := []string{}
+	for key := range remaining {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// walk the dependencies from any remaining resource until a resource repeats
+	path := []string{}
+	index := map[string]int{}
+	for key := keys[0]; ; {
+		if i, has := index[key]; has {
+			return errCircularDependency(append(path[i:], key))
+		}
+		index[key] = len(path)
+		path = append(path, key)
+		for _, dep := range g.dependsOn[key] {
+			if _, has := remaining[dep]; has {
+				key = dep
+				break
+			}
+		}
+	}
+}
+
+// DependsOn returns the resources the given resource depends on
+func (g *Graph) DependsOn(key string) []string {
+	return g.dependsOn[key]
+}
+
+// Levels returns the resources by level, in the order of provisioning
+func (g *Graph) Levels() [][]string {
+	return g.levels
+}
+
+// Level returns the level of the resource, or -1 if the resource is not in the graph
+func (g *Graph) Level(key string) int {
+	if level, has := g.level[key]; has {
+		return level
+	}
+	return -1
+}
+
+// Order returns the resources in the order of provisioning
+func (g *Graph) Order() []string {
+	order := []string{}
+	for _, level := range g.levels {
+		order = append(order, level...)
+	}
+	return order
+}
+
+// Reverse returns the resources in the order of teardown, which is the reverse of provisioning
+func (g *Graph) Reverse() []string {
+	order := g.Order()
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
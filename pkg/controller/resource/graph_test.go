@@ -0,0 +1,131 @@
+package resource // import "github.com/docker/infrakit/pkg/controller/resource"
+
+import (
+	"testing"
+
+	resource "github.com/docker/infrakit/pkg/controller/resource/types"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func testSpec(t *testing.T, buff string) types.Spec {
+	var spec types.Spec
+	err := types.Decode([]byte(buff), &spec)
+	require.NoError(t, err)
+	return spec
+}
+
+func TestBuildGraph(t *testing.T) {
+
+	spec := testSpec(t, `
+kind: resource
+metadata:
+  name: resources
+properties:
+  vm:
+    plugin: az1/compute
+    init: |
+      join --token @depend('post-provision:net/data/joinToken')@
+    Properties:
+      subnet: "@depend('subnet/ID')@"
+      other: "@depend('elsewhere/ID')@"
+  subnet:
+    plugin: az1/net
+    Properties:
+      net: "@depend('net/ID')@"
+  net:
+    plugin: az1/net
+    Properties:
+      cidr: 10.20.0.0/16
+  disk:
+    plugin: az1/disk
+    Properties:
+      size: 10
+      ignored: "@depend('other:vm/ID')@"
+`)
+
+	properties := resource.Properties{}
+	require.NoError(t, spec.Properties.Decode(&properties))
+
+	graph, err := BuildGraph(properties)
+	require.NoError(t, err)
+
+	require.Equal(t, [][]string{{"disk", "net"}, {"subnet"}, {"vm"}}, graph.Levels())
+	require.Equal(t, []string{"net", "subnet"}, graph.DependsOn("vm"))
+	require.Equal(t, 0, graph.Level("net"))
+	require.Equal(t, 2, graph.Level("vm"))
+	require.Equal(t, -1, graph.Level("elsewhere"))
+	require.Equal(t, []string{"disk", "net", "subnet", "vm"}, graph.Order())
+	require.Equal(t, []string{"vm", "subnet", "net", "disk"}, graph.Reverse())
+
+	c, err := newCollection(scope.DefaultScope(), DefaultOptions)
+	require.NoError(t, err)
+
+	plan, err := c.(*collection).plan(controller.Enforce, spec)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"provision 0: disk, net",
+		"provision 1: subnet",
+		"provision 2: vm",
+	}, plan.Message)
+
+	plan, err = c.(*collection).plan(controller.Destroy, spec)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"destroy 0: vm",
+		"destroy 1: subnet",
+		"destroy 2: disk, net",
+	}, plan.Message)
+}
+
+func TestSplitModifier(t *testing.T) {
+
+	modifier, name := splitModifier("post-provision:net")
+	require.Equal(t, postProvision, modifier)
+	require.Equal(t, "net", name)
+
+	modifier, name = splitModifier("net")
+	require.Equal(t, "", modifier)
+	require.Equal(t, "net", name)
+}
+
+func TestBuildGraphCycle(t *testing.T) {
+
+	spec := testSpec(t, `
+kind: resource
+metadata:
+  name: resources
+properties:
+  a:
+    plugin: az1/net
+    Properties:
+      x: "@depend('c/ID')@"
+  b:
+    plugin: az1/net
+    Properties:
+      x: "@depend('a/ID')@"
+  c:
+    plugin: az1/net
+    Properties:
+      x: "@depend('b/ID')@"
+  d:
+    plugin: az1/net
+    Properties:
+      x: "@depend('a/ID')@"
+`)
+
+	properties := resource.Properties{}
+	require.NoError(t, spec.Properties.Decode(&properties))
+
+	_, err := BuildGraph(properties)
+	require.Error(t, err)
+	require.Equal(t, "circular dependency: a -> c -> b -> a", err.Error())
+
+	c, err := newCollection(scope.DefaultScope(), DefaultOptions)
+	require.NoError(t, err)
+
+	_, _, err = c.Plan(controller.Enforce, spec)
+	require.Error(t, err)
+}
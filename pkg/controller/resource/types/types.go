@@ -49,7 +49,15 @@ func ResolveDependencies(spec types.Spec) (depends.Runnables, error) {
 }
 
 // Properties is the schema of the configuration in the types.Spec.Properties
-type Properties map[string]internal.InstanceAccess
+type Properties map[string]Resource
+
+// Resource is the configuration of a resource in the Properties
+type Resource struct {
+	internal.InstanceAccess `json:",inline" yaml:",inline"`
+
+	// Drift configures the detection of drift of the resource from its spec.  Optional.
+	Drift *Drift `json:",omitempty" yaml:",omitempty"`
+}
 
 // ModelProperties contain fsm tuning parameters
 type ModelProperties struct {
@@ -64,6 +72,16 @@ type ModelProperties struct {
 
 // Validate validates the input properties
 func (p Properties) Validate(ctx context.Context) error {
+	for name, r := range p {
+		if r.Drift == nil {
+			continue
+		}
+		switch r.Drift.Remediation {
+		case "", Report, Reprovision, Relabel:
+		default:
+			return fmt.Errorf("bad remediation for %v: %v", name, r.Drift.Remediation)
+		}
+	}
 	return nil
 }
 
@@ -89,6 +107,35 @@ type Options struct {
 
 	// DestroyDeadline is the deadline for synchronously calling the plugin to destroy
 	DestroyDeadline types.Duration
+
+	// Parallelism is the max number of resources of the same level in the dependency graph
+	// that are provisioned and not yet observed at the same time.  0 means no limit.
+	Parallelism int
+}
+
+// Remediation is the action taken when a resource has drifted from its spec
+type Remediation string
+
+const (
+	// Report reports the drift in an event and in the description of the resource
+	Report Remediation = "report"
+
+	// Reprovision destroys the instance so that it is provisioned again from the spec
+	Reprovision Remediation = "reprovision"
+
+	// Relabel updates the tags of the instance to match the spec
+	Relabel Remediation = "relabel"
+)
+
+// Drift configures the detection and remediation of drift of a resource from its spec
+type Drift struct {
+
+	// Paths are the paths of the values compared between the spec and the observed instance,
+	// e.g. Properties/size or Tags/env
+	Paths []string
+
+	// Remediation is the action taken when drift is detected. Default is to report only.
+	Remediation Remediation
 }
 
 // Validate validates the controller's options
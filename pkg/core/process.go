@@ -26,7 +26,7 @@ type Process struct {
 	ProcessDefinition
 
 	workflow  *fsm.Spec
-	instances *fsm.Set
+	instances fsm.Instances
 
 	Constructor fsm.Action
 
@@ -134,7 +134,7 @@ func NewProcess(model ModelDefinition,
 
 // Start starts the management process of the instances
 func (p *Process) Start(clock *fsm.Clock) error {
-	p.instances = fsm.NewSet(p.workflow, clock, fsm.DefaultOptions(p.ProcessDefinition.Spec.Metadata.Name))
+	p.instances = fsm.NewInstances(p.workflow, clock, fsm.DefaultOptions(p.ProcessDefinition.Spec.Metadata.Name))
 	return nil
 }
 
@@ -145,7 +145,7 @@ func (p *Process) NewInstance(initialState fsm.Index) (fsm.FSM, error) {
 }
 
 // Instances returns a collection of fsm instances
-func (p *Process) Instances() *fsm.Set {
+func (p *Process) Instances() fsm.Instances {
 	return p.instances
 }
 
@@ -0,0 +1,290 @@
+package fsm // import "github.com/docker/infrakit/pkg/fsm"
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DwellBuckets are the upper bounds, in ticks, of the buckets of the dwell time histograms.
+var DwellBuckets = []Tick{1, 2, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+// Histogram is a cumulative histogram of observed values, in the style of Prometheus.
+type Histogram struct {
+
+	// Buckets are the upper bounds of the buckets
+	Buckets []Tick
+
+	// Counts are the cumulative counts of observations less than or equal to the bucket upper bound
+	Counts []uint64
+
+	// Count is the total number of observations
+	Count uint64
+
+	// Sum is the sum of all the observed values
+	Sum Tick
+}
+
+func newHistogram(buckets []Tick) *Histogram {
+	return &Histogram{
+		Buckets: buckets,
+		Counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *Histogram) observe(v Tick) {
+	for i, b := range h.Buckets {
+		if v <= b {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += v
+}
+
+func (h *Histogram) merge(other Histogram) {
+	for i := range h.Counts {
+		if i < len(other.Counts) {
+			h.Counts[i] += other.Counts[i]
+		}
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+}
+
+func (h *Histogram) copy() Histogram {
+	copy := *h
+	copy.Counts = append([]uint64{}, h.Counts...)
+	return copy
+}
+
+// Metrics is a snapshot of the metrics of a set of fsm instances.  States and signals are
+// keyed by their names as set in the spec.
+type Metrics struct {
+
+	// Name is the name of the set
+	Name string
+
+	// Labels are additional labels identifying the source of the metrics when exported
+	Labels map[string]string `json:",omitempty"`
+
+	// States is the number of instances currently in each state
+	States map[string]int
+
+	// Transitions counts the transitions by from state, signal, and to state
+	Transitions map[string]map[string]map[string]uint64
+
+	// Errors is the number of errors encountered during processing of events, including errors from actions
+	Errors uint64
+
+	// Dwell are the histograms, by state, of the time in ticks instances spent in a state before leaving it
+	Dwell map[string]Histogram
+}
+
+// merge adds the other metrics to this one
+func (m *Metrics) merge(other Metrics) {
+	for state, count := range other.States {
+		m.States[state] += count
+	}
+	for from, bySignal := range other.Transitions {
+		for signal, byTo := range bySignal {
+			for to, count := range byTo {
+				m.countTransition(from, signal, to, count)
+			}
+		}
+	}
+	m.Errors += other.Errors
+	for state, h := range other.Dwell {
+		dwell, has := m.Dwell[state]
+		if !has {
+			dwell = *newHistogram(h.Buckets)
+		}
+		dwell.merge(h)
+		m.Dwell[state] = dwell
+	}
+}
+
+func (m *Metrics) countTransition(from, signal, to string, count uint64) {
+	if _, has := m.Transitions[from]; !has {
+		m.Transitions[from] = map[string]map[string]uint64{}
+	}
+	if _, has := m.Transitions[from][signal]; !has {
+		m.Transitions[from][signal] = map[string]uint64{}
+	}
+	m.Transitions[from][signal][to] += count
+}
+
+type transitionKey struct {
+	from   Index
+	signal Signal
+	to     Index
+}
+
+// metrics is the recorder of the metrics of a set.  It's not synchronized; it's updated only
+// by the transaction processing of the set, and is read via the serialized reads.
+type metrics struct {
+	transitions map[transitionKey]uint64
+	errors      uint64
+	dwell       map[Index]*Histogram
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		transitions: map[transitionKey]uint64{},
+		dwell:       map[Index]*Histogram{},
+	}
+}
+
+func (m *metrics) transition(from Index, signal Signal, to Index, dwell Tick) {
+	m.transitions[transitionKey{from: from, signal: signal, to: to}]++
+	m.left(from, dwell)
+}
+
+func (m *metrics) left(state Index, dwell Tick) {
+	h, has := m.dwell[state]
+	if !has {
+		h = newHistogram(DwellBuckets)
+		m.dwell[state] = h
+	}
+	h.observe(dwell)
+}
+
+func (m *metrics) error() {
+	m.errors++
+}
+
+// snapshot returns the metrics keyed by the names of states and signals
+func (m *metrics) snapshot(name string, spec *Spec, bystate map[Index]map[ID]*instance) Metrics {
+	out := Metrics{
+		Name:        name,
+		States:      map[string]int{},
+		Transitions: map[string]map[string]map[string]uint64{},
+		Errors:      m.errors,
+		Dwell:       map[string]Histogram{},
+	}
+	for state, members := range bystate {
+		out.States[spec.StateName(state)] = len(members)
+	}
+	for k, count := range m.transitions {
+		out.countTransition(spec.StateName(k.from), spec.SignalName(k.signal), spec.StateName(k.to), count)
+	}
+	for state, h := range m.dwell {
+		out.Dwell[spec.StateName(state)] = h.copy()
+	}
+	return out
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format.  The labels
+// of each Metrics, along with the name of the set, are attached to its samples.  The samples are
+// grouped by metric name so that the same metric across multiple sets are written together.
+func WritePrometheus(w io.Writer, metrics ...Metrics) error {
+
+	const (
+		instances   = "infrakit_fsm_instances"
+		transitions = "infrakit_fsm_transitions_total"
+		errors      = "infrakit_fsm_errors_total"
+		dwell       = "infrakit_fsm_dwell_ticks"
+	)
+
+	// labels for the ith metrics
+	with := func(i int, kv ...string) string {
+		l := map[string]string{"set": metrics[i].Name}
+		for k, v := range metrics[i].Labels {
+			l[k] = v
+		}
+		for j := 0; j+1 < len(kv); j += 2 {
+			l[kv[j]] = kv[j+1]
+		}
+		return formatLabels(l)
+	}
+
+	fmt.Fprintf(w, "# HELP %s Number of instances in each state.\n", instances)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", instances)
+	for i, m := range metrics {
+		for _, state := range sortedKeys(m.States) {
+			fmt.Fprintf(w, "%s%s %d\n", instances, with(i, "state", state), m.States[state])
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP %s Number of transitions by from state, signal and to state.\n", transitions)
+	fmt.Fprintf(w, "# TYPE %s counter\n", transitions)
+	for i, m := range metrics {
+		for _, from := range sortedKeys(m.Transitions) {
+			for _, signal := range sortedKeys(m.Transitions[from]) {
+				for _, to := range sortedKeys(m.Transitions[from][signal]) {
+					fmt.Fprintf(w, "%s%s %d\n", transitions,
+						with(i, "from", from, "signal", signal, "to", to), m.Transitions[from][signal][to])
+				}
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP %s Number of errors encountered processing events.\n", errors)
+	fmt.Fprintf(w, "# TYPE %s counter\n", errors)
+	for i, m := range metrics {
+		fmt.Fprintf(w, "%s%s %d\n", errors, with(i), m.Errors)
+	}
+
+	fmt.Fprintf(w, "# HELP %s Time in ticks spent in a state before leaving it.\n", dwell)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", dwell)
+	for i, m := range metrics {
+		for _, state := range sortedKeys(m.Dwell) {
+			h := m.Dwell[state]
+			for j, b := range h.Buckets {
+				fmt.Fprintf(w, "%s_bucket%s %d\n", dwell, with(i, "state", state, "le", fmt.Sprintf("%d", b)), h.Counts[j])
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", dwell, with(i, "state", state, "le", "+Inf"), h.Count)
+			fmt.Fprintf(w, "%s_sum%s %d\n", dwell, with(i, "state", state), h.Sum)
+			_, err := fmt.Fprintf(w, "%s_count%s %d\n", dwell, with(i, "state", state), h.Count)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := []string{}
+	for _, k := range sortedKeys(labels) {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[k])
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, k, v))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch m := m.(type) {
+	case map[string]string:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]int:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]Histogram:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]uint64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]map[string]uint64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]map[string]map[string]uint64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,111 @@
+package fsm // import "github.com/docker/infrakit/pkg/fsm"
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+
+	const (
+		boot Index = iota
+		running
+		down
+	)
+
+	const (
+		start Signal = iota
+		fail
+	)
+
+	failed := fmt.Errorf("failed")
+
+	spec, err := Define(
+		State{
+			Index: boot,
+			Transitions: map[Signal]Index{
+				start: running,
+				fail:  down,
+			},
+			Actions: map[Signal]Action{
+				fail: func(FSM) error { return failed },
+			},
+			Errors: map[Signal]Index{
+				fail: boot,
+			},
+		},
+		State{
+			Index: running,
+			Transitions: map[Signal]Index{
+				fail: down,
+			},
+		},
+		State{
+			Index: down,
+		},
+	)
+	require.NoError(t, err)
+
+	spec.SetStateNames(map[Index]string{
+		boot:    "boot",
+		running: "running",
+		down:    "down",
+	}).SetSignalNames(map[Signal]string{
+		start: "start",
+		fail:  "fail",
+	})
+
+	clock := NewClock()
+	set := NewSet(spec, clock, DefaultOptions("test"))
+	defer set.Stop()
+
+	clock.Start()
+
+	for i := 0; i < 5; i++ {
+		set.Add(boot)
+	}
+
+	clock.Tick()
+	clock.Tick()
+
+	require.NoError(t, set.Signal(start, ID(0)))
+	require.NoError(t, set.Signal(start, ID(1)))
+	require.NoError(t, set.Signal(fail, ID(2))) // action fails and goes back to boot
+
+	clock.Tick()
+
+	require.NoError(t, set.Signal(fail, ID(1)))
+
+	metrics := set.Metrics()
+	require.Equal(t, "test", metrics.Name)
+	require.Equal(t, map[string]int{"boot": 3, "running": 1, "down": 1}, metrics.States)
+	require.Equal(t, uint64(2), metrics.Transitions["boot"]["start"]["running"])
+	require.Equal(t, uint64(1), metrics.Transitions["boot"]["fail"]["boot"])
+	require.Equal(t, uint64(1), metrics.Transitions["running"]["fail"]["down"])
+	require.Equal(t, uint64(1), metrics.Errors)
+
+	require.Equal(t, uint64(3), metrics.Dwell["boot"].Count)
+	require.Equal(t, Tick(6), metrics.Dwell["boot"].Sum)
+	require.Equal(t, uint64(1), metrics.Dwell["running"].Count)
+	require.Equal(t, Tick(1), metrics.Dwell["running"].Sum)
+	require.Equal(t, uint64(0), metrics.Dwell["boot"].Counts[0]) // <= 1 tick
+	require.Equal(t, uint64(3), metrics.Dwell["boot"].Counts[1]) // <= 2 ticks
+
+	metrics.Labels = map[string]string{"collection": "workers"}
+
+	buff := new(bytes.Buffer)
+	require.NoError(t, WritePrometheus(buff, metrics))
+
+	text := buff.String()
+	require.Contains(t, text, "# TYPE infrakit_fsm_instances gauge\n")
+	require.Contains(t, text, `infrakit_fsm_instances{collection="workers",set="test",state="boot"} 3`)
+	require.Contains(t, text,
+		`infrakit_fsm_transitions_total{collection="workers",from="boot",set="test",signal="start",to="running"} 2`)
+	require.Contains(t, text, `infrakit_fsm_errors_total{collection="workers",set="test"} 1`)
+	require.Contains(t, text, `infrakit_fsm_dwell_ticks_bucket{collection="workers",le="2",set="test",state="boot"} 3`)
+	require.Contains(t, text, `infrakit_fsm_dwell_ticks_bucket{collection="workers",le="+Inf",set="test",state="boot"} 3`)
+	require.Contains(t, text, `infrakit_fsm_dwell_ticks_sum{collection="workers",set="test",state="boot"} 6`)
+}
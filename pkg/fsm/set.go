@@ -16,6 +16,11 @@ func NewSet(spec *Spec, clock *Clock, optional ...Options) *Set {
 	if len(optional) > 0 {
 		options = optional[0]
 	}
+	return newSet(spec, clock, options, make(chan error))
+}
+
+// newSet returns a new set that reports its errors on the given channel
+func newSet(spec *Spec, clock *Clock, options Options, errors chan error) *Set {
 
 	if options.BufferSize == 0 {
 		options.BufferSize = defaultBufferSize
@@ -31,10 +36,11 @@ func NewSet(spec *Spec, clock *Clock, optional ...Options) *Set {
 		reads:        make(chan func(Set)),
 		add:          make(chan addOp),
 		delete:       make(chan ID),
-		errors:       make(chan error),
+		errors:       errors,
 		events:       make(chan *event),
 		transactions: make(chan *txn, options.BufferSize),
 		deadlines:    newQueue(),
+		metrics:      newMetrics(),
 	}
 
 	for i := range spec.states {
@@ -114,6 +120,17 @@ func (s *Set) ForEachInState(check Index, view func(ID, Index, interface{}) bool
 	<-blocker
 }
 
+// Metrics returns a snapshot of the metrics of the set
+func (s *Set) Metrics() (metrics Metrics) {
+	blocker := make(chan struct{})
+	s.reads <- func(set Set) {
+		defer close(blocker)
+		metrics = set.metrics.snapshot(set.options.Name, &set.spec, set.bystate)
+	}
+	<-blocker
+	return
+}
+
 // Name returns the name of the set
 func (s *Set) Name() string {
 	return s.options.Name
@@ -195,6 +212,8 @@ func (s *Set) handleError(tid int64, err error, ctx interface{}) {
 		message = fmt.Sprintf("%s: %v", err.Error(), err)
 	}
 
+	s.metrics.error()
+
 	defer log.Error("error", "tid", tid, "err", message, "context", ctx)
 	select {
 	case s.errors <- err: // non-blocking send
@@ -203,8 +222,11 @@ func (s *Set) handleError(tid int64, err error, ctx interface{}) {
 }
 func (s *Set) handleAdd(tid int64, op addOp) error {
 	// add a new instance
-	id := s.next
-	s.next++
+	id := op.id
+	if !op.assigned {
+		id = s.next
+		s.next++
+	}
 
 	new := &instance{
 		id:     id,
@@ -460,12 +482,14 @@ func (s *Set) handleEvent(tid int64, event *event) error {
 				log.Debug("Err executing action", "tid", tid, "instance", instance.id,
 					"state", current, "signal", event.signal, "alternate", alternate, "next", next)
 
+				s.metrics.error()
 				next = alternate
 			}
 		}
 	}
 
 	// Action has been run... We landed in the new state (next)
+	s.metrics.transition(current, event.signal, next, Tick(now-instance.start))
 
 	// process deadline, if any
 	if err := s.processDeadline(tid, instance, next); err != nil {
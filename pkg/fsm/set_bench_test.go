@@ -0,0 +1,115 @@
+package fsm // import "github.com/docker/infrakit/pkg/fsm"
+
+import (
+	"testing"
+)
+
+const (
+	benchFleetSize = 10000
+	benchShards    = 8
+)
+
+const (
+	benchUp Index = iota
+	benchDown
+)
+
+const (
+	benchFlip Signal = iota
+)
+
+// benchSpec returns a spec where every instance flips state on every tick so that
+// each tick does work proportional to the size of the set.
+func benchSpec(b *testing.B) *Spec {
+	spec, err := Define(
+		State{
+			Index: benchUp,
+			Transitions: map[Signal]Index{
+				benchFlip: benchDown,
+			},
+			TTL: Expiry{1, benchFlip},
+		},
+		State{
+			Index: benchDown,
+			Transitions: map[Signal]Index{
+				benchFlip: benchUp,
+			},
+			TTL: Expiry{1, benchFlip},
+		},
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return spec
+}
+
+func benchSet(b *testing.B, shards int) (Instances, *Clock) {
+	clock := NewClock()
+
+	// every instance can raise a signal on the same tick, so size the transaction buffer for all of them
+	options := DefaultOptions("bench")
+	options.BufferSize = 2 * benchFleetSize
+
+	var set Instances
+	if shards > 0 {
+		set = newShardedSet(benchSpec(b), clock, shards, options)
+	} else {
+		set = NewSet(benchSpec(b), clock, options)
+	}
+	for i := 0; i < benchFleetSize; i++ {
+		set.Add(benchUp)
+	}
+	return set, clock
+}
+
+func benchmarkForEach(b *testing.B, shards int) {
+	set, _ := benchSet(b, shards)
+	defer set.Stop()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count := 0
+		set.ForEach(func(ID, Index, interface{}) bool {
+			count++
+			return true
+		})
+		if count != benchFleetSize {
+			b.Fatal("wrong count", count)
+		}
+	}
+}
+
+func benchmarkSignal(b *testing.B, shards int) {
+	set, _ := benchSet(b, shards)
+	defer set.Stop()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			set.Signal(benchFlip, ID(i%benchFleetSize))
+			i++
+		}
+	})
+	set.Size() // wait for the queued signals to be processed
+}
+
+func benchmarkTick(b *testing.B, shards int) {
+	set, clock := benchSet(b, shards)
+	defer set.Stop()
+
+	clock.Start()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clock.Tick()
+		set.CountByState(benchUp) // the read is queued behind the tick
+	}
+}
+
+func BenchmarkSetForEach(b *testing.B)        { benchmarkForEach(b, 0) }
+func BenchmarkShardedSetForEach(b *testing.B) { benchmarkForEach(b, benchShards) }
+func BenchmarkSetSignal(b *testing.B)         { benchmarkSignal(b, 0) }
+func BenchmarkShardedSetSignal(b *testing.B)  { benchmarkSignal(b, benchShards) }
+func BenchmarkSetTick(b *testing.B)           { benchmarkTick(b, 0) }
+func BenchmarkShardedSetTick(b *testing.B)    { benchmarkTick(b, benchShards) }
@@ -0,0 +1,249 @@
+package fsm // import "github.com/docker/infrakit/pkg/fsm"
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// check that the set implementations satisfy the interface
+	_ Instances = &Set{}
+	_ Instances = &shardedSet{}
+)
+
+// NewInstances returns the instances of the spec, partitioned across the number of shards of the options.
+// With less than two shards, it's a Set.
+func NewInstances(spec *Spec, clock *Clock, optional ...Options) Instances {
+
+	options := Options{}
+	if len(optional) > 0 {
+		options = optional[0]
+	}
+	if options.Shards > 1 {
+		return newShardedSet(spec, clock, options.Shards, options)
+	}
+	return NewSet(spec, clock, options)
+}
+
+// newShardedSet returns a set whose instances are partitioned by the hash of their IDs
+// across the given number of shards.  Each shard is a Set with its own event loop and
+// deadlines queue, so that operations on instances in different shards do not contend
+// with one another.  The clock given is fanned out to all the shards.
+func newShardedSet(spec *Spec, clock *Clock, shards int, optional ...Options) *shardedSet {
+
+	options := Options{}
+	if len(optional) > 0 {
+		options = optional[0]
+	}
+
+	if shards < 1 {
+		shards = 1
+	}
+
+	set := &shardedSet{
+		options: options,
+		spec:    spec,
+		clock:   clock,
+		shards:  make([]*Set, shards),
+		clocks:  make([]*Clock, shards),
+		errors:  make(chan error),
+		stop:    make(chan struct{}),
+		ticking: make(chan struct{}),
+	}
+
+	for i := range set.shards {
+		shardOptions := options
+		shardOptions.Name = fmt.Sprintf("%s/%d", options.Name, i)
+		shardOptions.Shards = 0
+
+		set.clocks[i] = NewClock()
+		// the errors of a shard are buffered, so they aren't dropped while the errors of the others are forwarded
+		set.shards[i] = newSet(spec, set.clocks[i], shardOptions, make(chan error, defaultBufferSize))
+		set.clocks[i].Start()
+	}
+
+	set.run()
+	set.running = true
+	return set
+}
+
+// shardedSet is a collection of fsm instances that follow a given spec, partitioned across
+// a number of independent Sets.  It has the same API as the Set.
+type shardedSet struct {
+	options Options
+	spec    *Spec
+	next    uint64
+	clock   *Clock
+	shards  []*Set
+	clocks  []*Clock
+	errors  chan error
+	stop    chan struct{}
+	ticking chan struct{}
+	running bool
+}
+
+// shard returns the set that owns the given id
+func (s *shardedSet) shard(id ID) *Set {
+	// Fibonacci hashing so that sequential ids are spread out across the shards
+	h := uint64(id) * 0x9E3779B97F4A7C15
+	return s.shards[(h>>32)%uint64(len(s.shards))]
+}
+
+// Signal sends a signal to the instance
+func (s *shardedSet) Signal(signal Signal, instance ID, optionalData ...interface{}) error {
+	return s.shard(instance).Signal(signal, instance, optionalData...)
+}
+
+// Size returns the size of the set
+func (s *shardedSet) Size() int {
+	total := 0
+	for _, shard := range s.shards {
+		total += shard.Size()
+	}
+	return total
+}
+
+// CountByState returns a count of instances in a given state.
+func (s *shardedSet) CountByState(state Index) int {
+	total := 0
+	for _, shard := range s.shards {
+		total += shard.CountByState(state)
+	}
+	return total
+}
+
+// ForEach iterates through the set.  The view is consistent within each shard but not across shards.
+func (s *shardedSet) ForEach(view func(ID, Index, interface{}) bool) {
+	more := true
+	for _, shard := range s.shards {
+		shard.ForEach(func(id ID, state Index, data interface{}) bool {
+			more = view(id, state, data)
+			return more
+		})
+		if !more {
+			return
+		}
+	}
+}
+
+// ForEachInState iterates through the instances in the given state.  The view is consistent within
+// each shard but not across shards.
+func (s *shardedSet) ForEachInState(check Index, view func(ID, Index, interface{}) bool) {
+	more := true
+	for _, shard := range s.shards {
+		shard.ForEachInState(check, func(id ID, state Index, data interface{}) bool {
+			more = view(id, state, data)
+			return more
+		})
+		if !more {
+			return
+		}
+	}
+}
+
+// Metrics returns the metrics of all the shards combined
+func (s *shardedSet) Metrics() Metrics {
+	metrics := Metrics{
+		Name:        s.options.Name,
+		States:      map[string]int{},
+		Transitions: map[string]map[string]map[string]uint64{},
+		Dwell:       map[string]Histogram{},
+	}
+	for _, shard := range s.shards {
+		metrics.merge(shard.Metrics())
+	}
+	return metrics
+}
+
+// Name returns the name of the set
+func (s *shardedSet) Name() string {
+	return s.options.Name
+}
+
+// Get returns the instance by id. Nil if no id matched
+func (s *shardedSet) Get(id ID) FSM {
+	return s.shard(id).Get(id)
+}
+
+// Add adds an instance given initial state
+func (s *shardedSet) Add(initial Index) FSM {
+	id := ID(atomic.AddUint64(&s.next, 1) - 1)
+	op := addOp{initial: initial, result: make(chan FSM), id: id, assigned: true}
+	s.shard(id).add <- op
+	return <-op.result
+}
+
+// Delete deletes an instance
+func (s *shardedSet) Delete(instance FSM) {
+	s.shard(instance.ID()).Delete(instance)
+}
+
+// Stop stops the state machine loops of all the shards
+func (s *shardedSet) Stop() {
+	if s.running {
+		close(s.stop)
+		s.clock.Stop()
+		<-s.ticking // wait for the fan out to finish before closing the shards' clocks
+		for _, shard := range s.shards {
+			shard.Stop()
+		}
+		s.running = false
+	}
+}
+
+// Errors returns the errors encountered during async processing of events
+func (s *shardedSet) Errors() <-chan error {
+	return s.errors
+}
+
+func (s *shardedSet) run() {
+
+	// Fan out the clock ticks to all the shards.  The shards process the ticks in
+	// parallel and the next tick is not accepted until all the shards have received this one.
+	go func() {
+		defer close(s.ticking)
+		for {
+			select {
+			case <-s.stop:
+				return
+
+			case _, ok := <-s.clock.C:
+				if !ok {
+					return
+				}
+
+				var wg sync.WaitGroup
+				for _, c := range s.clocks {
+					wg.Add(1)
+					go func(c *Clock) {
+						defer wg.Done()
+						select {
+						case c.c <- Tick(1):
+						case <-s.stop:
+						}
+					}(c)
+				}
+				wg.Wait()
+			}
+		}
+	}()
+
+	// Merge the errors of the shards.  The errors are forwarded as they are received, until stopped.
+	for _, shard := range s.shards {
+		go func(shard *Set) {
+			for {
+				select {
+				case <-s.stop:
+					return
+				case err := <-shard.errors:
+					select {
+					case s.errors <- err:
+					case <-s.stop:
+						return
+					}
+				}
+			}
+		}(shard)
+	}
+}
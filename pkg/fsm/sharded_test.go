@@ -0,0 +1,178 @@
+package fsm // import "github.com/docker/infrakit/pkg/fsm"
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedSetDeadlineTransition(t *testing.T) {
+
+	const (
+		running Index = iota
+		wait
+	)
+
+	const (
+		start Signal = iota
+	)
+
+	spec, err := Define(
+		State{
+			Index: wait,
+			Transitions: map[Signal]Index{
+				start: running,
+			},
+			TTL: Expiry{5, start},
+		},
+		State{
+			Index: running,
+		},
+	)
+	require.NoError(t, err)
+
+	clock := NewClock()
+
+	set := newShardedSet(spec, clock, 4, DefaultOptions("sharded"))
+	defer set.Stop()
+
+	require.Equal(t, "sharded", set.Name())
+
+	for i := 0; i < 100; i++ {
+		set.Add(wait)
+	}
+
+	require.Equal(t, 100, set.Size())
+	require.Equal(t, 100, set.CountByState(wait))
+
+	// all the shards should have some instances
+	for _, shard := range set.shards {
+		require.True(t, shard.Size() > 0)
+	}
+
+	// ids are unique across the shards
+	seen := map[ID]bool{}
+	set.ForEach(func(id ID, state Index, data interface{}) bool {
+		require.False(t, seen[id])
+		seen[id] = true
+		return true
+	})
+	require.Equal(t, 100, len(seen))
+
+	// Returning false stops scanning across all shards
+	count := 0
+	set.ForEachInState(wait, func(id ID, state Index, data interface{}) bool {
+		count++
+		return false
+	})
+	require.Equal(t, 1, count)
+
+	clock.Tick() // t = 1
+	clock.Tick() // t = 2
+
+	// transition a few instances
+	for i := 10; i < 20; i++ {
+		instance := set.Get(ID(i))
+		require.Equal(t, ID(i), instance.ID())
+		require.Equal(t, wait, instance.State())
+		require.NoError(t, instance.Signal(start))
+	}
+
+	require.Equal(t, 10, set.CountByState(running))
+	require.Equal(t, 90, set.CountByState(wait))
+
+	clock.Tick() // t = 3
+	clock.Tick() // t = 4
+	clock.Tick() // t = 5
+
+	time.Sleep(1 * time.Second) // give a little time for the set to settle
+
+	require.Equal(t, 100, set.CountByState(running))
+	require.Equal(t, 0, set.CountByState(wait))
+	for _, shard := range set.shards {
+		require.Equal(t, 0, shard.deadlines.Len())
+	}
+
+	set.Delete(set.Get(ID(15)))
+	require.Equal(t, 99, set.Size())
+}
+
+func TestShardedSetErrors(t *testing.T) {
+
+	const (
+		running Index = iota
+		stopped
+	)
+
+	const (
+		start Signal = iota
+		stop
+	)
+
+	spec, err := Define(
+		State{
+			Index: running,
+			Transitions: map[Signal]Index{
+				stop: stopped,
+			},
+		},
+		State{
+			Index: stopped,
+			Transitions: map[Signal]Index{
+				start: running,
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	clock := NewClock()
+	set := newShardedSet(spec, clock, 4, Options{Name: "sharded", BufferSize: 100})
+	defer set.Stop()
+
+	for i := 0; i < 20; i++ {
+		set.Add(running)
+	}
+
+	// the errors of all the shards are received
+	errs := make(chan error)
+	go func() {
+		for i := 0; i < 20; i++ {
+			errs <- <-set.Errors()
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, set.Signal(start, ID(i)))
+	}
+
+	for i := 0; i < 20; i++ {
+		select {
+		case err := <-errs:
+			_, is := err.(ErrUnknownTransition)
+			require.True(t, is)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "missing errors", "received %d", i)
+		}
+	}
+}
+
+func TestNewInstances(t *testing.T) {
+
+	spec, err := Define(State{Index: Index(0)})
+	require.NoError(t, err)
+
+	options := DefaultOptions("instances")
+	set := NewInstances(spec, NewClock(), options)
+	defer set.Stop()
+	_, is := set.(*Set)
+	require.True(t, is)
+
+	options.Shards = 4
+	sharded := NewInstances(spec, NewClock(), options)
+	defer sharded.Stop()
+	require.Equal(t, 4, len(sharded.(*shardedSet).shards))
+
+	sharded.Add(Index(0))
+	require.Equal(t, 1, sharded.Size())
+}
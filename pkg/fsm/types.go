@@ -107,11 +107,61 @@ type Options struct {
 
 	// IgnoreUndefinedSignals will not report error from undefined signal for the state on Error() chan, if true
 	IgnoreUndefinedSignals bool
+
+	// Shards is the number of shards the instances are partitioned across by NewInstances.  With more than one
+	// shard, each shard has its own event loop and deadlines queue.
+	Shards int
 }
 
 type addOp struct {
 	initial Index
 	result  chan FSM
+
+	// id is the preassigned id of the new instance, if assigned is true.  This is used
+	// when the ids are allocated outside of the set, as in the case of a shard.
+	id       ID
+	assigned bool
+}
+
+// Instances is the interface for a collection of fsm instances that follow the same spec.
+// It's implemented by Set and the sharded set.  See NewInstances.
+type Instances interface {
+
+	// Signal sends a signal to the instance
+	Signal(signal Signal, instance ID, optionalData ...interface{}) error
+
+	// Size returns the size of the collection
+	Size() int
+
+	// CountByState returns a count of instances in a given state.
+	CountByState(state Index) int
+
+	// ForEach iterates through the collection
+	ForEach(view func(ID, Index, interface{}) bool)
+
+	// ForEachInState iterates through the instances in the given state
+	ForEachInState(check Index, view func(ID, Index, interface{}) bool)
+
+	// Name returns the name of the collection
+	Name() string
+
+	// Get returns the instance by id. Nil if no id matched
+	Get(id ID) FSM
+
+	// Add adds an instance given initial state
+	Add(initial Index) FSM
+
+	// Delete deletes an instance
+	Delete(instance FSM)
+
+	// Stop stops the processing
+	Stop()
+
+	// Errors returns the errors encountered during async processing of events
+	Errors() <-chan error
+
+	// Metrics returns a snapshot of the metrics of the collection
+	Metrics() Metrics
 }
 
 // Set is a collection of fsm instances that follow a given spec.  This is
@@ -132,5 +182,6 @@ type Set struct {
 	events       chan *event
 	transactions chan *txn
 	deadlines    *queue
+	metrics      *metrics
 	running      bool
 }
@@ -0,0 +1,192 @@
+package consul // import "github.com/docker/infrakit/pkg/leader/consul"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/infrakit/pkg/leader"
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/util/consul"
+)
+
+var (
+	log    = logutil.New("module", "leader/consul")
+	debugV = logutil.V(1000)
+)
+
+const (
+	// DefaultAddress is the address of the local Consul agent
+	DefaultAddress = consul.DefaultAddress
+
+	// DefaultKey is the key of the lock.  The location of the leader is stored under it.
+	DefaultKey = "infrakit/leader"
+
+	// LocationKey is the key, under the key of the lock, that stores the location of the leader
+	LocationKey = "location"
+
+	// DefaultTTL is the TTL of the session holding the lock
+	DefaultTTL = 15 * time.Second
+)
+
+// Options capture the Consul agent and the lock
+type Options struct {
+	// Address is the url of the Consul agent.  It's http if there's no scheme.
+	Address string
+
+	// Token is the ACL token, if ACLs are enabled
+	Token string
+
+	// Key is the key of the lock
+	Key string
+
+	// Identity is the value written to the key while this instance holds the lock
+	Identity string
+
+	// TTL is the TTL of the session holding the lock.  It should be a few times the poll interval.  Consul
+	// requires it to be between 10s and 24h.
+	TTL time.Duration
+}
+
+// agent is the client of the Consul agent of the options
+type agent struct {
+	*consul.Client
+	options Options
+}
+
+func newAgent(client *http.Client, options Options) *agent {
+	if options.Key == "" {
+		options.Key = DefaultKey
+	}
+	if options.TTL == 0 {
+		options.TTL = DefaultTTL
+	}
+	options.Key = strings.Trim(options.Key, "/")
+	return &agent{
+		Client:  consul.NewClient(client, consul.Options{Address: options.Address, Token: options.Token}),
+		options: options,
+	}
+}
+
+// Lock is a Consul lock on a key held by a session.  It implements leader.Lock.
+type Lock struct {
+	*agent
+
+	lock    sync.Mutex
+	session string
+}
+
+// NewLock returns the lock of the options
+func NewLock(client *http.Client, options Options) *Lock {
+	return &Lock{agent: newAgent(client, options)}
+}
+
+// NewDetector returns a detector where the instance holding the lock is the leader
+func NewDetector(pollInterval time.Duration, client *http.Client, options Options) *leader.LockDetector {
+	return leader.NewLockDetector(pollInterval, NewLock(client, options))
+}
+
+// TryAcquireOrRenew implements leader.Lock.  The session is created when there's none, or when Consul
+// invalidated it because it wasn't renewed in time.
+func (l *Lock) TryAcquireOrRenew() (held bool, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	defer func() {
+		log.Debug("lock", "key", l.options.Key, "identity", l.options.Identity, "session", l.session,
+			"held", held, "err", err, "V", debugV)
+	}()
+
+	if l.session != "" {
+		status, err := l.Do(http.MethodPut, "/v1/session/renew/"+l.session, nil, nil, nil)
+		if status == http.StatusNotFound {
+			log.Warn("Session expired", "key", l.options.Key, "session", l.session)
+			l.session = ""
+		} else if err != nil {
+			return false, err
+		}
+	}
+
+	if l.session == "" {
+		body, err := json.Marshal(map[string]string{
+			"Name":     fmt.Sprintf("infrakit-leader-%s", l.options.Identity),
+			"TTL":      l.options.TTL.String(),
+			"Behavior": "release",
+		})
+		if err != nil {
+			return false, err
+		}
+		created := struct{ ID string }{}
+		if _, err := l.Do(http.MethodPut, "/v1/session/create", nil, body, &created); err != nil {
+			return false, err
+		}
+		l.session = created.ID
+	}
+
+	_, err = l.Do(http.MethodPut, "/v1/kv/"+l.options.Key, url.Values{"acquire": {l.session}},
+		[]byte(l.options.Identity), &held)
+	return held, err
+}
+
+// Release implements leader.Lock.  The session is destroyed.
+func (l *Lock) Release() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.session == "" {
+		return nil
+	}
+	released := false
+	_, err := l.Do(http.MethodPut, "/v1/kv/"+l.options.Key, url.Values{"release": {l.session}}, nil, &released)
+	if err != nil {
+		return err
+	}
+	_, err = l.Do(http.MethodPut, "/v1/session/destroy/"+l.session, nil, nil, nil)
+	l.session = ""
+	return err
+}
+
+// Store stores the location of the leader in Consul
+type Store struct {
+	*agent
+}
+
+// NewStore returns a store for registration of leader location
+func NewStore(client *http.Client, options Options) leader.Store {
+	return &Store{agent: newAgent(client, options)}
+}
+
+func (s *Store) path() string {
+	return "/v1/kv/" + s.options.Key + "/" + LocationKey
+}
+
+// UpdateLocation writes the location to Consul.
+func (s *Store) UpdateLocation(location *url.URL) error {
+	ok := false
+	_, err := s.Do(http.MethodPut, s.path(), nil, []byte(location.String()), &ok)
+	if err == nil && !ok {
+		err = fmt.Errorf("cannot write location to %v", s.path())
+	}
+	return err
+}
+
+// GetLocation returns the location of the leader
+func (s *Store) GetLocation() (*url.URL, error) {
+	raw := []byte{}
+	status, err := s.Do(http.MethodGet, s.path(), url.Values{"raw": {""}}, nil, &raw)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	log.Debug("leader location", "location", string(raw), "V", debugV)
+	return url.Parse(string(raw))
+}
@@ -0,0 +1,209 @@
+package consul // import "github.com/docker/infrakit/pkg/leader/consul"
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	testing_leader "github.com/docker/infrakit/pkg/testing/leader"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAgent serves the sessions and kv of the Consul agent HTTP API
+type fakeAgent struct {
+	sync.Mutex
+	seq      int
+	sessions map[string]bool
+	values   map[string]string
+	locks    map[string]string
+}
+
+func (s *fakeAgent) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	body, _ := ioutil.ReadAll(req.Body)
+	path := req.URL.Path
+	switch {
+	case path == "/v1/session/create":
+		s.seq++
+		id := fmt.Sprintf("session-%d", s.seq)
+		s.sessions[id] = true
+		json.NewEncoder(resp).Encode(map[string]string{"ID": id})
+
+	case strings.HasPrefix(path, "/v1/session/renew/"):
+		id := strings.TrimPrefix(path, "/v1/session/renew/")
+		if !s.sessions[id] {
+			http.Error(resp, "Session id '"+id+"' not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(resp).Encode([]map[string]string{{"ID": id}})
+
+	case strings.HasPrefix(path, "/v1/session/destroy/"):
+		s.invalidate(strings.TrimPrefix(path, "/v1/session/destroy/"))
+		json.NewEncoder(resp).Encode(true)
+
+	case strings.HasPrefix(path, "/v1/kv/") && req.Method == http.MethodGet:
+		v, has := s.values[strings.TrimPrefix(path, "/v1/kv/")]
+		if !has {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		resp.Write([]byte(v))
+
+	case strings.HasPrefix(path, "/v1/kv/") && req.Method == http.MethodPut:
+		key := strings.TrimPrefix(path, "/v1/kv/")
+		holder := s.locks[key]
+		if id := req.URL.Query().Get("acquire"); id != "" {
+			if !s.sessions[id] {
+				http.Error(resp, "invalid session", http.StatusInternalServerError)
+				return
+			}
+			ok := holder == "" || holder == id
+			if ok {
+				s.locks[key], s.values[key] = id, string(body)
+			}
+			json.NewEncoder(resp).Encode(ok)
+			return
+		}
+		if id := req.URL.Query().Get("release"); id != "" {
+			ok := holder == id
+			if ok {
+				delete(s.locks, key)
+			}
+			json.NewEncoder(resp).Encode(ok)
+			return
+		}
+		s.values[key] = string(body)
+		json.NewEncoder(resp).Encode(true)
+
+	default:
+		http.Error(resp, "no such path", http.StatusNotFound)
+	}
+}
+
+// invalidate destroys the session and releases its locks, like when its TTL expires
+func (s *fakeAgent) invalidate(id string) {
+	delete(s.sessions, id)
+	for key, holder := range s.locks {
+		if holder == id {
+			delete(s.locks, key)
+		}
+	}
+}
+
+func (s *fakeAgent) holder() string {
+	s.Lock()
+	defer s.Unlock()
+	if _, has := s.locks[DefaultKey]; !has {
+		return ""
+	}
+	return s.values[DefaultKey]
+}
+
+func startFakeAgent() (*fakeAgent, *httptest.Server) {
+	fake := &fakeAgent{
+		sessions: map[string]bool{},
+		values:   map[string]string{},
+		locks:    map[string]string{},
+	}
+	return fake, httptest.NewServer(fake)
+}
+
+func options(server *httptest.Server, id string) Options {
+	return Options{Address: server.URL, Identity: id}
+}
+
+func TestLockAcquireAndExpire(t *testing.T) {
+
+	fake, server := startFakeAgent()
+	defer server.Close()
+
+	lock1 := NewLock(server.Client(), options(server, "instance1"))
+	lock2 := NewLock(server.Client(), options(server, "instance2"))
+
+	held, err := lock1.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.True(t, held)
+	require.Equal(t, "instance1", fake.holder())
+
+	held, err = lock2.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.False(t, held)
+
+	held, err = lock1.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.True(t, held)
+
+	// the session of instance1 expires
+	fake.Lock()
+	fake.invalidate(lock1.session)
+	fake.Unlock()
+
+	held, err = lock2.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.True(t, held)
+	require.Equal(t, "instance2", fake.holder())
+
+	// instance1 gets a new session but not the lock
+	held, err = lock1.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.False(t, held)
+	require.NotEqual(t, "", lock1.session)
+}
+
+func TestLockToken(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Consul-Token") != "secret" {
+			http.Error(resp, "Permission denied", http.StatusForbidden)
+			return
+		}
+		if strings.HasPrefix(req.URL.Path, "/v1/kv/") {
+			json.NewEncoder(resp).Encode(true)
+			return
+		}
+		json.NewEncoder(resp).Encode(map[string]string{"ID": "session-1"})
+	}))
+	defer server.Close()
+
+	held, err := NewLock(server.Client(), options(server, "instance1")).TryAcquireOrRenew()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Permission denied")
+	require.False(t, held)
+
+	withToken := options(server, "instance1")
+	withToken.Token = "secret"
+	held, err = NewLock(server.Client(), withToken).TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.True(t, held)
+}
+
+func TestDetectorStepDown(t *testing.T) {
+
+	fake, server := startFakeAgent()
+	defer server.Close()
+
+	testing_leader.StepDown(t,
+		NewDetector(10*time.Millisecond, server.Client(), options(server, "instance1")),
+		NewDetector(10*time.Millisecond, server.Client(), options(server, "instance2")),
+		fake.holder, "instance2")
+
+	fake.Lock()
+	require.Equal(t, 0, len(fake.sessions))
+	fake.Unlock()
+}
+
+func TestStore(t *testing.T) {
+
+	_, server := startFakeAgent()
+	defer server.Close()
+
+	testing_leader.Store(t, NewStore(server.Client(), options(server, "instance1")))
+}
@@ -0,0 +1,316 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/leader/kubernetes"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/infrakit/pkg/leader"
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/util/kubernetes/api"
+)
+
+var (
+	log    = logutil.New("module", "leader/kubernetes")
+	debugV = logutil.V(1000)
+)
+
+const (
+	// DefaultName is the name of the lease
+	DefaultName = "infrakit-leader"
+
+	// LocationAnnotation is the annotation on the lease that stores the location of the leader
+	LocationAnnotation = "infrakit.docker.com/leader.location"
+
+	// microTimeFormat is the format of the times of a lease
+	microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+	// retries is the number of tries to write a lease when others write it at the same time
+	retries = 3
+)
+
+// Options capture the lease and who holds it
+type Options struct {
+	// Namespace is the namespace of the lease
+	Namespace string
+
+	// Name is the name of the lease
+	Name string
+
+	// Identity is the holder identity of this instance.  It must be unique among the instances.
+	Identity string
+
+	// LeaseDuration is how long the lease is valid without renewal.  It should be a few times the poll interval.
+	LeaseDuration time.Duration
+}
+
+type microTime struct {
+	time.Time
+}
+
+func (t microTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.UTC().Format(microTimeFormat))
+}
+
+func (t *microTime) UnmarshalJSON(buff []byte) error {
+	s := ""
+	if err := json.Unmarshal(buff, &s); err != nil {
+		return err
+	}
+	v, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+	t.Time = v
+	return nil
+}
+
+type leaseSpec struct {
+	HolderIdentity       string     `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int        `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *microTime `json:"acquireTime,omitempty"`
+	RenewTime            *microTime `json:"renewTime,omitempty"`
+	LeaseTransitions     int        `json:"leaseTransitions,omitempty"`
+}
+
+// equal compares the holder and the times.  Time is compared with Equal because the decoded times don't
+// have the same location as the encoded ones.
+func (s leaseSpec) equal(o leaseSpec) bool {
+	sameTime := func(a, b *microTime) bool {
+		if a == nil || b == nil {
+			return a == b
+		}
+		return a.Equal(b.Time)
+	}
+	return s.HolderIdentity == o.HolderIdentity &&
+		s.LeaseDurationSeconds == o.LeaseDurationSeconds &&
+		s.LeaseTransitions == o.LeaseTransitions &&
+		sameTime(s.AcquireTime, o.AcquireTime) &&
+		sameTime(s.RenewTime, o.RenewTime)
+}
+
+type lease struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   api.ObjectMeta `json:"metadata"`
+	Spec       leaseSpec      `json:"spec"`
+}
+
+// Lease is a coordination.k8s.io/v1 Lease that is held by the leader.  It implements leader.Lock.
+type Lease struct {
+	client  *http.Client
+	url     string
+	options Options
+
+	lock         sync.Mutex
+	observed     leaseSpec
+	observedTime time.Time
+	now          func() time.Time
+}
+
+// NewLease returns the lease of the options at the API server of the host.  The http client must be
+// authenticated to the API server.
+func NewLease(host string, client *http.Client, options Options) *Lease {
+	if options.Name == "" {
+		options.Name = DefaultName
+	}
+	if options.Namespace == "" {
+		options.Namespace = "default"
+	}
+	return &Lease{
+		client: client,
+		url: fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases",
+			strings.TrimRight(host, "/"), url.PathEscape(options.Namespace)),
+		options: options,
+		now:     time.Now,
+	}
+}
+
+// NewDetector returns a detector where the instance holding the lease is the leader
+func NewDetector(pollInterval time.Duration, host string, client *http.Client, options Options) *leader.LockDetector {
+	return leader.NewLockDetector(pollInterval, NewLease(host, client, options))
+}
+
+// TryAcquireOrRenew implements leader.Lock.  Another holder's lease is taken to have expired when it's
+// not renewed within its duration as observed by this instance, so the clocks of the instances don't
+// need to agree.
+func (l *Lease) TryAcquireOrRenew() (held bool, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	defer func() {
+		log.Debug("lease", "name", l.options.Name, "identity", l.options.Identity, "held", held, "err", err,
+			"V", debugV)
+	}()
+
+	for i := 0; i < retries; i++ {
+		now := l.now()
+
+		current, err := l.get()
+		if err != nil {
+			return false, err
+		}
+
+		if current == nil {
+			current = &lease{
+				Metadata: api.ObjectMeta{Name: l.options.Name, Namespace: l.options.Namespace},
+			}
+			l.hold(current, now)
+			ok, err := l.write(http.MethodPost, l.url, current)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				continue
+			}
+			l.observed, l.observedTime = current.Spec, now
+			return true, nil
+		}
+
+		if !current.Spec.equal(l.observed) {
+			l.observed, l.observedTime = current.Spec, now
+		}
+
+		holder := current.Spec.HolderIdentity
+		if holder != "" && holder != l.options.Identity && now.Before(l.observedTime.Add(l.duration(current))) {
+			return false, nil
+		}
+
+		l.hold(current, now)
+		ok, err := l.write(http.MethodPut, l.url+"/"+url.PathEscape(l.options.Name), current)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			l.observed, l.observedTime = current.Spec, now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Release implements leader.Lock.  The lease is kept with no holder.
+func (l *Lease) Release() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for i := 0; i < retries; i++ {
+		current, err := l.get()
+		if err != nil || current == nil || current.Spec.HolderIdentity != l.options.Identity {
+			return err
+		}
+
+		now := microTime{l.now()}
+		current.Spec.HolderIdentity = ""
+		current.Spec.LeaseDurationSeconds = 1
+		current.Spec.RenewTime = &now
+		ok, err := l.write(http.MethodPut, l.url+"/"+url.PathEscape(l.options.Name), current)
+		if err != nil || ok {
+			return err
+		}
+	}
+	return fmt.Errorf("cannot release lease %v: conflict", l.options.Name)
+}
+
+// hold sets the lease to be held by this instance
+func (l *Lease) hold(current *lease, now time.Time) {
+	t := microTime{now}
+	if current.Spec.HolderIdentity != l.options.Identity {
+		current.Spec.AcquireTime = &t
+		if current.Spec.HolderIdentity != "" || current.Spec.RenewTime != nil {
+			current.Spec.LeaseTransitions++
+		}
+	}
+	current.Spec.HolderIdentity = l.options.Identity
+	current.Spec.LeaseDurationSeconds = int(l.options.LeaseDuration / time.Second)
+	current.Spec.RenewTime = &t
+}
+
+func (l *Lease) duration(current *lease) time.Duration {
+	if current.Spec.LeaseDurationSeconds > 0 {
+		return time.Duration(current.Spec.LeaseDurationSeconds) * time.Second
+	}
+	return l.options.LeaseDuration
+}
+
+// get returns the lease, nil if not found
+func (l *Lease) get() (*lease, error) {
+	current := &lease{}
+	status, err := api.Do(l.client, http.MethodGet, l.url+"/"+url.PathEscape(l.options.Name), nil, current)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// write creates or updates the lease.  It returns false if the lease exists or was changed since read.
+// The lease is updated with the response.
+func (l *Lease) write(method, u string, current *lease) (bool, error) {
+	current.APIVersion = "coordination.k8s.io/v1"
+	current.Kind = "Lease"
+	status, err := api.Do(l.client, method, u, current, current)
+	if status == http.StatusConflict {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Store stores the location of the leader in an annotation of the lease
+type Store struct {
+	lease *Lease
+}
+
+// NewStore returns a store for registration of leader location
+func NewStore(host string, client *http.Client, options Options) leader.Store {
+	return &Store{lease: NewLease(host, client, options)}
+}
+
+// UpdateLocation writes the location to the lease.
+func (s *Store) UpdateLocation(location *url.URL) error {
+	s.lease.lock.Lock()
+	defer s.lease.lock.Unlock()
+
+	for i := 0; i < retries; i++ {
+		current, err := s.lease.get()
+		if err != nil {
+			return err
+		}
+		method, u := http.MethodPut, s.lease.url+"/"+url.PathEscape(s.lease.options.Name)
+		if current == nil {
+			method, u = http.MethodPost, s.lease.url
+			current = &lease{
+				Metadata: api.ObjectMeta{Name: s.lease.options.Name, Namespace: s.lease.options.Namespace},
+			}
+		}
+		if current.Metadata.Annotations == nil {
+			current.Metadata.Annotations = map[string]string{}
+		}
+		current.Metadata.Annotations[LocationAnnotation] = location.String()
+		ok, err := s.lease.write(method, u, current)
+		if err != nil || ok {
+			return err
+		}
+	}
+	return fmt.Errorf("cannot update location of lease %v: conflict", s.lease.options.Name)
+}
+
+// GetLocation returns the location of the leader
+func (s *Store) GetLocation() (*url.URL, error) {
+	current, err := s.lease.get()
+	if err != nil || current == nil {
+		return nil, err
+	}
+	l, has := current.Metadata.Annotations[LocationAnnotation]
+	if !has {
+		return nil, nil
+	}
+	log.Debug("leader location", "location", l, "V", debugV)
+	return url.Parse(l)
+}
@@ -0,0 +1,185 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/leader/kubernetes"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	testing_leader "github.com/docker/infrakit/pkg/testing/leader"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPIServer serves the leases of a namespace with optimistic concurrency like the API server
+type fakeAPIServer struct {
+	sync.Mutex
+	version int
+	leases  map[string]lease
+}
+
+func (s *fakeAPIServer) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	prefix := "/apis/coordination.k8s.io/v1/namespaces/test/leases"
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		s.status(resp, http.StatusNotFound, "no such path")
+		return
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, prefix), "/")
+
+	in := lease{}
+	if req.Method != http.MethodGet {
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			s.status(resp, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	current, has := s.leases[name]
+	switch req.Method {
+	case http.MethodGet:
+		if !has {
+			s.status(resp, http.StatusNotFound, "not found")
+			return
+		}
+		json.NewEncoder(resp).Encode(current)
+		return
+	case http.MethodPost:
+		if _, has := s.leases[in.Metadata.Name]; has {
+			s.status(resp, http.StatusConflict, "already exists")
+			return
+		}
+	case http.MethodPut:
+		if !has {
+			s.status(resp, http.StatusNotFound, "not found")
+			return
+		}
+		if in.Metadata.ResourceVersion != current.Metadata.ResourceVersion {
+			s.status(resp, http.StatusConflict, "the object has been modified")
+			return
+		}
+	}
+	s.version++
+	in.Metadata.ResourceVersion = fmt.Sprintf("%d", s.version)
+	s.leases[in.Metadata.Name] = in
+	json.NewEncoder(resp).Encode(in)
+}
+
+func (s *fakeAPIServer) status(resp http.ResponseWriter, code int, message string) {
+	resp.WriteHeader(code)
+	json.NewEncoder(resp).Encode(map[string]interface{}{"kind": "Status", "code": code, "message": message})
+}
+
+func (s *fakeAPIServer) holder(name string) string {
+	s.Lock()
+	defer s.Unlock()
+	return s.leases[name].Spec.HolderIdentity
+}
+
+func startFakeAPIServer() (*fakeAPIServer, *httptest.Server) {
+	fake := &fakeAPIServer{leases: map[string]lease{}}
+	return fake, httptest.NewServer(fake)
+}
+
+func options(id string) Options {
+	return Options{Namespace: "test", Identity: id, LeaseDuration: 10 * time.Second}
+}
+
+func TestLeaseAcquireAndExpire(t *testing.T) {
+
+	fake, server := startFakeAPIServer()
+	defer server.Close()
+
+	now := time.Now()
+	lease1 := NewLease(server.URL, server.Client(), options("instance1"))
+	lease1.now = func() time.Time { return now }
+	lease2 := NewLease(server.URL, server.Client(), options("instance2"))
+	lease2.now = func() time.Time { return now }
+
+	held, err := lease1.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.True(t, held)
+	require.Equal(t, "instance1", fake.holder(DefaultName))
+
+	held, err = lease2.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.False(t, held)
+
+	// renewal keeps the lease
+	now = now.Add(8 * time.Second)
+	held, err = lease1.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.True(t, held)
+
+	held, err = lease2.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.False(t, held)
+
+	// instance1 stops renewing and the lease expires as observed by instance2
+	now = now.Add(11 * time.Second)
+	held, err = lease2.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.True(t, held)
+	require.Equal(t, "instance2", fake.holder(DefaultName))
+
+	held, err = lease1.TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.False(t, held)
+
+	fake.Lock()
+	require.Equal(t, 1, fake.leases[DefaultName].Spec.LeaseTransitions)
+	fake.Unlock()
+}
+
+func TestLeaseAPIError(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(resp).Encode(map[string]interface{}{"kind": "Status", "message": "leases is forbidden"})
+	}))
+	defer server.Close()
+
+	held, err := NewLease(server.URL, server.Client(), options("instance1")).TryAcquireOrRenew()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "leases is forbidden")
+	require.False(t, held)
+}
+
+func TestDetectorStepDown(t *testing.T) {
+
+	fake, server := startFakeAPIServer()
+	defer server.Close()
+
+	testing_leader.StepDown(t,
+		NewDetector(10*time.Millisecond, server.URL, server.Client(), options("instance1")),
+		NewDetector(10*time.Millisecond, server.URL, server.Client(), options("instance2")),
+		func() string { return fake.holder(DefaultName) }, "instance2")
+}
+
+func TestStore(t *testing.T) {
+
+	fake, server := startFakeAPIServer()
+	defer server.Close()
+
+	store := NewStore(server.URL, server.Client(), options("instance1"))
+	testing_leader.Store(t, store)
+
+	u, err := store.GetLocation()
+	require.NoError(t, err)
+	require.NotNil(t, u)
+
+	// the holder renewing the lease keeps the location
+	held, err := NewLease(server.URL, server.Client(), options("instance1")).TryAcquireOrRenew()
+	require.NoError(t, err)
+	require.True(t, held)
+	require.Equal(t, "instance1", fake.holder(DefaultName))
+
+	after, err := store.GetLocation()
+	require.NoError(t, err)
+	require.Equal(t, u, after)
+}
@@ -0,0 +1,64 @@
+package leader // import "github.com/docker/infrakit/pkg/leader"
+
+import (
+	"sync"
+	"time"
+)
+
+// Lock is implemented by backends where the leader holds a lock, like a lease, that expires unless renewed.
+type Lock interface {
+
+	// TryAcquireOrRenew acquires the lock if free or expired, or renews it if already held.  It returns
+	// true if the lock is held after the call.
+	TryAcquireOrRenew() (bool, error)
+
+	// Release gives up the lock if held so another instance can take over without waiting for it to expire.
+	Release() error
+}
+
+// LockDetector is a detector that acquires or renews a lock on every poll.  The instance holding the lock is
+// the leader.  Stopping the detector releases the lock.
+type LockDetector struct {
+	*Poller
+
+	lock    Lock
+	mutex   sync.Mutex
+	stopped bool
+}
+
+// NewLockDetector returns a detector that polls the lock at the interval
+func NewLockDetector(pollInterval time.Duration, lock Lock) *LockDetector {
+	d := &LockDetector{lock: lock}
+	d.Poller = NewPoller(pollInterval, d.check)
+	return d
+}
+
+func (d *LockDetector) check() (bool, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.stopped {
+		return false, nil
+	}
+	return d.lock.TryAcquireOrRenew()
+}
+
+// Start implements Detector.Start
+func (d *LockDetector) Start() (<-chan Leadership, error) {
+	d.mutex.Lock()
+	d.stopped = false
+	d.mutex.Unlock()
+	return d.Poller.Start()
+}
+
+// Stop implements Detector.Stop.  It steps down by releasing the lock.  A poll that is in flight when
+// stopped will not acquire the lock again.
+func (d *LockDetector) Stop() {
+	d.Poller.Stop()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.stopped = true
+	if err := d.lock.Release(); err != nil {
+		log.Warn("Cannot release lock", "err", err)
+	}
+}
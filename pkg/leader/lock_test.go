@@ -0,0 +1,61 @@
+package leader // import "github.com/docker/infrakit/pkg/leader"
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLock struct {
+	sync.Mutex
+	holder   string
+	id       string
+	released int
+}
+
+func (l *fakeLock) TryAcquireOrRenew() (bool, error) {
+	l.Lock()
+	defer l.Unlock()
+	if l.holder == "" {
+		l.holder = l.id
+	}
+	return l.holder == l.id, nil
+}
+
+func (l *fakeLock) Release() error {
+	l.Lock()
+	defer l.Unlock()
+	if l.holder == l.id {
+		l.holder = ""
+	}
+	l.released++
+	return nil
+}
+
+func TestLockDetector(t *testing.T) {
+
+	lock := &fakeLock{id: "instance1"}
+	detector := NewLockDetector(10*time.Millisecond, lock)
+
+	events, err := detector.Start()
+	require.NoError(t, err)
+
+	event := <-events
+	require.Equal(t, Leader, event.Status)
+
+	detector.Stop()
+
+	for range events {
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, "", lock.holder)
+	require.Equal(t, 1, lock.released)
+
+	ok, err := detector.check()
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,45 @@
+package raft // import "github.com/docker/infrakit/pkg/leader/raft"
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/docker/infrakit/pkg/leader"
+	"github.com/docker/infrakit/pkg/util/raft"
+)
+
+const (
+	// DefaultKey is the key used to persist the location
+	DefaultKey = "infrakit/leader/location"
+)
+
+// NewDetector returns a detector of the leadership of the node in its group.
+func NewDetector(pollInterval time.Duration, node *raft.Node) *leader.Poller {
+	return leader.NewPoller(pollInterval, func() (bool, error) {
+		return node.IsLeader(), nil
+	})
+}
+
+// Store keeps the location of the leader in the key space of the node
+type Store struct {
+	node *raft.Node
+}
+
+// NewStore returns a store for registration of leader location
+func NewStore(node *raft.Node) leader.Store {
+	return &Store{node: node}
+}
+
+// UpdateLocation writes the location.  It's written by the leader.
+func (s Store) UpdateLocation(location *url.URL) error {
+	return s.node.Put(DefaultKey, []byte(location.String()))
+}
+
+// GetLocation returns the location of the leader
+func (s Store) GetLocation() (*url.URL, error) {
+	v, has := s.node.Get(DefaultKey)
+	if !has {
+		return nil, nil
+	}
+	return url.Parse(string(v))
+}
@@ -0,0 +1,97 @@
+package raft // import "github.com/docker/infrakit/pkg/leader/raft"
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/leader"
+	"github.com/docker/infrakit/pkg/util/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func waitFor(t *testing.T, events <-chan leader.Leadership, status leader.Status) {
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Status == status {
+				return
+			}
+		case <-deadline:
+			require.FailNow(t, "timed out")
+		}
+	}
+}
+
+func TestDetectorWithThreeNodes(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "raft")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	nodes := []*raft.Node{}
+	detectors := []*leader.Poller{}
+	events := []<-chan leader.Leadership{}
+	for i, id := range []string{"n1", "n2", "n3"} {
+		node, err := raft.NewNode(raft.Options{
+			ID:        id,
+			Dir:       filepath.Join(dir, id),
+			Listen:    "127.0.0.1:0",
+			Bootstrap: i == 0,
+			Timeout:   100 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		nodes = append(nodes, node)
+
+		detector := NewDetector(20*time.Millisecond, node)
+		ch, err := detector.Start()
+		require.NoError(t, err)
+		detectors = append(detectors, detector)
+		events = append(events, ch)
+	}
+
+	// n1 is the leader of the group it bootstrapped, and adds the others
+	waitFor(t, events[0], leader.Leader)
+	require.NoError(t, nodes[0].Join("n2", nodes[1].Address()))
+	require.NoError(t, nodes[0].Join("n3", nodes[2].Address()))
+	waitFor(t, events[1], leader.NotLeader)
+	waitFor(t, events[2], leader.NotLeader)
+
+	location, err := url.Parse("tcp://n1:24864")
+	require.NoError(t, err)
+	require.NoError(t, NewStore(nodes[0]).UpdateLocation(location))
+
+	// one of the others takes over when the leader stops
+	detectors[0].Stop()
+	require.NoError(t, nodes[0].Close())
+
+	next := 0
+	deadline := time.After(10 * time.Second)
+	for next == 0 {
+		select {
+		case e := <-events[1]:
+			if e.Status == leader.Leader {
+				next = 1
+			}
+		case e := <-events[2]:
+			if e.Status == leader.Leader {
+				next = 2
+			}
+		case <-deadline:
+			require.FailNow(t, "no leader")
+		}
+	}
+
+	found, err := NewStore(nodes[next]).GetLocation()
+	require.NoError(t, err)
+	require.Equal(t, location, found)
+
+	for _, i := range []int{1, 2} {
+		detectors[i].Stop()
+		require.NoError(t, nodes[i].Close())
+	}
+}
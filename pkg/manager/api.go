@@ -62,6 +62,15 @@ type Options struct {
 	// MetadataStore persists var information
 	MetadataStore store.Snapshot `json:"-" yaml:"-"`
 
+	// HistoryStore persists the revisions of the specs.  If not set, the revisions are kept in memory.
+	HistoryStore store.Snapshot `json:"-" yaml:"-"`
+
+	// HistoryLimit is how many revisions to keep.  The default is 50.
+	HistoryLimit int
+
+	// Quotas limit the groups of the namespaces, by namespace.  The default namespace is "".
+	Quotas map[string]Quota
+
 	// LeaderCommitSpecsRetries is how many times to retry commit specs when becomes leader
 	LeaderCommitSpecsRetries int
 
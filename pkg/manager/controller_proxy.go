@@ -1,6 +1,8 @@
 package manager // import "github.com/docker/infrakit/pkg/manager"
 
 import (
+	"fmt"
+
 	"github.com/docker/infrakit/pkg/controller/group"
 	"github.com/docker/infrakit/pkg/plugin"
 	"github.com/docker/infrakit/pkg/spi/controller"
@@ -59,7 +61,7 @@ func (m *manager) updateSpec(spec types.Spec, handler plugin.Name) error {
 	defer log.Debug("Saved snapshot", "global", stored, "spec", spec)
 
 	stored.updateSpec(spec, handler)
-	return stored.store(m.Options.SpecStore)
+	return m.saveSpecs(stored, fmt.Sprintf("commit %v/%v", spec.Kind, spec.Metadata.Name))
 }
 
 func (m *manager) removeSpec(spec types.Spec) error {
@@ -78,7 +80,7 @@ func (m *manager) removeSpec(spec types.Spec) error {
 	defer log.Debug("Saved snapshot", "global", stored, "spec", spec)
 
 	stored.removeSpec(spec.Kind, spec.Metadata)
-	return stored.store(m.Options.SpecStore)
+	return m.saveSpecs(stored, fmt.Sprintf("destroy %v/%v", spec.Kind, spec.Metadata.Name))
 }
 
 type controllerAdapter struct {
@@ -127,7 +129,7 @@ func (m controllerAdapter) Commit(op controller.Operation, spec types.Spec) (obj
 	retry := false
 	<-m.manager.queue("commit",
 		func() (bool, error) {
-			object, err = m.backend.Commit(op, spec)
+			object, err = m.backend.Commit(op, m.manager.tagged(spec))
 			return retry, err
 		})
 	return
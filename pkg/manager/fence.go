@@ -0,0 +1,61 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"sync/atomic"
+
+	"github.com/docker/infrakit/pkg/store"
+)
+
+// fencingToken returns the fencing token of the current term of leadership, 0 if none
+func (m *manager) fencingToken() store.Token {
+	return store.Token(atomic.LoadUint64(&m.token))
+}
+
+// startTerm starts a term of leadership with a token greater than the tokens of all past terms.  The stores
+// are fenced with the token, so they reject the writes of the past leaders that haven't noticed yet that they
+// lost leadership.  It fails if another manager started a term since the tokens were read, and the token
+// of this manager is left as is, which is stale.  If none of the stores are fenced, there's no token.  The
+// manager reports that it's the leader only once the term has started.
+func (m *manager) startTerm() error {
+	stores := []store.Snapshot{}
+	for _, s := range []store.Snapshot{m.Options.SpecStore, m.Options.HistoryStore, m.Options.MetadataStore} {
+		if s != nil && store.IsFenced(s) {
+			stores = append(stores, s)
+		}
+	}
+	if len(stores) == 0 {
+		m.setTerm()
+		return nil
+	}
+
+	token := store.Token(0)
+	for _, s := range stores {
+		current, err := store.CurrentToken(s)
+		if err != nil {
+			return err
+		}
+		if current > token {
+			token = current
+		}
+	}
+	token++
+
+	// the spec store first, so another manager starting a term with the same token fails before fencing
+	// the other stores
+	for _, s := range stores {
+		if err := store.Fence(s, token); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&m.token, uint64(token))
+	m.setTerm()
+	log.Info("Started term of leadership", "token", token)
+	return nil
+}
+
+// setTerm records that the term has started, unless the leadership was lost meanwhile
+func (m *manager) setTerm() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.term = m.isLeader
+}
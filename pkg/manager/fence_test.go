@@ -0,0 +1,254 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	rpc_controller "github.com/docker/infrakit/pkg/rpc/controller"
+	"github.com/docker/infrakit/pkg/rpc/server"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/store/file"
+	testing_controller "github.com/docker/infrakit/pkg/testing/controller"
+	testing_group "github.com/docker/infrakit/pkg/testing/group"
+	testing_leader "github.com/docker/infrakit/pkg/testing/leader"
+	testing_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func waitFor(t *testing.T, check func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !check() {
+		if time.Now().After(deadline) {
+			require.FailNow(t, "timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func testFencedManager(t *testing.T, dir, id string, partitions *testing_leader.Partitions) *manager {
+	detector, err := partitions.Detector(id, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	// the managers share the stores, each with its own snapshot of the files
+	specs, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	controllers, err := rpc_controller.NewClient(plugin.Name("ingress"), filepath.Join(dir, "ingress"))
+	require.NoError(t, err)
+	groups := &testing_group.Plugin{
+		DoInspectGroups: func() ([]group.Spec, error) { return nil, nil },
+	}
+	scope := testing_scope.DefaultScope()
+	scope.ResolveController = func(n string) (controller.Controller, error) { return controllers, nil }
+	scope.ResolveGroup = func(n string) (group.Plugin, error) { return groups, nil }
+
+	return NewManager(scope, Options{
+		Name:        plugin.Name("group"),
+		Group:       plugin.Name("group-stateless"),
+		Controllers: []plugin.Name{"ingress"},
+		Leader:      detector,
+		SpecStore:   specs,
+	}).(*manager)
+}
+
+func TestFencingAfterPartition(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "fencing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the controller that both managers commit to
+	var lock sync.Mutex
+	committed := []string{}
+	tagged := []string{}
+	ingress := &testing_controller.Controller{
+		DoCommit: func(op controller.Operation, spec types.Spec) (types.Object, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			if _, has := spec.Metadata.Tags[controller.TokenTag]; has {
+				tagged = append(tagged, spec.Metadata.Name)
+			}
+			committed = append(committed, spec.Metadata.Name)
+			return types.Object{Spec: spec}, nil
+		},
+	}
+	st, err := server.StartPluginAtPath(filepath.Join(dir, "ingress"), rpc_controller.Server(ingress))
+	require.NoError(t, err)
+	defer st.Stop()
+
+	partitions := testing_leader.NewPartitions(dir)
+	require.NoError(t, partitions.Elect("m1"))
+
+	m1 := testFencedManager(t, dir, "m1", partitions)
+	m2 := testFencedManager(t, dir, "m2", partitions)
+
+	_, err = m1.Start()
+	require.NoError(t, err)
+	defer m1.Stop()
+	_, err = m2.Start()
+	require.NoError(t, err)
+	defer m2.Stop()
+
+	waitFor(t, func() bool { return m1.fencingToken() == 1 })
+	is, err := m2.IsLeader()
+	require.NoError(t, err)
+	require.False(t, is)
+
+	spec := func(name string) types.Spec {
+		return types.Spec{Kind: "ingress", Version: "Controller/v0.1.0", Metadata: types.Metadata{Name: name}}
+	}
+
+	c1, err := m1.Controllers()
+	require.NoError(t, err)
+	_, err = c1["ingress"].Commit(controller.Enforce, spec("lb1"))
+	require.NoError(t, err)
+
+	// m1 is cut off and m2 is elected.  m1 doesn't know and keeps acting as the leader.
+	partitions.Partition("m1")
+	require.NoError(t, partitions.Elect("m2"))
+
+	waitFor(t, func() bool { return m2.fencingToken() == 2 })
+	is, err = m1.IsLeader()
+	require.NoError(t, err)
+	require.True(t, is)
+
+	// the writes of m1 to the store are rejected
+	_, err = c1["ingress"].Commit(controller.Enforce, spec("lb2"))
+	require.Error(t, err)
+	require.True(t, store.IsStaleToken(err))
+
+	// the commits of m1 to the controller too, once the controller has seen a commit of m2
+	c2, err := m2.Controllers()
+	require.NoError(t, err)
+	_, err = c2["ingress"].Commit(controller.Enforce, spec("lb3"))
+	require.NoError(t, err)
+
+	ingressClient, err := rpc_controller.NewClient(plugin.Name("ingress"), filepath.Join(dir, "ingress"))
+	require.NoError(t, err)
+	_, err = ingressClient.Commit(controller.Enforce, controller.WithToken(spec("lb4"), m1.fencingToken()))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "stale fencing token 1, current is 2")
+
+	specs, err := m2.Specs()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(specs))
+	for _, s := range specs {
+		require.NotEqual(t, "lb2", s.Metadata.Name)
+	}
+
+	lock.Lock()
+	require.Empty(t, tagged) // the fence strips the tag before the commit
+	require.NotContains(t, committed, "lb2")
+	require.NotContains(t, committed, "lb4")
+	require.Contains(t, committed, "lb3")
+	lock.Unlock()
+
+	// healed, m1 sees that it's not the leader
+	require.NoError(t, partitions.Heal("m1"))
+	waitFor(t, func() bool {
+		is, err := m1.IsLeader()
+		return err == nil && !is
+	})
+}
+
+// flakyFence fails to fence the first times
+type flakyFence struct {
+	store.Fenced
+	lock  sync.Mutex
+	fails int
+}
+
+func (f *flakyFence) Fence(token store.Token) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.fails > 0 {
+		f.fails--
+		return store.ErrStaleToken{Token: token, Current: token}
+	}
+	return f.Fenced.Fence(token)
+}
+
+func TestStartTermRetry(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "fencing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	st, err := server.StartPluginAtPath(filepath.Join(dir, "ingress"), rpc_controller.Server(&testing_controller.Controller{}))
+	require.NoError(t, err)
+	defer st.Stop()
+
+	partitions := testing_leader.NewPartitions(dir)
+	require.NoError(t, partitions.Elect("m1"))
+
+	m := testFencedManager(t, dir, "m1", partitions)
+
+	specs, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	flaky := &flakyFence{Fenced: specs.(store.Fenced), fails: 1}
+	m.Options.SpecStore = store.WithToken(flaky, m.fencingToken)
+
+	_, err = m.Start()
+	require.NoError(t, err)
+	defer m.Stop()
+
+	// elected, but not the leader until the term starts
+	waitFor(t, func() bool {
+		flaky.lock.Lock()
+		defer flaky.lock.Unlock()
+		return flaky.fails == 0
+	})
+	is, err := m.IsLeader()
+	require.NoError(t, err)
+	require.False(t, is)
+
+	// the term starts on retry
+	waitFor(t, func() bool {
+		is, err := m.IsLeader()
+		return err == nil && is
+	})
+	require.Equal(t, store.Token(1), m.fencingToken())
+}
+
+// fencedGroups records the tokens of the groups committed and destroyed
+type fencedGroups struct {
+	*testing_group.Plugin
+	tokens []store.Token
+}
+
+func (f *fencedGroups) CommitGroupWithToken(grp group.Spec, pretend bool, token store.Token) (string, error) {
+	f.tokens = append(f.tokens, token)
+	return f.CommitGroup(grp, pretend)
+}
+
+func (f *fencedGroups) DestroyGroupWithToken(id group.ID, token store.Token) error {
+	f.tokens = append(f.tokens, token)
+	return f.DestroyGroup(id)
+}
+
+func TestDestroyGroupFenced(t *testing.T) {
+
+	destroyed := []group.ID{}
+	groups := &fencedGroups{Plugin: &testing_group.Plugin{
+		DoDestroyGroup: func(id group.ID) error {
+			destroyed = append(destroyed, id)
+			return nil
+		},
+	}}
+	scope := testing_scope.DefaultScope()
+	scope.ResolveGroup = func(n string) (group.Plugin, error) { return groups, nil }
+
+	m := &manager{scope: scope, token: 3}
+	require.NoError(t, m.destroy(key{Namespace: "team-a", Kind: "group", Name: "workers"},
+		record{Handler: plugin.Name("group")}))
+	require.Equal(t, []group.ID{"team-a::workers"}, destroyed)
+	require.Equal(t, []store.Token{3}, groups.tokens)
+}
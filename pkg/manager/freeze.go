@@ -0,0 +1,131 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"time"
+
+	"github.com/docker/infrakit/pkg/manager/freeze"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/stack"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+const (
+	// TopicFreeze is the topic of the events of freezing and unfreezing the manager
+	TopicFreeze = "freeze"
+
+	// EventFrozen is the type of the event when the manager is frozen.  The data is the stack.Freeze.
+	EventFrozen = event.Type("Frozen")
+
+	// EventUnfrozen is the type of the event when the manager is unfrozen.  The data is the stack.Freeze
+	// that's lifted.
+	EventUnfrozen = event.Type("Unfrozen")
+)
+
+// Freeze stops all automated changes to the infrastructure until unfrozen.  The freeze is stored with the
+// specs so that it holds across restarts and changes of leadership.
+func (m *manager) Freeze(reason string) error {
+	f := &stack.Freeze{
+		Reason: reason,
+		Author: author(),
+		Time:   time.Now(),
+	}
+	config, err := m.setFreeze(f)
+	if err != nil {
+		return err
+	}
+	log.Warn("Frozen", "reason", reason, "author", f.Author)
+	m.publish(event.Event{Type: EventFrozen, ID: TopicFreeze, Message: reason}.Init().
+		WithTopic(TopicFreeze).WithDataMust(f))
+	return m.doCommitAll(config)
+}
+
+// Unfreeze resumes the automated changes to the infrastructure
+func (m *manager) Unfreeze(reason string) error {
+	lifted := m.currentFreeze()
+	config, err := m.setFreeze(nil)
+	if err != nil {
+		return err
+	}
+	log.Warn("Unfrozen", "reason", reason, "author", author())
+	e := event.Event{Type: EventUnfrozen, ID: TopicFreeze, Message: reason}.Init().WithTopic(TopicFreeze)
+	if lifted != nil {
+		e = e.WithDataMust(lifted)
+	}
+	m.publish(e)
+	return m.doCommitAll(config)
+}
+
+// setFreeze stores the freeze with the specs and returns the specs.  The specs are committed again by the
+// caller, so the plugins in other processes receive the freeze.
+func (m *manager) setFreeze(f *stack.Freeze) (globalSpec, error) {
+	stored := globalSpec{}
+	if is, err := m.IsLeader(); err != nil || !is {
+		return stored, errNotLeader
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if err := stored.load(m.Options.SpecStore); err != nil {
+		return stored, err
+	}
+	stored.freeze = f
+	if err := stored.store(m.Options.SpecStore); err != nil {
+		return stored, err
+	}
+	m.frozen = f
+	freeze.Set(f)
+	return stored, nil
+}
+
+// currentFreeze returns the freeze of the manager, nil if not frozen.  It's the freeze stored with the specs, which
+// the manager sends to the plugins, and not the freeze received by the plugins in the process of the manager.
+func (m *manager) currentFreeze() *stack.Freeze {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.frozen
+}
+
+// tagged returns the spec tagged with the fencing token and the freeze of the manager, for the controllers
+func (m *manager) tagged(spec types.Spec) types.Spec {
+	return controller.WithToken(freeze.With(spec, m.currentFreeze()), m.fencingToken())
+}
+
+// commitGroup commits the group with the fencing token and the freeze of the manager
+func (m *manager) commitGroup(p group.Plugin, grp group.Spec, pretend bool) (string, error) {
+	return group.CommitWithFreeze(p, grp, pretend, m.fencingToken(), freeze.Encode(m.currentFreeze()))
+}
+
+// freezeObject returns the object of the freeze in Inspect
+func freezeObject(f *stack.Freeze) types.Object {
+	return types.Object{
+		Spec: types.Spec{
+			Kind:     stack.FreezeKind,
+			Metadata: types.Metadata{Name: stack.FreezeKind},
+		},
+		State: types.AnyValueMust(f),
+	}
+}
+
+// PublishOn implements event.Publisher
+func (m *manager) PublishOn(c chan<- *event.Event) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.events = c
+}
+
+func (m *manager) publish(e *event.Event) {
+	m.lock.RLock()
+	events := m.events
+	m.lock.RUnlock()
+	if events == nil {
+		return
+	}
+	select {
+	case events <- e:
+	case <-time.After(1 * time.Second):
+		log.Warn("Dropped event", "topic", e.Topic, "type", e.Type)
+	}
+}
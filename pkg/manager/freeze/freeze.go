@@ -0,0 +1,111 @@
+// Package freeze is the freeze of the manager as seen by the groups and controllers in a process.  While
+// frozen, the groups and controllers observe the infrastructure and report drift, but do not provision or
+// destroy.  The manager sets the freeze from its specs when it becomes the leader, and as it's frozen and
+// unfrozen.  The freeze is sent with the commits of the manager to the plugins, which receive it, so that
+// the groups and controllers in their processes observe it too.
+package freeze // import "github.com/docker/infrakit/pkg/manager/freeze"
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/docker/infrakit/pkg/spi/stack"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// Tag is the tag of the specs committed by the manager with its freeze.  The value is as encoded by Encode.
+const Tag = "infrakit.freeze"
+
+var (
+	current *stack.Freeze
+	lock    sync.RWMutex
+)
+
+// ErrFrozen is the error of a change refused because of the freeze
+type ErrFrozen stack.Freeze
+
+func (e ErrFrozen) Error() string {
+	return fmt.Sprintf("frozen: %s", e.Reason)
+}
+
+// Set sets the freeze, or clears it if nil
+func Set(f *stack.Freeze) {
+	lock.Lock()
+	defer lock.Unlock()
+	if f == nil {
+		current = nil
+		return
+	}
+	copy := *f
+	current = &copy
+}
+
+// Get returns the freeze, or nil if not frozen
+func Get() *stack.Freeze {
+	lock.RLock()
+	defer lock.RUnlock()
+	if current == nil {
+		return nil
+	}
+	copy := *current
+	return &copy
+}
+
+// Check returns ErrFrozen if frozen, or nil otherwise
+func Check() error {
+	if f := Get(); f != nil {
+		return ErrFrozen(*f)
+	}
+	return nil
+}
+
+// Encode returns the freeze in JSON, or null if not frozen
+func Encode(f *stack.Freeze) string {
+	buff, err := json.Marshal(f)
+	if err != nil {
+		return "null"
+	}
+	return string(buff)
+}
+
+// Receive sets the freeze from the freeze encoded by the manager.  The freeze is left as is if empty, e.g. for
+// the commits that are not from the manager.
+func Receive(encoded string) error {
+	if encoded == "" {
+		return nil
+	}
+	var f *stack.Freeze
+	if err := json.Unmarshal([]byte(encoded), &f); err != nil {
+		return err
+	}
+	Set(f)
+	return nil
+}
+
+// With returns a copy of the spec tagged with the freeze
+func With(spec types.Spec, f *stack.Freeze) types.Spec {
+	tags := map[string]string{}
+	for k, v := range spec.Metadata.Tags {
+		tags[k] = v
+	}
+	tags[Tag] = Encode(f)
+	spec.Metadata.Tags = tags
+	return spec
+}
+
+// ReceiveSpec sets the freeze from the tag of the spec, if tagged, and returns the spec without the tag.
+func ReceiveSpec(spec types.Spec) (types.Spec, error) {
+	encoded, has := spec.Metadata.Tags[Tag]
+	if !has {
+		return spec, nil
+	}
+	tags := map[string]string{}
+	for k, v := range spec.Metadata.Tags {
+		if k != Tag {
+			tags[k] = v
+		}
+	}
+	spec.Metadata.Tags = tags
+	return spec, Receive(encoded)
+}
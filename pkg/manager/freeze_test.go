@@ -0,0 +1,184 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/manager/freeze"
+	"github.com/docker/infrakit/pkg/plugin"
+	rpc_controller "github.com/docker/infrakit/pkg/rpc/controller"
+	rpc_group "github.com/docker/infrakit/pkg/rpc/group"
+	"github.com/docker/infrakit/pkg/rpc/server"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/stack"
+	"github.com/docker/infrakit/pkg/store/file"
+	testing_controller "github.com/docker/infrakit/pkg/testing/controller"
+	testing_group "github.com/docker/infrakit/pkg/testing/group"
+	testing_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeze(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "freeze")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	destroyed := []string{}
+	m := testNamespaceManager(t, dir, &destroyed)
+	defer close(m.backendOps)
+	defer freeze.Set(nil)
+
+	events := make(chan *event.Event, 10)
+	m.PublishOn(events)
+
+	_, err = m.CommitGroup(groupSpec("workers", 1), false)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Freeze("incident 42"))
+	require.Error(t, freeze.Check())
+	require.Equal(t, "incident 42", freeze.Get().Reason)
+
+	frozen := <-events
+	require.Equal(t, TopicFreeze, frozen.Topic.String())
+	require.Equal(t, EventFrozen, frozen.Type)
+	require.Equal(t, "incident 42", frozen.Message)
+
+	// the freeze is stored with the specs, but is not a spec nor a revision
+	stored := globalSpec{}
+	require.NoError(t, stored.load(m.Options.SpecStore))
+	require.NotNil(t, stored.freeze)
+	require.Equal(t, "incident 42", stored.freeze.Reason)
+	require.Equal(t, 1, len(stored.index))
+
+	specs, err := m.Specs()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(specs))
+
+	revisions, err := m.History()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(revisions))
+
+	objects, err := m.Inspect()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(objects))
+	require.Equal(t, stack.FreezeKind, objects[1].Kind)
+	state := stack.Freeze{}
+	require.NoError(t, objects[1].State.Decode(&state))
+	require.Equal(t, "incident 42", state.Reason)
+
+	// rollbacks don't lift the freeze
+	require.NoError(t, m.Rollback(1))
+	require.NoError(t, stored.load(m.Options.SpecStore))
+	require.NotNil(t, stored.freeze)
+
+	// the leader loads the freeze with the specs
+	freeze.Set(nil)
+	require.NoError(t, m.loadAndCommitSpecs())
+	require.NotNil(t, freeze.Get())
+
+	require.NoError(t, m.Unfreeze("resolved"))
+	require.NoError(t, freeze.Check())
+
+	unfrozen := <-events
+	require.Equal(t, EventUnfrozen, unfrozen.Type)
+	require.Equal(t, "resolved", unfrozen.Message)
+	lifted := stack.Freeze{}
+	require.NoError(t, unfrozen.Data.Decode(&lifted))
+	require.Equal(t, "incident 42", lifted.Reason)
+
+	require.NoError(t, stored.load(m.Options.SpecStore))
+	require.Nil(t, stored.freeze)
+
+	objects, err = m.Inspect()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(objects))
+}
+
+func TestFreezeSentToPlugins(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "freeze")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer freeze.Set(nil)
+
+	// the controller and the groups are plugins in other processes, behind their rpc servers
+	controlled := make(chan error, 10)
+	ingress := &testing_controller.Controller{
+		DoCommit: func(op controller.Operation, spec types.Spec) (types.Object, error) {
+			controlled <- freeze.Check()
+			return types.Object{Spec: spec}, nil
+		},
+	}
+	grouped := make(chan error, 10)
+	groups := &testing_group.Plugin{
+		DoCommitGroup: func(spec group.Spec, pretend bool) (string, error) {
+			grouped <- freeze.Check()
+			return "", nil
+		},
+	}
+	cs, err := server.StartPluginAtPath(filepath.Join(dir, "ingress"), rpc_controller.Server(ingress))
+	require.NoError(t, err)
+	defer cs.Stop()
+	gs, err := server.StartPluginAtPath(filepath.Join(dir, "group-stateless"), rpc_group.PluginServer(groups))
+	require.NoError(t, err)
+	defer gs.Stop()
+
+	controllerClient, err := rpc_controller.NewClient(plugin.Name("ingress"), filepath.Join(dir, "ingress"))
+	require.NoError(t, err)
+	groupClient, err := rpc_group.NewClient(plugin.Name("group-stateless"), filepath.Join(dir, "group-stateless"))
+	require.NoError(t, err)
+
+	scope := testing_scope.DefaultScope()
+	scope.ResolveController = func(n string) (controller.Controller, error) { return controllerClient, nil }
+	scope.ResolveGroup = func(n string) (group.Plugin, error) { return groupClient, nil }
+
+	specs, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	ops := make(chan backendOp, 10)
+	defer close(ops)
+	go func() {
+		for op := range ops {
+			op.operation()
+		}
+	}()
+	m := &manager{
+		scope:  scope,
+		Plugin: groupClient,
+		Options: Options{
+			Group:     plugin.Name("group-stateless"),
+			SpecStore: specs,
+		},
+		isLeader:   true,
+		term:       true,
+		backendOps: ops,
+	}
+
+	require.NoError(t, m.updateSpec(types.Spec{Kind: "ingress", Metadata: types.Metadata{Name: "lb"}},
+		plugin.Name("ingress")))
+	require.NoError(t, m.updateConfig(groupSpec("workers", 1)))
+
+	// the specs are committed again with the freeze
+	require.NoError(t, m.Freeze("incident 42"))
+	err = <-grouped
+	require.Error(t, err)
+	require.Equal(t, "frozen: incident 42", err.Error())
+	require.Error(t, <-controlled)
+	<-grouped
+
+	// the plugins take the freeze from the commits of the manager, not from the process of the manager
+	freeze.Set(nil)
+	_, err = m.CommitGroup(groupSpec("workers", 2), false)
+	require.NoError(t, err)
+	require.Error(t, <-grouped)
+
+	require.NoError(t, m.Unfreeze("resolved"))
+	require.NoError(t, <-grouped)
+	require.NoError(t, <-controlled)
+	require.NoError(t, <-grouped)
+}
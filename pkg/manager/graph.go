@@ -0,0 +1,233 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/run/depends"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+func (k key) String() string {
+	if k.Namespace != "" {
+		return k.Namespace + ":" + k.Kind + "/" + k.Name
+	}
+	return k.Kind + "/" + k.Name
+}
+
+// errCircularDependency is returned when the specs depend on one another in a cycle
+type errCircularDependency []key
+
+func (e errCircularDependency) Error() string {
+	s := []string{}
+	for _, k := range e {
+		s = append(s, k.String())
+	}
+	return fmt.Sprintf("circular dependency: %s", strings.Join(s, " -> "))
+}
+
+// errMissingDependency is returned when a spec depends on a spec that is not in the global spec
+type errMissingDependency [2]key
+
+func (e errMissingDependency) Error() string {
+	return fmt.Sprintf("missing dependency: %v depends on %v, which is not committed", e[0], e[1])
+}
+
+// specGraph is the graph of dependencies among the specs.  The specs are partitioned into levels, where
+// the specs in a level depend only on those of the lower levels.
+type specGraph struct {
+	dependsOn map[key][]key
+	levels    [][]key
+}
+
+// graph returns the graph of dependencies of the specs, or an error if there are cycles or missing dependencies.
+// A spec depends on the specs in its Depends, in the same namespace, and on the specs of the plugins returned by the
+// dependency resolver registered for its kind.  A spec without Depends also depends on the specs of the kinds ranked
+// before its kind in the same namespace (e.g. an ingress on the groups).
+func (g *globalSpec) graph() (*specGraph, error) {
+
+	sg := &specGraph{dependsOn: map[key][]key{}}
+
+	for k, r := range g.index {
+
+		seen := map[key]bool{}
+		deps := []key{}
+		add := func(d key) error {
+			if d == k {
+				return errCircularDependency{k, k}
+			}
+			if !seen[d] {
+				seen[d] = true
+				deps = append(deps, d)
+			}
+			return nil
+		}
+
+		for _, d := range r.Spec.Depends {
+			dk := key{Namespace: k.Namespace, Kind: d.Kind, Name: d.Name}
+			if _, has := g.index[dk]; !has {
+				return nil, errMissingDependency{k, dk}
+			}
+			if err := add(dk); err != nil {
+				return nil, err
+			}
+		}
+
+		// properties that cannot be parsed are reported by the plugin when the spec is committed
+		runnables, err := depends.Resolve(r.Spec, strings.Split(k.Kind, "/")[0], nil)
+		if err != nil {
+			log.Warn("Cannot resolve dependencies", "key", k, "err", err)
+		}
+		for _, runnable := range runnables {
+			for _, dk := range g.specsOf(runnable.Plugin()) {
+				if dk == k {
+					continue // the spec's own plugin
+				}
+				if err := add(dk); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if rank := kindRank[k.Kind]; rank > 0 && len(r.Spec.Depends) == 0 {
+			for dk := range g.index {
+				if other := kindRank[dk.Kind]; other > 0 && other < rank && dk.Namespace == k.Namespace {
+					if err := add(dk); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+
+		sortKeys(deps)
+		sg.dependsOn[k] = deps
+	}
+
+	return sg, sg.sort()
+}
+
+// specsOf returns the specs served by the plugin of the given name, e.g. group/workers is the spec of the group workers
+func (g *globalSpec) specsOf(name plugin.Name) []key {
+	lookup, typeName := name.GetLookupAndType()
+	if typeName == "" {
+		return nil
+	}
+	out := []key{}
+	for k, r := range g.index {
+		if types.Namespaced(k.Namespace, k.Name) == typeName && (k.Kind == lookup || r.Handler.Lookup() == lookup) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func sortKeys(keys []key) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Namespace != keys[j].Namespace {
+			return keys[i].Namespace < keys[j].Namespace
+		}
+		if keys[i].Kind == keys[j].Kind {
+			return keys[i].Name < keys[j].Name
+		}
+		return keys[i].Kind < keys[j].Kind
+	})
+}
+
+// sort partitions the specs into levels
+func (sg *specGraph) sort() error {
+	level := map[key]int{}
+	remaining := map[key]bool{}
+	for k := range sg.dependsOn {
+		remaining[k] = true
+	}
+
+	for len(remaining) > 0 {
+		current := []key{}
+		for k := range remaining {
+			ready := true
+			for _, d := range sg.dependsOn[k] {
+				if _, has := level[d]; !has {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				current = append(current, k)
+			}
+		}
+		if len(current) == 0 {
+			return sg.cycle(remaining)
+		}
+		sortKeys(current)
+		for _, k := range current {
+			delete(remaining, k)
+			level[k] = len(sg.levels)
+		}
+		sg.levels = append(sg.levels, current)
+	}
+	return nil
+}
+
+// cycle returns the error of a cycle among the specs that cannot be sorted
+func (sg *specGraph) cycle(remaining map[key]bool) error {
+	keys := []key{}
+	for k := range remaining {
+		keys = append(keys, k)
+	}
+	sortKeys(keys)
+
+	// walk the dependencies from any remaining spec until a spec repeats
+	path := []key{}
+	index := map[key]int{}
+	for k := keys[0]; ; {
+		if i, has := index[k]; has {
+			return errCircularDependency(append(path[i:], k))
+		}
+		index[k] = len(path)
+		path = append(path, k)
+		for _, d := range sg.dependsOn[k] {
+			if remaining[d] {
+				k = d
+				break
+			}
+		}
+	}
+}
+
+// waitFor returns the specs each spec waits for before it's worked on, among the selected specs: its dependencies,
+// or its dependents when reverse is set.  All the specs are selected if selected is nil.
+func (sg *specGraph) waitFor(selected func(key) bool, reverse bool) map[key][]key {
+	wait := map[key][]key{}
+	for k := range sg.dependsOn {
+		if selected == nil || selected(k) {
+			wait[k] = []key{}
+		}
+	}
+	for k, deps := range sg.dependsOn {
+		if _, has := wait[k]; !has {
+			continue
+		}
+		for _, d := range deps {
+			if _, has := wait[d]; !has {
+				continue
+			}
+			if reverse {
+				wait[d] = append(wait[d], k)
+			} else {
+				wait[k] = append(wait[k], d)
+			}
+		}
+	}
+	return wait
+}
+
+// order returns the specs in the order of commit
+func (sg *specGraph) order() []key {
+	order := []key{}
+	for _, level := range sg.levels {
+		order = append(order, level...)
+	}
+	return order
+}
@@ -0,0 +1,343 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/run/depends"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/group"
+	testing_controller "github.com/docker/infrakit/pkg/testing/controller"
+	testing_group "github.com/docker/infrakit/pkg/testing/group"
+	testing_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func testGlobalSpec(t *testing.T, buff string) *globalSpec {
+	specs := []types.Spec{}
+	require.NoError(t, types.Decode([]byte(buff), &specs))
+
+	g := globalSpec{}
+	for _, spec := range specs {
+		g.updateSpec(spec, plugin.Name(spec.Kind))
+	}
+	return &g
+}
+
+func TestGraphLevels(t *testing.T) {
+
+	depends.Register("graph-test", types.InterfaceSpec(controller.InterfaceSpec),
+		func(spec types.Spec) (depends.Runnables, error) {
+			return depends.Runnables{depends.RunnableFrom(plugin.Name("group/workers"))}, nil
+		})
+	defer depends.Unregister("graph-test", types.InterfaceSpec(controller.InterfaceSpec))
+
+	g := testGlobalSpec(t, `
+- kind: group
+  metadata:
+    name: workers
+- kind: group
+  metadata:
+    name: managers
+- kind: ingress
+  metadata:
+    name: lb
+- kind: enrollment
+  metadata:
+    name: nfs
+  depends:
+    - kind: group
+      name: managers
+- kind: graph-test
+  metadata:
+    name: test
+- kind: resource
+  metadata:
+    name: net
+- kind: resource
+  metadata:
+    name: disks
+  depends:
+    - kind: enrollment
+      name: nfs
+    - kind: resource
+      name: net
+`)
+
+	graph, err := g.graph()
+	require.NoError(t, err)
+
+	require.Equal(t, [][]key{
+		{{Kind: "group", Name: "managers"}, {Kind: "group", Name: "workers"}, {Kind: "resource", Name: "net"}},
+		{{Kind: "enrollment", Name: "nfs"}, {Kind: "graph-test", Name: "test"}, {Kind: "ingress", Name: "lb"}},
+		{{Kind: "resource", Name: "disks"}},
+	}, graph.levels)
+
+	require.Equal(t, []key{{Kind: "group", Name: "workers"}}, graph.dependsOn[key{Kind: "graph-test", Name: "test"}])
+}
+
+func TestGraphErrors(t *testing.T) {
+
+	_, err := testGlobalSpec(t, `
+- kind: group
+  metadata:
+    name: workers
+  depends:
+    - kind: resource
+      name: net
+`).graph()
+	require.Error(t, err)
+	require.Equal(t, "missing dependency: group/workers depends on resource/net, which is not committed", err.Error())
+
+	_, err = testGlobalSpec(t, `
+- kind: resource
+  metadata:
+    name: net
+  depends:
+    - kind: group
+      name: workers
+- kind: group
+  metadata:
+    name: workers
+  depends:
+    - kind: resource
+      name: net
+- kind: ingress
+  metadata:
+    name: lb
+`).graph()
+	require.Error(t, err)
+	require.Equal(t, "circular dependency: group/workers -> resource/net -> group/workers", err.Error())
+
+	_, err = testGlobalSpec(t, `
+- kind: resource
+  metadata:
+    name: net
+  depends:
+    - kind: resource
+      name: net
+`).graph()
+	require.Error(t, err)
+	require.Equal(t, "circular dependency: resource/net -> resource/net", err.Error())
+}
+
+func TestExecPluginsOrder(t *testing.T) {
+
+	defer func(interval time.Duration) { execRetryInterval = interval }(execRetryInterval)
+	execRetryInterval = time.Millisecond
+
+	var lock sync.Mutex
+	calls := []string{}
+	call := func(s string) {
+		lock.Lock()
+		defer lock.Unlock()
+		calls = append(calls, s)
+	}
+	fail := true
+	up := false
+
+	controllers := &testing_controller.Controller{
+		DoCommit: func(op controller.Operation, spec types.Spec) (types.Object, error) {
+			if spec.Metadata.Name == "lb" && fail {
+				fail = false
+				return types.Object{}, fmt.Errorf("boom")
+			}
+			call("commit " + spec.Metadata.Name)
+			return types.Object{Spec: spec}, nil
+		},
+		DoFree: func(metadata *types.Metadata) ([]types.Object, error) {
+			call("free " + metadata.Name)
+			return nil, nil
+		},
+	}
+	groups := &testing_group.Plugin{
+		DoCommitGroup: func(spec group.Spec, pretend bool) (string, error) {
+			call("commit " + string(spec.ID))
+			return "", nil
+		},
+		DoFreeGroup: func(id group.ID) error {
+			call("free " + string(id))
+			return nil
+		},
+	}
+	scope := testing_scope.DefaultScope()
+	scope.ResolveController = func(n string) (controller.Controller, error) {
+		if n == "enrollment" && !up {
+			up = true
+			return nil, fmt.Errorf("not up")
+		}
+		return controllers, nil
+	}
+	scope.ResolveGroup = func(n string) (group.Plugin, error) { return groups, nil }
+
+	ops := make(chan backendOp, 10)
+	m := &manager{scope: scope, backendOps: ops}
+
+	g := testGlobalSpec(t, `
+- kind: group
+  metadata:
+    name: workers
+  properties: workers
+- kind: resource
+  metadata:
+    name: net
+- kind: ingress
+  metadata:
+    name: lb
+- kind: resource
+  metadata:
+    name: disks
+  depends:
+    - kind: ingress
+      name: lb
+- kind: resource
+  metadata:
+    name: vols
+  depends:
+    - kind: group
+      name: workers
+- kind: enrollment
+  metadata:
+    name: nfs
+  depends:
+    - kind: group
+      name: workers
+`)
+
+	require.NoError(t, m.execPlugins(*g, func(c controller.Controller, spec types.Spec) (bool, error) {
+		_, err := c.Commit(controller.Enforce, spec)
+		return true, err
+	}, func(p group.Plugin, spec group.Spec) (bool, error) {
+		_, err := p.CommitGroup(spec, false)
+		return true, err
+	}, true, false))
+
+	// the failures don't hold up the specs that don't depend on them
+	op := <-ops
+	retry, err := op.operation()
+	require.False(t, retry)
+	require.Equal(t, "pass 1 of 2: enrollment/nfs: not up, ingress/lb: boom", err.Error())
+	sort.Strings(calls[:2])
+	require.Equal(t, []string{"commit net", "commit workers", "commit vols"}, calls)
+
+	// queued again after the backoff
+	op = <-ops
+	retry, err = op.operation()
+	require.False(t, retry)
+	require.NoError(t, err)
+	// the order of the specs ready at the same time is not deterministic
+	sort.Strings(calls[3:5])
+	require.Equal(t, []string{"commit net", "commit workers", "commit vols",
+		"commit lb", "commit nfs", "commit disks", "commit workers"}, calls)
+
+	require.NoError(t, m.execPlugins(*g, freeController, freeGroup, false, true))
+	calls = []string{}
+	op = <-ops
+	_, err = op.operation()
+	require.NoError(t, err)
+
+	sort.Strings(calls[:3])
+	sort.Strings(calls[3:5])
+	require.Equal(t, []string{"free disks", "free nfs", "free vols", "free lb", "free net", "free workers"}, calls)
+}
+
+func TestExecPluginsStale(t *testing.T) {
+
+	defer func(interval time.Duration) { execRetryInterval = interval }(execRetryInterval)
+	execRetryInterval = time.Millisecond
+
+	committed := []string{}
+	controllers := &testing_controller.Controller{
+		DoCommit: func(op controller.Operation, spec types.Spec) (types.Object, error) {
+			committed = append(committed, spec.Metadata.Name)
+			return types.Object{}, fmt.Errorf("boom")
+		},
+	}
+	scope := testing_scope.DefaultScope()
+	scope.ResolveController = func(n string) (controller.Controller, error) { return controllers, nil }
+
+	ops := make(chan backendOp, 10)
+	m := &manager{scope: scope, backendOps: ops, isLeader: true, term: true}
+
+	g := testGlobalSpec(t, `
+- kind: resource
+  metadata:
+    name: net
+`)
+	commit := func(c controller.Controller, spec types.Spec) (bool, error) {
+		_, err := c.Commit(controller.Enforce, spec)
+		return true, err
+	}
+
+	require.NoError(t, m.execPlugins(*g, commit, nil, false, false))
+	op := <-ops
+	_, err := op.operation()
+	require.Error(t, err)
+	retry := <-ops
+
+	// newer specs are committed, so the retry of the older ones is dropped
+	require.NoError(t, m.execPlugins(*g, commit, nil, false, false))
+	_, err = retry.operation()
+	require.NoError(t, err)
+	require.Equal(t, []string{"net"}, committed)
+
+	op = <-ops
+	_, err = op.operation()
+	require.Error(t, err)
+	retry = <-ops
+
+	// the leadership is lost, so the retry is dropped
+	m.lock.Lock()
+	m.isLeader, m.term = false, false
+	m.lock.Unlock()
+	_, err = retry.operation()
+	require.NoError(t, err)
+	require.Equal(t, []string{"net", "net"}, committed)
+
+	// the retries are not queued once stopped
+	execRetryInterval = time.Hour
+	require.NoError(t, m.execPlugins(*g, commit, nil, false, false))
+	op = <-ops
+	_, err = op.operation()
+	require.Error(t, err)
+	m.lock.RLock()
+	require.Equal(t, 1, len(m.retries))
+	m.lock.RUnlock()
+
+	m.stopRetries()
+	m.retryLater(0, op)
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, 0, len(ops))
+	require.Equal(t, 0, len(m.retries))
+}
+
+func TestExecPluginsAbandoned(t *testing.T) {
+
+	ops := make(chan backendOp, 10)
+	m := &manager{scope: testing_scope.DefaultScope(), backendOps: ops}
+
+	g := testGlobalSpec(t, `
+- kind: group
+  metadata:
+    name: workers
+- kind: resource
+  metadata:
+    name: disks
+  depends:
+    - kind: group
+      name: workers
+`)
+	require.NoError(t, m.execPlugins(*g, nil, nil, false, false))
+
+	// the group has no spec, so it cannot be retried, nor can the specs depending on it
+	op := <-ops
+	retry, err := op.operation()
+	require.False(t, retry)
+	require.Equal(t, "pass 1 of 1: group/workers: no spec for group workers plugin=group", err.Error())
+	require.Equal(t, 0, len(ops))
+}
@@ -57,10 +57,11 @@ func (m *manager) updateConfig(spec group.Spec) error {
 	defer log.Debug("Saved snapshot", "global", stored, "spec", spec)
 
 	stored.updateGroupSpec(spec, m.Options.Group)
-	return stored.store(m.Options.SpecStore)
+	return m.saveSpecs(stored, fmt.Sprintf("commit group/%v", spec.ID))
 }
 
-func (m *manager) removeConfig(id group.ID) error {
+// removeConfig removes the spec of the group, recording the operation, e.g. destroy or free, in the history
+func (m *manager) removeConfig(id group.ID, operation string) error {
 	log.Debug("Removing config", "groupID", id)
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -76,7 +77,7 @@ func (m *manager) removeConfig(id group.ID) error {
 	defer log.Debug("Saved snapshot", "global", stored, "id", id)
 
 	stored.removeGroup(id)
-	return stored.store(m.Options.SpecStore)
+	return m.saveSpecs(stored, fmt.Sprintf("%s group/%v", operation, id))
 }
 
 // This implements/ overrides the Group Plugin interface to support single group-only operations
@@ -102,7 +103,7 @@ func (m *manager) CommitGroup(grp group.Spec, pretend bool) (resp string, err er
 				}
 			}
 
-			resp, err = m.Plugin.CommitGroup(grp, pretend)
+			resp, err = m.commitGroup(m.Plugin, grp, pretend)
 			return retry, err
 		})
 
@@ -141,7 +142,7 @@ func (m *manager) DestroyGroup(id group.ID) (err error) {
 			log.Debug("Manager DestroyGroup", "groupID", id, "V", debugV)
 
 			// We first update the user's desired state first
-			if removeErr := m.removeConfig(id); removeErr != nil {
+			if removeErr := m.removeConfig(id, "destroy"); removeErr != nil {
 				log.Warn("Error updating/ remove", "err", removeErr)
 				err = removeErr
 				return retry, err
@@ -167,7 +168,7 @@ func (m *manager) FreeGroup(id group.ID) (err error) {
 			log.Debug("Manager FreeGroup", "groupID", id, "V", debugV)
 
 			// We first update the user's desired state first
-			if removeErr := m.removeConfig(id); removeErr != nil {
+			if removeErr := m.removeConfig(id, "free"); removeErr != nil {
 				log.Warn("Error updating / remove", "err", removeErr)
 				err = removeErr
 				return retry, err
@@ -0,0 +1,228 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"fmt"
+	"os/user"
+	"time"
+
+	"github.com/docker/infrakit/pkg/spi/stack"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+const defaultHistoryLimit = 50
+
+// revision is a revision as stored.  It keeps the handlers of the specs so the revision can be enforced again.
+type revision struct {
+	Number      int
+	Author      string
+	Time        time.Time
+	Message     string
+	Fingerprint string
+	Entries     []entry
+}
+
+func (r revision) toRevision() stack.Revision {
+	g := globalSpec{data: r.Entries}
+	return stack.Revision{
+		Number:      r.Number,
+		Author:      r.Author,
+		Time:        r.Time,
+		Message:     r.Message,
+		Fingerprint: r.Fingerprint,
+		Specs:       g.toSpecs(),
+	}
+}
+
+// author returns the author of the revisions committed by this process
+var author = func() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+func (m *manager) loadHistory() ([]revision, error) {
+	if m.Options.HistoryStore == nil {
+		return m.revisions, nil
+	}
+	history := []revision{}
+	err := m.Options.HistoryStore.Load(&history)
+	return history, err
+}
+
+func (m *manager) saveHistory(history []revision) error {
+	if m.Options.HistoryStore == nil {
+		m.revisions = history
+		return nil
+	}
+	return m.Options.HistoryStore.Save(history)
+}
+
+// fingerprint returns the fingerprint of the specs.  The specs are decoded first so the fingerprint does not
+// depend on the formatting of the properties, which changes as the specs are stored and loaded.
+func fingerprint(specs types.Specs) (string, error) {
+	var v interface{}
+	if err := types.AnyValueMust(specs).Decode(&v); err != nil {
+		return "", err
+	}
+	return types.Fingerprint(types.AnyValueMust(v)), nil
+}
+
+// saveSpecs saves the specs and records them as a new revision, unless they are the same as the
+// latest revision.  It must be called with the lock held.  Specs with circular or missing dependencies,
+// or that exceed the quota of a namespace, are not saved.  Failing to record the revision does not fail
+// the save of the specs.
+func (m *manager) saveSpecs(stored globalSpec, message string) error {
+	if _, err := stored.graph(); err != nil {
+		return err
+	}
+	if err := m.checkQuotas(&stored); err != nil {
+		return err
+	}
+	if err := stored.store(m.Options.SpecStore); err != nil {
+		return err
+	}
+	if err := m.record(stored, message); err != nil {
+		log.Error("Cannot record revision", "message", message, "err", err)
+	}
+	return nil
+}
+
+func (m *manager) record(stored globalSpec, message string) error {
+	history, err := m.loadHistory()
+	if err != nil {
+		return err
+	}
+
+	fingerprint, err := fingerprint(stored.toSpecs())
+	if err != nil {
+		return err
+	}
+	number := 1
+	if n := len(history); n > 0 {
+		if history[n-1].Fingerprint == fingerprint {
+			return nil
+		}
+		number = history[n-1].Number + 1
+	}
+
+	history = append(history, revision{
+		Number:      number,
+		Author:      author(),
+		Time:        time.Now(),
+		Message:     message,
+		Fingerprint: fingerprint,
+		Entries:     stored.data,
+	})
+
+	limit := m.Options.HistoryLimit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	log.Debug("Recorded revision", "revision", number, "message", message, "V", debugV)
+	return m.saveHistory(history)
+}
+
+// History returns the revisions of the specs, oldest first
+func (m *manager) History() ([]stack.Revision, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	history, err := m.loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	out := []stack.Revision{}
+	for _, r := range history {
+		out = append(out, r.toRevision())
+	}
+	return out, nil
+}
+
+// Rollback enforces the specs of the given revision, as a new revision.  The objects committed after
+// the revision are freed, not destroyed.  Like the other changes of the specs, the rollback is serialized
+// on the backend queue, and the frees and commits are queued from there.
+func (m *manager) Rollback(number int) (err error) {
+
+	if is, errLeader := m.IsLeader(); errLeader != nil || !is {
+		err = errNotLeader
+		return
+	}
+
+	retry := false
+	<-m.queue("rollback",
+		func() (bool, error) {
+			log.Debug("Manager Rollback", "revision", number, "V", debugV)
+
+			var target, removed globalSpec
+			target, removed, err = m.rollbackConfig(number)
+			if err != nil {
+				return retry, err
+			}
+
+			if err = m.execPlugins(removed, freeController, freeGroup, false, true); err != nil {
+				return retry, err
+			}
+			err = m.doCommitAll(target)
+			return retry, err
+		})
+	return
+}
+
+// rollbackConfig saves the specs of the revision, as a new revision, with the lock held.  It returns the specs
+// saved and the ones removed, with only the dependencies among the removed specs so they can be freed in order.
+func (m *manager) rollbackConfig(number int) (target, removed globalSpec, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	history, err := m.loadHistory()
+	if err != nil {
+		return
+	}
+	var found *revision
+	for i := range history {
+		if history[i].Number == number {
+			found = &history[i]
+			break
+		}
+	}
+	if found == nil {
+		err = fmt.Errorf("no revision %v", number)
+		return
+	}
+
+	current := globalSpec{}
+	if err = current.load(m.Options.SpecStore); err != nil {
+		return
+	}
+
+	// the freeze is not part of the revisions
+	target = globalSpec{index: map[key]record{}, freeze: current.freeze}
+	for _, e := range found.Entries {
+		target.index[e.Key] = e.Record
+	}
+	removed = globalSpec{index: map[key]record{}}
+	for k, r := range current.index {
+		if _, has := target.index[k]; !has {
+			removed.index[k] = r
+		}
+	}
+	// only the dependencies among the removed specs order their free
+	for k, r := range removed.index {
+		depends := []types.Dependency{}
+		for _, d := range r.Spec.Depends {
+			if _, has := removed.index[key{Namespace: k.Namespace, Kind: d.Kind, Name: d.Name}]; has {
+				depends = append(depends, d)
+			}
+		}
+		r.Spec.Depends = depends
+		removed.index[k] = r
+	}
+
+	log.Info("Rolling back", "revision", number, "removed", len(removed.index))
+	err = m.saveSpecs(target, fmt.Sprintf("rollback to %d", number))
+	return
+}
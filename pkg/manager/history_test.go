@@ -0,0 +1,130 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/store/file"
+	testing_controller "github.com/docker/infrakit/pkg/testing/controller"
+	testing_group "github.com/docker/infrakit/pkg/testing/group"
+	testing_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryRollback(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	specs, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	history, err := file.NewSnapshot(dir, "global.history")
+	require.NoError(t, err)
+
+	committed := []types.Spec{}
+	freed := []string{}
+	controllers := &testing_controller.Controller{
+		DoCommit: func(op controller.Operation, spec types.Spec) (types.Object, error) {
+			committed = append(committed, spec)
+			return types.Object{Spec: spec}, nil
+		},
+		DoFree: func(metadata *types.Metadata) ([]types.Object, error) {
+			freed = append(freed, metadata.Name)
+			return nil, nil
+		},
+	}
+	groups := &testing_group.Plugin{
+		DoCommitGroup: func(spec group.Spec, pretend bool) (string, error) {
+			committed = append(committed, types.Spec{Kind: "group", Metadata: types.Metadata{Name: string(spec.ID)}})
+			return "", nil
+		},
+	}
+	scope := testing_scope.DefaultScope()
+	scope.ResolveController = func(n string) (controller.Controller, error) { return controllers, nil }
+	scope.ResolveGroup = func(n string) (group.Plugin, error) { return groups, nil }
+
+	ops := make(chan backendOp, 100)
+	m := &manager{
+		scope: scope,
+		Options: Options{
+			Group:        plugin.Name("group-stateless"),
+			SpecStore:    specs,
+			HistoryStore: history,
+			HistoryLimit: 3,
+		},
+		isLeader:   true,
+		term:       true,
+		backendOps: ops,
+	}
+
+	ingress := func(v int) types.Spec {
+		return types.Spec{
+			Kind:       "ingress",
+			Version:    "Ingress/v1",
+			Metadata:   types.Metadata{Name: "lb"},
+			Properties: types.AnyValueMust(map[string]interface{}{"version": v}),
+		}
+	}
+
+	require.NoError(t, m.updateSpec(ingress(1), plugin.Name("ingress")))
+	require.NoError(t, m.updateConfig(group.Spec{ID: "workers", Properties: types.AnyValueMust("workers")}))
+	require.NoError(t, m.updateSpec(ingress(1), plugin.Name("ingress"))) // no change, no revision
+	require.NoError(t, m.updateSpec(ingress(2), plugin.Name("ingress")))
+	require.NoError(t, m.updateSpec(types.Spec{Kind: "gc", Metadata: types.Metadata{Name: "gc"}}, plugin.Name("gc")))
+
+	revisions, err := m.History()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(revisions)) // limited
+	require.Equal(t, 2, revisions[0].Number)
+	require.Equal(t, "commit group/workers", revisions[0].Message)
+	require.Equal(t, 2, len(revisions[0].Specs))
+	require.Equal(t, 4, revisions[2].Number)
+	require.Equal(t, "commit gc/gc", revisions[2].Message)
+
+	rev2 := revisions[0]
+
+	// the backend queue, with the errors of the operations
+	errs := make(chan error, 100)
+	go func() {
+		for op := range ops {
+			if _, err := op.operation(); err != nil {
+				errs <- err
+			}
+		}
+		close(errs)
+	}()
+
+	require.Error(t, m.Rollback(1))
+	require.NoError(t, m.Rollback(2))
+	<-m.queue("sync", func() (bool, error) { return false, nil }) // the frees and commits queued by the rollback
+	close(ops)
+
+	failed := []error{}
+	for err := range errs {
+		failed = append(failed, err)
+	}
+	require.Equal(t, 1, len(failed)) // no revision 1
+
+	require.Equal(t, []string{"gc"}, freed)
+	require.Equal(t, 3, len(committed)) // groups are committed twice
+	require.Equal(t, "workers", committed[0].Metadata.Name)
+	require.Equal(t, m.tagged(ingress(1)).Fingerprint(), committed[1].Fingerprint())
+	require.Equal(t, "workers", committed[2].Metadata.Name)
+
+	revisions, err = m.History()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(revisions))
+	require.Equal(t, 5, revisions[2].Number)
+	require.Equal(t, "rollback to 2", revisions[2].Message)
+	require.Equal(t, rev2.Fingerprint, revisions[2].Fingerprint)
+
+	current, err := m.Specs()
+	require.NoError(t, err)
+	require.Equal(t, types.AnyValueMust(rev2.Specs).String(), types.AnyValueMust(current).String())
+}
@@ -3,15 +3,22 @@ package manager // import "github.com/docker/infrakit/pkg/manager"
 import (
 	"fmt"
 	"net/url"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/infrakit/pkg/leader"
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	metadata_plugin "github.com/docker/infrakit/pkg/plugin/metadata"
 	"github.com/docker/infrakit/pkg/run/scope"
 	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/metadata"
+	"github.com/docker/infrakit/pkg/spi/stack"
+	"github.com/docker/infrakit/pkg/store"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -35,6 +42,9 @@ type manager struct {
 	stop     chan struct{}
 	running  chan struct{}
 
+	// term is true once the term of the leadership has started.  Guarded by lock.
+	term bool
+
 	// Status is the status metadata (readonly)
 	Status            metadata.Plugin
 	refreshStatus     chan struct{}
@@ -42,6 +52,29 @@ type manager struct {
 
 	// queued operations
 	backendOps chan<- backendOp
+
+	// revisions are the revisions of the specs when there is no history store
+	revisions []revision
+
+	// events is where the events of the manager are published
+	events chan<- *event.Event
+
+	// token is the fencing token of the current term of leadership.  Accessed atomically.
+	token uint64
+
+	// generation counts the commits of the specs, so the retries of older commits are dropped.  Accessed atomically.
+	generation uint64
+
+	// retries are the timers of the work to queue again later.  They are stopped when the manager stops.
+	// Guarded by lock.
+	retries map[*time.Timer]struct{}
+
+	// stopped is true once the manager stops, so no more work is retried.  Guarded by lock.
+	stopped bool
+
+	// frozen is the freeze stored with the specs, which the manager sends with its commits, nil if not frozen.
+	// Guarded by lock.
+	frozen *stack.Freeze
 }
 
 const (
@@ -69,10 +102,16 @@ func NewManager(scope scope.Scope, options Options) Backend {
 		scope:         scope,
 		Options:       options,
 		Plugin:        gp, // the stateless backend group plugin
-		Updatable:     initUpdatable(scope, options),
 		refreshStatus: refreshStatus,
 	}
 
+	// the writes of the stores carry the token of the term so that the stores reject them after another
+	// manager has become the leader
+	impl.Options.SpecStore = store.WithToken(options.SpecStore, impl.fencingToken)
+	impl.Options.HistoryStore = store.WithToken(options.HistoryStore, impl.fencingToken)
+	impl.Options.MetadataStore = store.WithToken(options.MetadataStore, impl.fencingToken)
+	impl.Updatable = initUpdatable(scope, impl.Options)
+
 	impl.Status = initStatusMetadata(impl)
 	return impl
 }
@@ -137,11 +176,11 @@ var (
 	errNotLeader = fmt.Errorf("not a leader")
 )
 
-// IsLeader returns leader status.  False if not or unknown.
+// IsLeader returns leader status.  False if not or unknown, or until the term of the leadership has started.
 func (m *manager) IsLeader() (bool, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
-	return m.isLeader, nil
+	return m.isLeader && m.term, nil
 }
 
 // LeaderLocation returns the location of the leader
@@ -286,6 +325,9 @@ func (m *manager) Start() (<-chan struct{}, error) {
 					m.isLeader = evt.Status == leader.Leader
 				}
 				next := m.isLeader
+				if !next {
+					m.term = false
+				}
 
 				m.lock.Unlock()
 
@@ -309,6 +351,44 @@ func (m *manager) Stop() {
 	close(m.doneStatusUpdates)
 	close(m.stop)
 	m.Options.Leader.Stop()
+	m.stopRetries()
+}
+
+// stopRetries stops the timers of the work to retry and the retries of any work from now on.
+func (m *manager) stopRetries() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stopped = true
+	for timer := range m.retries {
+		timer.Stop()
+	}
+	m.retries = nil
+}
+
+// retryLater queues the work again after the wait, unless the manager stops by then.
+func (m *manager) retryLater(wait time.Duration, op backendOp) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.stopped {
+		return
+	}
+	if m.retries == nil {
+		m.retries = map[*time.Timer]struct{}{}
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(wait, func() {
+		m.lock.Lock()
+		_, pending := m.retries[timer]
+		delete(m.retries, timer)
+		m.lock.Unlock()
+
+		if pending {
+			m.backendOps <- op
+		}
+	})
+	m.retries[timer] = struct{}{}
 }
 
 func (m *manager) getCurrentState() (globalSpec, error) {
@@ -349,6 +429,13 @@ func (m *manager) loadAndCommitSpecs() error {
 		log.Warn("Error loading config", "err", err)
 		return err
 	}
+	m.lock.Lock()
+	m.frozen = config.freeze
+	m.lock.Unlock()
+	freeze.Set(config.freeze)
+	if config.freeze != nil {
+		log.Warn("Frozen", "reason", config.freeze.Reason, "author", config.freeze.Author, "since", config.freeze.Time)
+	}
 	return m.doCommitAll(*config)
 }
 
@@ -390,8 +477,36 @@ func (m *manager) loadMetadata() (err error) {
 	return m.Updatable.Commit(proposed, cas)
 }
 
-func (m *manager) onAssumeLeadership() (err error) {
+func (m *manager) onAssumeLeadership() error {
 	log.Info("Assuming leadership")
+	return m.assumeLeadership(0)
+}
+
+// assumeLeadership starts the term of leadership, then loads and commits the specs.  If the term cannot be
+// started, it's retried later without holding up the queue, waiting longer with each attempt up to execRetryMax,
+// until it starts or the leadership is lost.
+func (m *manager) assumeLeadership(attempt int) (err error) {
+	if err = m.startTerm(); err != nil {
+		attempt++
+		wait := execRetryInterval << uint(attempt-1)
+		if wait <= 0 || wait > execRetryMax {
+			wait = execRetryMax
+		}
+		log.Error("Cannot start term of leadership", "attempt", attempt, "wait", wait, "err", err)
+		m.retryLater(wait, backendOp{
+			name: "term",
+			operation: func() (bool, error) {
+				m.lock.RLock()
+				retry := m.isLeader && !m.term
+				m.lock.RUnlock()
+				if !retry {
+					return false, nil
+				}
+				return false, m.assumeLeadership(attempt)
+			},
+		})
+		return
+	}
 
 	defer func() {
 		log.Info("Running as leader")
@@ -460,7 +575,7 @@ func (m *manager) doCommitAll(config globalSpec) error {
 	return m.execPlugins(config,
 		func(control controller.Controller, spec types.Spec) (bool, error) {
 
-			_, err := control.Commit(controller.Enforce, spec)
+			_, err := control.Commit(controller.Enforce, m.tagged(spec))
 			if err != nil {
 				log.Error("Cannot commit", "spec", spec, "err", err)
 			}
@@ -468,13 +583,13 @@ func (m *manager) doCommitAll(config globalSpec) error {
 		},
 		func(plugin group.Plugin, spec group.Spec) (bool, error) {
 
-			_, err := plugin.CommitGroup(spec, false)
+			_, err := m.commitGroup(plugin, spec, false)
 			if err != nil {
 				log.Error("Cannot commit group", "spec", spec, "err", err)
 			}
 			return true, err
 		},
-		true) // Exec the plugins with groupRequeue=true since the initial group
+		true, false) // Exec the plugins with groupRequeue=true since the initial group
 	// commit only defines the group. Once all groups are defined then issue
 	// another commit to handle any updates that have not completed (occurs
 	// if there in a update and leadership changes)
@@ -485,97 +600,225 @@ func (m *manager) doFreeAll(config globalSpec) error {
 	defer m.metadataChanged()
 
 	log.Info("Freeing groups")
-	return m.execPlugins(config,
-		func(controller controller.Controller, spec types.Spec) (bool, error) {
+	return m.execPlugins(config, freeController, freeGroup, false, true)
+}
 
-			log.Info("Freeing spec", "spec", spec)
+func freeController(controller controller.Controller, spec types.Spec) (bool, error) {
 
-			_, err := controller.Free(&spec.Metadata)
-			return true, err
-		},
-		func(plugin group.Plugin, spec group.Spec) (bool, error) {
+	log.Info("Freeing spec", "spec", spec)
 
-			log.Info("Freeing group", "groupID", spec.ID)
-			return true, plugin.FreeGroup(spec.ID)
-		},
-		false)
+	_, err := controller.Free(&spec.Metadata)
+	return true, err
+}
+
+func freeGroup(plugin group.Plugin, spec group.Spec) (bool, error) {
+
+	log.Info("Freeing group", "groupID", spec.ID)
+	return true, plugin.FreeGroup(spec.ID)
 }
 
+var (
+	// execRetryInterval is how long to wait before the failed work on the specs is retried.  It doubles with each
+	// attempt, up to execRetryMax.
+	execRetryInterval = 1 * time.Second
+	execRetryMax      = 1 * time.Minute
+)
+
+// execPlugins queues the work on the specs in the order of their dependencies.  A spec is worked on as soon as
+// the specs it depends on are done, in parallel with the other specs that are ready.  When reverse is set, the
+// dependents are worked on before their dependencies (e.g. to free).  An error is returned right away if the specs
+// have circular or missing dependencies.  The specs that fail, like those of plugins that are not up yet, are
+// retried with a backoff, together with the specs that depend on them, while the other specs move on.  The retries
+// are dropped once they are stale: when the leadership changed since, or when newer specs are committed since,
+// unless the work is to free the specs.
 func (m *manager) execPlugins(config globalSpec,
 	controllerWork func(controller.Controller, types.Spec) (bool, error),
 	groupWork func(group.Plugin, group.Spec) (bool, error),
-	groupRequeue bool) (err error) {
+	groupRequeue bool, reverse bool) error {
 
-	// Operations that should be executed last
-	deferredOps := []backendOp{}
+	graph, err := config.graph()
+	if err != nil {
+		log.Error("Cannot order specs", "err", err)
+		return err
+	}
 
-	result := config.visit(func(k key, r record) error {
+	passes := []map[key][]key{graph.waitFor(nil, reverse)}
 
-		// TODO(chungers) ==> temporary
-		switch k.Kind {
-		case "ingress", "enrollment", "gc", "resource", "inventory", "pool":
+	// Groups are worked on again after all the specs
+	if groupRequeue {
+		passes = append(passes, graph.waitFor(func(k key) bool { return k.Kind == "group" }, reverse))
+	}
 
-			cp, err := m.scope.Controller(r.Handler.String())
-			if err != nil {
-				log.Error("Error getting controller", "plugin", r.Handler, "err", err)
-				break
-			}
+	// progress of the work, so that retries resume with the specs not done
+	next := 0
+	done := map[key]bool{}
+	abandoned := map[key]bool{}
+	attempt := 0
 
-			// queue up the work
-			m.backendOps <- backendOp{
-				name: k.Kind,
-				operation: func() (bool, error) {
-					return controllerWork(cp, r.Spec)
-				},
-			}
-			log.Debug("queued operation for controller", "key", k, "record", r, "V", debugV)
+	leader, _ := m.IsLeader()
+	generation := atomic.LoadUint64(&m.generation)
+	if !reverse {
+		generation = atomic.AddUint64(&m.generation, 1)
+	}
+	stale := func() bool {
+		current, _ := m.IsLeader()
+		return current != leader || (!reverse && atomic.LoadUint64(&m.generation) != generation)
+	}
 
-		case "group": // not ideal to use string here.
-			id := group.ID(k.Name)
-			gp, err := m.scope.Group(r.Handler.String())
-			if err != nil {
-				log.Error("Cannot contact group", "groupID", id, "plugin", r.Handler)
-				break
+	var op backendOp
+	op = backendOp{
+		name: "specs",
+		operation: func() (bool, error) {
+			if attempt > 0 && stale() {
+				log.Info("Dropping stale retry of specs", "attempt", attempt)
+				return false, nil
 			}
+			for ; next < len(passes); next++ {
+				retry, err := m.execPass(config, passes[next], done, abandoned, controllerWork, groupWork)
+				if err == nil {
+					done = map[key]bool{}
+					continue
+				}
+				err = fmt.Errorf("pass %d of %d: %v", next+1, len(passes), err)
+				if !retry {
+					return false, err
+				}
 
-			log.Debug("exec on group", "groupID", id, "plugin", r.Handler, "V", debugV)
-
-			// spec is store in the properties
-			if r.Spec.Properties == nil {
-				err = fmt.Errorf("no spec for group %s plugin=%v", id, r.Handler)
-				break
+				// retry later without holding up the queue
+				attempt++
+				wait := execRetryInterval << uint(attempt-1)
+				if wait <= 0 || wait > execRetryMax {
+					wait = execRetryMax
+				}
+				log.Warn("Retrying specs", "attempt", attempt, "wait", wait, "err", err)
+				m.retryLater(wait, op)
+				return false, err
 			}
+			return false, nil
+		},
+	}
+	m.backendOps <- op
+	log.Debug("queued operation for specs", "passes", passes, "V", debugV)
+	return nil
+}
 
-			spec := group.Spec{
-				ID:         id,
-				Properties: r.Spec.Properties,
+// execPass works on the specs of the pass that are not done, each once the specs it waits for are done.  The
+// specs that fail and the specs that wait for them are not done.  The specs whose work failed without retry are
+// abandoned.  It returns true to retry if any of the specs not done can be retried.
+func (m *manager) execPass(config globalSpec, waitFor map[key][]key, done, abandoned map[key]bool,
+	controllerWork func(controller.Controller, types.Spec) (bool, error),
+	groupWork func(group.Plugin, group.Spec) (bool, error)) (bool, error) {
+
+	var lock sync.Mutex
+	failed := map[key]bool{}
+	errs := []string{}
+	retry := false
+
+	for {
+		ready := []key{}
+		for k, wait := range waitFor {
+			if done[k] || failed[k] || abandoned[k] {
+				continue
 			}
-			op := backendOp{
-				name: k.Kind,
-				operation: func() (bool, error) {
-					return groupWork(gp, spec)
-				},
+			blocked := false
+			for _, w := range wait {
+				if !done[w] {
+					blocked = true
+					break
+				}
 			}
-			m.backendOps <- op
-			if groupRequeue {
-				deferredOps = append(deferredOps, op)
+			if !blocked {
+				ready = append(ready, k)
 			}
-			log.Debug("queued operation for group", "key", k, "record", r, "V", debugV)
+		}
+		if len(ready) == 0 {
+			break
+		}
+		sortKeys(ready)
+
+		var wg sync.WaitGroup
+		for _, k := range ready {
+			wg.Add(1)
+			go func(k key, r record) {
+				defer wg.Done()
+
+				again, err := m.exec(k, r, controllerWork, groupWork)
 
-		default:
-			log.Warn("not executing", "record", r, "key", k)
-			return nil
+				lock.Lock()
+				defer lock.Unlock()
+				if err != nil {
+					log.Error("Error from exec on plugin", "key", k, "err", err, "retry", again)
+					errs = append(errs, fmt.Sprintf("%v: %v", k, err))
+					if again {
+						failed[k] = true
+						retry = true
+					} else {
+						abandoned[k] = true
+					}
+					return
+				}
+				done[k] = true
+			}(k, config.index[k])
+		}
+		wg.Wait()
+	}
+
+	if len(done) == len(waitFor) {
+		return false, nil
+	}
+	if len(errs) == 0 {
+		// only the abandoned specs and the specs waiting for them are left
+		keys := []key{}
+		for k := range abandoned {
+			keys = append(keys, k)
+		}
+		sortKeys(keys)
+		return false, fmt.Errorf("abandoned %v", keys)
+	}
+	sort.Strings(errs)
+	return retry, fmt.Errorf("%s", strings.Join(errs, ", "))
+}
+
+// exec works on the spec with its plugin.  It returns true with the error if the work can be retried, e.g.
+// when the plugin is not up yet.
+func (m *manager) exec(k key, r record,
+	controllerWork func(controller.Controller, types.Spec) (bool, error),
+	groupWork func(group.Plugin, group.Spec) (bool, error)) (bool, error) {
+
+	// TODO(chungers) ==> temporary
+	switch k.Kind {
+	case "ingress", "enrollment", "gc", "resource", "inventory", "pool":
+
+		cp, err := m.scope.Controller(r.Handler.String())
+		if err != nil {
+			log.Error("Error getting controller", "plugin", r.Handler, "err", err)
+			return true, err
 		}
 
+		log.Debug("exec on controller", "key", k, "record", r, "V", debugV)
+		return controllerWork(cp, r.Spec)
+
+	case "group": // not ideal to use string here.
+		id := k.groupID()
+		gp, err := m.scope.Group(r.Handler.String())
 		if err != nil {
-			log.Error("Error from exec on plugin", "err", err)
+			log.Error("Cannot contact group", "groupID", id, "plugin", r.Handler)
+			return true, err
+		}
+
+		log.Debug("exec on group", "groupID", id, "plugin", r.Handler, "V", debugV)
+
+		// spec is store in the properties
+		if r.Spec.Properties == nil {
+			return false, fmt.Errorf("no spec for group %s plugin=%v", id, r.Handler)
 		}
-		return err
-	})
 
-	for _, op := range deferredOps {
-		m.backendOps <- op
+		return groupWork(gp, group.Spec{
+			ID:         id,
+			Properties: r.Spec.Properties,
+		})
 	}
 
-	return result
+	log.Warn("not executing", "record", r, "key", k)
+	return false, nil
 }
@@ -0,0 +1,75 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"fmt"
+	"sort"
+
+	group_types "github.com/docker/infrakit/pkg/controller/group/types"
+)
+
+// Quota limits the groups of a namespace.  A limit of 0 is no limit.
+type Quota struct {
+	// Groups is the maximum number of groups
+	Groups int
+
+	// Size is the maximum of the total size of the groups, counting the logical IDs of the groups that have them
+	Size uint
+}
+
+// usage returns the number of groups and their total size in the namespace
+func (g *globalSpec) usage(namespace string) (groups int, size uint, err error) {
+	for k, r := range g.index {
+		if k.Kind != "group" || k.Namespace != namespace {
+			continue
+		}
+		groups++
+		if r.Spec.Properties == nil {
+			continue
+		}
+		spec := group_types.Spec{}
+		if err = r.Spec.Properties.Decode(&spec); err != nil {
+			err = fmt.Errorf("cannot read the size of %v: %v", k, err)
+			return
+		}
+		size += spec.Allocation.Size + uint(len(spec.Allocation.LogicalIDs))
+	}
+	return
+}
+
+// checkQuotas returns an error if the specs make any namespace exceed its quota.  A namespace that already
+// exceeds its quota, e.g. after the quota is lowered, can still shrink.
+func (m *manager) checkQuotas(g *globalSpec) error {
+	if len(m.Options.Quotas) == 0 {
+		return nil
+	}
+
+	current := globalSpec{}
+	if err := current.load(m.Options.SpecStore); err != nil {
+		return err
+	}
+
+	namespaces := []string{}
+	for namespace := range m.Options.Quotas {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		quota := m.Options.Quotas[namespace]
+		groups, size, err := g.usage(namespace)
+		if err != nil {
+			return err
+		}
+		groupsBefore, sizeBefore, err := current.usage(namespace)
+		if err != nil {
+			return err
+		}
+		if quota.Groups > 0 && groups > quota.Groups && groups > groupsBefore {
+			return fmt.Errorf("quota exceeded: namespace %q would have %d groups, quota is %d", namespace, groups, quota.Groups)
+		}
+		if quota.Size > 0 && size > quota.Size && size > sizeBefore {
+			return fmt.Errorf("quota exceeded: namespace %q would have %d instances, quota is %d", namespace, size, quota.Size)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+
+	group_types "github.com/docker/infrakit/pkg/controller/group/types"
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/store/file"
+	testing_controller "github.com/docker/infrakit/pkg/testing/controller"
+	testing_group "github.com/docker/infrakit/pkg/testing/group"
+	testing_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespacedKeys(t *testing.T) {
+
+	require.Equal(t, key{Kind: "group", Name: "workers"}, keyFromGroupID(group.ID("workers")))
+	k := keyFromGroupID(group.ID("team-a::workers"))
+	require.Equal(t, key{Namespace: "team-a", Kind: "group", Name: "workers"}, k)
+	require.Equal(t, group.ID("team-a::workers"), k.groupID())
+	require.Equal(t, "team-a:group/workers", k.String())
+
+	g := testGlobalSpec(t, `
+- kind: group
+  metadata:
+    name: workers
+- kind: ingress
+  metadata:
+    name: lb
+- kind: group
+  metadata:
+    name: workers
+    namespace: team-a
+- kind: ingress
+  metadata:
+    name: lb
+    namespace: team-a
+  depends:
+    - kind: group
+      name: workers
+`)
+	require.Equal(t, 4, len(g.index))
+
+	graph, err := g.graph()
+	require.NoError(t, err)
+	require.Equal(t, []key{{Kind: "group", Name: "workers"}}, graph.dependsOn[key{Kind: "ingress", Name: "lb"}])
+	require.Equal(t, []key{{Namespace: "team-a", Kind: "group", Name: "workers"}},
+		graph.dependsOn[key{Namespace: "team-a", Kind: "ingress", Name: "lb"}])
+}
+
+func testNamespaceManager(t *testing.T, dir string, destroyed *[]string) *manager {
+	specs, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	controllers := &testing_controller.Controller{
+		DoCommit: func(op controller.Operation, spec types.Spec) (types.Object, error) {
+			if op == controller.Destroy {
+				*destroyed = append(*destroyed, types.Namespaced(spec.Metadata.Namespace, spec.Metadata.Name))
+			}
+			return types.Object{Spec: spec}, nil
+		},
+		DoDescribe: func(metadata *types.Metadata) ([]types.Object, error) {
+			return []types.Object{{Spec: types.Spec{Kind: "ingress", Metadata: types.Metadata{Name: metadata.Name}}}}, nil
+		},
+	}
+	groups := &testing_group.Plugin{
+		DoCommitGroup: func(spec group.Spec, pretend bool) (string, error) {
+			return "", nil
+		},
+		DoDescribeGroup: func(id group.ID) (group.Description, error) {
+			return group.Description{Instances: []instance.Description{{ID: instance.ID(id)}}}, nil
+		},
+		DoDestroyGroup: func(id group.ID) error {
+			*destroyed = append(*destroyed, string(id))
+			return nil
+		},
+	}
+	scope := testing_scope.DefaultScope()
+	scope.ResolveController = func(n string) (controller.Controller, error) { return controllers, nil }
+	scope.ResolveGroup = func(n string) (group.Plugin, error) { return groups, nil }
+
+	ops := make(chan backendOp, 10)
+	go func() {
+		for op := range ops {
+			op.operation()
+		}
+	}()
+
+	return &manager{
+		scope:  scope,
+		Plugin: groups,
+		Options: Options{
+			Group:     plugin.Name("group-stateless"),
+			SpecStore: specs,
+			Quotas: map[string]Quota{
+				"team-a": {Groups: 2, Size: 10},
+			},
+		},
+		isLeader:   true,
+		term:       true,
+		backendOps: ops,
+	}
+}
+
+func groupSpec(id string, size uint) group.Spec {
+	return group.Spec{
+		ID:         group.ID(id),
+		Properties: types.AnyValueMust(group_types.Spec{Allocation: group.AllocationMethod{Size: size}}),
+	}
+}
+
+func TestNamespaceQuotas(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "namespace")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	destroyed := []string{}
+	m := testNamespaceManager(t, dir, &destroyed)
+	defer close(m.backendOps)
+
+	// no quota in the default namespace
+	_, err = m.CommitGroup(groupSpec("workers", 100), false)
+	require.NoError(t, err)
+
+	_, err = m.CommitGroup(groupSpec("team-a::workers", 8), false)
+	require.NoError(t, err)
+
+	_, err = m.CommitGroup(groupSpec("team-a::managers", 3), false)
+	require.Error(t, err)
+	require.Equal(t, `quota exceeded: namespace "team-a" would have 11 instances, quota is 10`, err.Error())
+
+	_, err = m.CommitGroup(groupSpec("team-a::managers", 2), false)
+	require.NoError(t, err)
+
+	_, err = m.CommitGroup(groupSpec("team-a::db", 0), false)
+	require.Error(t, err)
+	require.Equal(t, `quota exceeded: namespace "team-a" would have 3 groups, quota is 2`, err.Error())
+
+	specs, err := m.Specs()
+	require.NoError(t, err)
+	names := []string{}
+	for _, spec := range specs {
+		names = append(names, types.Namespaced(spec.Metadata.Namespace, spec.Metadata.Name))
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"team-a::managers", "team-a::workers", "workers"}, names)
+
+	// the quota applies to the groups committed as specs too
+	err = m.updateSpec(types.Spec{
+		Kind:       "group",
+		Metadata:   types.Metadata{Name: "db", Namespace: "team-a"},
+		Properties: types.AnyValueMust(group_types.Spec{}),
+	}, plugin.Name("group"))
+	require.Error(t, err)
+
+	// over the quota after it's lowered, the namespace can still shrink
+	m.Options.Quotas["team-a"] = Quota{Size: 5}
+	_, err = m.CommitGroup(groupSpec("team-a::workers", 9), false)
+	require.Error(t, err)
+	_, err = m.CommitGroup(groupSpec("team-a::workers", 7), false)
+	require.NoError(t, err)
+}
+
+func TestNamespaceInspectTerminate(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "namespace")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	destroyed := []string{}
+	m := testNamespaceManager(t, dir, &destroyed)
+	defer close(m.backendOps)
+
+	lb := types.Spec{
+		Kind:     "ingress",
+		Metadata: types.Metadata{Name: "lb", Namespace: "team-a"},
+		Depends:  []types.Dependency{{Kind: "group", Name: "workers"}},
+	}
+	_, err = m.CommitGroup(groupSpec("workers", 1), false)
+	require.NoError(t, err)
+	_, err = m.CommitGroup(groupSpec("team-a::workers", 1), false)
+	require.NoError(t, err)
+	require.NoError(t, m.updateSpec(lb, plugin.Name("ingress")))
+
+	objects, err := m.Inspect()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(objects))
+	require.Equal(t, "workers", objects[0].Metadata.Name)
+	require.Equal(t, "", objects[0].Metadata.Namespace)
+	require.Equal(t, "workers", objects[1].Metadata.Name)
+	require.Equal(t, "team-a", objects[1].Metadata.Namespace)
+	require.Equal(t, "team-a::workers", objects[1].Metadata.Identity.ID)
+	require.Equal(t, "lb", objects[2].Metadata.Name)
+	require.Equal(t, "team-a", objects[2].Metadata.Namespace)
+
+	// the group is depended on by the ingress
+	err = m.Terminate([]types.Spec{{Kind: "group", Metadata: types.Metadata{Name: "workers", Namespace: "team-a"}}})
+	require.Error(t, err)
+	require.Equal(t, 0, len(destroyed))
+
+	specs, err := m.Specs()
+	require.NoError(t, err)
+	require.NoError(t, m.Terminate(inNamespace(specs, "team-a")))
+	require.Equal(t, []string{"team-a::lb", "team-a::workers"}, destroyed)
+
+	specs, err = m.Specs()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(specs))
+	require.Equal(t, "workers", specs[0].Metadata.Name)
+}
+
+func TestTerminateKeepsNotDestroyed(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "namespace")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	destroyed := []string{}
+	m := testNamespaceManager(t, dir, &destroyed)
+	defer close(m.backendOps)
+
+	lb := types.Spec{
+		Kind:     "ingress",
+		Metadata: types.Metadata{Name: "lb", Namespace: "team-a"},
+		Depends:  []types.Dependency{{Kind: "group", Name: "workers"}},
+	}
+	_, err = m.CommitGroup(groupSpec("team-a::workers", 1), false)
+	require.NoError(t, err)
+	require.NoError(t, m.updateSpec(lb, plugin.Name("ingress")))
+
+	m.scope.(*testing_scope.Scope).ResolveGroup = func(n string) (group.Plugin, error) {
+		return &testing_group.Plugin{
+			DoDestroyGroup: func(id group.ID) error {
+				return fmt.Errorf("boom")
+			},
+		}, nil
+	}
+
+	specs, err := m.Specs()
+	require.NoError(t, err)
+	err = m.Terminate(specs)
+	require.Error(t, err)
+	require.Equal(t, []string{"team-a::lb"}, destroyed)
+
+	// only the ingress destroyed is removed
+	specs, err = m.Specs()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(specs))
+	require.Equal(t, "group", specs[0].Kind)
+}
+
+func inNamespace(specs []types.Spec, namespace string) []types.Spec {
+	out := []types.Spec{}
+	for _, spec := range specs {
+		if spec.Metadata.Namespace == namespace {
+			out = append(out, spec)
+		}
+	}
+	return out
+}
@@ -0,0 +1,213 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"fmt"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/stack"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Plan returns the changes of enforcing the specs, by comparing them to the stored specs and asking
+// the groups and controllers for their plans.  Nothing is committed.
+func (m *manager) Plan(specs []types.Spec) (plan stack.Plan, err error) {
+
+	if is, errLeader := m.IsLeader(); errLeader != nil || !is {
+		err = errNotLeader
+		return
+	}
+
+	m.lock.RLock()
+	current := globalSpec{}
+	err = current.load(m.Options.SpecStore)
+	m.lock.RUnlock()
+	if err != nil {
+		return
+	}
+
+	planned := globalSpec{}
+	for _, spec := range specs {
+		handler := m.handlerOf(current, spec)
+		if spec.Kind == "group" {
+			id := group.ID(types.Namespaced(spec.Metadata.Namespace, spec.Metadata.Name))
+			planned.updateGroupSpec(group.Spec{ID: id, Properties: spec.Properties}, handler)
+			continue
+		}
+		planned.updateSpec(spec, handler)
+	}
+
+	graph, err := planned.graph()
+	if err != nil {
+		return
+	}
+
+	// the removed specs are planned as they would be destroyed, dependents first
+	enforced, err := current.graph()
+	if err != nil {
+		return
+	}
+	removed := []key{}
+	order := enforced.order()
+	for i := range order {
+		k := order[len(order)-1-i]
+		if _, has := planned.index[k]; !has {
+			removed = append(removed, k)
+		}
+	}
+
+	retry := false
+	<-m.queue("plan",
+		func() (bool, error) {
+			for _, k := range graph.order() {
+				before, has := current.index[k]
+				var change stack.Change
+				if has {
+					change, err = m.planChange(k, &before, planned.index[k])
+				} else {
+					change, err = m.planChange(k, nil, planned.index[k])
+				}
+				if err != nil {
+					return retry, err
+				}
+				plan.Changes = append(plan.Changes, change)
+			}
+			for _, k := range removed {
+				var change stack.Change
+				change, err = m.planRemove(k, current.index[k])
+				if err != nil {
+					return retry, err
+				}
+				plan.Changes = append(plan.Changes, change)
+			}
+			return retry, nil
+		})
+	return
+}
+
+// handlerOf returns the plugin that handles the spec: the plugin of the stored spec, the group plugin for groups,
+// or the controller with the kind of the spec.
+func (m *manager) handlerOf(current globalSpec, spec types.Spec) plugin.Name {
+	if r, has := current.index[key{Namespace: spec.Metadata.Namespace, Kind: spec.Kind, Name: spec.Metadata.Name}]; has {
+		return r.Handler
+	}
+	if spec.Kind == "group" {
+		return m.Options.Group
+	}
+	lookup := plugin.Name(spec.Kind).Lookup()
+	for _, c := range m.Options.Controllers {
+		if c.Lookup() == lookup {
+			return c
+		}
+	}
+	return plugin.Name(lookup)
+}
+
+func (m *manager) planChange(k key, before *record, after record) (stack.Change, error) {
+	change := stack.Change{Type: stack.Added, Kind: k.Kind, Name: k.Name}
+
+	var from *types.Spec
+	if before != nil {
+		from = &before.Spec
+	}
+	diff, err := specDiff(k, from, &after.Spec)
+	if err != nil {
+		return change, err
+	}
+	change.Diff = diff
+
+	switch {
+	case before == nil:
+	case diff == "":
+		change.Type = stack.Unchanged
+		return change, nil
+	default:
+		change.Type = stack.Changed
+	}
+
+	message, err := m.planSpec(k, after, controller.Enforce)
+	if err != nil {
+		change.Error = err.Error()
+	}
+	change.Message = message
+	return change, nil
+}
+
+func (m *manager) planRemove(k key, before record) (stack.Change, error) {
+	change := stack.Change{Type: stack.Removed, Kind: k.Kind, Name: k.Name}
+
+	diff, err := specDiff(k, &before.Spec, nil)
+	if err != nil {
+		return change, err
+	}
+	change.Diff = diff
+
+	// groups have no plan for destroy
+	if k.Kind == "group" {
+		return change, nil
+	}
+	message, err := m.planSpec(k, before, controller.Destroy)
+	if err != nil {
+		change.Error = err.Error()
+	}
+	change.Message = message
+	return change, nil
+}
+
+// planSpec returns the plan of the group or controller of the spec
+func (m *manager) planSpec(k key, r record, op controller.Operation) ([]string, error) {
+	if k.Kind == "group" {
+		gp, err := m.scope.Group(r.Handler.String())
+		if err != nil {
+			return nil, err
+		}
+		message, err := gp.CommitGroup(group.Spec{ID: k.groupID(), Properties: r.Spec.Properties}, true)
+		if err != nil {
+			return nil, err
+		}
+		return []string{message}, nil
+	}
+
+	cp, err := m.scope.Controller(r.Handler.String())
+	if err != nil {
+		return nil, err
+	}
+	_, plan, err := cp.Plan(op, r.Spec)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Message, nil
+}
+
+// specDiff returns the unified diff of the specs in YAML, or an empty string if they are the same.  A nil spec
+// is a spec that does not exist.
+func specDiff(k key, before, after *types.Spec) (string, error) {
+	text := []string{"", ""}
+	for i, spec := range []*types.Spec{before, after} {
+		if spec == nil {
+			continue
+		}
+		// decode first so the YAML does not depend on the formatting of the properties
+		var v interface{}
+		if err := types.AnyValueMust(spec).Decode(&v); err != nil {
+			return "", err
+		}
+		buff, err := types.AnyValueMust(v).MarshalYAML()
+		if err != nil {
+			return "", err
+		}
+		text[i] = string(buff)
+	}
+	if text[0] == text[1] {
+		return "", nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(text[0]),
+		B:        difflib.SplitLines(text[1]),
+		FromFile: fmt.Sprintf("%v (enforced)", k),
+		ToFile:   fmt.Sprintf("%v (planned)", k),
+		Context:  3,
+	})
+}
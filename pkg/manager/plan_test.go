@@ -0,0 +1,130 @@
+package manager // import "github.com/docker/infrakit/pkg/manager"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/stack"
+	"github.com/docker/infrakit/pkg/store/file"
+	testing_controller "github.com/docker/infrakit/pkg/testing/controller"
+	testing_group "github.com/docker/infrakit/pkg/testing/group"
+	testing_scope "github.com/docker/infrakit/pkg/testing/scope"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "plan")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	specs, err := file.NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	controllers := &testing_controller.Controller{
+		DoPlan: func(op controller.Operation, spec types.Spec) (types.Object, controller.Plan, error) {
+			if spec.Kind == "gc" {
+				return types.Object{}, controller.Plan{}, fmt.Errorf("no gc")
+			}
+			return types.Object{}, controller.Plan{Message: []string{fmt.Sprintf("%v %v", op, spec.Metadata.Name)}}, nil
+		},
+	}
+	groups := &testing_group.Plugin{
+		DoCommitGroup: func(spec group.Spec, pretend bool) (string, error) {
+			require.True(t, pretend)
+			return "update " + string(spec.ID), nil
+		},
+	}
+	scope := testing_scope.DefaultScope()
+	scope.ResolveController = func(n string) (controller.Controller, error) { return controllers, nil }
+	scope.ResolveGroup = func(n string) (group.Plugin, error) { return groups, nil }
+
+	ops := make(chan backendOp, 10)
+	go func() {
+		for op := range ops {
+			op.operation()
+		}
+	}()
+	defer close(ops)
+
+	m := &manager{
+		scope: scope,
+		Options: Options{
+			Group:       plugin.Name("group-stateless"),
+			Controllers: []plugin.Name{plugin.Name("ingress"), plugin.Name("gc")},
+			SpecStore:   specs,
+		},
+		isLeader:   true,
+		term:       true,
+		backendOps: ops,
+	}
+
+	ingress := func(v int) types.Spec {
+		return types.Spec{
+			Kind:       "ingress",
+			Version:    "Ingress/v1",
+			Metadata:   types.Metadata{Name: "lb"},
+			Properties: types.AnyValueMust(map[string]interface{}{"version": v}),
+		}
+	}
+	workers := types.Spec{
+		Kind:       "group",
+		Metadata:   types.Metadata{Name: "workers"},
+		Properties: types.AnyValueMust(map[string]interface{}{"size": 3}),
+	}
+	nfs := types.Spec{
+		Kind:     "resource",
+		Version:  "Controller/v1",
+		Metadata: types.Metadata{Name: "nfs"},
+	}
+
+	require.NoError(t, m.updateSpec(ingress(1), plugin.Name("ingress")))
+	require.NoError(t, m.updateSpec(nfs, plugin.Name("resource")))
+	require.NoError(t, m.updateConfig(group.Spec{ID: "workers", Properties: workers.Properties}))
+
+	plan, err := m.Plan([]types.Spec{
+		ingress(2),
+		workers,
+		{
+			Kind:     "gc",
+			Version:  "Controller/v1",
+			Metadata: types.Metadata{Name: "nodes"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, len(plan.Changes))
+
+	require.Equal(t, stack.Change{Type: stack.Added, Kind: "gc", Name: "nodes", Error: "no gc",
+		Diff: plan.Changes[0].Diff}, plan.Changes[0])
+	require.Contains(t, plan.Changes[0].Diff, "+kind: gc")
+
+	require.Equal(t, stack.Change{Type: stack.Unchanged, Kind: "group", Name: "workers"}, plan.Changes[1])
+
+	require.Equal(t, stack.Changed, plan.Changes[2].Type)
+	require.Equal(t, "lb", plan.Changes[2].Name)
+	require.Equal(t, []string{fmt.Sprintf("%v lb", controller.Enforce)}, plan.Changes[2].Message)
+	require.Contains(t, plan.Changes[2].Diff, "-  version: 1")
+	require.Contains(t, plan.Changes[2].Diff, "+  version: 2")
+
+	require.Equal(t, stack.Removed, plan.Changes[3].Type)
+	require.Equal(t, "nfs", plan.Changes[3].Name)
+	require.Equal(t, []string{fmt.Sprintf("%v nfs", controller.Destroy)}, plan.Changes[3].Message)
+	require.Contains(t, plan.Changes[3].Diff, "-kind: resource")
+
+	// nothing is committed
+	stored, err := m.Specs()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(stored))
+
+	// the plan of specs with missing dependencies fails
+	bad := ingress(2)
+	bad.Depends = []types.Dependency{{Kind: "group", Name: "managers"}}
+	_, err = m.Plan([]types.Spec{bad})
+	require.Error(t, err)
+}
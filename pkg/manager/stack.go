@@ -2,7 +2,11 @@ package manager // import "github.com/docker/infrakit/pkg/manager"
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -35,14 +39,189 @@ func (m *manager) Specs() ([]types.Spec, error) {
 	return config.toSpecs(), nil
 }
 
-// Inspect returns the current state of the infrastructure
-func (m *manager) Inspect() ([]types.Object, error) {
+// Inspect returns the current state of the infrastructure, as described by the groups and controllers
+// of the specs.  The objects are in the namespaces of their specs.
+func (m *manager) Inspect() (objects []types.Object, err error) {
 	log.Debug("stack.Inspect")
-	return nil, fmt.Errorf("not implemented -- coming soon")
+
+	if is, errLeader := m.IsLeader(); errLeader != nil || !is {
+		err = errNotLeader
+		return
+	}
+
+	m.lock.RLock()
+	config := globalSpec{}
+	err = config.load(m.Options.SpecStore)
+	m.lock.RUnlock()
+	if err != nil {
+		return
+	}
+	graph, err := config.graph()
+	if err != nil {
+		return
+	}
+
+	retry := false
+	<-m.queue("inspect",
+		func() (bool, error) {
+			for _, k := range graph.order() {
+				var found []types.Object
+				found, err = m.describe(k, config.index[k])
+				if err != nil {
+					return retry, err
+				}
+				for _, object := range found {
+					if object.Metadata.Namespace == "" {
+						object.Metadata.Namespace = k.Namespace
+					}
+					objects = append(objects, object)
+				}
+			}
+			return retry, nil
+		})
+	if err == nil && config.freeze != nil {
+		objects = append(objects, freezeObject(config.freeze))
+	}
+	return
 }
 
-// Terminate destroys all resources associated with the specs
-func (m *manager) Terminate(specs []types.Spec) error {
+func (m *manager) describe(k key, r record) ([]types.Object, error) {
+	if k.Kind == "group" {
+		gp, err := m.scope.Group(r.Handler.String())
+		if err != nil {
+			return nil, err
+		}
+		desc, err := gp.DescribeGroup(k.groupID())
+		if err != nil {
+			return nil, err
+		}
+		state, err := types.AnyValue(desc)
+		if err != nil {
+			return nil, err
+		}
+		object := types.Object{Spec: r.Spec, State: state}
+		object.Metadata.Identity = &types.Identity{ID: string(k.groupID())}
+		return []types.Object{object}, nil
+	}
+
+	cp, err := m.scope.Controller(r.Handler.String())
+	if err != nil {
+		return nil, err
+	}
+	return cp.Describe(&r.Spec.Metadata)
+}
+
+// Terminate destroys all resources associated with the specs and removes the specs.  The specs are
+// matched by namespace, kind and name.  The specs still depended on by other specs are not terminated.
+// The dependents are destroyed first, and a spec is removed only once it's destroyed: the specs not
+// destroyed because of an error are kept.
+func (m *manager) Terminate(specs []types.Spec) (err error) {
 	log.Debug("stack.Terminate", "specs", specs)
-	return fmt.Errorf("not implemented -- destroy via direct calls to plugins")
+
+	if is, errLeader := m.IsLeader(); errLeader != nil || !is {
+		err = errNotLeader
+		return
+	}
+
+	retry := false
+	<-m.queue("terminate",
+		func() (bool, error) {
+			var order []key
+			var terminated map[key]record
+			order, terminated, err = m.terminating(specs)
+			if err != nil {
+				return retry, err
+			}
+
+			destroyed := []key{}
+			for i := range order {
+				k := order[len(order)-1-i]
+				r, has := terminated[k]
+				if !has {
+					continue
+				}
+				log.Info("Terminating", "key", k)
+				if err = m.destroy(k, r); err != nil {
+					break
+				}
+				destroyed = append(destroyed, k)
+			}
+			if removeErr := m.removeTerminated(destroyed); removeErr != nil && err == nil {
+				err = removeErr
+			}
+			return retry, err
+		})
+	return
+}
+
+// terminating returns the order of the specs and the specs to terminate, or an error if any is not
+// found or still depended on by the specs that are not terminated.
+func (m *manager) terminating(specs []types.Spec) (order []key, terminated map[key]record, err error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	stored := globalSpec{}
+	if err = stored.load(m.Options.SpecStore); err != nil {
+		return
+	}
+	graph, err := stored.graph()
+	if err != nil {
+		return
+	}
+
+	terminated = map[key]record{}
+	for _, spec := range specs {
+		k := key{Namespace: spec.Metadata.Namespace, Kind: spec.Kind, Name: spec.Metadata.Name}
+		r, has := stored.index[k]
+		if !has {
+			err = fmt.Errorf("not found %v", k)
+			return
+		}
+		terminated[k] = r
+		delete(stored.index, k)
+	}
+	if _, err = stored.graph(); err != nil {
+		return
+	}
+	order = graph.order()
+	return
+}
+
+// removeTerminated removes the specs destroyed from the stored specs, as a new revision
+func (m *manager) removeTerminated(destroyed []key) error {
+	if len(destroyed) == 0 {
+		return nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stored := globalSpec{}
+	if err := stored.load(m.Options.SpecStore); err != nil {
+		return err
+	}
+	names := []string{}
+	for _, k := range destroyed {
+		delete(stored.index, k)
+		names = append(names, k.String())
+	}
+	sort.Strings(names)
+	return m.saveSpecs(stored, "terminate "+strings.Join(names, ", "))
+}
+
+func (m *manager) destroy(k key, r record) error {
+	if k.Kind == "group" {
+		gp, err := m.scope.Group(r.Handler.String())
+		if err != nil {
+			return err
+		}
+		return group.DestroyWithToken(gp, k.groupID(), m.fencingToken())
+	}
+
+	cp, err := m.scope.Controller(r.Handler.String())
+	if err != nil {
+		return err
+	}
+	_, err = cp.Commit(controller.Destroy, m.tagged(r.Spec))
+	return err
 }
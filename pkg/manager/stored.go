@@ -6,47 +6,26 @@ import (
 
 	"github.com/docker/infrakit/pkg/plugin"
 	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/spi/stack"
 	"github.com/docker/infrakit/pkg/store"
 	"github.com/docker/infrakit/pkg/types"
 )
 
 type key struct {
-	Kind string
-	Name string
+	Namespace string `json:",omitempty" yaml:",omitempty"`
+	Kind      string
+	Name      string
 }
 
-// Ideally we calculate a DAG from the entire set of specs, but
-// for now, we just capture simple dependency ordering using a rank.
-// If any kind isn't in this map then it's defaulted to 0
+// kindRank orders the kinds of the specs that don't declare their Depends, e.g. an ingress
+// without Depends is committed after all the groups.
+// If any kind isn't in this map then it's defaulted to 0, which has no implicit dependencies.
 var kindRank = map[string]int{
 	"group":      100,
 	"ingress":    200,
 	"enrollment": 300,
 }
 
-type keys []key
-
-// Len is part of sort.Interface.
-func (keys keys) Len() int {
-	return len(keys)
-}
-
-// Swap is part of sort.Interface.
-func (keys keys) Swap(i, j int) {
-	keys[i], keys[j] = keys[j], keys[i]
-}
-
-// Less is part of sort.Interface.
-func (keys keys) Less(i, j int) bool {
-	ranki := kindRank[keys[i].Kind]
-	rankj := kindRank[keys[j].Kind]
-	diff := ranki - rankj
-	if diff == 0 {
-		return keys[i].Name < keys[j].Name
-	}
-	return diff < 0
-}
-
 type record struct {
 	// Handler is the actual plugin used to process the input
 	Handler plugin.Name
@@ -63,28 +42,14 @@ type entry struct {
 type globalSpec struct {
 	data  []entry
 	index map[key]record
-}
 
-// returns the keys in sorted order based on dependencies of kinds
-func (g *globalSpec) orderedKeys() []key {
-	all := keys{}
-	for k := range g.index {
-		all = append(all, k)
-	}
-	sort.Sort(all)
-	return all
+	// freeze is the freeze of the stack, if frozen.  It's stored as an entry of the kind stack.FreezeKind,
+	// which is not in the data or the index.
+	freeze *stack.Freeze
 }
 
-func (g *globalSpec) visit(f func(key, record) error) error {
-	keys := g.orderedKeys()
-	for _, k := range keys {
-		v := g.index[k]
-		if err := f(k, v); err != nil {
-			return err
-		}
-	}
-	return nil
-}
+// freezeKey is the key of the entry of the freeze
+var freezeKey = key{Kind: stack.FreezeKind, Name: stack.FreezeKind}
 
 func (g *globalSpec) store(store store.Snapshot) error {
 	data := []entry{}
@@ -92,17 +57,45 @@ func (g *globalSpec) store(store store.Snapshot) error {
 		data = append(data, entry{Key: k, Record: v})
 	}
 	g.data = data
-	return store.Save(g.data)
+	if g.freeze == nil {
+		return store.Save(g.data)
+	}
+	properties, err := types.AnyValue(g.freeze)
+	if err != nil {
+		return err
+	}
+	return store.Save(append(data, entry{
+		Key: freezeKey,
+		Record: record{
+			Spec: types.Spec{
+				Kind:       stack.FreezeKind,
+				Metadata:   types.Metadata{Name: stack.FreezeKind},
+				Properties: properties,
+			},
+		},
+	}))
 }
 
 func (g *globalSpec) load(store store.Snapshot) error {
-	g.data = []entry{}
-	err := store.Load(&g.data)
+	data := []entry{}
+	err := store.Load(&data)
 	if err != nil {
 		return err
 	}
+	g.data = []entry{}
 	g.index = map[key]record{}
-	for _, p := range g.data {
+	g.freeze = nil
+	for _, p := range data {
+		if p.Key == freezeKey {
+			g.freeze = &stack.Freeze{}
+			if p.Record.Spec.Properties != nil {
+				if err := p.Record.Spec.Properties.Decode(g.freeze); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		g.data = append(g.data, p)
 		g.index[p.Key] = p.Record
 	}
 	return nil
@@ -113,8 +106,9 @@ func (g *globalSpec) updateSpec(spec types.Spec, handler plugin.Name) {
 		g.index = map[key]record{}
 	}
 	key := key{
-		Kind: spec.Kind,
-		Name: spec.Metadata.Name,
+		Namespace: spec.Metadata.Namespace,
+		Kind:      spec.Kind,
+		Name:      spec.Metadata.Name,
 	}
 	g.index[key] = record{
 		Spec:    spec,
@@ -122,29 +116,37 @@ func (g *globalSpec) updateSpec(spec types.Spec, handler plugin.Name) {
 	}
 }
 
+// keyFromGroupID returns the key of the group.  The ID of a group in a namespace is qualified
+// by the namespace, e.g. team-a::workers.
 func keyFromGroupID(id group.ID) key {
-	return key{
+	k := key{
 		// TODO(chungers) - the group value should be constant for the 'kind'.
 		// Currently Kind is in the pkg/run/v0/group package and we can't have dependency on that because
 		// the pkg/run is like main/ downstream from the core package here.  So we should refactor code a bit to
 		// clean it up and make 'kind' more a top level concept.
 		Kind: "group",
-		Name: string(id),
 	}
+	k.Namespace, k.Name = types.SplitNamespaced(string(id))
+	return k
+}
+
+// groupID returns the ID of the group of the key
+func (k key) groupID() group.ID {
+	return group.ID(types.Namespaced(k.Namespace, k.Name))
 }
 
 func (g *globalSpec) removeSpec(kind string, metadata types.Metadata) {
 	if g.index == nil {
 		g.index = map[key]record{}
 	}
-	delete(g.index, key{Kind: kind, Name: metadata.Name})
+	delete(g.index, key{Namespace: metadata.Namespace, Kind: kind, Name: metadata.Name})
 }
 
 func (g *globalSpec) getSpec(kind string, metadata types.Metadata) (types.Spec, error) {
 	if g.index == nil {
 		g.index = map[key]record{}
 	}
-	r, has := g.index[key{Kind: kind, Name: metadata.Name}]
+	r, has := g.index[key{Namespace: metadata.Namespace, Kind: kind, Name: metadata.Name}]
 	if !has {
 		return types.Spec{}, fmt.Errorf("not found %v %v", kind, metadata.Name)
 	}
@@ -186,7 +188,8 @@ func (g *globalSpec) updateGroupSpec(gspec group.Spec, handler plugin.Name) {
 			Spec: types.Spec{
 				Kind: "group",
 				Metadata: types.Metadata{
-					Name: string(gspec.ID),
+					Name:      key.Name,
+					Namespace: key.Namespace,
 				},
 			},
 			Handler: handler,
@@ -89,15 +89,9 @@ func TestSortRecords(t *testing.T) {
 			Name: s2.Metadata.Name,
 		},
 	}
-	require.Equal(t, ordered, g.orderedKeys())
-
-	visited := []key{}
-	err := g.visit(func(k key, r record) error {
-		visited = append(visited, k)
-		return nil
-	})
-	require.Equal(t, ordered, visited)
+	graph, err := g.graph()
 	require.NoError(t, err)
+	require.Equal(t, ordered, graph.order())
 }
 
 func TestStoredRecords(t *testing.T) {
@@ -51,19 +51,8 @@ func (m *manager) status() (chan func(map[string]interface{}), chan struct{}) {
 					log.Warn("Cannot check leader for metadata", "err", err)
 				}
 
-				// update config
-				snapshot := map[string]interface{}{}
-				objects, err := m.Inspect()
-				if err != nil {
-					log.Warn("Error inspecting manager states", "err", err)
-					continue
-				}
-				for _, o := range objects {
-					snapshot[o.Spec.Metadata.Name] = o.Spec
-				}
-				model <- func(view map[string]interface{}) {
-					types.Put([]string{"configs"}, snapshot, view)
-				}
+				// The states of the specs are not polled here: Inspect queues work behind the
+				// leadership changes that signal this refresh.
 
 			case <-stop:
 				log.Info("Snapshot updater stopped")
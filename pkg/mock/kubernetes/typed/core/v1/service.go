@@ -0,0 +1,146 @@
+// Mock of k8s.io/client-go/kubernetes/typed/core/v1 (interfaces: ServiceInterface), written by hand in the
+// form of the mocks generated by MockGen.
+
+package v1 // import "github.com/docker/infrakit/pkg/mock/kubernetes/typed/core/v1"
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	v1 "k8s.io/client-go/pkg/api/v1"
+	rest "k8s.io/client-go/rest"
+)
+
+// Mock of ServiceInterface interface
+type MockServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *_MockServiceInterfaceRecorder
+}
+
+// Recorder for MockServiceInterface (not exported)
+type _MockServiceInterfaceRecorder struct {
+	mock *MockServiceInterface
+}
+
+func NewMockServiceInterface(ctrl *gomock.Controller) *MockServiceInterface {
+	mock := &MockServiceInterface{ctrl: ctrl}
+	mock.recorder = &_MockServiceInterfaceRecorder{mock}
+	return mock
+}
+
+func (_m *MockServiceInterface) EXPECT() *_MockServiceInterfaceRecorder {
+	return _m.recorder
+}
+
+func (_m *MockServiceInterface) Create(_param0 *v1.Service) (*v1.Service, error) {
+	ret := _m.ctrl.Call(_m, "Create", _param0)
+	ret0, _ := ret[0].(*v1.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockServiceInterfaceRecorder) Create(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Create", arg0)
+}
+
+func (_m *MockServiceInterface) Delete(_param0 string, _param1 *meta_v1.DeleteOptions) error {
+	ret := _m.ctrl.Call(_m, "Delete", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockServiceInterfaceRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Delete", arg0, arg1)
+}
+
+func (_m *MockServiceInterface) DeleteCollection(_param0 *meta_v1.DeleteOptions, _param1 meta_v1.ListOptions) error {
+	ret := _m.ctrl.Call(_m, "DeleteCollection", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockServiceInterfaceRecorder) DeleteCollection(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteCollection", arg0, arg1)
+}
+
+func (_m *MockServiceInterface) Get(_param0 string, _param1 meta_v1.GetOptions) (*v1.Service, error) {
+	ret := _m.ctrl.Call(_m, "Get", _param0, _param1)
+	ret0, _ := ret[0].(*v1.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockServiceInterfaceRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Get", arg0, arg1)
+}
+
+func (_m *MockServiceInterface) List(_param0 meta_v1.ListOptions) (*v1.ServiceList, error) {
+	ret := _m.ctrl.Call(_m, "List", _param0)
+	ret0, _ := ret[0].(*v1.ServiceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockServiceInterfaceRecorder) List(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "List", arg0)
+}
+
+func (_m *MockServiceInterface) Patch(_param0 string, _param1 types.PatchType, _param2 []byte, _param3 ...string) (*v1.Service, error) {
+	_s := []interface{}{_param0, _param1, _param2}
+	for _, _x := range _param3 {
+		_s = append(_s, _x)
+	}
+	ret := _m.ctrl.Call(_m, "Patch", _s...)
+	ret0, _ := ret[0].(*v1.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockServiceInterfaceRecorder) Patch(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	_s := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Patch", _s...)
+}
+
+func (_m *MockServiceInterface) ProxyGet(_param0 string, _param1 string, _param2 string, _param3 string, _param4 map[string]string) rest.ResponseWrapper {
+	ret := _m.ctrl.Call(_m, "ProxyGet", _param0, _param1, _param2, _param3, _param4)
+	ret0, _ := ret[0].(rest.ResponseWrapper)
+	return ret0
+}
+
+func (_mr *_MockServiceInterfaceRecorder) ProxyGet(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ProxyGet", arg0, arg1, arg2, arg3, arg4)
+}
+
+func (_m *MockServiceInterface) Update(_param0 *v1.Service) (*v1.Service, error) {
+	ret := _m.ctrl.Call(_m, "Update", _param0)
+	ret0, _ := ret[0].(*v1.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockServiceInterfaceRecorder) Update(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Update", arg0)
+}
+
+func (_m *MockServiceInterface) UpdateStatus(_param0 *v1.Service) (*v1.Service, error) {
+	ret := _m.ctrl.Call(_m, "UpdateStatus", _param0)
+	ret0, _ := ret[0].(*v1.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockServiceInterfaceRecorder) UpdateStatus(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UpdateStatus", arg0)
+}
+
+func (_m *MockServiceInterface) Watch(_param0 meta_v1.ListOptions) (watch.Interface, error) {
+	ret := _m.ctrl.Call(_m, "Watch", _param0)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockServiceInterfaceRecorder) Watch(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Watch", arg0)
+}
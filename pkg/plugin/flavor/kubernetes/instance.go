@@ -0,0 +1,126 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/plugin/flavor/kubernetes"
+
+import (
+	"fmt"
+
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// NewNodePlugin creates the instance plugin of the nodes of a kubernetes cluster
+func NewNodePlugin(nodes core_v1.NodeInterface) instance.Plugin {
+	return &NodePlugin{nodes: nodes}
+}
+
+// NodePlugin is the instance plugin of the nodes of a kubernetes cluster
+type NodePlugin struct {
+	nodes core_v1.NodeInterface
+}
+
+// DescribeInstances returns a slice of instance.Description objects, each having:
+// - Node name as ID
+// - Node labels as Tags, with the name of the node added
+// - Node "infrakit-link" label as the LogicalID (if set)
+// - Node object as Properties
+func (s *NodePlugin) DescribeInstances(labels map[string]string, properties bool) ([]instance.Description, error) {
+	list, err := s.nodes.List(meta_v1.ListOptions{})
+	if err != nil {
+		return []instance.Description{}, err
+	}
+	result := []instance.Description{}
+	for _, n := range list.Items {
+		var propsAny *types.Any
+		if properties {
+			propsAny, err = types.AnyValue(n)
+			if err != nil {
+				log.Error("DescribeInstances", "msg", "Failed to encode node properties", "error", err)
+				return []instance.Description{}, err
+			}
+		}
+		tags := map[string]string{}
+		for k, v := range n.Labels {
+			tags[k] = v
+		}
+		tags["name"] = n.Name
+		var logicalID *instance.LogicalID
+		if link, has := tags[types.LinkLabel]; has {
+			v := instance.LogicalID(link)
+			logicalID = &v
+		}
+		result = append(result, instance.Description{
+			ID:         instance.ID(n.Name),
+			LogicalID:  logicalID,
+			Properties: propsAny,
+			Tags:       tags,
+		})
+	}
+	return result, nil
+}
+
+// Drain cordons the node with the given instance ID, so no more pods are scheduled on it.
+func (s *NodePlugin) Drain(instance instance.ID) error {
+	name := string(instance)
+	node, err := s.nodes.Get(name, meta_v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return s.cordon(node)
+}
+
+func (s *NodePlugin) cordon(node *v1.Node) error {
+	if node.Spec.Unschedulable {
+		return nil
+	}
+	node.Spec.Unschedulable = true
+	if _, err := s.nodes.Update(node); err != nil {
+		log.Warn("Failed to cordon node", "name", node.Name, "error", err)
+		return err
+	}
+	log.Info("Cordoned node", "name", node.Name)
+	return nil
+}
+
+// Destroy cordons the node with the given instance ID, so no more pods are scheduled on it, and
+// then deletes it from the cluster.
+func (s *NodePlugin) Destroy(instance instance.ID, instContext instance.Context) error {
+	name := string(instance)
+	node, err := s.nodes.Get(name, meta_v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Warn("Unable to remove from cluster - node not found", "name", name)
+			return nil
+		}
+		log.Info("Node removal, failed to get node", "name", name, "error", err)
+		return err
+	}
+
+	if err := s.cordon(node); err != nil {
+		return err
+	}
+
+	if err := s.nodes.Delete(name, &meta_v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		log.Warn("Node removal, failed to delete node", "name", name, "error", err)
+		return err
+	}
+	log.Info("Successfully removed node from cluster", "name", name)
+	return nil
+}
+
+// Validate is not supported
+func (s *NodePlugin) Validate(req *types.Any) error {
+	return fmt.Errorf("Validate not supported for kubernetes node")
+}
+
+// Provision is not supported
+func (s *NodePlugin) Provision(spec instance.Spec) (*instance.ID, error) {
+	return nil, fmt.Errorf("Provision not supported for kubernetes node")
+}
+
+// Label is not supported
+func (s *NodePlugin) Label(instance instance.ID, labels map[string]string) error {
+	return fmt.Errorf("Label not supported for kubernetes node")
+}
@@ -0,0 +1,67 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/plugin/flavor/kubernetes"
+
+import (
+	"testing"
+
+	mock_core_v1 "github.com/docker/infrakit/pkg/mock/kubernetes/typed/core/v1"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestNodePluginDescribe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	node1 := v1.Node{}
+	node1.Name = "node1"
+	node1.Labels = map[string]string{"role": "worker", types.LinkLabel: "link1"}
+	node2 := v1.Node{}
+	node2.Name = "node2"
+
+	nodes := mock_core_v1.NewMockNodeInterface(ctrl)
+	nodes.EXPECT().List(meta_v1.ListOptions{}).Return(&v1.NodeList{Items: []v1.Node{node1, node2}}, nil)
+
+	described, err := NewNodePlugin(nodes).DescribeInstances(nil, true)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(described))
+
+	require.Equal(t, instance.ID("node1"), described[0].ID)
+	require.Equal(t, instance.LogicalID("link1"), *described[0].LogicalID)
+	require.Equal(t, map[string]string{"role": "worker", types.LinkLabel: "link1", "name": "node1"}, described[0].Tags)
+
+	node := v1.Node{}
+	require.NoError(t, described[0].Properties.Decode(&node))
+	require.Equal(t, "node1", node.Name)
+
+	require.Equal(t, instance.ID("node2"), described[1].ID)
+	require.Nil(t, described[1].LogicalID)
+}
+
+func TestNodePluginDestroy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	node := &v1.Node{}
+	node.Name = "node1"
+
+	nodes := mock_core_v1.NewMockNodeInterface(ctrl)
+	gomock.InOrder(
+		nodes.EXPECT().Get("node1", meta_v1.GetOptions{}).Return(node, nil),
+		nodes.EXPECT().Update(gomock.Any()).Do(func(n *v1.Node) {
+			require.True(t, n.Spec.Unschedulable)
+		}).Return(node, nil),
+		nodes.EXPECT().Delete("node1", &meta_v1.DeleteOptions{}).Return(nil),
+	)
+	require.NoError(t, NewNodePlugin(nodes).Destroy(instance.ID("node1"), instance.Termination))
+
+	// already gone
+	notFound := errors.NewNotFound(schema.GroupResource{Resource: "nodes"}, "node2")
+	nodes.EXPECT().Get("node2", meta_v1.GetOptions{}).Return(nil, notFound)
+	require.NoError(t, NewNodePlugin(nodes).Destroy(instance.ID("node2"), instance.Termination))
+}
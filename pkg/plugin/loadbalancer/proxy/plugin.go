@@ -0,0 +1,346 @@
+package proxy // import "github.com/docker/infrakit/pkg/plugin/loadbalancer/proxy"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/spi/loadbalancer"
+	"github.com/docker/infrakit/pkg/util/exec"
+)
+
+var (
+	log    = logutil.New("module", "plugin/loadbalancer/proxy")
+	debugV = logutil.V(300)
+)
+
+// Kind is the kind of proxy whose configuration is rendered
+type Kind string
+
+const (
+	// HAProxy renders a haproxy.cfg
+	HAProxy Kind = "haproxy"
+
+	// Nginx renders a nginx.conf with a stream block
+	Nginx Kind = "nginx"
+)
+
+// Options are the options of the loadbalancer
+type Options struct {
+	// Kind is the kind of proxy, haproxy or nginx
+	Kind Kind
+
+	// ConfigPath is the path of the configuration file of the proxy
+	ConfigPath string
+
+	// Template is the text of a template to render the configuration instead of the built-in one of the kind
+	Template string
+
+	// BackendAddress is a template of the address of a backend from its instance ID.  The default is the ID itself.
+	BackendAddress string
+
+	// ReloadCommand is the command run after the configuration is written, e.g. systemctl reload haproxy.
+	// The path of the configuration is the argument {{ arg 1 }} of the command.
+	ReloadCommand string
+}
+
+// Validate checks the options
+func (o Options) Validate() error {
+	switch o.Kind {
+	case HAProxy, Nginx:
+	default:
+		if o.Template == "" {
+			return fmt.Errorf("unknown kind %v", o.Kind)
+		}
+	}
+	if o.ConfigPath == "" {
+		return fmt.Errorf("no config path")
+	}
+	return nil
+}
+
+// state is the state of the loadbalancer.  It is saved next to the configuration so it survives restarts.
+type state struct {
+	Routes       map[int]loadbalancer.Route
+	Backends     map[instance.ID]struct{}
+	HealthChecks map[int]loadbalancer.HealthCheck
+}
+
+// copy returns a copy of the state
+func (s state) copy() state {
+	c := state{
+		Routes:       map[int]loadbalancer.Route{},
+		Backends:     map[instance.ID]struct{}{},
+		HealthChecks: map[int]loadbalancer.HealthCheck{},
+	}
+	for k, v := range s.Routes {
+		c.Routes[k] = v
+	}
+	for k, v := range s.Backends {
+		c.Backends[k] = v
+	}
+	for k, v := range s.HealthChecks {
+		c.HealthChecks[k] = v
+	}
+	return c
+}
+
+type proxy struct {
+	name    string
+	options Options
+	render  func(config) ([]byte, error)
+	reload  func() error
+	state   state
+	lock    sync.Mutex
+
+	// reloadPending is true after a failed reload, so the next apply reloads even if the configuration
+	// is unchanged
+	reloadPending bool
+}
+
+type result string
+
+func (r result) String() string {
+	return string(r)
+}
+
+// NewL4 returns a L4 loadbalancer that renders the configuration of a local proxy like HAProxy or nginx
+func NewL4(name string, options Options) (loadbalancer.L4, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	render, err := renderer(options)
+	if err != nil {
+		return nil, err
+	}
+	p := &proxy{
+		name:    name,
+		options: options,
+		render:  render,
+		state: state{
+			Routes:       map[int]loadbalancer.Route{},
+			Backends:     map[instance.ID]struct{}{},
+			HealthChecks: map[int]loadbalancer.HealthCheck{},
+		},
+	}
+	p.reload = p.runReload
+
+	buff, err := ioutil.ReadFile(p.statePath())
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(buff, &p.state); err != nil {
+			return nil, err
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *proxy) statePath() string {
+	return p.options.ConfigPath + ".json"
+}
+
+func (p *proxy) runReload() error {
+	if p.options.ReloadCommand == "" {
+		return nil
+	}
+	output, err := exec.Command(p.options.ReloadCommand).Output(p.options.ConfigPath)
+	if err != nil {
+		log.Error("Reload failed", "name", p.name, "command", p.options.ReloadCommand, "output", string(output), "err", err)
+		return err
+	}
+	log.Info("Reloaded", "name", p.name, "command", p.options.ReloadCommand)
+	return nil
+}
+
+// apply renders and writes the configuration and reloads the proxy, if the configuration changed or the last
+// reload failed.  If any of it fails, the state is rolled back to the previous one, in memory and on disk, along
+// with the configuration file.  It must be called with the lock held.
+func (p *proxy) apply(previous state) (err error) {
+	defer func() {
+		if err != nil {
+			p.state = previous
+		}
+	}()
+
+	buff, err := p.render(p.config())
+	if err != nil {
+		return err
+	}
+	saved, err := json.MarshalIndent(p.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	restore, err := json.MarshalIndent(previous, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	current, readErr := ioutil.ReadFile(p.options.ConfigPath)
+	changed := readErr != nil || !bytes.Equal(current, buff)
+
+	if err = writeFile(p.statePath(), saved); err != nil {
+		return err
+	}
+	if !changed && !p.reloadPending {
+		return nil
+	}
+	if changed {
+		err = writeFile(p.options.ConfigPath, buff)
+	}
+	if err == nil {
+		err = p.reload()
+		p.reloadPending = err != nil
+	}
+	if err == nil {
+		return nil
+	}
+
+	// roll back the files, so they match the state in memory
+	if e := writeFile(p.statePath(), restore); e != nil {
+		log.Error("Cannot restore state", "name", p.name, "err", e)
+	}
+	if changed && readErr == nil {
+		if e := writeFile(p.options.ConfigPath, current); e != nil {
+			log.Error("Cannot restore config", "name", p.name, "err", e)
+		}
+	}
+	return err
+}
+
+// writeFile writes the file by renaming a temp file so the proxy never sees partial content
+func writeFile(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Name is the name of the load balancer
+func (p *proxy) Name() string {
+	return p.name
+}
+
+// Routes lists all known routes.
+func (p *proxy) Routes() ([]loadbalancer.Route, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	out := []loadbalancer.Route{}
+	for _, port := range sortedPorts(p.state.Routes) {
+		out = append(out, p.state.Routes[port])
+	}
+	return out, nil
+}
+
+// Publish publishes a route in the LB by adding a load balancing rule
+func (p *proxy) Publish(route loadbalancer.Route) (loadbalancer.Result, error) {
+	log.Debug("Publish", "name", p.name, "route", route, "V", debugV)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, has := p.state.Routes[route.LoadBalancerPort]; has {
+		return result(""), fmt.Errorf("duplicate port %v", route.LoadBalancerPort)
+	}
+	if err := route.Validate(); err != nil {
+		return result(""), err
+	}
+	previous := p.state.copy()
+	p.state.Routes[route.LoadBalancerPort] = route
+	return result("publish"), p.apply(previous)
+}
+
+// Unpublish dissociates the load balancer from the backend service at the given port.
+func (p *proxy) Unpublish(extPort int) (loadbalancer.Result, error) {
+	log.Debug("Unpublish", "name", p.name, "extPort", extPort, "V", debugV)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, has := p.state.Routes[extPort]; !has {
+		return result(""), fmt.Errorf("unknown port %v", extPort)
+	}
+	previous := p.state.copy()
+	delete(p.state.Routes, extPort)
+	return result("unpublish"), p.apply(previous)
+}
+
+// ConfigureHealthCheck configures the health checks of the backends at the backend port of the health check.
+func (p *proxy) ConfigureHealthCheck(hc loadbalancer.HealthCheck) (loadbalancer.Result, error) {
+	log.Debug("ConfigureHealthCheck", "name", p.name, "healthCheck", hc, "V", debugV)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	previous := p.state.copy()
+	p.state.HealthChecks[hc.BackendPort] = hc
+	return result("healthcheck"), p.apply(previous)
+}
+
+// RegisterBackends registers instances identified by the IDs to the LB's backend pool
+func (p *proxy) RegisterBackends(ids []instance.ID) (loadbalancer.Result, error) {
+	log.Debug("RegisterBackends", "name", p.name, "ids", ids, "V", debugV)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	previous := p.state.copy()
+	for _, id := range ids {
+		p.state.Backends[id] = struct{}{}
+	}
+	return result("ok"), p.apply(previous)
+}
+
+// DeregisterBackends removes the specified instances from the backend pool
+func (p *proxy) DeregisterBackends(ids []instance.ID) (loadbalancer.Result, error) {
+	log.Debug("DeregisterBackends", "name", p.name, "ids", ids, "V", debugV)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	previous := p.state.copy()
+	for _, id := range ids {
+		delete(p.state.Backends, id)
+	}
+	return result("ok"), p.apply(previous)
+}
+
+// Backends returns a list of backends
+func (p *proxy) Backends() ([]instance.ID, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return sortedBackends(p.state.Backends), nil
+}
+
+func sortedPorts(routes map[int]loadbalancer.Route) []int {
+	ports := []int{}
+	for port := range routes {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+func sortedBackends(backends map[instance.ID]struct{}) []instance.ID {
+	out := []instance.ID{}
+	for id := range backends {
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
@@ -0,0 +1,219 @@
+package proxy // import "github.com/docker/infrakit/pkg/plugin/loadbalancer/proxy"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/spi/loadbalancer"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T, options Options) (*proxy, *int, func()) {
+	dir, err := ioutil.TempDir("", "proxy")
+	require.NoError(t, err)
+
+	options.ConfigPath = filepath.Join(dir, "proxy.cfg")
+	l4, err := NewL4("lb1", options)
+	require.NoError(t, err)
+
+	p := l4.(*proxy)
+	reloads := 0
+	p.reload = func() error {
+		reloads++
+		return nil
+	}
+	return p, &reloads, func() { os.RemoveAll(dir) }
+}
+
+func configure(t *testing.T, p *proxy) {
+	cert := "/etc/ssl/web.pem"
+	health := "/healthz"
+
+	_, err := p.Publish(loadbalancer.Route{
+		Port:                 8080,
+		Protocol:             loadbalancer.HTTP,
+		LoadBalancerPort:     443,
+		LoadBalancerProtocol: loadbalancer.HTTPS,
+		Certificate:          &cert,
+		HealthMonitorPath:    &health,
+	})
+	require.NoError(t, err)
+	_, err = p.Publish(loadbalancer.Route{
+		Port:                 30053,
+		Protocol:             loadbalancer.UDP,
+		LoadBalancerPort:     53,
+		LoadBalancerProtocol: loadbalancer.UDP,
+	})
+	require.NoError(t, err)
+	_, err = p.ConfigureHealthCheck(loadbalancer.HealthCheck{
+		BackendPort: 8080,
+		Healthy:     2,
+		Unhealthy:   3,
+		Interval:    5 * time.Second,
+		Timeout:     time.Second,
+	})
+	require.NoError(t, err)
+	_, err = p.RegisterBackends([]instance.ID{"10.0.0.2", "10.0.0.1"})
+	require.NoError(t, err)
+}
+
+func TestHAProxy(t *testing.T) {
+	p, reloads, cleanup := setup(t, Options{Kind: HAProxy})
+	defer cleanup()
+
+	configure(t, p)
+	require.Equal(t, 4, *reloads)
+
+	buff, err := ioutil.ReadFile(p.options.ConfigPath)
+	require.NoError(t, err)
+	require.Equal(t, `# Generated by infrakit for loadbalancer lb1. Do not edit.
+global
+    daemon
+
+defaults
+    timeout connect 5s
+    timeout client  1m
+    timeout server  1m
+
+# lb1_53: UDP is not supported by haproxy
+
+frontend lb1_443
+    mode http
+    bind *:443 ssl crt /etc/ssl/web.pem
+    default_backend lb1_443
+
+backend lb1_443
+    mode http
+    balance roundrobin
+    option httpchk GET /healthz
+    timeout check 1000ms
+    server 10.0.0.1 10.0.0.1:8080 check inter 5000ms rise 2 fall 3
+    server 10.0.0.2 10.0.0.2:8080 check inter 5000ms rise 2 fall 3
+`, string(buff))
+
+	// no change, no reload
+	_, err = p.RegisterBackends([]instance.ID{"10.0.0.1"})
+	require.NoError(t, err)
+	require.Equal(t, 4, *reloads)
+
+	_, err = p.Publish(loadbalancer.Route{Port: 1, Protocol: loadbalancer.TCP, LoadBalancerPort: 443,
+		LoadBalancerProtocol: loadbalancer.TCP})
+	require.Error(t, err)
+
+	_, err = p.Unpublish(443)
+	require.NoError(t, err)
+	require.Equal(t, 5, *reloads)
+	_, err = p.DeregisterBackends([]instance.ID{"10.0.0.2"})
+	require.NoError(t, err)
+	require.Equal(t, 5, *reloads) // haproxy has no udp servers, so no change
+
+	// the state survives restarts
+	l4, err := NewL4("lb1", p.options)
+	require.NoError(t, err)
+	routes, err := l4.Routes()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(routes))
+	require.Equal(t, 53, routes[0].LoadBalancerPort)
+	backends, err := l4.Backends()
+	require.NoError(t, err)
+	require.Equal(t, []instance.ID{"10.0.0.1"}, backends)
+}
+
+func TestReloadFailure(t *testing.T) {
+	p, _, cleanup := setup(t, Options{Kind: HAProxy})
+	defer cleanup()
+
+	configure(t, p)
+	before, err := ioutil.ReadFile(p.options.ConfigPath)
+	require.NoError(t, err)
+
+	reloads := 0
+	p.reload = func() error {
+		reloads++
+		if reloads == 1 {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	}
+
+	route := loadbalancer.Route{Port: 9090, Protocol: loadbalancer.TCP, LoadBalancerPort: 9000,
+		LoadBalancerProtocol: loadbalancer.TCP}
+	_, err = p.Publish(route)
+	require.Error(t, err)
+	require.Equal(t, 1, reloads)
+
+	// the state and the config are rolled back
+	_, has := p.state.Routes[9000]
+	require.False(t, has)
+	after, err := ioutil.ReadFile(p.options.ConfigPath)
+	require.NoError(t, err)
+	require.Equal(t, string(before), string(after))
+	l4, err := NewL4("lb1", p.options)
+	require.NoError(t, err)
+	routes, err := l4.Routes()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(routes))
+
+	// the retry is not a duplicate and reloads
+	_, err = p.Publish(route)
+	require.NoError(t, err)
+	require.Equal(t, 2, reloads)
+	require.False(t, p.reloadPending)
+
+	// a pending reload is retried even if the config is unchanged
+	p.reloadPending = true
+	_, err = p.RegisterBackends([]instance.ID{"10.0.0.1"})
+	require.NoError(t, err)
+	require.Equal(t, 3, reloads)
+	require.False(t, p.reloadPending)
+}
+
+func TestNginx(t *testing.T) {
+	p, _, cleanup := setup(t, Options{Kind: Nginx, BackendAddress: "{{.}}.nodes.local"})
+	defer cleanup()
+
+	configure(t, p)
+
+	buff, err := ioutil.ReadFile(p.options.ConfigPath)
+	require.NoError(t, err)
+	require.Equal(t, `# Generated by infrakit for loadbalancer lb1. Do not edit.
+events {}
+
+stream {
+
+    upstream lb1_53 {
+        server 10.0.0.1.nodes.local:30053;
+        server 10.0.0.2.nodes.local:30053;
+    }
+
+    server {
+        listen 53 udp;
+        proxy_pass lb1_53;
+    }
+
+    upstream lb1_443 {
+        server 10.0.0.1.nodes.local:8080 max_fails=3 fail_timeout=5000ms;
+        server 10.0.0.2.nodes.local:8080 max_fails=3 fail_timeout=5000ms;
+    }
+
+    server {
+        listen 443 ssl;
+        ssl_certificate /etc/ssl/web.pem;
+        ssl_certificate_key /etc/ssl/web.pem;
+        proxy_connect_timeout 1000ms;
+        proxy_pass lb1_443;
+    }
+}
+`, string(buff))
+}
+
+func TestOptionsValidate(t *testing.T) {
+	require.Error(t, Options{Kind: "envoy", ConfigPath: "/tmp/x"}.Validate())
+	require.NoError(t, Options{Kind: "envoy", ConfigPath: "/tmp/x", Template: "{{.Name}}"}.Validate())
+	require.Error(t, Options{Kind: HAProxy}.Validate())
+}
@@ -0,0 +1,166 @@
+package proxy // import "github.com/docker/infrakit/pkg/plugin/loadbalancer/proxy"
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/spi/loadbalancer"
+)
+
+// config is the input of the templates of the configuration
+type config struct {
+	// Name is the name of the loadbalancer
+	Name string
+
+	// Listeners are the routes, ordered by loadbalancer port
+	Listeners []listener
+}
+
+// listener is a route with its backends
+type listener struct {
+	loadbalancer.Route
+
+	// Name is unique name of the listener, for frontend, backend and upstream names
+	Name string
+
+	// HTTP is true if the proxy terminates HTTP, i.e. the loadbalancer protocol is HTTP, or HTTPS with a certificate
+	HTTP bool
+
+	// UDP is true if the route is for UDP
+	UDP bool
+
+	// TLS is true if the proxy terminates TLS with the certificate
+	TLS bool
+
+	// HealthCheck is the health check of the backend port, if configured
+	HealthCheck *loadbalancer.HealthCheck
+
+	// Servers are the backends
+	Servers []server
+}
+
+// server is a backend
+type server struct {
+	ID      instance.ID
+	Address string
+	Port    int
+}
+
+// config builds the input of the templates from the state.  It must be called with the lock held.
+func (p *proxy) config() config {
+	c := config{Name: p.name, Listeners: []listener{}}
+	for _, port := range sortedPorts(p.state.Routes) {
+		route := p.state.Routes[port]
+		l := listener{
+			Route:   route,
+			Name:    fmt.Sprintf("%s_%d", strings.Replace(p.name, "/", "_", -1), port),
+			UDP:     route.LoadBalancerProtocol == loadbalancer.UDP,
+			TLS:     route.Certificate != nil && (route.LoadBalancerProtocol == loadbalancer.HTTPS || route.LoadBalancerProtocol == loadbalancer.SSL),
+			Servers: []server{},
+		}
+		l.HTTP = route.LoadBalancerProtocol == loadbalancer.HTTP || (l.TLS && route.LoadBalancerProtocol == loadbalancer.HTTPS)
+		if hc, has := p.state.HealthChecks[route.Port]; has {
+			copy := hc
+			l.HealthCheck = &copy
+		}
+		for _, id := range sortedBackends(p.state.Backends) {
+			l.Servers = append(l.Servers, server{ID: id, Address: p.address(id), Port: route.Port})
+		}
+		c.Listeners = append(c.Listeners, l)
+	}
+	return c
+}
+
+// address returns the address of the backend, from the BackendAddress template if set
+func (p *proxy) address(id instance.ID) string {
+	if p.options.BackendAddress == "" {
+		return string(id)
+	}
+	t, err := template.New("address").Parse(p.options.BackendAddress)
+	if err != nil {
+		log.Warn("Bad backend address template", "template", p.options.BackendAddress, "err", err)
+		return string(id)
+	}
+	var buff bytes.Buffer
+	if err := t.Execute(&buff, id); err != nil {
+		log.Warn("Cannot render backend address", "id", id, "err", err)
+		return string(id)
+	}
+	return buff.String()
+}
+
+var funcs = template.FuncMap{
+	"ms": func(d time.Duration) int64 {
+		return int64(d / time.Millisecond)
+	},
+}
+
+// renderer returns the function that renders the configuration from the built-in template of the kind,
+// or from the template in the options.
+func renderer(options Options) (func(config) ([]byte, error), error) {
+	text := options.Template
+	if text == "" {
+		switch options.Kind {
+		case HAProxy:
+			text = haproxyTemplate
+		case Nginx:
+			text = nginxTemplate
+		}
+	}
+	t, err := template.New(string(options.Kind)).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return func(c config) ([]byte, error) {
+		var buff bytes.Buffer
+		err := t.Execute(&buff, c)
+		return buff.Bytes(), err
+	}, nil
+}
+
+const haproxyTemplate = `# Generated by infrakit for loadbalancer {{ .Name }}. Do not edit.
+global
+    daemon
+
+defaults
+    timeout connect 5s
+    timeout client  1m
+    timeout server  1m
+{{ range .Listeners }}{{ if .UDP }}
+# {{ .Name }}: UDP is not supported by haproxy
+{{ else }}
+frontend {{ .Name }}
+    mode {{ if .HTTP }}http{{ else }}tcp{{ end }}
+    bind *:{{ .LoadBalancerPort }}{{ if .TLS }} ssl crt {{ .Certificate }}{{ end }}
+    default_backend {{ .Name }}
+
+backend {{ .Name }}
+    mode {{ if .HTTP }}http{{ else }}tcp{{ end }}
+    balance roundrobin{{ if and .HTTP .HealthMonitorPath }}
+    option httpchk GET {{ .HealthMonitorPath }}{{ end }}{{ with .HealthCheck }}{{ if .Timeout }}
+    timeout check {{ ms .Timeout }}ms{{ end }}{{ end }}
+{{ $hc := .HealthCheck }}{{ $https := eq .Protocol "HTTPS" }}{{ range .Servers }}    server {{ .ID }} {{ .Address }}:{{ .Port }}{{ if $https }} ssl verify none{{ end }}{{ with $hc }} check{{ if .Interval }} inter {{ ms .Interval }}ms{{ end }}{{ if .Healthy }} rise {{ .Healthy }}{{ end }}{{ if .Unhealthy }} fall {{ .Unhealthy }}{{ end }}{{ end }}
+{{ end }}{{ end }}{{ end }}`
+
+const nginxTemplate = `# Generated by infrakit for loadbalancer {{ .Name }}. Do not edit.
+events {}
+
+stream {
+{{ range .Listeners }}
+    upstream {{ .Name }} {
+{{ $hc := .HealthCheck }}{{ range .Servers }}        server {{ .Address }}:{{ .Port }}{{ with $hc }}{{ if .Unhealthy }} max_fails={{ .Unhealthy }}{{ end }}{{ if .Interval }} fail_timeout={{ ms .Interval }}ms{{ end }}{{ end }};
+{{ end }}    }
+
+    server {
+        listen {{ .LoadBalancerPort }}{{ if .UDP }} udp{{ end }}{{ if .TLS }} ssl{{ end }};{{ if .TLS }}
+        ssl_certificate {{ .Certificate }};
+        ssl_certificate_key {{ .Certificate }};{{ end }}{{ with .HealthCheck }}{{ if .Timeout }}
+        proxy_connect_timeout {{ ms .Timeout }}ms;{{ end }}{{ end }}
+        proxy_pass {{ .Name }};
+    }
+{{ end }}}
+`
@@ -0,0 +1,77 @@
+package rpc // import "github.com/docker/infrakit/pkg/rpc"
+
+import (
+	"strings"
+	"time"
+)
+
+// AuditedMethods are the methods, as Interface.Method, of the calls that change the infrastructure.  These
+// calls are recorded by the servers and are queryable at URLAudit.
+var AuditedMethods = []string{
+	"Instance.Provision",
+	"Instance.Destroy",
+	"Group.CommitGroup",
+	"Group.SetSize",
+	"Group.FreeGroup",
+	"Group.DestroyGroup",
+	"Group.DestroyInstances",
+	"Controller.Commit",
+	"Controller.Free",
+	"Manager.Enforce",
+	"Manager.Terminate",
+	"Manager.Rollback",
+	"Manager.Freeze",
+	"Manager.Unfreeze",
+	"Updatable.Commit",
+}
+
+// Audited returns true if calls of the method are audited
+func Audited(method string) bool {
+	for _, m := range AuditedMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditRecord is the record of a mutating call to a plugin
+type AuditRecord struct {
+	// Time is when the call was received
+	Time time.Time
+
+	// Plugin is the name of the plugin called
+	Plugin string
+
+	// Method is the method called, as Interface.Method
+	Method string
+
+	// Caller is the name of the authenticated caller, if the server authenticates its callers
+	Caller string `json:",omitempty"`
+
+	// Roles are the roles of the authenticated caller
+	Roles []string `json:",omitempty"`
+
+	// Remote is the remote address of the caller
+	Remote string `json:",omitempty"`
+
+	// Fingerprint is the fingerprint of the arguments of the call
+	Fingerprint string
+
+	// Error is the error returned by the call, if it failed
+	Error string `json:",omitempty"`
+
+	// Duration is how long the call took
+	Duration time.Duration
+}
+
+// Kind returns the kind of the call, which is the method without the interface, e.g. SetSize
+func (r AuditRecord) Kind() string {
+	return r.Method[strings.LastIndex(r.Method, ".")+1:]
+}
+
+// IsKind returns true if the record is of the kind, given either as the kind, e.g. Commit, or as the
+// method, e.g. Controller.Commit.
+func (r AuditRecord) IsKind(kind string) bool {
+	return kind == r.Method || kind == r.Kind()
+}
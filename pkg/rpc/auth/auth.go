@@ -0,0 +1,111 @@
+package auth // import "github.com/docker/infrakit/pkg/rpc/auth"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/run/local"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+var log = logutil.New("module", "rpc/auth")
+
+const (
+	// EnvOptions is the environment variable for the path of the file of the auth Options of the rpc servers.
+	// If not set, the servers accept all requests.
+	EnvOptions = "INFRAKIT_AUTH_OPTIONS"
+)
+
+// Identity is the authenticated caller of a request
+type Identity struct {
+	// Name is the name of the caller, e.g. the common name of the client certificate
+	Name string
+
+	// Roles are the roles of the caller, matched by the rules of the policy
+	Roles []string `json:",omitempty" yaml:",omitempty"`
+}
+
+// Authenticator authenticates the callers of requests
+type Authenticator interface {
+	// Authenticate returns the identity of the caller of the request, or nil if the request does not
+	// have credentials of this kind.  An error is returned if the credentials are not valid.
+	Authenticate(req *http.Request) (*Identity, error)
+}
+
+// Tokens authenticates the requests with bearer tokens, by the identities of the tokens
+type Tokens map[string]Identity
+
+// Authenticate implements Authenticator
+func (t Tokens) Authenticate(req *http.Request) (*Identity, error) {
+	header := req.Header.Get("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, fmt.Errorf("not a bearer token")
+	}
+	identity, has := t[strings.TrimSpace(header[len("Bearer "):])]
+	if !has {
+		return nil, fmt.Errorf("unknown token")
+	}
+	return &identity, nil
+}
+
+// ClientCertificates authenticates the requests with the verified client certificates of TLS connections.  The
+// name is the common name of the certificate and the roles are its organizational units.
+type ClientCertificates struct{}
+
+// Authenticate implements Authenticator
+func (c ClientCertificates) Authenticate(req *http.Request) (*Identity, error) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	subject := req.TLS.VerifiedChains[0][0].Subject
+	return &Identity{
+		Name:  subject.CommonName,
+		Roles: subject.OrganizationalUnit,
+	}, nil
+}
+
+// Options are the options of authentication and authorization
+type Options struct {
+	// Tokens are the bearer tokens and the identities they authenticate
+	Tokens Tokens `json:",omitempty" yaml:",omitempty"`
+
+	// ClientCertificates authenticates the callers of TLS connections by their client certificates
+	ClientCertificates bool `json:",omitempty" yaml:",omitempty"`
+
+	// Local is the identity of the callers over unix sockets without other credentials.  If not set, these
+	// callers are not authenticated.  The requests proxied by the mux are local to the plugins, so the rules
+	// for the remote callers are enforced only by the mux.
+	Local *Identity `json:",omitempty" yaml:",omitempty"`
+
+	// Rules are the rules of the policy.  A request is allowed if a rule allows it.
+	Rules []Rule
+}
+
+// Default returns the middleware of the options in the file at the path of the environment variable EnvOptions,
+// or nil if the variable is not set.
+func Default() (*Middleware, error) {
+	path := local.Getenv(EnvOptions, "")
+	if path == "" {
+		return nil, nil
+	}
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	any, err := types.AnyYAML(buff)
+	if err != nil {
+		return nil, err
+	}
+	options := Options{}
+	if err := any.Decode(&options); err != nil {
+		return nil, err
+	}
+	log.Info("Loaded auth options", "path", path, "rules", len(options.Rules))
+	return New(options), nil
+}
@@ -0,0 +1,222 @@
+package auth // import "github.com/docker/infrakit/pkg/rpc/auth"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	broker "github.com/docker/infrakit/pkg/broker/server"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+const (
+	// TopicDenied is the topic of the events of the denied requests
+	TopicDenied = "auth/denied"
+)
+
+// ErrNotAuthenticated is the error when the caller of a request is not authenticated
+type ErrNotAuthenticated string
+
+func (e ErrNotAuthenticated) Error() string {
+	return fmt.Sprintf("not authenticated: %s", string(e))
+}
+
+// StatusCode implements broker.StatusCoder
+func (e ErrNotAuthenticated) StatusCode() int {
+	return http.StatusUnauthorized
+}
+
+// ErrDenied is the error when the caller of a request is not allowed to call the method
+type ErrDenied string
+
+func (e ErrDenied) Error() string {
+	return fmt.Sprintf("denied: %s", string(e))
+}
+
+// StatusCode implements broker.StatusCoder
+func (e ErrDenied) StatusCode() int {
+	return http.StatusForbidden
+}
+
+// Denial is the data of the event of a denied request
+type Denial struct {
+	// Identity is the caller, if authenticated
+	Identity *Identity `json:",omitempty"`
+
+	// Method is the method called, as Interface.Method
+	Method string `json:",omitempty"`
+
+	// Namespaces are the namespaces of the call
+	Namespaces []string `json:",omitempty"`
+
+	// Remote is the remote address of the caller
+	Remote string `json:",omitempty"`
+
+	// Error is the reason of the denial
+	Error string
+}
+
+// Middleware authenticates and authorizes the requests to the rpc servers, the mux and the event streams.
+// The denied requests are published as events on TopicDenied.
+type Middleware struct {
+	authenticators []Authenticator
+	local          *Identity
+	rules          []Rule
+
+	events chan<- *event.Event
+	lock   sync.RWMutex
+}
+
+// New returns the middleware of the options
+func New(options Options) *Middleware {
+	m := &Middleware{
+		local: options.Local,
+		rules: options.Rules,
+	}
+	if options.ClientCertificates {
+		m.authenticators = append(m.authenticators, ClientCertificates{})
+	}
+	if len(options.Tokens) > 0 {
+		m.authenticators = append(m.authenticators, options.Tokens)
+	}
+	return m
+}
+
+// PublishOn implements event.Publisher
+func (m *Middleware) PublishOn(c chan<- *event.Event) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.events = c
+}
+
+// Authenticate returns the identity of the caller of the request
+func (m *Middleware) Authenticate(req *http.Request) (*Identity, error) {
+	for _, authenticator := range m.authenticators {
+		identity, err := authenticator.Authenticate(req)
+		if err != nil {
+			return nil, ErrNotAuthenticated(err.Error())
+		}
+		if identity != nil {
+			return identity, nil
+		}
+	}
+	if m.local != nil && isLocal(req) {
+		identity := *m.local
+		return &identity, nil
+	}
+	return nil, ErrNotAuthenticated("no credentials")
+}
+
+// Authorize returns an error if the caller of the request is not allowed to call the method in the namespaces
+func (m *Middleware) Authorize(req *http.Request, method string, namespaces []string) error {
+	identity, err := m.Authenticate(req)
+	if err != nil {
+		m.deny(req, nil, method, namespaces, err)
+		return err
+	}
+	if !allowed(m.rules, *identity, method, namespaces) {
+		err = ErrDenied(fmt.Sprintf("%v cannot call %v", identity.Name, method))
+		m.deny(req, identity, method, namespaces, err)
+		return err
+	}
+	return nil
+}
+
+// Subscribe returns an error if the caller of the request is not allowed to subscribe to the topic.
+// Topics are not in namespaces.
+func (m *Middleware) Subscribe(req *http.Request, topic string) error {
+	return m.Authorize(req, MethodSubscribe, nil)
+}
+
+// Handler returns the handler that authorizes the JSON-RPC calls before calling the next handler.  The
+// other requests, e.g. the event streams, are authenticated only, and their subscriptions are authorized
+// with Subscribe.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			if _, err := m.Authenticate(req); err != nil {
+				m.deny(req, nil, "", nil, err)
+				http.Error(resp, err.Error(), broker.AuthorizeStatusCode(err))
+				return
+			}
+			next.ServeHTTP(resp, req)
+			return
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		call := struct {
+			Method string           `json:"method"`
+			Params *types.Any       `json:"params"`
+			ID     *json.RawMessage `json:"id"`
+		}{}
+		if err := json.Unmarshal(body, &call); err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := m.Authorize(req, call.Method, namespacesOf(call.Method, call.Params)); err != nil {
+			writeError(resp, broker.AuthorizeStatusCode(err), call.ID, err)
+			return
+		}
+		next.ServeHTTP(resp, req)
+	})
+}
+
+// writeError writes the error as the JSON-RPC error of the call so the clients return it
+func writeError(resp http.ResponseWriter, status int, id *json.RawMessage, err error) {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+	json.NewEncoder(resp).Encode(struct {
+		Version string           `json:"jsonrpc"`
+		Error   *json2.Error     `json:"error"`
+		ID      *json.RawMessage `json:"id"`
+	}{
+		Version: "2.0",
+		Error:   &json2.Error{Code: json2.E_SERVER, Message: err.Error()},
+		ID:      id,
+	})
+}
+
+func (m *Middleware) deny(req *http.Request, identity *Identity, method string, namespaces []string, err error) {
+	log.Warn("Denied", "identity", identity, "method", method, "namespaces", namespaces,
+		"remote", req.RemoteAddr, "err", err)
+
+	m.lock.RLock()
+	events := m.events
+	m.lock.RUnlock()
+	if events == nil {
+		return
+	}
+
+	denial := Denial{
+		Identity:   identity,
+		Method:     method,
+		Namespaces: namespaces,
+		Remote:     req.RemoteAddr,
+		Error:      err.Error(),
+	}
+	select {
+	case events <- event.Event{Type: "auth", ID: method}.Init().WithTopic(TopicDenied).WithDataMust(denial):
+	case <-time.After(1 * time.Second):
+		log.Warn("Dropped denial event", "denial", denial)
+	}
+}
+
+// isLocal returns true if the request is over a unix socket
+func isLocal(req *http.Request) bool {
+	addr, is := req.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return is && addr.Network() == "unix"
+}
@@ -0,0 +1,82 @@
+package auth // import "github.com/docker/infrakit/pkg/rpc/auth"
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	broker "github.com/docker/infrakit/pkg/broker/server"
+	"github.com/docker/infrakit/pkg/spi/event"
+	"github.com/stretchr/testify/require"
+)
+
+func testCall(t *testing.T, url, token, body string) int {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestMiddleware(t *testing.T) {
+
+	m := New(Options{
+		Tokens: Tokens{
+			"viewer-token": Identity{Name: "joe", Roles: []string{"viewer"}},
+			"team-a-token": Identity{Name: "jane", Roles: []string{"team-a"}},
+		},
+		Rules: []Rule{
+			{Roles: []string{"viewer"}, Methods: []string{"Group.Describe*"}},
+			{Roles: []string{"team-a"}, Methods: []string{"Group.*"}, Namespaces: []string{"team-a"}},
+		},
+	})
+
+	events := make(chan *event.Event, 10)
+	m.PublishOn(events)
+
+	called := 0
+	server := httptest.NewServer(m.Handler(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		called++
+	})))
+	defer server.Close()
+
+	describe := `{"jsonrpc":"2.0","method":"Group.DescribeGroup","params":{"ID":"team-a::workers"},"id":1}`
+	destroy := `{"jsonrpc":"2.0","method":"Group.DestroyGroup","params":{"ID":"team-a::workers"},"id":2}`
+	destroyB := `{"jsonrpc":"2.0","method":"Group.DestroyGroup","params":{"ID":"team-b::workers"},"id":3}`
+
+	require.Equal(t, http.StatusUnauthorized, testCall(t, server.URL, "", describe))
+	require.Equal(t, http.StatusUnauthorized, testCall(t, server.URL, "bad-token", describe))
+	require.Equal(t, http.StatusOK, testCall(t, server.URL, "viewer-token", describe))
+	require.Equal(t, http.StatusForbidden, testCall(t, server.URL, "viewer-token", destroy))
+	require.Equal(t, http.StatusOK, testCall(t, server.URL, "team-a-token", destroy))
+	require.Equal(t, http.StatusForbidden, testCall(t, server.URL, "team-a-token", destroyB))
+	require.Equal(t, 2, called)
+
+	require.Equal(t, 4, len(events))
+	for i := 0; i < 3; i++ {
+		<-events
+	}
+	denied := <-events
+	require.Equal(t, TopicDenied, denied.Topic.String())
+	denial := Denial{}
+	require.NoError(t, denied.Data.Decode(&denial))
+	require.Equal(t, "jane", denial.Identity.Name)
+	require.Equal(t, "Group.DestroyGroup", denial.Method)
+	require.Equal(t, []string{"team-b"}, denial.Namespaces)
+
+	// event streams are authorized by Subscribe
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/events?topic=instance", nil)
+	require.NoError(t, err)
+	err = m.Subscribe(req, "instance")
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, broker.AuthorizeStatusCode(err))
+	req.Header.Set("Authorization", "Bearer viewer-token")
+	err = m.Subscribe(req, "instance")
+	require.Error(t, err)
+	require.Equal(t, http.StatusForbidden, broker.AuthorizeStatusCode(err))
+}
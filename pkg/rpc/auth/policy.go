@@ -0,0 +1,153 @@
+package auth // import "github.com/docker/infrakit/pkg/rpc/auth"
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/docker/infrakit/pkg/spi/group"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+const (
+	// AnyRole matches the roles of every authenticated caller
+	AnyRole = "*"
+
+	// MethodSubscribe is the method of subscribing to the event topics, as authorized by the rules
+	MethodSubscribe = "Event.Subscribe"
+)
+
+// Rule allows the callers with any of the roles to call the methods, in the namespaces.
+type Rule struct {
+	// Roles are the roles allowed.  AnyRole allows all authenticated callers.
+	Roles []string
+
+	// Methods are the patterns of the methods allowed, as Interface.Method, e.g. Group.Describe* or Controller.*
+	Methods []string
+
+	// Namespaces are the namespaces allowed.  If empty, all namespaces are allowed.  The default namespace is "".
+	// The calls not in any namespace, e.g. Manager.Rollback, are allowed only by the rules without Namespaces.
+	Namespaces []string `json:",omitempty" yaml:",omitempty"`
+}
+
+func (r Rule) allows(identity Identity, method string, namespaces []string) bool {
+	if !r.hasRole(identity) || !r.hasMethod(method) {
+		return false
+	}
+	// topics are not in namespaces
+	return method == MethodSubscribe || r.hasNamespaces(namespaces)
+}
+
+func (r Rule) hasRole(identity Identity) bool {
+	for _, role := range r.Roles {
+		if role == AnyRole {
+			return true
+		}
+		for _, has := range identity.Roles {
+			if role == has {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r Rule) hasMethod(method string) bool {
+	for _, pattern := range r.Methods {
+		if match, err := path.Match(pattern, method); err == nil && match {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) hasNamespaces(namespaces []string) bool {
+	if len(r.Namespaces) == 0 {
+		return true
+	}
+	if len(namespaces) == 0 {
+		return false
+	}
+	for _, namespace := range namespaces {
+		allowed := false
+		for _, n := range r.Namespaces {
+			if n == namespace {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// allowed returns true if a rule allows the call.  The methods of the handshake are allowed for all authenticated
+// callers so that clients can connect.
+func allowed(rules []Rule, identity Identity, method string, namespaces []string) bool {
+	if strings.HasPrefix(method, "Handshake.") {
+		return true
+	}
+	for _, rule := range rules {
+		if rule.allows(identity, method, namespaces) {
+			return true
+		}
+	}
+	return false
+}
+
+// namespacesOf returns the namespaces of the specs and groups in the parameters of the call.  These are
+// the namespaces of the metadata of the parameters or their specs, and for groups, of the group IDs.
+// The parameters are decoded into the types of the requests, like the handlers do, so that the namespaces
+// are those the handlers see, even if the keys differ in case.  A call without namespaces is allowed only
+// by the rules without namespaces.
+func namespacesOf(method string, params *types.Any) []string {
+	found := map[string]bool{}
+
+	if strings.HasPrefix(method, "Group.") {
+		arg := struct {
+			ID   *group.ID
+			Spec *struct {
+				ID group.ID
+			}
+		}{}
+		if params != nil {
+			params.Decode(&arg)
+		}
+		if arg.ID != nil {
+			ns, _ := types.SplitNamespaced(string(*arg.ID))
+			found[ns] = true
+		}
+		if arg.Spec != nil {
+			ns, _ := types.SplitNamespaced(string(arg.Spec.ID))
+			found[ns] = true
+		}
+	} else {
+		// the Metadata of the parameters, e.g. of Controller.Describe, and the metadata of the specs
+		arg := struct {
+			Metadata *types.Metadata
+			Spec     *types.Spec
+			Specs    []types.Spec
+		}{}
+		if params != nil {
+			params.Decode(&arg)
+		}
+		if arg.Metadata != nil {
+			found[arg.Metadata.Namespace] = true
+		}
+		if arg.Spec != nil {
+			found[arg.Spec.Metadata.Namespace] = true
+		}
+		for _, spec := range arg.Specs {
+			found[spec.Metadata.Namespace] = true
+		}
+	}
+
+	namespaces := []string{}
+	for ns := range found {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
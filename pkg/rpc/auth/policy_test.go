@@ -0,0 +1,88 @@
+package auth // import "github.com/docker/infrakit/pkg/rpc/auth"
+
+import (
+	"testing"
+
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+
+	rules := []Rule{
+		{
+			Roles:   []string{AnyRole},
+			Methods: []string{"*.Describe*", MethodSubscribe},
+		},
+		{
+			Roles:      []string{"team-a"},
+			Methods:    []string{"Group.*", "Controller.*"},
+			Namespaces: []string{"team-a"},
+		},
+		{
+			Roles:   []string{"admin"},
+			Methods: []string{"*"},
+		},
+	}
+
+	viewer := Identity{Name: "joe"}
+	teamA := Identity{Name: "jane", Roles: []string{"team-a"}}
+	admin := Identity{Name: "root", Roles: []string{"admin"}}
+
+	require.True(t, allowed(rules, viewer, "Group.DescribeGroup", nil))
+	require.True(t, allowed(rules, viewer, "Instance.DescribeInstances", []string{"team-a"}))
+	require.True(t, allowed(rules, viewer, MethodSubscribe, nil))
+	require.True(t, allowed(rules, viewer, "Handshake.Hello", nil))
+	require.False(t, allowed(rules, viewer, "Group.DestroyGroup", nil))
+
+	require.True(t, allowed(rules, teamA, "Group.DestroyGroup", []string{"team-a"}))
+	require.False(t, allowed(rules, teamA, "Group.DestroyGroup", nil))
+	require.False(t, allowed(rules, teamA, "Controller.Commit", nil))
+	require.True(t, allowed(rules, teamA, MethodSubscribe, nil))
+	require.False(t, allowed(rules, teamA, "Group.DestroyGroup", []string{""}))
+	require.False(t, allowed(rules, teamA, "Group.DestroyGroup", []string{"team-a", "team-b"}))
+	require.False(t, allowed(rules, teamA, "Instance.Destroy", []string{"team-a"}))
+
+	require.True(t, allowed(rules, admin, "Instance.Destroy", []string{"team-b"}))
+	require.False(t, allowed(nil, admin, "Instance.Destroy", nil))
+}
+
+func TestNamespacesOf(t *testing.T) {
+
+	params := func(v interface{}) *types.Any {
+		return types.AnyValueMust(v)
+	}
+	workers := types.Spec{Kind: "group", Metadata: types.Metadata{Name: "workers"}}
+	lb := types.Spec{Kind: "ingress", Metadata: types.Metadata{Name: "lb", Namespace: "team-a"}}
+
+	require.Equal(t, []string{"team-a"},
+		namespacesOf("Group.DescribeGroup", params(map[string]interface{}{"ID": "team-a::workers"})))
+	require.Equal(t, []string{""},
+		namespacesOf("Group.DescribeGroup", params(map[string]interface{}{"ID": "workers/a"})))
+	require.Equal(t, []string{"team-b"},
+		namespacesOf("Group.CommitGroup", params(map[string]interface{}{
+			"Spec": map[string]interface{}{"ID": "team-b::workers"},
+		})))
+	require.Equal(t, []string{"", "team-a"},
+		namespacesOf("Manager.Plan", params(map[string]interface{}{"Specs": []types.Spec{workers, lb}})))
+	require.Equal(t, []string{"team-a"},
+		namespacesOf("Controller.Commit", params(map[string]interface{}{"Operation": 0, "Spec": lb})))
+	require.Equal(t, []string{"team-a"},
+		namespacesOf("Controller.Describe", params(map[string]interface{}{"Metadata": lb.Metadata})))
+	require.Equal(t, []string{},
+		namespacesOf("Controller.Describe", params(map[string]interface{}{"Metadata": nil})))
+	require.Equal(t, []string{},
+		namespacesOf("Manager.Rollback", params(map[string]interface{}{"Revision": 1})))
+	require.Equal(t, []string{},
+		namespacesOf("Instance.Destroy", params(map[string]interface{}{"Instance": "i-1"})))
+	require.Equal(t, []string{}, namespacesOf("Handshake.Hello", nil))
+
+	// the keys are matched regardless of case, like the handlers decode them
+	require.Equal(t, []string{"team-b"}, namespacesOf("Controller.Commit",
+		types.AnyString(`{"Spec":{"kind":"group","metadata":{"name":"w","Namespace":"team-b"}}}`)))
+	require.Equal(t, []string{"team-b", "team-c"}, namespacesOf("Manager.Plan",
+		types.AnyString(`{"spec":{"Metadata":{"NAMESPACE":"team-b"}},"specs":[{"metadata":{"namespace":"team-c"}}]}`)))
+	require.Equal(t, []string{"team-b"}, namespacesOf("Group.DestroyGroup", types.AnyString(`{"id":"team-b::workers"}`)))
+	require.Equal(t, []string{"team-b"}, namespacesOf("Group.CommitGroup",
+		types.AnyString(`{"spec":{"id":"team-b::workers"}}`)))
+}
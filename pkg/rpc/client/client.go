@@ -141,11 +141,17 @@ func parseAddress(address string) (connectURL *url.URL, httpClient *http.Client,
 	case "http", "https":
 
 		if httpClient == nil {
+			tlsConfig, e := local.ClientTLS()
+			if e != nil {
+				err = e
+				return
+			}
 			transport := &http.Transport{
 				Dial: (&net.Dialer{
 					Timeout: local.ClientTimeout(),
 				}).Dial,
 				TLSHandshakeTimeout: local.ClientTimeout(),
+				TLSClientConfig:     tlsConfig,
 			}
 			httpClient = &http.Client{Transport: transport}
 			cacheClient(address, httpClient)
@@ -188,6 +194,9 @@ func (c client) Call(method string, arg interface{}, result interface{}) error {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if token := local.AuthToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	requestData, err := httputil.DumpRequest(req, true)
 	if err == nil {
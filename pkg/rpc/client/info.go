@@ -2,12 +2,17 @@ package client // import "github.com/docker/infrakit/pkg/rpc/client"
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/docker/infrakit/pkg/plugin"
 	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/run/local"
 	"github.com/docker/infrakit/pkg/template"
 )
 
@@ -58,3 +63,39 @@ func (i *InfoClient) GetFunctions() (map[string][]template.Function, error) {
 	err = json.NewDecoder(resp.Body).Decode(&meta)
 	return meta, err
 }
+
+// GetAudit returns the audit records of the mutating calls to the plugin since the given time, of the kind
+// if not empty, in order of time.
+func (i *InfoClient) GetAudit(since time.Time, kind string) ([]rpc.AuditRecord, error) {
+	records := []rpc.AuditRecord{}
+
+	dest := *i.url
+	dest.Path = path.Clean(path.Join(i.url.Path, rpc.URLAudit))
+	query := url.Values{}
+	if !since.IsZero() {
+		query.Set("since", since.Format(time.RFC3339Nano))
+	}
+	if kind != "" {
+		query.Set("kind", kind)
+	}
+	dest.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, dest.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := local.AuthToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		buff, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(buff)))
+	}
+	err = json.NewDecoder(resp.Body).Decode(&records)
+	return records, err
+}
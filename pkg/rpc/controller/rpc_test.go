@@ -2,13 +2,17 @@ package controller // import "github.com/docker/infrakit/pkg/rpc/controller"
 
 import (
 	"fmt"
+	"net/url"
 	"path/filepath"
 	"reflect"
 	"testing"
 
+	"github.com/docker/infrakit/pkg/fsm"
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	"github.com/docker/infrakit/pkg/plugin"
 	rpc_server "github.com/docker/infrakit/pkg/rpc/server"
 	"github.com/docker/infrakit/pkg/spi/controller"
+	"github.com/docker/infrakit/pkg/spi/stack"
 	testing_controller "github.com/docker/infrakit/pkg/testing/controller"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/stretchr/testify/require"
@@ -102,6 +106,54 @@ func TestControllerCommit(t *testing.T) {
 
 }
 
+func TestControllerCommitFreeze(t *testing.T) {
+	socketPath := tempSocket()
+	name := filepath.Base(socketPath)
+	defer freeze.Set(nil)
+
+	committed := make(chan types.Spec, 1)
+	frozen := make(chan error, 1)
+	c := &testing_controller.Controller{
+		DoCommit: func(operation controller.Operation, spec types.Spec) (types.Object, error) {
+			committed <- spec
+			frozen <- freeze.Check()
+			return types.Object{Spec: spec}, nil
+		},
+	}
+	server, err := rpc_server.StartPluginAtPath(socketPath, Server(c))
+	require.NoError(t, err)
+	defer server.Stop()
+
+	client := must(NewClient(plugin.Name(name), socketPath))
+	spec := types.Spec{Metadata: types.Metadata{Name: "small"}}
+
+	// the controllers observe the freeze of the manager, which the tag doesn't reach
+	_, err = client.Commit(controller.Enforce, freeze.With(spec, &stack.Freeze{Reason: "incident"}))
+	require.NoError(t, err)
+	require.Equal(t, spec.Metadata.Name, (<-committed).Metadata.Name)
+	require.Equal(t, freeze.ErrFrozen{Reason: "incident"}, <-frozen)
+
+	// commits not from the manager leave the freeze as is
+	_, err = client.Commit(controller.Enforce, spec)
+	require.NoError(t, err)
+	<-committed
+	require.Error(t, <-frozen)
+
+	// nor do the commits of past leaders
+	_, err = client.Commit(controller.Enforce, controller.WithToken(spec, 2))
+	require.NoError(t, err)
+	<-committed
+	<-frozen
+	_, err = client.Commit(controller.Enforce, controller.WithToken(freeze.With(spec, nil), 1))
+	require.Error(t, err)
+	require.Error(t, freeze.Check())
+
+	_, err = client.Commit(controller.Enforce, controller.WithToken(freeze.With(spec, nil), 2))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{}, (<-committed).Metadata.Tags)
+	require.NoError(t, <-frozen)
+}
+
 func TestControllerDescribe(t *testing.T) {
 	socketPath := tempSocket()
 	name := filepath.Base(socketPath)
@@ -188,3 +240,35 @@ func TestControllerFree(t *testing.T) {
 	server.Stop()
 
 }
+
+// measured is a controller that maintains metrics
+type measured struct {
+	controller.Controller
+}
+
+func (m measured) Metrics() []fsm.Metrics {
+	return []fsm.Metrics{{Name: "ingress", States: map[string]int{"ready": 2}}}
+}
+
+func TestControllerMetricsOfSingleton(t *testing.T) {
+
+	follower := func() stack.Leadership { return fakeLeadership(false) }
+	c := Server(controller.Singleton(measured{&testing_controller.Controller{}}, follower))
+
+	// the metrics are of the controller the singleton runs on the leader, regardless of leadership
+	metrics := c.Metrics()
+	require.Equal(t, 1, len(metrics))
+	require.Equal(t, "ingress", metrics[0].Name)
+
+	require.Nil(t, Server(&testing_controller.Controller{}).Metrics())
+}
+
+type fakeLeadership bool
+
+func (f fakeLeadership) IsLeader() (bool, error) {
+	return bool(f), nil
+}
+
+func (f fakeLeadership) LeaderLocation() (*url.URL, error) {
+	return nil, nil
+}
@@ -3,7 +3,9 @@ package controller // import "github.com/docker/infrakit/pkg/rpc/controller"
 import (
 	"net/http"
 
+	"github.com/docker/infrakit/pkg/fsm"
 	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	"github.com/docker/infrakit/pkg/plugin"
 	"github.com/docker/infrakit/pkg/rpc"
 	"github.com/docker/infrakit/pkg/rpc/internal"
@@ -33,17 +35,20 @@ func ServerWithNames(subcontrollers func() (map[string]controller.Controller, er
 		},
 	)
 
-	return &Controller{keyed: keyed}
+	return &Controller{keyed: keyed, fence: &controller.Fence{}}
 }
 
 // Server returns a Controller that conforms to the net/rpc rpc call convention.
 func Server(c controller.Controller) *Controller {
-	return &Controller{keyed: internal.ServeSingle(c)}
+	return &Controller{keyed: internal.ServeSingle(c), fence: &controller.Fence{}}
 }
 
 // Controller is the exported type for json-rpc
 type Controller struct {
 	keyed *internal.Keyed
+
+	// fence rejects the commits of past leaders
+	fence *controller.Fence
 }
 
 // VendorInfo returns a metadata object about the plugin, if the plugin implements it.  See plugin.Vendor
@@ -64,6 +69,32 @@ func (c *Controller) ExampleProperties() *types.Any {
 	return nil
 }
 
+// Metrics returns the metrics of the state machines of the controller, if the controller maintains them.
+// The metrics of a singleton are of the controller it runs on the leader.
+func (c *Controller) Metrics() []fsm.Metrics {
+	base, _ := c.keyed.Keyed(plugin.Name("."))
+	if s, is := base.(interface {
+		Unwrap() controller.Controller
+	}); is {
+		base = s.Unwrap()
+	}
+	if m, is := base.(rpc.MetricsExporter); is {
+		return m.Metrics()
+	}
+	return nil
+}
+
+// Exports returns the exports of the controller, if the controller has any.
+func (c *Controller) Exports() map[string]http.Handler {
+	base, _ := c.keyed.Keyed(plugin.Name("."))
+	if e, is := base.(interface {
+		Exports() map[string]http.Handler
+	}); is {
+		return e.Exports()
+	}
+	return nil
+}
+
 // ImplementedInterface returns the interface implemented by this RPC service.
 func (c *Controller) ImplementedInterface() spi.InterfaceSpec {
 	return controller.InterfaceSpec
@@ -92,9 +123,20 @@ func (c *Controller) Plan(_ *http.Request, req *ChangeRequest, resp *ChangeRespo
 // Commit is the rpc method for Commit
 func (c *Controller) Commit(_ *http.Request, req *ChangeRequest, resp *ChangeResponse) error {
 
+	spec, err := c.fence.Check(req.Spec)
+	if err != nil {
+		log.Warn("Rejected commit", "name", req.Name, "err", err)
+		return err
+	}
+	// the controllers in this process observe the freeze of the manager
+	spec, err = freeze.ReceiveSpec(spec)
+	if err != nil {
+		return err
+	}
+
 	return c.keyed.Do(req, func(v interface{}) error {
 		resp.Name = req.Name
-		object, err := v.(controller.Controller).Commit(req.Operation, req.Spec)
+		object, err := v.(controller.Controller).Commit(req.Operation, spec)
 		if err == nil {
 			resp.Object = object
 		}
@@ -5,6 +5,7 @@ import (
 	rpc_client "github.com/docker/infrakit/pkg/rpc/client"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/store"
 )
 
 // NewClient returns a plugin interface implementation connected to a remote plugin
@@ -29,7 +30,17 @@ type client struct {
 }
 
 func (c client) CommitGroup(grp group.Spec, pretend bool) (string, error) {
-	req := CommitGroupRequest{Name: c.name, Spec: grp, Pretend: pretend}
+	return c.CommitGroupWithToken(grp, pretend, 0)
+}
+
+// CommitGroupWithToken implements group.Fenced
+func (c client) CommitGroupWithToken(grp group.Spec, pretend bool, token store.Token) (string, error) {
+	return c.CommitGroupWithFreeze(grp, pretend, token, "")
+}
+
+// CommitGroupWithFreeze implements group.Freezable
+func (c client) CommitGroupWithFreeze(grp group.Spec, pretend bool, token store.Token, freeze string) (string, error) {
+	req := CommitGroupRequest{Name: c.name, Spec: grp, Pretend: pretend, Token: token, Freeze: freeze}
 	resp := CommitGroupResponse{}
 	err := c.client.Call("Group.CommitGroup", req, &resp)
 	return resp.Details, err
@@ -49,7 +60,12 @@ func (c client) DescribeGroup(id group.ID) (group.Description, error) {
 }
 
 func (c client) DestroyGroup(id group.ID) error {
-	req := DestroyGroupRequest{Name: c.name, ID: id}
+	return c.DestroyGroupWithToken(id, 0)
+}
+
+// DestroyGroupWithToken implements group.Fenced
+func (c client) DestroyGroupWithToken(id group.ID, token store.Token) error {
+	req := DestroyGroupRequest{Name: c.name, ID: id, Token: token}
 	resp := DestroyGroupResponse{}
 	return c.client.Call("Group.DestroyGroup", req, &resp)
 }
@@ -5,10 +5,12 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	"github.com/docker/infrakit/pkg/plugin"
 	rpc_server "github.com/docker/infrakit/pkg/rpc/server"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/spi/stack"
 	testing_group "github.com/docker/infrakit/pkg/testing/group"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/stretchr/testify/require"
@@ -157,6 +159,113 @@ func TestGroupPluginCommitGroupError(t *testing.T) {
 	require.Equal(t, groupSpec, <-groupSpecActual)
 }
 
+func TestGroupPluginCommitGroupFencing(t *testing.T) {
+	socketPath := tempSocket()
+
+	committed := make(chan group.Spec, 3)
+	groupSpec := group.Spec{
+		ID:         group.ID("group"),
+		Properties: types.AnyString(`{"foo":"bar"}`),
+	}
+
+	server, err := rpc_server.StartPluginAtPath(socketPath, PluginServer(&testing_group.Plugin{
+		DoCommitGroup: func(req group.Spec, pretend bool) (string, error) {
+			committed <- req
+			return "commit details", nil
+		},
+	}))
+	require.NoError(t, err)
+
+	client := must(NewClient(nameFromPath(socketPath), socketPath))
+
+	_, err = group.CommitWithToken(client, groupSpec, false, 2)
+	require.NoError(t, err)
+
+	// the commits of a past leader are rejected
+	_, err = group.CommitWithToken(client, groupSpec, false, 1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "stale fencing token 1, current is 2")
+
+	// commits without a token are not fenced
+	_, err = client.CommitGroup(groupSpec, false)
+	require.NoError(t, err)
+
+	server.Stop()
+
+	require.Equal(t, 2, len(committed))
+}
+
+func TestGroupPluginCommitGroupFreeze(t *testing.T) {
+	socketPath := tempSocket()
+	defer freeze.Set(nil)
+
+	frozen := make(chan error, 1)
+	server, err := rpc_server.StartPluginAtPath(socketPath, PluginServer(&testing_group.Plugin{
+		DoCommitGroup: func(req group.Spec, pretend bool) (string, error) {
+			frozen <- freeze.Check()
+			return "commit details", nil
+		},
+	}))
+	require.NoError(t, err)
+	defer server.Stop()
+
+	client := must(NewClient(nameFromPath(socketPath), socketPath))
+	groupSpec := group.Spec{ID: group.ID("group")}
+
+	// the groups observe the freeze of the manager
+	_, err = group.CommitWithFreeze(client, groupSpec, false, 0, freeze.Encode(&stack.Freeze{Reason: "incident"}))
+	require.NoError(t, err)
+	require.Equal(t, freeze.ErrFrozen{Reason: "incident"}, <-frozen)
+
+	// commits not from the manager leave the freeze as is
+	_, err = client.CommitGroup(groupSpec, false)
+	require.NoError(t, err)
+	require.Error(t, <-frozen)
+
+	_, err = group.CommitWithFreeze(client, groupSpec, false, 0, freeze.Encode(nil))
+	require.NoError(t, err)
+	require.NoError(t, <-frozen)
+}
+
+func TestGroupPluginDestroyGroupFencing(t *testing.T) {
+	socketPath := tempSocket()
+
+	committed := make(chan group.Spec, 1)
+	destroyed := make(chan group.ID, 3)
+	id := group.ID("group")
+
+	server, err := rpc_server.StartPluginAtPath(socketPath, PluginServer(&testing_group.Plugin{
+		DoCommitGroup: func(req group.Spec, pretend bool) (string, error) {
+			committed <- req
+			return "commit details", nil
+		},
+		DoDestroyGroup: func(id group.ID) error {
+			destroyed <- id
+			return nil
+		},
+	}))
+	require.NoError(t, err)
+
+	client := must(NewClient(nameFromPath(socketPath), socketPath))
+
+	_, err = group.CommitWithToken(client, group.Spec{ID: id}, false, 2)
+	require.NoError(t, err)
+
+	// the destroys of a past leader are rejected
+	err = group.DestroyWithToken(client, id, 1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "stale fencing token 1, current is 2")
+
+	require.NoError(t, group.DestroyWithToken(client, id, 2))
+
+	// destroys without a token are not fenced
+	require.NoError(t, client.DestroyGroup(id))
+
+	server.Stop()
+
+	require.Equal(t, 2, len(destroyed))
+}
+
 func TestGroupPluginFreeGroup(t *testing.T) {
 	socketPath := tempSocket()
 
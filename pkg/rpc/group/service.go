@@ -3,10 +3,12 @@ package group // import "github.com/docker/infrakit/pkg/rpc/group"
 import (
 	"net/http"
 
+	"github.com/docker/infrakit/pkg/manager/freeze"
 	"github.com/docker/infrakit/pkg/plugin"
 	"github.com/docker/infrakit/pkg/rpc"
 	"github.com/docker/infrakit/pkg/rpc/internal"
 	"github.com/docker/infrakit/pkg/spi"
+	"github.com/docker/infrakit/pkg/spi/controller"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/types"
 )
@@ -31,17 +33,21 @@ func PluginServerWithGroups(list func() (map[group.ID]group.Plugin, error)) *Gro
 
 	return &Group{
 		keyed: keyed,
+		fence: &controller.Fence{},
 	}
 }
 
 // PluginServer returns a RPCService that conforms to the net/rpc rpc call convention.
 func PluginServer(p group.Plugin) *Group {
-	return &Group{keyed: internal.ServeSingle(p)}
+	return &Group{keyed: internal.ServeSingle(p), fence: &controller.Fence{}}
 }
 
 // Group the exported type needed to conform to json-rpc call convention
 type Group struct {
 	keyed *internal.Keyed
+
+	// fence rejects the commits and destroys of past leaders
+	fence *controller.Fence
 }
 
 // VendorInfo returns a metadata object about the plugin, if the plugin implements it.  See plugin.Vendor
@@ -75,6 +81,13 @@ func (p *Group) Objects() []rpc.Object {
 
 // CommitGroup is the rpc method to commit a group
 func (p *Group) CommitGroup(_ *http.Request, req *CommitGroupRequest, resp *CommitGroupResponse) error {
+	if err := p.fence.CheckToken(req.Token); err != nil {
+		return err
+	}
+	// the groups in this process observe the freeze of the manager
+	if err := freeze.Receive(req.Freeze); err != nil {
+		return err
+	}
 	return p.keyed.Do(req, func(v interface{}) error {
 		resp.Name = req.Name
 		details, err := v.(group.Plugin).CommitGroup(req.Spec, req.Pretend)
@@ -116,6 +129,9 @@ func (p *Group) DescribeGroup(_ *http.Request, req *DescribeGroupRequest, resp *
 
 // DestroyGroup is the rpc method to destroy a group
 func (p *Group) DestroyGroup(_ *http.Request, req *DestroyGroupRequest, resp *DestroyGroupResponse) error {
+	if err := p.fence.CheckToken(req.Token); err != nil {
+		return err
+	}
 	return p.keyed.Do(req, func(v interface{}) error {
 		resp.Name = req.Name
 		err := v.(group.Plugin).DestroyGroup(req.ID)
@@ -4,6 +4,7 @@ import (
 	"github.com/docker/infrakit/pkg/plugin"
 	"github.com/docker/infrakit/pkg/spi/group"
 	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/store"
 )
 
 // CommitGroupRequest is the rpc wrapper for input to commit a group
@@ -11,6 +12,12 @@ type CommitGroupRequest struct {
 	Name    plugin.Name
 	Spec    group.Spec
 	Pretend bool
+
+	// Token is the fencing token of the leader that commits, 0 if none
+	Token store.Token `json:",omitempty"`
+
+	// Freeze is the freeze of the manager that commits, as encoded by the manager, empty if none
+	Freeze string `json:",omitempty"`
 }
 
 // Plugin implements pkg/rpc/internal/Addressable
@@ -64,6 +71,9 @@ type DescribeGroupResponse struct {
 type DestroyGroupRequest struct {
 	Name plugin.Name
 	ID   group.ID
+
+	// Token is the fencing token of the leader that destroys, 0 if none
+	Token store.Token `json:",omitempty"`
 }
 
 // Plugin implements pkg/rpc/internal/Addressable
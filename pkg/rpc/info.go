@@ -9,6 +9,15 @@ const (
 
 	// URLEventsPrefix is the prefix of the events endpoint
 	URLEventsPrefix = "/events"
+
+	// URLMetrics is the endpoint for metrics in the Prometheus text format, if the plugin exports any.
+	URLMetrics = "/metrics"
+
+	// URLExportsPrefix is the prefix of the endpoints of the exports of the plugin, if it has any.
+	URLExportsPrefix = "/exports/"
+
+	// URLAudit is the endpoint of the audit records of the mutating calls to the plugin
+	URLAudit = "/audit"
 )
 
 // InputExample is the interface implemented by the rpc implementations for
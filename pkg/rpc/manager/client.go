@@ -77,3 +77,51 @@ func (c client) Terminate(specs []types.Spec) error {
 	err := c.client.Call("Manager.Terminate", req, &resp)
 	return err
 }
+
+// History returns the revisions of the specs
+func (c client) History() ([]stack.Revision, error) {
+	req := HistoryRequest{}
+	resp := HistoryResponse{}
+	err := c.client.Call("Manager.History", req, &resp)
+	return resp.Revisions, err
+}
+
+// Rollback enforces the specs of the given revision
+func (c client) Rollback(revision int) error {
+	req := RollbackRequest{
+		Revision: revision,
+	}
+	resp := RollbackResponse{}
+	err := c.client.Call("Manager.Rollback", req, &resp)
+	return err
+}
+
+// Plan returns the changes of enforcing the specs
+func (c client) Plan(specs []types.Spec) (stack.Plan, error) {
+	req := PlanRequest{
+		Specs: specs,
+	}
+	resp := PlanResponse{}
+	err := c.client.Call("Manager.Plan", req, &resp)
+	return resp.Plan, err
+}
+
+// Freeze stops all automated changes to the infrastructure until unfrozen
+func (c client) Freeze(reason string) error {
+	req := FreezeRequest{
+		Reason: reason,
+	}
+	resp := FreezeResponse{}
+	err := c.client.Call("Manager.Freeze", req, &resp)
+	return err
+}
+
+// Unfreeze resumes the automated changes to the infrastructure
+func (c client) Unfreeze(reason string) error {
+	req := UnfreezeRequest{
+		Reason: reason,
+	}
+	resp := UnfreezeResponse{}
+	err := c.client.Call("Manager.Unfreeze", req, &resp)
+	return err
+}
@@ -227,3 +227,107 @@ func TestManagerInspect(t *testing.T) {
 	server.Stop()
 
 }
+
+func TestManagerHistoryRollback(t *testing.T) {
+	socketPath := tempSocket()
+
+	expect := []stack.Revision{
+		{
+			Number:      1,
+			Author:      "joe",
+			Message:     "commit group/workers",
+			Fingerprint: "abc",
+			Specs: []types.Spec{
+				{
+					Kind: "group",
+					Metadata: types.Metadata{
+						Name: "workers",
+					},
+				},
+			},
+		},
+	}
+
+	rolledBack := make(chan int, 1)
+	server, err := server.StartPluginAtPath(socketPath, PluginServer(&testing_manager.Plugin{
+		DoHistory: func() ([]stack.Revision, error) {
+			return expect, nil
+		},
+		DoRollback: func(revision int) error {
+			rolledBack <- revision
+			return nil
+		},
+	}))
+	require.NoError(t, err)
+	defer server.Stop()
+
+	actual, err := must(NewClient(socketPath)).History()
+	require.NoError(t, err)
+	require.EqualValues(t, types.AnyValueMust(expect), types.AnyValueMust(actual))
+
+	require.NoError(t, must(NewClient(socketPath)).Rollback(1))
+	require.Equal(t, 1, <-rolledBack)
+}
+
+func TestManagerPlan(t *testing.T) {
+	socketPath := tempSocket()
+
+	specs := []types.Spec{
+		{
+			Kind: "ingress",
+			Metadata: types.Metadata{
+				Name: "lb",
+			},
+		},
+	}
+	expect := stack.Plan{
+		Changes: []stack.Change{
+			{
+				Type:    stack.Added,
+				Kind:    "ingress",
+				Name:    "lb",
+				Diff:    "+kind: ingress",
+				Message: []string{"add route 80"},
+			},
+		},
+	}
+
+	planned := make(chan []types.Spec, 1)
+	server, err := server.StartPluginAtPath(socketPath, PluginServer(&testing_manager.Plugin{
+		DoPlan: func(specs []types.Spec) (stack.Plan, error) {
+			planned <- specs
+			return expect, nil
+		},
+	}))
+	require.NoError(t, err)
+	defer server.Stop()
+
+	actual, err := must(NewClient(socketPath)).Plan(specs)
+	require.NoError(t, err)
+	require.Equal(t, expect, actual)
+	require.Equal(t, specs, <-planned)
+}
+
+func TestManagerFreeze(t *testing.T) {
+	socketPath := tempSocket()
+
+	reasons := make(chan string, 2)
+	server, err := server.StartPluginAtPath(socketPath, PluginServer(&testing_manager.Plugin{
+		DoFreeze: func(reason string) error {
+			reasons <- "freeze:" + reason
+			return nil
+		},
+		DoUnfreeze: func(reason string) error {
+			reasons <- "unfreeze:" + reason
+			return nil
+		},
+	}))
+	require.NoError(t, err)
+	defer server.Stop()
+
+	require.NoError(t, must(NewClient(socketPath)).Freeze("incident 42"))
+	require.Equal(t, "freeze:incident 42", <-reasons)
+
+	require.NoError(t, must(NewClient(socketPath)).Unfreeze("resolved"))
+	require.Equal(t, "unfreeze:resolved", <-reasons)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/docker/infrakit/pkg/rpc"
 	"github.com/docker/infrakit/pkg/spi"
+	"github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/spi/stack"
 	"github.com/docker/infrakit/pkg/types"
 )
@@ -131,3 +132,91 @@ type TerminateResponse struct {
 func (p *Manager) Terminate(_ *http.Request, req *TerminateRequest, resp *TerminateResponse) error {
 	return p.manager.Terminate(req.Specs)
 }
+
+// HistoryRequest is the rpc request
+type HistoryRequest struct {
+}
+
+// HistoryResponse is the rpc response
+type HistoryResponse struct {
+	Revisions []stack.Revision
+}
+
+// History is the rpc method for Manager.History
+func (p *Manager) History(_ *http.Request, req *HistoryRequest, resp *HistoryResponse) error {
+	revisions, err := p.manager.History()
+	if err != nil {
+		return err
+	}
+	resp.Revisions = revisions
+	return nil
+}
+
+// RollbackRequest is the rpc request
+type RollbackRequest struct {
+	Revision int
+}
+
+// RollbackResponse is the rpc response
+type RollbackResponse struct {
+}
+
+// Rollback is the rpc method for Manager.Rollback
+func (p *Manager) Rollback(_ *http.Request, req *RollbackRequest, resp *RollbackResponse) error {
+	return p.manager.Rollback(req.Revision)
+}
+
+// PlanRequest is the rpc request
+type PlanRequest struct {
+	Specs []types.Spec
+}
+
+// PlanResponse is the rpc response
+type PlanResponse struct {
+	Plan stack.Plan
+}
+
+// Plan is the rpc method for Manager.Plan
+func (p *Manager) Plan(_ *http.Request, req *PlanRequest, resp *PlanResponse) error {
+	plan, err := p.manager.Plan(req.Specs)
+	if err != nil {
+		return err
+	}
+	resp.Plan = plan
+	return nil
+}
+
+// FreezeRequest is the rpc request
+type FreezeRequest struct {
+	Reason string
+}
+
+// FreezeResponse is the rpc response
+type FreezeResponse struct {
+}
+
+// Freeze is the rpc method for Manager.Freeze
+func (p *Manager) Freeze(_ *http.Request, req *FreezeRequest, resp *FreezeResponse) error {
+	return p.manager.Freeze(req.Reason)
+}
+
+// UnfreezeRequest is the rpc request
+type UnfreezeRequest struct {
+	Reason string
+}
+
+// UnfreezeResponse is the rpc response
+type UnfreezeResponse struct {
+}
+
+// Unfreeze is the rpc method for Manager.Unfreeze
+func (p *Manager) Unfreeze(_ *http.Request, req *UnfreezeRequest, resp *UnfreezeResponse) error {
+	return p.manager.Unfreeze(req.Reason)
+}
+
+// PublishOn sets the channel to publish the events of the manager, if it publishes events
+func (p *Manager) PublishOn(c chan<- *event.Event) {
+	if pub, is := p.manager.(event.Publisher); is {
+		pub.PublishOn(c)
+	}
+}
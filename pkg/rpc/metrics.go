@@ -0,0 +1,12 @@
+package rpc // import "github.com/docker/infrakit/pkg/rpc"
+
+import (
+	"github.com/docker/infrakit/pkg/fsm"
+)
+
+// MetricsExporter is implemented by objects that maintain metrics of their state machines.  If any of
+// the objects served implements this interface, the metrics are available at URLMetrics in the
+// Prometheus text format.
+type MetricsExporter interface {
+	Metrics() []fsm.Metrics
+}
@@ -11,10 +11,12 @@ import (
 	"strings"
 	"sync"
 
+	broker "github.com/docker/infrakit/pkg/broker/server"
 	"github.com/docker/infrakit/pkg/discovery"
 	logutil "github.com/docker/infrakit/pkg/log"
 	manager_discovery "github.com/docker/infrakit/pkg/manager/discovery"
 	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/rpc"
 	"github.com/docker/infrakit/pkg/rpc/event"
 	event_spi "github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/types"
@@ -29,6 +31,12 @@ type ReverseProxy struct {
 	// if this is nil, consider this is local.  If it's not nil, then redirect to this url instead
 	forward     *url.URL
 	forwardLock sync.Mutex
+
+	// Authorize checks that the caller of the request can subscribe to the topic.  This is optional.
+	Authorize func(req *http.Request, topic string) error
+
+	// Events serves the events of the mux itself, e.g. the denied requests, at /events.  This is optional.
+	Events http.Handler
 }
 
 // NewReverseProxy creates a mux reverse proxy
@@ -72,6 +80,10 @@ func (rp *ReverseProxy) listPlugins(resp http.ResponseWriter, req *http.Request,
 
 // ServeHTTP implements HTTP handler
 func (rp *ReverseProxy) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if rp.Events != nil && req.URL.Path == rpc.URLEventsPrefix {
+		rp.Events.ServeHTTP(resp, req)
+		return
+	}
 	if rp.forward != nil {
 		rp.forwardHTTP(resp, req)
 		return
@@ -160,6 +172,13 @@ func (rp *ReverseProxy) serveHTTPLocal(resp http.ResponseWriter, req *http.Reque
 		topic := req.URL.Query().Get("topic")
 		log.Info("events", "plugin", prefix, "topic", topic)
 
+		if rp.Authorize != nil {
+			if err := rp.Authorize(req, topic); err != nil {
+				http.Error(resp, err.Error(), broker.AuthorizeStatusCode(err))
+				return
+			}
+		}
+
 		topicPath := types.PathFromString(topic)
 		socketPath, _ := rp.socketPath(req.URL)
 		if socketPath == "" {
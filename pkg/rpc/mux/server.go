@@ -1,6 +1,7 @@
 package mux // import "github.com/docker/infrakit/pkg/rpc/mux"
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -12,12 +13,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/docker/go-connections/tlsconfig"
+	broker "github.com/docker/infrakit/pkg/broker/server"
 	"github.com/docker/infrakit/pkg/discovery"
 	"github.com/docker/infrakit/pkg/discovery/local"
 	"github.com/docker/infrakit/pkg/leader"
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	rpc_server "github.com/docker/infrakit/pkg/rpc/server"
+	"github.com/docker/infrakit/pkg/spi/event"
 	"gopkg.in/tylerb/graceful.v1"
 )
 
@@ -25,6 +30,12 @@ import (
 type Options struct {
 	Leadership <-chan leader.Leadership
 	Registry   leader.Store
+
+	// Auth authenticates and authorizes the requests.  If nil, all requests are accepted.
+	Auth *auth.Middleware
+
+	// TLS is the TLS config of the listener.  Set the ClientAuth to verify the client certificates.
+	TLS *tlsconfig.Options
 }
 
 // SavePID makes sure the directory exists and writes the pid to a file
@@ -56,10 +67,63 @@ func NewServer(listen string, advertiseHostPort string,
 
 	advertise := &url.URL{Host: advertiseHostPort, Scheme: "http"}
 
+	var tlsConfig *tls.Config
+	if options.TLS != nil {
+		config, err := tlsconfig.Server(*options.TLS)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig = config
+		advertise.Scheme = "https"
+	}
+
 	proxy := NewReverseProxy(plugins)
+
+	// the events of the mux itself, i.e. the denied requests, are served at /events
+	events := broker.NewBroker()
+	eventsStop := make(chan struct{})
+
+	var handler http.Handler = proxy
+	if options.Auth != nil {
+		proxy.Authorize = options.Auth.Subscribe
+		handler = options.Auth.Handler(proxy)
+
+		eventChan := make(chan *event.Event)
+		options.Auth.PublishOn(eventChan)
+		go func() {
+			for {
+				select {
+				case event, ok := <-eventChan:
+					if !ok {
+						return
+					}
+					if event.Timestamp.IsZero() {
+						event.Now()
+					}
+					events.Publish(event.Topic.String(), event, 1*time.Second)
+				case <-eventsStop:
+					log.Info("Stopping event relay")
+					return
+				}
+			}
+		}()
+
+		intercept := broker.Interceptor{
+			Pre: func(topic string, headers map[string][]string) error {
+				return nil
+			},
+			Do: events.ServeHTTP,
+			Post: func(topic string) {
+				log.Debug("Client left", "topic", topic, "V", logutil.V(100))
+			},
+			Authorize: options.Auth.Subscribe,
+		}
+		proxy.Events = http.HandlerFunc(intercept.ServeHTTP)
+	}
+
 	server := &graceful.Server{
 		Timeout: 10 * time.Second,
-		Server:  &http.Server{Addr: listen, Handler: proxy},
+		Server:  &http.Server{Addr: listen, Handler: handler},
 	}
 
 	var advertiseURL *url.URL
@@ -118,12 +182,17 @@ func NewServer(listen string, advertiseHostPort string,
 	if err != nil {
 		return nil, err
 	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
 
 	log.Info("Listening", "listen", listen)
 
 	go func() {
 		defer func() {
 			close(leaderStop)
+			close(eventsStop)
+			events.Stop()
 			log.Info("listener stopped")
 			os.Remove(pidPath)
 		}()
@@ -1,14 +1,19 @@
 package mux // import "github.com/docker/infrakit/pkg/rpc/mux"
 
 import (
+	"bufio"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/infrakit/pkg/discovery"
 	"github.com/docker/infrakit/pkg/discovery/local"
 	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	"github.com/docker/infrakit/pkg/rpc/client"
 	rpc_metadata "github.com/docker/infrakit/pkg/rpc/metadata"
 	"github.com/docker/infrakit/pkg/types"
@@ -70,3 +75,82 @@ func TestMuxServer(t *testing.T) {
 	require.Equal(t, "Metadata", m["Implements"].([]interface{})[0].(map[string]interface{})["Name"])
 	T(100).Infoln("body=", string(body))
 }
+
+func muxGet(t *testing.T, url, token string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestMuxServerAuth(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "mux-auth")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	lookup, err := local.NewPluginDiscoveryWithDir(dir)
+	require.NoError(t, err)
+
+	server, err := NewServer(":9091", "127.0.0.1:9091", func() discovery.Plugins {
+		return lookup
+	}, Options{
+		Auth: auth.New(auth.Options{
+			Tokens: auth.Tokens{
+				"viewer-token": auth.Identity{Name: "joe", Roles: []string{"viewer"}},
+				"admin-token":  auth.Identity{Name: "root", Roles: []string{"admin"}},
+			},
+			Rules: []auth.Rule{
+				{Roles: []string{"viewer"}, Methods: []string{"Group.Describe*"}},
+				{Roles: []string{"admin"}, Methods: []string{auth.MethodSubscribe}},
+			},
+		}),
+	})
+	require.NoError(t, err)
+	defer server.Stop()
+
+	events := "http://localhost:9091" + rpc.URLEventsPrefix + "?topic=" + auth.TopicDenied
+
+	resp := muxGet(t, events, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = muxGet(t, events, "viewer-token")
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// the stream starts with the first event, so keep calling until the denial is received
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+			req, err := http.NewRequest(http.MethodPost, "http://localhost:9091/group",
+				strings.NewReader(`{"jsonrpc":"2.0","method":"Group.DestroyGroup","params":{"ID":"workers"},"id":1}`))
+			if err != nil {
+				continue
+			}
+			req.Header.Set("Authorization", "Bearer viewer-token")
+			if resp, err := http.DefaultClient.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}()
+
+	resp = muxGet(t, events, "admin-token")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	require.Contains(t, line, "Group.DestroyGroup")
+	require.Contains(t, line, "joe")
+}
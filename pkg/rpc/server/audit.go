@@ -0,0 +1,220 @@
+package server // import "github.com/docker/infrakit/pkg/rpc/server"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
+	"github.com/docker/infrakit/pkg/run/local"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/store/file"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+const (
+	// EnvAuditDir is the environment variable for the directory of the audit records.  If not set, only the
+	// latest records are kept in memory.
+	EnvAuditDir = "INFRAKIT_AUDIT_DIR"
+
+	auditType = "audit"
+)
+
+// auditMax is the number of the latest records that are kept in memory and served.  If the records are in
+// the directory of EnvAuditDir, the older records are still in the directory.
+var auditMax = 10000
+
+// appendOnly is a store that does not overwrite or delete its entries
+type appendOnly struct {
+	store.KV
+}
+
+// Write implements store.KV
+func (s appendOnly) Write(key interface{}, value []byte) error {
+	if exists, err := s.KV.Exists(key); err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("record exists: %v", key)
+	}
+	return s.KV.Write(key, value)
+}
+
+// Delete implements store.KV
+func (s appendOnly) Delete(key interface{}) error {
+	return fmt.Errorf("records cannot be deleted: %v", key)
+}
+
+// auditor records the audited calls to a plugin and serves the records at rpc.URLAudit
+type auditor struct {
+	plugin string
+	kv     store.KV // nil if the records are kept in memory only
+	authn  *auth.Middleware
+	seq    uint64
+
+	// latest are the latest records of the plugin, in order of time
+	latest []rpc.AuditRecord
+	lock   sync.RWMutex
+}
+
+func newAuditor(plugin string, authn *auth.Middleware) (*auditor, error) {
+	a := &auditor{plugin: plugin, authn: authn}
+	dir := local.Getenv(EnvAuditDir, "")
+	if dir == "" {
+		return a, nil
+	}
+	if err := local.EnsureDir(dir); err != nil {
+		return nil, err
+	}
+	a.kv = appendOnly{file.NewStore(auditType, dir)}
+
+	// the records are decoded once, when the server starts
+	entries, err := a.kv.Entries()
+	if err != nil {
+		return nil, err
+	}
+	for entry := range entries {
+		r := rpc.AuditRecord{}
+		if err := json.Unmarshal(entry.Value, &r); err != nil {
+			log.Warn("Cannot decode record", "key", entry.Key, "err", err)
+			continue
+		}
+		if r.Plugin == a.plugin {
+			a.keep(r)
+		}
+	}
+	return a, nil
+}
+
+// Handler returns the handler that records the audited JSON-RPC calls to the next handler
+func (a *auditor) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			next.ServeHTTP(resp, req)
+			return
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		call := struct {
+			Method string     `json:"method"`
+			Params *types.Any `json:"params"`
+		}{}
+		if err := json.Unmarshal(body, &call); err != nil || !rpc.Audited(call.Method) {
+			next.ServeHTTP(resp, req)
+			return
+		}
+
+		record := rpc.AuditRecord{
+			Time:        time.Now(),
+			Plugin:      a.plugin,
+			Method:      call.Method,
+			Remote:      req.RemoteAddr,
+			Fingerprint: types.Fingerprint(call.Params),
+		}
+		if a.authn != nil {
+			if identity, err := a.authn.Authenticate(req); err == nil {
+				record.Caller, record.Roles = identity.Name, identity.Roles
+			}
+		}
+
+		recorder := rpc.NewRecorder()
+		next.ServeHTTP(recorder, req)
+		record.Duration = time.Since(record.Time)
+
+		reply := struct {
+			Error *json2.Error `json:"error"`
+		}{}
+		switch {
+		case recorder.Code != http.StatusOK:
+			record.Error = http.StatusText(recorder.Code)
+		case json.Unmarshal(recorder.Body.Bytes(), &reply) != nil:
+			record.Error = "bad reply"
+		case reply.Error != nil:
+			record.Error = reply.Error.Message
+		}
+		a.record(record)
+
+		for k, v := range recorder.HeaderMap {
+			resp.Header()[k] = v
+		}
+		resp.WriteHeader(recorder.Code)
+		recorder.Body.WriteTo(resp)
+	})
+}
+
+// record writes the record.  The call is already made, so a failure to write is only logged.
+func (a *auditor) record(record rpc.AuditRecord) {
+	a.keep(record)
+	if a.kv == nil {
+		return
+	}
+	buff, err := json.Marshal(record)
+	if err == nil {
+		seq := atomic.AddUint64(&a.seq, 1)
+		err = a.kv.Write(fmt.Sprintf("%s-%d-%d", a.plugin, record.Time.UnixNano(), seq), buff)
+	}
+	if err != nil {
+		log.Error("Cannot record call", "record", record, "err", err)
+	}
+}
+
+// keep adds the record to the latest records, in order of time, and drops the oldest beyond auditMax
+func (a *auditor) keep(record rpc.AuditRecord) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	i := sort.Search(len(a.latest), func(i int) bool { return a.latest[i].Time.After(record.Time) })
+	a.latest = append(a.latest, rpc.AuditRecord{})
+	copy(a.latest[i+1:], a.latest[i:])
+	a.latest[i] = record
+
+	if over := len(a.latest) - auditMax; over > 0 {
+		a.latest = append([]rpc.AuditRecord{}, a.latest[over:]...)
+	}
+}
+
+// records returns the latest records since the given time, of the kind if not empty, in order of time
+func (a *auditor) records(since time.Time, kind string) []rpc.AuditRecord {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	out := []rpc.AuditRecord{}
+	first := sort.Search(len(a.latest), func(i int) bool { return !a.latest[i].Time.Before(since) })
+	for _, r := range a.latest[first:] {
+		if kind == "" || r.IsKind(kind) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ServeHTTP serves the records at rpc.URLAudit.  The query parameters are since, as a RFC3339 time, and kind.
+func (a *auditor) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	since := time.Time{}
+	if v := req.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+	records := a.records(since, req.URL.Query().Get("kind"))
+	resp.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(resp).Encode(records); err != nil {
+		log.Error("error writing audit records", "err", err)
+	}
+}
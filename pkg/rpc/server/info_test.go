@@ -6,6 +6,7 @@ import (
 	"path"
 	"testing"
 
+	"github.com/docker/infrakit/pkg/fsm"
 	rpc_flavor "github.com/docker/infrakit/pkg/rpc/flavor"
 	rpc_instance "github.com/docker/infrakit/pkg/rpc/instance"
 	"github.com/docker/infrakit/pkg/spi/flavor"
@@ -117,3 +118,79 @@ func TestFetchFunctionsFromPlugin(t *testing.T) {
 
 	server.Stop()
 }
+
+type Measured struct {
+	*rpc_instance.Instance
+}
+
+func (m *Measured) Metrics() []fsm.Metrics {
+	return []fsm.Metrics{
+		{
+			Name:   "test",
+			Labels: map[string]string{"collection": "workers"},
+			States: map[string]int{"ready": 3},
+		},
+	}
+}
+
+func TestFetchMetricsFromPlugin(t *testing.T) {
+	socketPath := tempSocket()
+
+	url := "unix://" + socketPath
+
+	server, err := StartPluginAtPath(socketPath,
+		&Measured{Instance: rpc_instance.PluginServer(&testing_instance.Plugin{})})
+	require.NoError(t, err)
+
+	buff, err := template.Fetch(url, template.Options{
+		CustomizeFetch: func(req *http.Request) {
+			req.URL.Path = "/metrics"
+			req.URL.Host = "h"
+		},
+	})
+	require.NoError(t, err)
+	require.Contains(t, string(buff), `infrakit_fsm_instances{collection="workers",set="test",state="ready"} 3`)
+
+	server.Stop()
+}
+
+type Exported struct {
+	*rpc_instance.Instance
+}
+
+func (e *Exported) Exports() map[string]http.Handler {
+	return map[string]http.Handler{
+		"workers/hosts.csv": http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			resp.Write([]byte("key,id\nworkers/1,i-1\n"))
+		}),
+	}
+}
+
+func TestFetchExportsFromPlugin(t *testing.T) {
+	socketPath := tempSocket()
+
+	url := "unix://" + socketPath
+
+	server, err := StartPluginAtPath(socketPath,
+		&Exported{Instance: rpc_instance.PluginServer(&testing_instance.Plugin{})})
+	require.NoError(t, err)
+
+	buff, err := template.Fetch(url, template.Options{
+		CustomizeFetch: func(req *http.Request) {
+			req.URL.Path = "/exports/workers/hosts.csv"
+			req.URL.Host = "h"
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "key,id\nworkers/1,i-1\n", string(buff))
+
+	_, err = template.Fetch(url, template.Options{
+		CustomizeFetch: func(req *http.Request) {
+			req.URL.Path = "/exports/missing"
+			req.URL.Host = "h"
+		},
+	})
+	require.Error(t, err)
+
+	server.Stop()
+}
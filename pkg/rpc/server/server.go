@@ -7,12 +7,15 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"os"
+	"path/filepath"
 	"time"
 
 	broker "github.com/docker/infrakit/pkg/broker/server"
+	"github.com/docker/infrakit/pkg/fsm"
 	logutil "github.com/docker/infrakit/pkg/log"
 	rpc_base "github.com/docker/infrakit/pkg/rpc"
 	rpc_server "github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	"github.com/docker/infrakit/pkg/spi"
 	"github.com/docker/infrakit/pkg/spi/event"
 	"github.com/docker/infrakit/pkg/types"
@@ -90,6 +93,27 @@ type VersionedInterface interface {
 	Objects() []rpc_base.Object
 }
 
+// Exporter is implemented by objects that render their state in the formats of external systems.  If
+// any of the objects served implements this interface, the exports are available by path under
+// rpc.URLExportsPrefix.
+type Exporter interface {
+	Exports() map[string]http.Handler
+}
+
+// exportsHandler returns the handler that serves the exports by path.  The exports are looked up on
+// each request since they change as the objects served change.
+func exportsHandler(exporters []Exporter) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		for _, exporter := range exporters {
+			if handler, has := exporter.Exports()[req.URL.Path]; has {
+				handler.ServeHTTP(resp, req)
+				return
+			}
+		}
+		http.NotFound(resp, req)
+	})
+}
+
 // StartListenerAtPath starts an HTTP server listening on tcp port with discovery entry at specified path.
 // Returns a Stoppable that can be used to stop or block on the server.
 func StartListenerAtPath(listen []string, discoverPath string,
@@ -145,21 +169,32 @@ func startAtPath(listen []string, discoverPath string,
 		return nil, err
 	}
 
+	// authentication and authorization of the requests, if configured
+	authz, err := auth.Default()
+	if err != nil {
+		return nil, err
+	}
+
 	// a list of channels to close on stop
 	stops := []chan struct{}{}
 
 	// events handler
 	events := broker.NewBroker()
 
-	// wire up the publish event source channel to the plugin implementations
+	// wire up the publish event source channel to the plugin implementations, and
+	// to the middleware for the denied requests
+	publishers := []event.Publisher{}
 	for _, t := range targets {
-
-		pub, is := t.(event.Publisher)
-		if !is {
-			continue
+		if pub, is := t.(event.Publisher); is {
+			publishers = append(publishers, pub)
 		}
+	}
+	if authz != nil {
+		publishers = append(publishers, authz)
+	}
+	for _, pub := range publishers {
 
-		log.Info("Object is an event producer", "object", t, "discover", discoverPath)
+		log.Info("Object is an event producer", "object", pub, "discover", discoverPath)
 
 		stop := make(chan struct{})
 		stops = append(stops, stop)
@@ -197,6 +232,36 @@ func startAtPath(listen []string, discoverPath string,
 	router.HandleFunc(rpc_server.URLAPI, info.ShowAPI)
 	router.HandleFunc(rpc_server.URLFunctions, info.ShowTemplateFunctions)
 
+	exporters := []rpc_server.MetricsExporter{}
+	for _, t := range targets {
+		if exporter, is := t.(rpc_server.MetricsExporter); is {
+			exporters = append(exporters, exporter)
+		}
+	}
+	if len(exporters) > 0 {
+		router.HandleFunc(rpc_server.URLMetrics, func(resp http.ResponseWriter, req *http.Request) {
+			metrics := []fsm.Metrics{}
+			for _, exporter := range exporters {
+				metrics = append(metrics, exporter.Metrics()...)
+			}
+			resp.Header().Set("Content-Type", "text/plain; version=0.0.4")
+			if err := fsm.WritePrometheus(resp, metrics...); err != nil {
+				log.Error("error writing metrics", "err", err)
+			}
+		})
+	}
+
+	exports := []Exporter{}
+	for _, t := range targets {
+		if exporter, is := t.(Exporter); is {
+			exports = append(exports, exporter)
+		}
+	}
+	if len(exports) > 0 {
+		router.PathPrefix(rpc_server.URLExportsPrefix).Handler(
+			http.StripPrefix(rpc_server.URLExportsPrefix, exportsHandler(exports)))
+	}
+
 	// Disable this so that clients can connect/subscribe to streams before the topics
 	// actually become available (dynamically added topics)
 	// TODO(chungers) - make this an option somehow
@@ -222,10 +287,25 @@ func startAtPath(listen []string, discoverPath string,
 			log.Debug("Client left", "topic", topic, "V", logutil.V(100))
 		},
 	}
+	if authz != nil {
+		intercept.Authorize = authz.Subscribe
+	}
 	router.HandleFunc(rpc_server.URLEventsPrefix, intercept.ServeHTTP)
 
+	// audit of the mutating calls
+	audit, err := newAuditor(filepath.Base(discoverPath), authz)
+	if err != nil {
+		return nil, err
+	}
+	router.Handle(rpc_server.URLAudit, audit)
+
 	logger := loggingHandler{handler: server, listen: listen, discoverPath: discoverPath}
-	router.Handle("/", logger)
+	router.Handle("/", audit.Handler(logger))
+
+	var handler http.Handler = router
+	if authz != nil {
+		handler = authz.Handler(router)
+	}
 
 	gracefulServer := graceful.Server{
 		Timeout: 10 * time.Second,
@@ -239,7 +319,7 @@ func startAtPath(listen []string, discoverPath string,
 
 		gracefulServer.Server = &http.Server{
 			Addr:    listen[0],
-			Handler: router,
+			Handler: handler,
 		}
 		l, err := net.Listen("tcp", listen[0])
 		if err != nil {
@@ -264,7 +344,7 @@ func startAtPath(listen []string, discoverPath string,
 
 		gracefulServer.Server = &http.Server{
 			Addr:    fmt.Sprintf("unix://%s", discoverPath),
-			Handler: router,
+			Handler: handler,
 		}
 		l, err := net.Listen("unix", discoverPath)
 		if err != nil {
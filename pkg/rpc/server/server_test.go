@@ -3,6 +3,7 @@ package server // import "github.com/docker/infrakit/pkg/rpc/server"
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -10,6 +11,9 @@ import (
 
 	plugin_mock "github.com/docker/infrakit/pkg/mock/spi/instance"
 	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/rpc"
+	"github.com/docker/infrakit/pkg/rpc/auth"
+	rpc_client "github.com/docker/infrakit/pkg/rpc/client"
 	plugin_rpc "github.com/docker/infrakit/pkg/rpc/instance"
 	"github.com/docker/infrakit/pkg/spi/instance"
 	"github.com/docker/infrakit/pkg/types"
@@ -102,3 +106,154 @@ func TestTCPServer(t *testing.T) {
 
 	server.Stop()
 }
+
+func TestUnixSocketServerAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	options := filepath.Join(os.TempDir(), fmt.Sprintf("%d-auth.yml", time.Now().UnixNano()))
+	err := ioutil.WriteFile(options, []byte(`
+Local:
+  Name: local
+  Roles:
+    - viewer
+Rules:
+  - Roles:
+      - viewer
+    Methods:
+      - Instance.Validate
+      - Instance.Describe*
+`), 0644)
+	require.NoError(t, err)
+	defer os.Remove(options)
+
+	os.Setenv(auth.EnvOptions, options)
+	defer os.Unsetenv(auth.EnvOptions)
+
+	mock := plugin_mock.NewMockPlugin(ctrl)
+
+	properties := types.AnyString(`{"foo":"bar"}`)
+	validateErr := errors.New("validate-error")
+
+	mock.EXPECT().Validate(properties).Return(validateErr)
+
+	service := plugin_rpc.PluginServer(mock)
+
+	socket := filepath.Join(os.TempDir(), fmt.Sprintf("%d-auth.sock", time.Now().UnixNano()))
+	name := plugin.Name(filepath.Base(socket))
+	server, err := StartPluginAtPath(socket, service)
+	require.NoError(t, err)
+	defer server.Stop()
+
+	c, err := plugin_rpc.NewClient(name, socket)
+	require.NoError(t, err)
+
+	err = c.Validate(properties)
+	require.Error(t, err)
+	require.Equal(t, validateErr.Error(), err.Error())
+
+	_, err = c.Provision(instance.Spec{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "denied: local cannot call Instance.Provision")
+}
+
+func TestUnixSocketServerAudit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv(EnvAuditDir, dir)
+	defer os.Unsetenv(EnvAuditDir)
+
+	mock := plugin_mock.NewMockPlugin(ctrl)
+
+	instanceID := instance.ID("id")
+	properties := types.AnyString(`{"foo":"bar"}`)
+	destroyErr := errors.New("destroy-error")
+
+	gomock.InOrder(
+		mock.EXPECT().Validate(properties).Return(nil),
+		mock.EXPECT().Provision(instance.Spec{Properties: properties}).Return(&instanceID, nil),
+		mock.EXPECT().Destroy(instanceID, instance.Termination).Return(destroyErr),
+	)
+
+	service := plugin_rpc.PluginServer(mock)
+
+	socket := filepath.Join(os.TempDir(), fmt.Sprintf("%d-audit.sock", time.Now().UnixNano()))
+	name := plugin.Name(filepath.Base(socket))
+	server, err := StartPluginAtPath(socket, service)
+	require.NoError(t, err)
+	defer server.Stop()
+
+	c, err := plugin_rpc.NewClient(name, socket)
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	require.NoError(t, c.Validate(properties))
+	_, err = c.Provision(instance.Spec{Properties: properties})
+	require.NoError(t, err)
+	require.Error(t, c.Destroy(instanceID, instance.Termination))
+
+	infoClient, err := rpc_client.NewPluginInfoClient(socket)
+	require.NoError(t, err)
+
+	records, err := infoClient.GetAudit(time.Time{}, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(records))
+	require.Equal(t, "Instance.Provision", records[0].Method)
+	require.Equal(t, filepath.Base(socket), records[0].Plugin)
+	require.Equal(t, "", records[0].Error)
+	require.NotEqual(t, "", records[0].Fingerprint)
+	require.False(t, records[0].Time.Before(start))
+	require.Equal(t, "Instance.Destroy", records[1].Method)
+	require.Equal(t, destroyErr.Error(), records[1].Error)
+
+	records, err = infoClient.GetAudit(time.Time{}, "Destroy")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(records))
+	require.Equal(t, "Instance.Destroy", records[0].Method)
+
+	records, err = infoClient.GetAudit(time.Now(), "")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(records))
+
+	// the records are in the store, which is append only
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+
+	audit, err := newAuditor(filepath.Base(socket), nil)
+	require.NoError(t, err)
+	require.Error(t, audit.kv.Delete(files[0].Name()))
+
+	// the records are read from the store when the server starts
+	require.Equal(t, 2, len(audit.records(time.Time{}, "")))
+}
+
+func TestAuditorLatest(t *testing.T) {
+
+	defer func(max int) { auditMax = max }(auditMax)
+	auditMax = 2
+
+	audit, err := newAuditor("group", nil)
+	require.NoError(t, err)
+	require.Nil(t, audit.kv)
+
+	now := time.Now()
+	audit.record(rpc.AuditRecord{Time: now.Add(-3 * time.Minute), Plugin: "group", Method: "Group.CommitGroup"})
+	audit.record(rpc.AuditRecord{Time: now.Add(-1 * time.Minute), Plugin: "group", Method: "Group.DestroyGroup"})
+	audit.record(rpc.AuditRecord{Time: now.Add(-2 * time.Minute), Plugin: "group", Method: "Group.SetSize"})
+
+	records := audit.records(time.Time{}, "")
+	require.Equal(t, 2, len(records))
+	require.Equal(t, "Group.SetSize", records[0].Method)
+	require.Equal(t, "Group.DestroyGroup", records[1].Method)
+
+	require.Equal(t, 1, len(audit.records(now.Add(-90*time.Second), "")))
+	require.Equal(t, 0, len(audit.records(time.Time{}, "CommitGroup")))
+	require.Equal(t, 1, len(audit.records(time.Time{}, "Group.SetSize")))
+}
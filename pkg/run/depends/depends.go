@@ -34,6 +34,17 @@ func Register(key string, interfaceSpec types.InterfaceSpec, f ParseDependsFunc)
 	parsers[key][interfaceSpec] = f
 }
 
+// Unregister removes the helper registered for the key and interface spec, e.g. one registered by a test
+func Unregister(key string, interfaceSpec types.InterfaceSpec) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	delete(parsers[key], interfaceSpec)
+	if len(parsers[key]) == 0 {
+		delete(parsers, key)
+	}
+}
+
 // Resolve returns the dependencies listed in the spec as well as inside the properties.
 // InterfaceSpec is optional.  If nil, the first match by key (kind) is used.  If nothing is registered, returns nil
 // and no error.  Error is returned for exceptions (eg. parsing, etc.)
@@ -56,4 +56,9 @@ options:
 	found, err = Resolve(mustSpec(types.SpecFromString(``)), "nope", &v)
 	require.NoError(t, err)
 	require.Equal(t, 0, len(found))
+
+	Unregister("test", v)
+	found, err = Resolve(mustSpec(types.SpecFromString(``)), "test", &v)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(found))
 }
@@ -1,12 +1,14 @@
 package local // import "github.com/docker/infrakit/pkg/run/local"
 
 import (
+	"crypto/tls"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
 	"time"
 
+	"github.com/docker/go-connections/tlsconfig"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -20,6 +22,18 @@ const (
 
 	// EnvClientTimeout is the timeout used by the rpc client
 	EnvClientTimeout = "INFRAKIT_CLIENT_TIMEOUT"
+
+	// EnvAuthToken is the bearer token sent by the rpc clients
+	EnvAuthToken = "INFRAKIT_AUTH_TOKEN"
+
+	// EnvTLSCAFile is the CA file used by the rpc clients to verify the servers over https
+	EnvTLSCAFile = "INFRAKIT_TLS_CA_FILE"
+
+	// EnvTLSCertFile is the client certificate file sent by the rpc clients over https
+	EnvTLSCertFile = "INFRAKIT_TLS_CERT_FILE"
+
+	// EnvTLSKeyFile is the key file of the client certificate
+	EnvTLSKeyFile = "INFRAKIT_TLS_KEY_FILE"
 )
 
 // ClientTimeout returns the client timeout
@@ -27,6 +41,24 @@ func ClientTimeout() time.Duration {
 	return types.MustParseDuration(Getenv(EnvClientTimeout, "15s")).Duration()
 }
 
+// AuthToken returns the bearer token of the rpc clients, or an empty string if not set
+func AuthToken() string {
+	return Getenv(EnvAuthToken, "")
+}
+
+// ClientTLS returns the TLS config of the rpc clients over https, or nil if not set
+func ClientTLS() (*tls.Config, error) {
+	options := tlsconfig.Options{
+		CAFile:   Getenv(EnvTLSCAFile, ""),
+		CertFile: Getenv(EnvTLSCertFile, ""),
+		KeyFile:  Getenv(EnvTLSKeyFile, ""),
+	}
+	if options.CAFile == "" && options.CertFile == "" {
+		return nil, nil
+	}
+	return tlsconfig.Client(options)
+}
+
 // InfrakitHome returns the directory of INFRAKIT_HOME if specified. Otherwise, it will return
 // the user's home directory.  If that cannot be determined, then it returns the current working
 // directory.  If that still cannot be determined, a temporary directory is returned.
@@ -13,7 +13,6 @@ import (
 	"github.com/docker/infrakit/pkg/run"
 	"github.com/docker/infrakit/pkg/run/local"
 	"github.com/docker/infrakit/pkg/run/scope"
-	"github.com/docker/infrakit/pkg/spi/controller"
 	"github.com/docker/infrakit/pkg/spi/stack"
 	"github.com/docker/infrakit/pkg/types"
 )
@@ -94,16 +93,12 @@ func Run(scope scope.Scope, name plugin.Name,
 	}
 
 	transport.Name = name
+
+	enrollment := enrollment.NewComponents(scope, options)
+
 	impls = map[run.PluginCode]interface{}{
-		run.Controller: func() (map[string]controller.Controller, error) {
-			m := map[string]controller.Controller{}
-			if all, err := enrollment.NewTypedControllers(scope, options)(); err == nil {
-				for k, p := range all {
-					m[k] = controller.Singleton(p, leader)
-				}
-			}
-			return m, nil
-		},
+		run.Controller: enrollment.Singletons(leader),
+		run.Event:      enrollment.Events,
 	}
 
 	return
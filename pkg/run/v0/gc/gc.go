@@ -15,6 +15,8 @@ import (
 	"github.com/docker/infrakit/pkg/types"
 
 	// builtin models for gc
+	_ "github.com/docker/infrakit/pkg/controller/gc/model/kubernetes"
+	_ "github.com/docker/infrakit/pkg/controller/gc/model/membership"
 	_ "github.com/docker/infrakit/pkg/controller/gc/model/swarm"
 )
 
@@ -15,6 +15,7 @@ import (
 	"github.com/docker/infrakit/pkg/types"
 
 	// load the handlers for ingress con
+	_ "github.com/docker/infrakit/pkg/controller/ingress/kubernetes"
 	_ "github.com/docker/infrakit/pkg/controller/ingress/swarm"
 )
 
@@ -0,0 +1,70 @@
+package manager // import "github.com/docker/infrakit/pkg/run/v0/manager"
+
+import (
+	"net/http"
+	"time"
+
+	consul_leader "github.com/docker/infrakit/pkg/leader/consul"
+	"github.com/docker/infrakit/pkg/run/local"
+	"github.com/docker/infrakit/pkg/store"
+	consul_store "github.com/docker/infrakit/pkg/store/consul"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/docker/infrakit/pkg/util/consul"
+)
+
+// BackendConsulOptions contain the options for the consul backend.  The leader holds a lock in Consul.
+// The specs, history and metadata are stored in the KV store of Consul, under the StorePrefix.
+type BackendConsulOptions struct {
+	// PollInterval is how often to check
+	PollInterval types.Duration
+
+	// Address is the url of the Consul agent
+	Address string
+
+	// Token is the ACL token, if ACLs are enabled
+	Token string
+
+	// Key is the key of the lock
+	Key string
+
+	// ID is the id of the node, as the value of the lock
+	ID string
+
+	// TTL is the TTL of the session holding the lock
+	TTL types.Duration
+
+	// StorePrefix is the prefix of the keys where state is stored
+	StorePrefix string
+}
+
+// DefaultBackendConsulOptions is the default for the consul backend
+var DefaultBackendConsulOptions = BackendConsulOptions{
+	PollInterval: types.FromDuration(5 * time.Second),
+	Address:      local.Getenv("CONSUL_HTTP_ADDR", consul_leader.DefaultAddress),
+	Token:        local.Getenv("CONSUL_HTTP_TOKEN", ""),
+	Key:          consul_leader.DefaultKey,
+	ID:           local.Getenv(EnvID, hostname()),
+	TTL:          types.FromDuration(consul_leader.DefaultTTL),
+	StorePrefix:  consul_store.DefaultPrefix,
+}
+
+func configConsulBackends(options BackendConsulOptions, managerConfig *Options) error {
+	if managerConfig == nil {
+		return nil
+	}
+
+	lock := consul_leader.Options{
+		Address:  options.Address,
+		Token:    options.Token,
+		Key:      options.Key,
+		Identity: options.ID,
+		TTL:      options.TTL.Duration(),
+	}
+	managerConfig.Leader = consul_leader.NewDetector(options.PollInterval.Duration(), http.DefaultClient, lock)
+	managerConfig.LeaderStore = consul_leader.NewStore(http.DefaultClient, lock)
+
+	client := consul.NewClient(http.DefaultClient, consul.Options{Address: options.Address, Token: options.Token})
+	return configStores(func(key string) (store.Snapshot, error) {
+		return consul_store.NewSnapshot(client, options.StorePrefix, key)
+	}, managerConfig)
+}
@@ -63,6 +63,12 @@ func configEtcdBackends(options BackendEtcdOptions, managerConfig *Options) erro
 	managerConfig.Leader = leader
 	managerConfig.LeaderStore = leaderStore
 	managerConfig.SpecStore = snapshot
+
+	history, err := etcd_store.NewSnapshot(etcdClient, "specs.history")
+	if err != nil {
+		return err
+	}
+	managerConfig.HistoryStore = history
 	managerConfig.cleanUpFunc = func() { etcdClient.Close() }
 
 	key := "global.vars"
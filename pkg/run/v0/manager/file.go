@@ -7,6 +7,7 @@ import (
 
 	file_leader "github.com/docker/infrakit/pkg/leader/file"
 	"github.com/docker/infrakit/pkg/run/local"
+	"github.com/docker/infrakit/pkg/store"
 	file_store "github.com/docker/infrakit/pkg/store/file"
 	"github.com/docker/infrakit/pkg/types"
 )
@@ -55,22 +56,38 @@ func configFileBackends(options BackendFileOptions, managerConfig *Options) erro
 		return err
 	}
 
-	leaderStore := file_leader.NewStore(options.LeaderFile + ".loc")
-	snapshot, err := file_store.NewSnapshot(options.StoreDir, "global.config")
+	managerConfig.Leader = leader
+	managerConfig.LeaderStore = file_leader.NewStore(options.LeaderFile + ".loc")
+	return configFileStores(options.StoreDir, managerConfig)
+}
+
+// configFileStores sets the stores of specs, history and metadata to files in the directory
+func configFileStores(dir string, managerConfig *Options) error {
+	return configStores(func(key string) (store.Snapshot, error) {
+		return file_store.NewSnapshot(dir, key)
+	}, managerConfig)
+}
+
+// configStores sets the stores of specs, history and metadata to the snapshots of their keys
+func configStores(snapshot func(key string) (store.Snapshot, error), managerConfig *Options) error {
+	specs, err := snapshot("global.config")
 	if err != nil {
 		return err
 	}
+	managerConfig.SpecStore = specs
 
-	managerConfig.Leader = leader
-	managerConfig.LeaderStore = leaderStore
-	managerConfig.SpecStore = snapshot
+	history, err := snapshot("global.history")
+	if err != nil {
+		return err
+	}
+	managerConfig.HistoryStore = history
 
 	key := "global.vars"
 	if !managerConfig.Metadata.IsEmpty() {
 		key = fmt.Sprintf("%s.vars", managerConfig.Metadata.Lookup())
 	}
 
-	metadataSnapshot, err := file_store.NewSnapshot(options.StoreDir, key)
+	metadataSnapshot, err := snapshot(key)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,76 @@
+package manager // import "github.com/docker/infrakit/pkg/run/v0/manager"
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	k8s_leader "github.com/docker/infrakit/pkg/leader/kubernetes"
+	"github.com/docker/infrakit/pkg/run/local"
+	"github.com/docker/infrakit/pkg/store"
+	k8s_store "github.com/docker/infrakit/pkg/store/kubernetes"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/docker/infrakit/pkg/util/kubernetes"
+)
+
+// BackendKubernetesOptions contain the options for the kubernetes backend.  The leader holds a
+// coordination.k8s.io Lease.  The specs, history and metadata are stored in ConfigMaps in the Namespace,
+// named with the StorePrefix.
+type BackendKubernetesOptions struct {
+	// PollInterval is how often to check
+	PollInterval types.Duration
+
+	// Kubernetes holds the connection params to the API server
+	Kubernetes kubernetes.ConnectInfo `json:",inline" yaml:",inline"`
+
+	// Namespace is the namespace of the lease and the ConfigMaps
+	Namespace string
+
+	// Lease is the name of the lease
+	Lease string
+
+	// ID is the id of the node, as the holder of the lease
+	ID string
+
+	// LeaseDuration is how long the lease is valid without renewal
+	LeaseDuration types.Duration
+
+	// StorePrefix is the prefix of the names of the ConfigMaps where state is stored
+	StorePrefix string
+}
+
+// DefaultBackendKubernetesOptions is the default for the kubernetes backend
+var DefaultBackendKubernetesOptions = BackendKubernetesOptions{
+	PollInterval:  types.FromDuration(5 * time.Second),
+	Namespace:     "default",
+	Lease:         k8s_leader.DefaultName,
+	ID:            local.Getenv(EnvID, hostname()),
+	LeaseDuration: types.FromDuration(15 * time.Second),
+	StorePrefix:   "infrakit",
+}
+
+func configKubernetesBackends(options BackendKubernetesOptions, managerConfig *Options) error {
+	if managerConfig == nil {
+		return nil
+	}
+
+	host, client, err := kubernetes.NewHTTPClient(options.Kubernetes)
+	log.Info("Connect to kubernetes", "host", host, "err", err)
+	if err != nil {
+		return err
+	}
+
+	lease := k8s_leader.Options{
+		Namespace:     options.Namespace,
+		Name:          options.Lease,
+		Identity:      options.ID,
+		LeaseDuration: options.LeaseDuration.Duration(),
+	}
+	managerConfig.Leader = k8s_leader.NewDetector(options.PollInterval.Duration(), host, client, lease)
+	managerConfig.LeaderStore = k8s_leader.NewStore(host, client, lease)
+	return configStores(func(key string) (store.Snapshot, error) {
+		// the names of ConfigMaps are lowercase
+		name := strings.ToLower(fmt.Sprintf("%s-%s", options.StorePrefix, key))
+		return k8s_store.NewSnapshot(host, client, options.Namespace, name)
+	}, managerConfig)
+}
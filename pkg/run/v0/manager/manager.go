@@ -5,10 +5,12 @@ import (
 	"os"
 	"strings"
 
+	"github.com/docker/go-connections/tlsconfig"
 	"github.com/docker/infrakit/pkg/launch/inproc"
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/manager"
 	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/rpc/auth"
 	"github.com/docker/infrakit/pkg/rpc/mux"
 	rpc "github.com/docker/infrakit/pkg/rpc/server"
 	"github.com/docker/infrakit/pkg/run"
@@ -61,7 +63,7 @@ type Options struct {
 	manager.Options
 
 	// Backend is the backend used for leadership, persistence, etc.
-	// Possible values are file, etcd, and swarm
+	// Possible values are file, etcd, swarm, kubernetes, consul, and raft
 	Backend string
 
 	// Settings is the configuration of the backend
@@ -80,6 +82,9 @@ type MuxConfig struct {
 
 	// Advertise is the public listen string e.g. public_ip:24864
 	Advertise string
+
+	// TLS is the TLS config of the mux.  Set the ClientAuth to authenticate the clients by their certificates.
+	TLS *tlsconfig.Options
 }
 
 // DefaultOptions return an Options with default values filled in.
@@ -109,6 +114,15 @@ func defaultOptions() (options Options) {
 	case "etcd":
 		options.Backend = "etcd"
 		options.Settings = types.AnyValueMust(DefaultBackendEtcdOptions)
+	case "kubernetes":
+		options.Backend = "kubernetes"
+		options.Settings = types.AnyValueMust(DefaultBackendKubernetesOptions)
+	case "consul":
+		options.Backend = "consul"
+		options.Settings = types.AnyValueMust(DefaultBackendConsulOptions)
+	case "raft":
+		options.Backend = "raft"
+		options.Settings = types.AnyValueMust(DefaultBackendRaftOptions)
 	case "file":
 		options.Backend = "file"
 		options.Settings = types.AnyValueMust(DefaultBackendFileOptions)
@@ -120,6 +134,15 @@ func defaultOptions() (options Options) {
 	return
 }
 
+// hostname returns the hostname, for the default id of the node
+func hostname() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return "manager1"
+	}
+	return h
+}
+
 // Run runs the plugin, blocking the current thread.  Error is returned immediately
 // if the plugin cannot be started.
 func Run(scope scope.Scope, name plugin.Name,
@@ -173,6 +196,42 @@ func Run(scope scope.Scope, name plugin.Name,
 			return
 		}
 		log.Info("swarm backend", "leader", options.Leader, "store", options.SpecStore, "cleanup", options.cleanUpFunc)
+	case "kubernetes":
+		backendOptions := DefaultBackendKubernetesOptions
+		err = options.Settings.Decode(&backendOptions)
+		if err != nil {
+			return
+		}
+		log.Info("starting up kubernetes backend", "options", backendOptions)
+		err = configKubernetesBackends(backendOptions, &options)
+		if err != nil {
+			return
+		}
+		log.Info("kubernetes backend", "leader", options.Leader, "store", options.SpecStore)
+	case "consul":
+		backendOptions := DefaultBackendConsulOptions
+		err = options.Settings.Decode(&backendOptions)
+		if err != nil {
+			return
+		}
+		log.Info("starting up consul backend", "options", backendOptions)
+		err = configConsulBackends(backendOptions, &options)
+		if err != nil {
+			return
+		}
+		log.Info("consul backend", "leader", options.Leader, "store", options.SpecStore)
+	case "raft":
+		backendOptions := DefaultBackendRaftOptions
+		err = options.Settings.Decode(&backendOptions)
+		if err != nil {
+			return
+		}
+		log.Info("starting up raft backend", "options", backendOptions)
+		err = configRaftBackends(backendOptions, &options)
+		if err != nil {
+			return
+		}
+		log.Info("raft backend", "leader", options.Leader, "store", options.SpecStore, "cleanup", options.cleanUpFunc)
 	default:
 		err = fmt.Errorf("unknown backend:%v", options.Backend)
 		return
@@ -202,10 +261,19 @@ func Run(scope scope.Scope, name plugin.Name,
 	if options.Mux != nil {
 
 		log.Info("Starting mux server", "listen", options.Mux.Listen, "advertise", options.Mux.Advertise)
+
+		var authz *auth.Middleware
+		authz, err = auth.Default()
+		if err != nil {
+			return
+		}
+
 		muxServer, err = mux.NewServer(options.Mux.Listen, options.Mux.Advertise, scope.Plugins,
 			mux.Options{
 				Leadership: options.Leader.Receive(),
 				Registry:   options.LeaderStore,
+				Auth:       authz,
+				TLS:        options.Mux.TLS,
 			})
 		if err != nil {
 			fmt.Printf("Cannot start up mux server.  Error: %v\n", err)
@@ -0,0 +1,115 @@
+package manager // import "github.com/docker/infrakit/pkg/run/v0/manager"
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	raft_leader "github.com/docker/infrakit/pkg/leader/raft"
+	"github.com/docker/infrakit/pkg/run/local"
+	raft_store "github.com/docker/infrakit/pkg/store/raft"
+	"github.com/docker/infrakit/pkg/types"
+	etcd "github.com/docker/infrakit/pkg/util/etcd/v3"
+	"github.com/docker/infrakit/pkg/util/raft"
+)
+
+// BackendRaftOptions contain the options for the raft backend.  The managers form a Raft group that elects
+// the leader and replicates the specs, without external dependencies.
+type BackendRaftOptions struct {
+	// PollInterval is how often to check
+	PollInterval types.Duration
+
+	// ID is the id of the node in the group
+	ID string
+
+	// Dir is the directory of the log and snapshots of the node
+	Dir string
+
+	// Listen is the address of the transport between the nodes
+	Listen string
+
+	// Advertise is the address that the other nodes reach this node at
+	Advertise string
+
+	// Bootstrap is set on the first node to start the group
+	Bootstrap bool
+
+	// Join are the urls of the admin endpoints of the members to ask to add this node
+	Join []string
+
+	// Admin is the listen address of the admin endpoint, for the membership of the group
+	Admin string
+
+	// Timeout is the heartbeat and election timeout
+	Timeout types.Duration
+}
+
+// DefaultBackendRaftOptions is the default for the raft backend
+var DefaultBackendRaftOptions = BackendRaftOptions{
+	PollInterval: types.FromDuration(1 * time.Second),
+	ID:           local.Getenv(EnvID, hostname()),
+	Dir:          filepath.Join(local.InfrakitHome(), "raft"),
+	Listen:       ":24865",
+	Advertise:    etcd.LocalIP() + ":24865",
+	Admin:        ":24866",
+	Timeout:      types.FromDuration(raft.DefaultTimeout),
+}
+
+func configRaftBackends(options BackendRaftOptions, managerConfig *Options) error {
+	if managerConfig == nil {
+		return nil
+	}
+
+	node, err := raft.NewNode(raft.Options{
+		ID:        options.ID,
+		Dir:       options.Dir,
+		Listen:    options.Listen,
+		Advertise: options.Advertise,
+		Bootstrap: options.Bootstrap,
+		Join:      options.Join,
+		Timeout:   options.Timeout.Duration(),
+	})
+	if err != nil {
+		return err
+	}
+
+	admin, err := net.Listen("tcp", options.Admin)
+	if err != nil {
+		node.Close()
+		return err
+	}
+	go http.Serve(admin, node.Handler())
+
+	managerConfig.Leader = raft_leader.NewDetector(options.PollInterval.Duration(), node)
+	managerConfig.LeaderStore = raft_leader.NewStore(node)
+	managerConfig.cleanUpFunc = func() {
+		admin.Close()
+		node.Close()
+	}
+
+	snapshot, err := raft_store.NewSnapshot(node, "specs")
+	if err != nil {
+		return err
+	}
+	managerConfig.SpecStore = snapshot
+
+	history, err := raft_store.NewSnapshot(node, "specs.history")
+	if err != nil {
+		return err
+	}
+	managerConfig.HistoryStore = history
+
+	key := "global.vars"
+	if !managerConfig.Metadata.IsEmpty() {
+		key = fmt.Sprintf("%s.vars", managerConfig.Metadata.Lookup())
+	}
+
+	metadataSnapshot, err := raft_store.NewSnapshot(node, key)
+	if err != nil {
+		return err
+	}
+	managerConfig.MetadataStore = metadataSnapshot
+	return nil
+}
@@ -45,12 +45,19 @@ func configSwarmBackends(options BackendSwarmOptions, managerConfig *Options) er
 		return err
 	}
 
+	history, err := swarm_store.NewSnapshot(dockerClient, "infrakit.specs.history")
+	if err != nil {
+		dockerClient.Close()
+		return err
+	}
+
 	leader := swarm_leader.NewDetector(options.PollInterval.Duration(), dockerClient)
 	leaderStore := swarm_leader.NewStore(dockerClient)
 
 	managerConfig.Leader = leader
 	managerConfig.LeaderStore = leaderStore
 	managerConfig.SpecStore = snapshot
+	managerConfig.HistoryStore = history
 	managerConfig.cleanUpFunc = func() {
 		dockerClient.Close()
 		log.Debug("closed docker connection", "client", dockerClient, "V", logutil.V(100))
@@ -10,7 +10,6 @@ import (
 	manager_rpc "github.com/docker/infrakit/pkg/rpc/manager"
 	"github.com/docker/infrakit/pkg/run"
 	"github.com/docker/infrakit/pkg/run/scope"
-	"github.com/docker/infrakit/pkg/spi/controller"
 	"github.com/docker/infrakit/pkg/spi/stack"
 	"github.com/docker/infrakit/pkg/types"
 )
@@ -75,17 +74,9 @@ func Run(scope scope.Scope, name plugin.Name,
 	}
 
 	impls = map[run.PluginCode]interface{}{
-		run.Controller: func() (map[string]controller.Controller, error) {
-			singletons := map[string]controller.Controller{}
-			if controllers, err := pool.Controllers(); err == nil {
-				for k, c := range controllers {
-					singletons[k] = controller.Singleton(c, leader)
-				}
-			}
-			return singletons, nil
-		},
-		run.Metadata: pool.Metadata,
-		run.Event:    pool.Events,
+		run.Controller: pool.Singletons(leader),
+		run.Metadata:   pool.Metadata,
+		run.Event:      pool.Events,
 	}
 
 	return
@@ -0,0 +1,82 @@
+package proxy // import "github.com/docker/infrakit/pkg/run/v0/proxy"
+
+import (
+	"github.com/docker/infrakit/pkg/launch/inproc"
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/plugin"
+	"github.com/docker/infrakit/pkg/plugin/loadbalancer/proxy"
+	"github.com/docker/infrakit/pkg/run"
+	"github.com/docker/infrakit/pkg/run/local"
+	"github.com/docker/infrakit/pkg/run/scope"
+	"github.com/docker/infrakit/pkg/spi/loadbalancer"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+const (
+	// Kind is the canonical name of the plugin for starting up, etc.
+	Kind = "proxy"
+
+	// EnvConfigPath is the environment variable to set the path of the haproxy config of the default L4
+	EnvConfigPath = "INFRAKIT_PROXY_CONFIG_PATH"
+
+	// EnvReloadCommand is the environment variable to set the reload command of the default L4
+	EnvReloadCommand = "INFRAKIT_PROXY_RELOAD_COMMAND"
+)
+
+var (
+	log = logutil.New("module", "run/v0/proxy")
+)
+
+func init() {
+	inproc.Register(Kind, Run, DefaultOptions)
+}
+
+// Options capture the options for starting up the plugin.
+type Options struct {
+	// L4s are the options of the loadbalancers by name, e.g. proxy/lb1 for lb1
+	L4s map[string]proxy.Options
+}
+
+// DefaultOptions return an Options with default values filled in.
+var DefaultOptions = Options{
+	L4s: map[string]proxy.Options{
+		"haproxy": {
+			Kind:          proxy.HAProxy,
+			ConfigPath:    local.Getenv(EnvConfigPath, "/etc/haproxy/haproxy.cfg"),
+			ReloadCommand: local.Getenv(EnvReloadCommand, "systemctl reload haproxy"),
+		},
+	},
+}
+
+// Run runs the plugin, blocking the current thread.  Error is returned immediately
+// if the plugin cannot be started.
+func Run(scope scope.Scope, name plugin.Name,
+	config *types.Any) (transport plugin.Transport, impls map[run.PluginCode]interface{}, onStop func(), err error) {
+
+	// decode into a fresh value so the map of the defaults isn't changed
+	options := Options{}
+	err = config.Decode(&options)
+	if err != nil {
+		return
+	}
+	if len(options.L4s) == 0 {
+		options.L4s = DefaultOptions.L4s
+	}
+
+	l4Map := map[string]loadbalancer.L4{}
+	for n, o := range options.L4s {
+		var l4 loadbalancer.L4
+		l4, err = proxy.NewL4(n, o)
+		if err != nil {
+			log.Error("Cannot start L4", "name", n, "err", err)
+			return
+		}
+		l4Map[n] = l4
+	}
+
+	transport.Name = name
+	impls = map[run.PluginCode]interface{}{
+		run.L4: func() (map[string]loadbalancer.L4, error) { return l4Map, nil },
+	}
+	return
+}
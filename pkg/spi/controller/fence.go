@@ -0,0 +1,80 @@
+package controller // import "github.com/docker/infrakit/pkg/spi/controller"
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+)
+
+// TokenTag is the tag of a committed spec that has the fencing token of the leader that commits it
+const TokenTag = "infrakit.fencing-token"
+
+// WithToken returns a copy of the spec tagged with the fencing token.  The spec is not tagged if the token is 0.
+func WithToken(spec types.Spec, token store.Token) types.Spec {
+	if token == 0 {
+		return spec
+	}
+	tags := map[string]string{}
+	for k, v := range spec.Metadata.Tags {
+		tags[k] = v
+	}
+	tags[TokenTag] = strconv.FormatUint(uint64(token), 10)
+	spec.Metadata.Tags = tags
+	return spec
+}
+
+// TokenOf returns the fencing token the spec is tagged with, 0 if none
+func TokenOf(spec types.Spec) (store.Token, error) {
+	v, has := spec.Metadata.Tags[TokenTag]
+	if !has {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseUint(v, 10, 64)
+	return store.Token(parsed), err
+}
+
+// Fence rejects the commits of the leaders whose term has ended.  It remembers the greatest token committed,
+// in memory only: once the plugin restarts the first token seen is the greatest, until the leader commits.
+type Fence struct {
+	lock    sync.Mutex
+	current store.Token
+}
+
+// Check returns the spec without the token tag, or store.ErrStaleToken if its token is less than the
+// greatest seen.  Specs without a token are not fenced.
+func (f *Fence) Check(spec types.Spec) (types.Spec, error) {
+	if _, has := spec.Metadata.Tags[TokenTag]; !has {
+		return spec, nil
+	}
+	token, err := TokenOf(spec)
+
+	tags := map[string]string{}
+	for k, v := range spec.Metadata.Tags {
+		if k != TokenTag {
+			tags[k] = v
+		}
+	}
+	spec.Metadata.Tags = tags
+
+	if err != nil {
+		return spec, err
+	}
+	return spec, f.CheckToken(token)
+}
+
+// CheckToken returns store.ErrStaleToken if the token is less than the greatest seen.  The token 0 is of no
+// term and is not fenced.
+func (f *Fence) CheckToken(token store.Token) error {
+	if token == 0 {
+		return nil
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if token < f.current {
+		return store.ErrStaleToken{Token: token, Current: f.current}
+	}
+	f.current = token
+	return nil
+}
@@ -0,0 +1,62 @@
+package controller // import "github.com/docker/infrakit/pkg/spi/controller"
+
+import (
+	"testing"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFence(t *testing.T) {
+
+	spec := types.Spec{
+		Kind: "ingress",
+		Metadata: types.Metadata{
+			Name: "lb",
+			Tags: map[string]string{"env": "dev"},
+		},
+	}
+
+	require.Equal(t, spec, WithToken(spec, 0))
+
+	tagged := WithToken(spec, 2)
+	require.Equal(t, "2", tagged.Metadata.Tags[TokenTag])
+	require.Equal(t, map[string]string{"env": "dev"}, spec.Metadata.Tags)
+
+	token, err := TokenOf(tagged)
+	require.NoError(t, err)
+	require.Equal(t, store.Token(2), token)
+	token, err = TokenOf(spec)
+	require.NoError(t, err)
+	require.Equal(t, store.Token(0), token)
+
+	fence := &Fence{}
+
+	checked, err := fence.Check(tagged)
+	require.NoError(t, err)
+	require.Equal(t, spec, checked)
+
+	_, err = fence.Check(WithToken(spec, 2))
+	require.NoError(t, err)
+
+	_, err = fence.Check(WithToken(spec, 1))
+	require.Equal(t, store.ErrStaleToken{Token: 1, Current: 2}, err)
+
+	// specs without a token are not fenced
+	checked, err = fence.Check(spec)
+	require.NoError(t, err)
+	require.Equal(t, spec, checked)
+
+	_, err = fence.Check(WithToken(spec, 3))
+	require.NoError(t, err)
+	_, err = fence.Check(WithToken(spec, 2))
+	require.True(t, store.IsStaleToken(err))
+
+	// the tokens of other calls share the fence
+	require.True(t, store.IsStaleToken(fence.CheckToken(2)))
+	require.NoError(t, fence.CheckToken(0))
+	require.NoError(t, fence.CheckToken(4))
+	_, err = fence.Check(WithToken(spec, 3))
+	require.Equal(t, store.ErrStaleToken{Token: 3, Current: 4}, err)
+}
@@ -2,6 +2,7 @@ package controller // import "github.com/docker/infrakit/pkg/spi/controller"
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/docker/infrakit/pkg/spi/stack"
 	"github.com/docker/infrakit/pkg/types"
@@ -76,3 +77,20 @@ func (s *singleton) Free(search *types.Metadata) (objects []types.Object, err er
 	})
 	return
 }
+
+// Unwrap returns the underlying controller, so that the servers reach what it maintains regardless of
+// leadership, like its metrics.
+func (s *singleton) Unwrap() Controller {
+	return s.Controller
+}
+
+// Exports returns the exports of the underlying controller, if it has any.  Like metrics, exports are
+// available regardless of leadership.
+func (s *singleton) Exports() map[string]http.Handler {
+	if e, is := s.Controller.(interface {
+		Exports() map[string]http.Handler
+	}); is {
+		return e.Exports()
+	}
+	return nil
+}
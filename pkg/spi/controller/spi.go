@@ -20,6 +20,14 @@ const (
 
 	// Destroy is the destroy operation. Destroy also implies Free.
 	Destroy
+
+	// Claim is the operation to claim resources held by an object, e.g. the warm instances of a pool.
+	// The spec's Properties describe the claim.  Objects that don't hold resources return an error.
+	Claim
+
+	// Approve is the operation to approve the changes an object has halted, e.g. a large number of removals.
+	// The spec's Properties describe the approval, which is not stored.  Objects without approvals return an error.
+	Approve
 )
 
 var (
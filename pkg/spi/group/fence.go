@@ -0,0 +1,48 @@
+package group // import "github.com/docker/infrakit/pkg/spi/group"
+
+import (
+	"github.com/docker/infrakit/pkg/store"
+)
+
+// Fenced is implemented by the group plugins whose commits and destroys carry the fencing token of the leader,
+// so that those of the leaders whose term has ended are rejected.
+type Fenced interface {
+	// CommitGroupWithToken commits the group with the fencing token.  The token 0 is of no term.
+	CommitGroupWithToken(grp Spec, pretend bool, token store.Token) (string, error)
+
+	// DestroyGroupWithToken destroys the group with the fencing token.  The token 0 is of no term.
+	DestroyGroupWithToken(id ID, token store.Token) error
+}
+
+// CommitWithToken commits the group with the fencing token if the plugin is fenced, and without otherwise.
+func CommitWithToken(p Plugin, grp Spec, pretend bool, token store.Token) (string, error) {
+	if fenced, is := p.(Fenced); is {
+		return fenced.CommitGroupWithToken(grp, pretend, token)
+	}
+	return p.CommitGroup(grp, pretend)
+}
+
+// Freezable is implemented by the group plugins whose commits carry the freeze of the manager along with the
+// fencing token, so that the groups in the processes of other plugins observe it.
+type Freezable interface {
+	// CommitGroupWithFreeze commits the group with the fencing token and the freeze, as encoded by the manager.
+	// The empty freeze is of no manager.
+	CommitGroupWithFreeze(grp Spec, pretend bool, token store.Token, freeze string) (string, error)
+}
+
+// CommitWithFreeze commits the group with the fencing token and the freeze if the plugin carries the freeze, and
+// as CommitWithToken otherwise.
+func CommitWithFreeze(p Plugin, grp Spec, pretend bool, token store.Token, freeze string) (string, error) {
+	if freezable, is := p.(Freezable); is {
+		return freezable.CommitGroupWithFreeze(grp, pretend, token, freeze)
+	}
+	return CommitWithToken(p, grp, pretend, token)
+}
+
+// DestroyWithToken destroys the group with the fencing token if the plugin is fenced, and without otherwise.
+func DestroyWithToken(p Plugin, id ID, token store.Token) error {
+	if fenced, is := p.(Fenced); is {
+		return fenced.DestroyGroupWithToken(id, token)
+	}
+	return p.DestroyGroup(id)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/docker/infrakit/pkg/spi/instance"
+	"github.com/docker/infrakit/pkg/store"
 )
 
 // LazyConnect returns a Plugin that defers connection to actual method invocation and can optionally
@@ -76,6 +77,23 @@ func (c *lazyConnect) CommitGroup(grp Spec, pretend bool) (resp string, err erro
 	return
 }
 
+func (c *lazyConnect) CommitGroupWithToken(grp Spec, pretend bool, token store.Token) (resp string, err error) {
+	err = c.do(func(p Plugin) error {
+		resp, err = CommitWithToken(p, grp, pretend, token)
+		return err
+	})
+	return
+}
+
+func (c *lazyConnect) CommitGroupWithFreeze(grp Spec, pretend bool, token store.Token,
+	freeze string) (resp string, err error) {
+	err = c.do(func(p Plugin) error {
+		resp, err = CommitWithFreeze(p, grp, pretend, token, freeze)
+		return err
+	})
+	return
+}
+
 func (c *lazyConnect) FreeGroup(id ID) (err error) {
 	err = c.do(func(p Plugin) error {
 		err = p.FreeGroup(id)
@@ -100,6 +118,14 @@ func (c *lazyConnect) DestroyGroup(id ID) (err error) {
 	return
 }
 
+func (c *lazyConnect) DestroyGroupWithToken(id ID, token store.Token) (err error) {
+	err = c.do(func(p Plugin) error {
+		err = DestroyWithToken(p, id, token)
+		return err
+	})
+	return
+}
+
 func (c *lazyConnect) InspectGroups() (specs []Spec, err error) {
 	err = c.do(func(p Plugin) error {
 		specs, err = p.InspectGroups()
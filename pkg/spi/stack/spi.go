@@ -2,6 +2,7 @@ package stack // import "github.com/docker/infrakit/pkg/spi/stack"
 
 import (
 	"net/url"
+	"time"
 
 	"github.com/docker/infrakit/pkg/spi"
 	"github.com/docker/infrakit/pkg/types"
@@ -10,7 +11,7 @@ import (
 // InterfaceSpec is the current name and version of the Instance API.
 var InterfaceSpec = spi.InterfaceSpec{
 	Name:    "Stack",
-	Version: "0.1.1",
+	Version: "0.4.0",
 }
 
 // Interface is a higher-level abstraction for all the groups, controllers, and plugins
@@ -29,6 +30,103 @@ type Interface interface {
 
 	// Terminate destroys all resources associated with the specs
 	Terminate(specs []types.Spec) error
+
+	// History returns the revisions of the specs, oldest first
+	History() ([]Revision, error)
+
+	// Rollback enforces the specs of the given revision, as a new revision.
+	Rollback(revision int) error
+
+	// Plan returns the changes of enforcing the specs, as planned by the groups and controllers, without
+	// making any change.
+	Plan(specs []types.Spec) (Plan, error)
+
+	// Freeze stops all automated changes to the infrastructure until unfrozen.  While frozen, the groups and
+	// controllers observe and report drift, but do not provision or destroy.
+	Freeze(reason string) error
+
+	// Unfreeze resumes the automated changes to the infrastructure.
+	Unfreeze(reason string) error
+}
+
+// FreezeKind is the kind of the object of the freeze in Inspect, if the stack is frozen
+const FreezeKind = "freeze"
+
+// Freeze is the freeze of the stack
+type Freeze struct {
+	// Reason is why the stack is frozen
+	Reason string
+
+	// Author is who froze the stack
+	Author string
+
+	// Time is when the stack was frozen
+	Time time.Time
+}
+
+// ChangeType is the type of change of a spec
+type ChangeType string
+
+const (
+	// Added is a spec that is not enforced yet
+	Added ChangeType = "added"
+
+	// Removed is a spec enforced but not in the specs planned
+	Removed ChangeType = "removed"
+
+	// Changed is a spec enforced with different content
+	Changed ChangeType = "changed"
+
+	// Unchanged is a spec enforced with the same content
+	Unchanged ChangeType = "unchanged"
+)
+
+// Change is the planned change of a spec
+type Change struct {
+	// Type is the type of change
+	Type ChangeType
+
+	// Kind is the kind of the spec
+	Kind string
+
+	// Name is the name of the spec
+	Name string
+
+	// Diff is the unified diff of the spec enforced and the spec planned, in YAML
+	Diff string `json:",omitempty" yaml:",omitempty"`
+
+	// Message is the plan of the group or controller of the spec
+	Message []string `json:",omitempty" yaml:",omitempty"`
+
+	// Error is the error of the group or controller, if it cannot plan the change
+	Error string `json:",omitempty" yaml:",omitempty"`
+}
+
+// Plan is the plan of enforcing specs on the stack
+type Plan struct {
+	// Changes are the changes of the specs, in the order they would be committed
+	Changes []Change
+}
+
+// Revision is an immutable version of the specs committed to the stack
+type Revision struct {
+	// Number is the number of the revision, increasing with each commit
+	Number int
+
+	// Author is who committed the revision
+	Author string
+
+	// Time is when the revision was committed
+	Time time.Time
+
+	// Message describes the change
+	Message string
+
+	// Fingerprint is the fingerprint of the specs
+	Fingerprint string
+
+	// Specs are the specs of the revision
+	Specs []types.Spec
 }
 
 // Leadership is the interface for getting information about the current leader node
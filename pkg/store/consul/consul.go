@@ -0,0 +1,166 @@
+package consul // import "github.com/docker/infrakit/pkg/store/consul"
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/docker/infrakit/pkg/util/consul"
+)
+
+const (
+	// DefaultPrefix is the prefix of the keys of the snapshots
+	DefaultPrefix = "infrakit/configs"
+
+	// retries is the number of tries to write a key when others write it at the same time
+	retries = 3
+)
+
+var log = logutil.New("module", "store/consul")
+
+// NewSnapshot returns a snapshot stored at the key, under the prefix, in the KV store of Consul.  The
+// snapshot and the fencing token are in the same value, which Consul limits to 512KB.
+func NewSnapshot(client *consul.Client, prefix, key string) (store.Snapshot, error) {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &snapshot{
+		client: client,
+		key:    path.Join(strings.Trim(prefix, "/"), key),
+	}, nil
+}
+
+type snapshot struct {
+	client *consul.Client
+	key    string
+}
+
+// record is the value of the key
+type record struct {
+	// Token is the fencing token of the current term
+	Token store.Token `json:",omitempty"`
+
+	// Snapshot is the object saved
+	Snapshot *types.Any `json:",omitempty"`
+}
+
+// read returns the record and the modify index of the key, 0 if there's none
+func (s *snapshot) read() (record, uint64, error) {
+	found := []struct {
+		Value       string
+		ModifyIndex uint64
+	}{}
+	current := record{}
+	status, err := s.client.Do(http.MethodGet, "/v1/kv/"+s.key, nil, nil, &found)
+	if status == http.StatusNotFound {
+		return current, 0, nil
+	}
+	if err != nil {
+		return current, 0, err
+	}
+	if len(found) == 0 {
+		return current, 0, nil
+	}
+	buff, err := base64.StdEncoding.DecodeString(found[0].Value)
+	if err != nil {
+		return current, 0, err
+	}
+	if len(buff) > 0 {
+		err = json.Unmarshal(buff, &current)
+	}
+	return current, found[0].ModifyIndex, err
+}
+
+// update writes the record changed by the function.  The write fails if the key is written since read,
+// and it's read and changed again.
+func (s *snapshot) update(change func(*record) error) error {
+	for i := 0; i < retries; i++ {
+		current, index, err := s.read()
+		if err != nil {
+			return err
+		}
+		if err := change(&current); err != nil {
+			return err
+		}
+		buff, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+		ok := false
+		cas := url.Values{"cas": {fmt.Sprintf("%d", index)}}
+		if _, err := s.client.Do(http.MethodPut, "/v1/kv/"+s.key, cas, buff, &ok); err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		log.Debug("Conflict", "key", s.key, "index", index)
+	}
+	return fmt.Errorf("cannot write %v: conflict", s.key)
+}
+
+// Save marshals (encodes) and saves a snapshot of the given object.
+func (s *snapshot) Save(obj interface{}) error {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+	return s.update(func(current *record) error {
+		current.Snapshot = any
+		return nil
+	})
+}
+
+// Load loads a snapshot and marshals (decodes) into the given reference.
+// If no data is available to unmarshal into the given struct, the fuction returns nil.
+func (s *snapshot) Load(output interface{}) error {
+	current, _, err := s.read()
+	if err != nil || current.Snapshot == nil {
+		return err
+	}
+	return current.Snapshot.Decode(output)
+}
+
+// Token implements store.Fenced
+func (s *snapshot) Token() (store.Token, error) {
+	current, _, err := s.read()
+	return current.Token, err
+}
+
+// Fence implements store.Fenced
+func (s *snapshot) Fence(token store.Token) error {
+	return s.update(func(current *record) error {
+		if token <= current.Token {
+			return store.ErrStaleToken{Token: token, Current: current.Token}
+		}
+		current.Token = token
+		return nil
+	})
+}
+
+// SaveWithToken implements store.Fenced
+func (s *snapshot) SaveWithToken(token store.Token, obj interface{}) error {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+	return s.update(func(current *record) error {
+		if token < current.Token {
+			return store.ErrStaleToken{Token: token, Current: current.Token}
+		}
+		current.Token, current.Snapshot = token, any
+		return nil
+	})
+}
+
+// Close implements io.Closer
+func (s *snapshot) Close() error {
+	return nil
+}
@@ -0,0 +1,125 @@
+package consul // import "github.com/docker/infrakit/pkg/store/consul"
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	testing_store "github.com/docker/infrakit/pkg/testing/store"
+	"github.com/docker/infrakit/pkg/util/consul"
+	"github.com/stretchr/testify/require"
+)
+
+type value struct {
+	Value       string
+	ModifyIndex uint64
+}
+
+// fakeKV serves the kv of the Consul agent HTTP API, with check-and-set
+type fakeKV struct {
+	sync.Mutex
+	index  uint64
+	values map[string]value
+}
+
+func (s *fakeKV) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	key := strings.TrimPrefix(req.URL.Path, "/v1/kv/")
+	current, has := s.values[key]
+	switch req.Method {
+	case http.MethodGet:
+		if !has {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(resp).Encode([]value{current})
+
+	case http.MethodPut:
+		if cas := req.URL.Query().Get("cas"); cas != "" {
+			index, err := strconv.ParseUint(cas, 10, 64)
+			if err != nil {
+				http.Error(resp, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if index != current.ModifyIndex {
+				json.NewEncoder(resp).Encode(false)
+				return
+			}
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		s.index++
+		s.values[key] = value{Value: base64.StdEncoding.EncodeToString(body), ModifyIndex: s.index}
+		json.NewEncoder(resp).Encode(true)
+
+	default:
+		http.Error(resp, "no such method", http.StatusMethodNotAllowed)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+
+	fake := &fakeKV{values: map[string]value{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := consul.NewClient(server.Client(), consul.Options{Address: server.URL})
+
+	snapshot, err := NewSnapshot(client, "", "global.config")
+	require.NoError(t, err)
+
+	specs := map[string]string{}
+	require.NoError(t, snapshot.Load(&specs))
+	require.Equal(t, 0, len(specs))
+
+	require.NoError(t, snapshot.Save(map[string]string{"workers": "group"}))
+	require.NoError(t, snapshot.Load(&specs))
+	require.Equal(t, map[string]string{"workers": "group"}, specs)
+
+	_, has := fake.values[DefaultPrefix+"/global.config"]
+	require.True(t, has)
+}
+
+func TestSnapshotFencing(t *testing.T) {
+
+	server := httptest.NewServer(&fakeKV{values: map[string]value{}})
+	defer server.Close()
+
+	// two leaders with their own snapshots of the same key
+	old, err := NewSnapshot(consul.NewClient(server.Client(), consul.Options{Address: server.URL}), "", "specs")
+	require.NoError(t, err)
+	current, err := NewSnapshot(consul.NewClient(server.Client(), consul.Options{Address: server.URL}), "", "specs")
+	require.NoError(t, err)
+
+	testing_store.Fencing(t, old, current)
+}
+
+func TestSnapshotConflict(t *testing.T) {
+
+	// another writer always writes in between
+	fake := &fakeKV{values: map[string]value{}}
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		fake.ServeHTTP(resp, req)
+		if req.Method == http.MethodGet {
+			fake.Lock()
+			fake.index++
+			fake.values["infrakit/configs/specs"] = value{ModifyIndex: fake.index}
+			fake.Unlock()
+		}
+	}))
+	defer server.Close()
+
+	snapshot, err := NewSnapshot(consul.NewClient(server.Client(), consul.Options{Address: server.URL}), "", "specs")
+	require.NoError(t, err)
+
+	err = snapshot.Save("specs")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "conflict")
+}
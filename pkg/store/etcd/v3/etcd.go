@@ -1,8 +1,11 @@
 package etcd
 
 import (
+	"fmt"
 	"path"
+	"strconv"
 
+	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	logutil "github.com/docker/infrakit/pkg/log"
 	"github.com/docker/infrakit/pkg/store"
@@ -13,6 +16,9 @@ import (
 
 const (
 	namespace = "infrakit/configs"
+
+	// retries is the number of tries to write the token when others write it at the same time
+	retries = 3
 )
 
 var log = logutil.New("module", "etcd/store")
@@ -96,6 +102,65 @@ func (s *snapshot) Load(output interface{}) error {
 	return any.Decode(&output)
 }
 
+// Token implements store.Fenced
+func (s *snapshot) Token() (store.Token, error) {
+	token, _, err := s.token()
+	return token, err
+}
+
+// token returns the token and the revision of its key, 0 if there's none
+func (s *snapshot) token() (store.Token, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Options.RequestTimeout)
+	resp, err := s.client.Client.Get(ctx, s.key+".token")
+	cancel()
+	if err != nil || resp.Count == 0 {
+		return 0, 0, err
+	}
+	v, err := strconv.ParseUint(string(resp.Kvs[0].Value), 10, 64)
+	return store.Token(v), resp.Kvs[0].ModRevision, err
+}
+
+// Fence implements store.Fenced
+func (s *snapshot) Fence(token store.Token) error {
+	return s.fenced(token, false)
+}
+
+// SaveWithToken implements store.Fenced
+func (s *snapshot) SaveWithToken(token store.Token, obj interface{}) error {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+	return s.fenced(token, true, clientv3.OpPut(s.key, any.String()))
+}
+
+// fenced writes the token with the ops in a transaction that fails if the token is written since read.
+func (s *snapshot) fenced(token store.Token, current bool, ops ...clientv3.Op) error {
+	for i := 0; i < retries; i++ {
+		found, revision, err := s.token()
+		if err != nil {
+			return err
+		}
+		if token < found || (token == found && !current) {
+			return store.ErrStaleToken{Token: token, Current: found}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.client.Options.RequestTimeout)
+		resp, err := s.client.Client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(s.key+".token"), "=", revision)).
+			Then(append([]clientv3.Op{clientv3.OpPut(s.key+".token", fmt.Sprintf("%d", token))}, ops...)...).
+			Commit()
+		cancel()
+		if err != nil {
+			return err
+		}
+		if resp.Succeeded {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot write token %d of %v: conflict", token, s.key)
+}
+
 // Close releases the resources and closes the connection to etcd
 func (s *snapshot) Close() error {
 	if s.client == nil {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
+	"github.com/docker/infrakit/pkg/store"
 	testutil "github.com/docker/infrakit/pkg/testing"
 	"github.com/docker/infrakit/pkg/types"
 	"github.com/docker/infrakit/pkg/util/etcd/v3"
@@ -40,6 +41,7 @@ func TestWithRealEtcd(t *testing.T) {
 	defer etcd.StopContainer.Start(containerName)
 
 	t.Run("SaveLoad", testSaveLoad)
+	t.Run("Fencing", testFencing)
 }
 
 func testSaveLoad(t *testing.T) {
@@ -102,3 +104,38 @@ func testSaveLoad(t *testing.T) {
 	require.Equal(t, config, config2)
 
 }
+
+func testFencing(t *testing.T) {
+
+	if testutil.SkipTests("etcd") {
+		t.SkipNow()
+	}
+
+	ip := etcd.LocalIP()
+	options := etcd.Options{
+		Config: clientv3.Config{
+			Endpoints: []string{ip + ":2379"},
+		},
+		RequestTimeout: 1 * time.Second,
+	}
+
+	etcdClient, err := etcd.NewClient(options)
+	require.NoError(t, err)
+	snap, err := NewSnapshot(etcdClient, "fencing")
+	require.NoError(t, err)
+
+	defer snap.Close()
+
+	require.NoError(t, store.Fence(snap, 1))
+	require.NoError(t, store.WithToken(snap, func() store.Token { return 1 }).Save("old"))
+
+	require.NoError(t, store.Fence(snap, 2))
+	require.True(t, store.IsStaleToken(store.Fence(snap, 2)))
+
+	err = store.WithToken(snap, func() store.Token { return 1 }).Save("stale")
+	require.Equal(t, store.ErrStaleToken{Token: 1, Current: 2}, err)
+
+	saved := ""
+	require.NoError(t, snap.Load(&saved))
+	require.Equal(t, "old", saved)
+}
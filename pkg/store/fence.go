@@ -0,0 +1,96 @@
+package store // import "github.com/docker/infrakit/pkg/store"
+
+import (
+	"fmt"
+)
+
+// Token is a fencing token.  Each term of leadership has a greater token than the terms before it, so the
+// writes of a leader that lost leadership but hasn't noticed yet can be told apart and rejected.
+type Token uint64
+
+// ErrStaleToken is the error of a write with the token of a past term
+type ErrStaleToken struct {
+	// Token is the token of the write
+	Token Token
+
+	// Current is the token of the current term
+	Current Token
+}
+
+// Error implements error
+func (e ErrStaleToken) Error() string {
+	return fmt.Sprintf("stale fencing token %d, current is %d", e.Token, e.Current)
+}
+
+// IsStaleToken returns true if the error is a rejection of a stale token
+func IsStaleToken(err error) bool {
+	_, is := err.(ErrStaleToken)
+	return is
+}
+
+// Fenced is implemented by the snapshots that reject the writes of past terms of leadership
+type Fenced interface {
+	Snapshot
+
+	// Token returns the token of the current term, 0 if none
+	Token() (Token, error)
+
+	// Fence starts the term of the token.  The token must be greater than the current one, otherwise
+	// ErrStaleToken is returned because another leader has started a term since.
+	Fence(token Token) error
+
+	// SaveWithToken saves the object if the token is not less than the current one.
+	SaveWithToken(token Token, obj interface{}) error
+}
+
+// WithToken returns a snapshot that saves with the token returned by the function.  Saves of the snapshots
+// that aren't fenced are not fenced.  The token 0 is of no term, and is stale once a term has started.
+func WithToken(snapshot Snapshot, token func() Token) Snapshot {
+	if snapshot == nil {
+		return nil
+	}
+	return &withToken{Snapshot: snapshot, token: token}
+}
+
+type withToken struct {
+	Snapshot
+	token func() Token
+}
+
+// Save implements Snapshot.Save
+func (s *withToken) Save(obj interface{}) error {
+	if fenced, is := s.Snapshot.(Fenced); is {
+		return fenced.SaveWithToken(s.token(), obj)
+	}
+	return s.Snapshot.Save(obj)
+}
+
+func fenced(snapshot Snapshot) (Fenced, bool) {
+	if w, is := snapshot.(*withToken); is {
+		snapshot = w.Snapshot
+	}
+	f, is := snapshot.(Fenced)
+	return f, is
+}
+
+// IsFenced returns true if the snapshot rejects the writes of past terms
+func IsFenced(snapshot Snapshot) bool {
+	_, is := fenced(snapshot)
+	return is
+}
+
+// CurrentToken returns the token of the current term of the snapshot, 0 if it's not fenced
+func CurrentToken(snapshot Snapshot) (Token, error) {
+	if f, is := fenced(snapshot); is {
+		return f.Token()
+	}
+	return 0, nil
+}
+
+// Fence starts the term of the token in the snapshot, if it's fenced.
+func Fence(snapshot Snapshot, token Token) error {
+	if f, is := fenced(snapshot); is {
+		return f.Fence(token)
+	}
+	return nil
+}
@@ -2,9 +2,13 @@ package file // import "github.com/docker/infrakit/pkg/store/file"
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/docker/infrakit/pkg/run/local"
 	"github.com/docker/infrakit/pkg/store"
@@ -15,8 +19,17 @@ type snapshot struct {
 	name string
 }
 
+const (
+	// lockTimeout is how long to wait for the lock of the fencing token
+	lockTimeout = 5 * time.Second
+
+	// staleLock is the age of a lock file that's left by a process that died holding it
+	staleLock = 30 * time.Second
+)
+
 // NewSnapshot returns an instance of the snapshot service where data is stored in the directory given.
-// This is a simple implementation that assumes a single file for the entire snapshot.
+// This is a simple implementation that assumes a single file for the entire snapshot.  The snapshot is
+// fenced with the token in the file of the name with the suffix .token.
 func NewSnapshot(dir, name string) (store.Snapshot, error) {
 
 	if err := local.EnsureDir(dir); err != nil {
@@ -47,6 +60,84 @@ func (s *snapshot) Load(output interface{}) error {
 	return nil
 }
 
+// Token implements store.Fenced
+func (s *snapshot) Token() (store.Token, error) {
+	buff, err := ioutil.ReadFile(filepath.Join(s.dir, s.name+".token"))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(buff)), 10, 64)
+	return store.Token(v), err
+}
+
+// Fence implements store.Fenced
+func (s *snapshot) Fence(token store.Token) error {
+	return s.fenced(func(current store.Token) error {
+		if token <= current {
+			return store.ErrStaleToken{Token: token, Current: current}
+		}
+		return s.writeToken(token)
+	})
+}
+
+// SaveWithToken implements store.Fenced
+func (s *snapshot) SaveWithToken(token store.Token, obj interface{}) error {
+	return s.fenced(func(current store.Token) error {
+		if token < current {
+			return store.ErrStaleToken{Token: token, Current: current}
+		}
+		if token > current {
+			if err := s.writeToken(token); err != nil {
+				return err
+			}
+		}
+		return s.Save(obj)
+	})
+}
+
+func (s *snapshot) writeToken(token store.Token) error {
+	path := filepath.Join(s.dir, s.name+".token")
+	if err := ioutil.WriteFile(path+".tmp", []byte(fmt.Sprintf("%d", token)), 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// fenced calls the function with the current token while holding the lock of the token.  The lock is a
+// file so that the snapshots of different processes on the same directory exclude each other.
+func (s *snapshot) fenced(f func(store.Token) error) error {
+	lock := filepath.Join(s.dir, s.name+".lock")
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+			break
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for lock %v", lock)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer os.Remove(lock)
+
+	current, err := s.Token()
+	if err != nil {
+		return err
+	}
+	return f(current)
+}
+
 // Close implements Closer
 func (s *snapshot) Close() error {
 	return nil
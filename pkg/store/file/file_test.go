@@ -0,0 +1,25 @@
+package file // import "github.com/docker/infrakit/pkg/store/file"
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	testing_store "github.com/docker/infrakit/pkg/testing/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotFencing(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "fencing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// two leaders with their own snapshots of the same file
+	old, err := NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+	current, err := NewSnapshot(dir, "global.config")
+	require.NoError(t, err)
+
+	testing_store.Fencing(t, old, current)
+}
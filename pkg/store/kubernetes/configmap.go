@@ -0,0 +1,177 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/store/kubernetes"
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/docker/infrakit/pkg/util/kubernetes/api"
+)
+
+const (
+	// TokenKey is the key of the data of the ConfigMap that stores the fencing token
+	TokenKey = "token"
+
+	// SnapshotKey is the key of the data of the ConfigMap that stores the snapshot
+	SnapshotKey = "snapshot"
+
+	// retries is the number of tries to write the ConfigMap when others write it at the same time
+	retries = 3
+)
+
+var log = logutil.New("module", "store/kubernetes")
+
+type configMap struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   api.ObjectMeta    `json:"metadata"`
+	Data       map[string]string `json:"data,omitempty"`
+}
+
+// NewSnapshot returns a snapshot stored in the ConfigMap of the name in the namespace, at the API server of
+// the host.  The http client must be authenticated to the API server.  The snapshot and the fencing token
+// are in the same ConfigMap, which Kubernetes limits to 1MB.
+func NewSnapshot(host string, client *http.Client, namespace, name string) (store.Snapshot, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return &snapshot{
+		client:    client,
+		url:       fmt.Sprintf("%s/api/v1/namespaces/%s/configmaps", strings.TrimRight(host, "/"), url.PathEscape(namespace)),
+		namespace: namespace,
+		name:      name,
+	}, nil
+}
+
+type snapshot struct {
+	client    *http.Client
+	url       string
+	namespace string
+	name      string
+}
+
+// get returns the ConfigMap, nil if not found
+func (s *snapshot) get() (*configMap, error) {
+	current := &configMap{}
+	status, err := api.Do(s.client, http.MethodGet, s.url+"/"+url.PathEscape(s.name), nil, current)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+func (s *snapshot) token(current *configMap) (store.Token, error) {
+	if current == nil || current.Data[TokenKey] == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(current.Data[TokenKey], 10, 64)
+	return store.Token(v), err
+}
+
+// update writes the ConfigMap changed by the function.  The write fails if the ConfigMap is written since
+// read, and it's read and changed again.
+func (s *snapshot) update(change func(token store.Token, data map[string]string) error) error {
+	for i := 0; i < retries; i++ {
+		current, err := s.get()
+		if err != nil {
+			return err
+		}
+		method, u := http.MethodPut, s.url+"/"+url.PathEscape(s.name)
+		if current == nil {
+			method, u = http.MethodPost, s.url
+			current = &configMap{Metadata: api.ObjectMeta{Name: s.name, Namespace: s.namespace}}
+		}
+		token, err := s.token(current)
+		if err != nil {
+			return err
+		}
+		if current.Data == nil {
+			current.Data = map[string]string{}
+		}
+		if err := change(token, current.Data); err != nil {
+			return err
+		}
+		current.APIVersion, current.Kind = "v1", "ConfigMap"
+		status, err := api.Do(s.client, method, u, current, current)
+		if status == http.StatusConflict {
+			log.Debug("Conflict", "name", s.name, "resourceVersion", current.Metadata.ResourceVersion)
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("cannot write configmap %v: conflict", s.name)
+}
+
+// Save marshals (encodes) and saves a snapshot of the given object.
+func (s *snapshot) Save(obj interface{}) error {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+	return s.update(func(token store.Token, data map[string]string) error {
+		data[SnapshotKey] = any.String()
+		return nil
+	})
+}
+
+// Load loads a snapshot and marshals (decodes) into the given reference.
+// If no data is available to unmarshal into the given struct, the fuction returns nil.
+func (s *snapshot) Load(output interface{}) error {
+	current, err := s.get()
+	if err != nil || current == nil {
+		return err
+	}
+	v, has := current.Data[SnapshotKey]
+	if !has {
+		return nil
+	}
+	return types.AnyString(v).Decode(output)
+}
+
+// Token implements store.Fenced
+func (s *snapshot) Token() (store.Token, error) {
+	current, err := s.get()
+	if err != nil {
+		return 0, err
+	}
+	return s.token(current)
+}
+
+// Fence implements store.Fenced
+func (s *snapshot) Fence(token store.Token) error {
+	return s.update(func(current store.Token, data map[string]string) error {
+		if token <= current {
+			return store.ErrStaleToken{Token: token, Current: current}
+		}
+		data[TokenKey] = fmt.Sprintf("%d", token)
+		return nil
+	})
+}
+
+// SaveWithToken implements store.Fenced
+func (s *snapshot) SaveWithToken(token store.Token, obj interface{}) error {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+	return s.update(func(current store.Token, data map[string]string) error {
+		if token < current {
+			return store.ErrStaleToken{Token: token, Current: current}
+		}
+		data[TokenKey], data[SnapshotKey] = fmt.Sprintf("%d", token), any.String()
+		return nil
+	})
+}
+
+// Close implements io.Closer
+func (s *snapshot) Close() error {
+	return nil
+}
@@ -0,0 +1,134 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/store/kubernetes"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	testing_store "github.com/docker/infrakit/pkg/testing/store"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPIServer serves the configmaps of a namespace with optimistic concurrency like the API server
+type fakeAPIServer struct {
+	sync.Mutex
+	version    int
+	configMaps map[string]configMap
+}
+
+func (s *fakeAPIServer) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	prefix := "/api/v1/namespaces/test/configmaps"
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		s.status(resp, http.StatusNotFound, "no such path")
+		return
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, prefix), "/")
+
+	in := configMap{}
+	if req.Method != http.MethodGet {
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			s.status(resp, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	current, has := s.configMaps[name]
+	switch req.Method {
+	case http.MethodGet:
+		if !has {
+			s.status(resp, http.StatusNotFound, "not found")
+			return
+		}
+		json.NewEncoder(resp).Encode(current)
+		return
+	case http.MethodPost:
+		if _, has := s.configMaps[in.Metadata.Name]; has {
+			s.status(resp, http.StatusConflict, "already exists")
+			return
+		}
+	case http.MethodPut:
+		if !has {
+			s.status(resp, http.StatusNotFound, "not found")
+			return
+		}
+		if in.Metadata.ResourceVersion != current.Metadata.ResourceVersion {
+			s.status(resp, http.StatusConflict, "the object has been modified")
+			return
+		}
+	}
+	s.version++
+	in.Metadata.ResourceVersion = fmt.Sprintf("%d", s.version)
+	s.configMaps[in.Metadata.Name] = in
+	json.NewEncoder(resp).Encode(in)
+}
+
+func (s *fakeAPIServer) status(resp http.ResponseWriter, code int, message string) {
+	resp.WriteHeader(code)
+	json.NewEncoder(resp).Encode(map[string]interface{}{"kind": "Status", "code": code, "message": message})
+}
+
+func startFakeAPIServer() (*fakeAPIServer, *httptest.Server) {
+	fake := &fakeAPIServer{configMaps: map[string]configMap{}}
+	return fake, httptest.NewServer(fake)
+}
+
+func TestSnapshot(t *testing.T) {
+
+	fake, server := startFakeAPIServer()
+	defer server.Close()
+
+	snapshot, err := NewSnapshot(server.URL, server.Client(), "test", "infrakit-global.config")
+	require.NoError(t, err)
+
+	specs := map[string]string{}
+	require.NoError(t, snapshot.Load(&specs))
+	require.Equal(t, 0, len(specs))
+
+	require.NoError(t, snapshot.Save(map[string]string{"workers": "group"}))
+	require.NoError(t, snapshot.Load(&specs))
+	require.Equal(t, map[string]string{"workers": "group"}, specs)
+
+	require.NoError(t, snapshot.Save(map[string]string{"workers": "group", "lb": "ingress"}))
+	require.NoError(t, snapshot.Load(&specs))
+	require.Equal(t, 2, len(specs))
+
+	require.Equal(t, 1, len(fake.configMaps))
+	require.Contains(t, fake.configMaps["infrakit-global.config"].Data[SnapshotKey], "ingress")
+}
+
+func TestSnapshotFencing(t *testing.T) {
+
+	_, server := startFakeAPIServer()
+	defer server.Close()
+
+	// two leaders with their own snapshots of the same configmap
+	old, err := NewSnapshot(server.URL, server.Client(), "test", "infrakit-specs")
+	require.NoError(t, err)
+	current, err := NewSnapshot(server.URL, server.Client(), "test", "infrakit-specs")
+	require.NoError(t, err)
+
+	testing_store.Fencing(t, old, current)
+}
+
+func TestSnapshotAPIError(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(resp).Encode(map[string]interface{}{"kind": "Status", "message": "configmaps is forbidden"})
+	}))
+	defer server.Close()
+
+	snapshot, err := NewSnapshot(server.URL, server.Client(), "test", "infrakit-specs")
+	require.NoError(t, err)
+
+	err = snapshot.Save("specs")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "configmaps is forbidden")
+}
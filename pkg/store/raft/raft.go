@@ -0,0 +1,150 @@
+package raft // import "github.com/docker/infrakit/pkg/store/raft"
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"strings"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/types"
+	"github.com/docker/infrakit/pkg/util/raft"
+)
+
+const (
+	namespace   = "infrakit/configs"
+	kvNamespace = "infrakit/kv"
+)
+
+var log = logutil.New("module", "raft/store")
+
+// NewSnapshot returns a snapshot stored in the key space of the node.  It's saved on the leader.
+func NewSnapshot(node *raft.Node, key string) (store.Snapshot, error) {
+	return &snapshot{
+		node: node,
+		key:  path.Join(namespace, key),
+	}, nil
+}
+
+type snapshot struct {
+	node *raft.Node
+	key  string
+}
+
+// Save marshals (encodes) and saves a snapshot of the given object.
+func (s *snapshot) Save(obj interface{}) error {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+	return s.node.Put(s.key, any.Bytes())
+}
+
+// Load loads a snapshot and marshals (decodes) into the given reference.
+// If no data is available to unmarshal into the given struct, the fuction returns nil.
+func (s *snapshot) Load(output interface{}) error {
+	v, has := s.node.Get(s.key)
+	if !has {
+		return nil
+	}
+	return types.AnyBytes(v).Decode(output)
+}
+
+// Token implements store.Fenced
+func (s *snapshot) Token() (store.Token, error) {
+	return s.node.Token(s.key + ".token")
+}
+
+// Fence implements store.Fenced
+func (s *snapshot) Fence(token store.Token) error {
+	return s.node.Fence(s.key+".token", token)
+}
+
+// SaveWithToken implements store.Fenced
+func (s *snapshot) SaveWithToken(token store.Token, obj interface{}) error {
+	any, err := types.AnyValue(obj)
+	if err != nil {
+		return err
+	}
+	return s.node.PutWithToken(s.key+".token", token, s.key, any.Bytes())
+}
+
+// Close implements io.Closer.  The node is not closed, since it's shared.
+func (s *snapshot) Close() error {
+	return nil
+}
+
+// NewKV returns a store of the objects of the type in the key space of the node.  Objects are written on the leader.
+func NewKV(node *raft.Node, typeName string) store.KV {
+	return &kv{
+		node:   node,
+		t:      typeName,
+		prefix: path.Join(kvNamespace, typeName) + "/",
+	}
+}
+
+type kv struct {
+	node   *raft.Node
+	t      string
+	prefix string
+}
+
+// Close implements io.Closer
+func (s *kv) Close() error {
+	return nil
+}
+
+// Write writes the object
+func (s *kv) Write(key interface{}, value []byte) error {
+	if key == nil {
+		key = rand.Int63()
+	}
+	log.Debug("Write", "key", s.Key(key))
+	return s.node.Put(s.prefix+s.Key(key), value)
+}
+
+// Key returns an id given the key. The id contains type, etc.
+func (s *kv) Key(key interface{}) string {
+	return fmt.Sprintf("%s-%v", s.t, key)
+}
+
+// Read loads the object
+func (s *kv) Read(key interface{}) ([]byte, error) {
+	v, has := s.node.Get(s.prefix + s.Key(key))
+	if !has {
+		return nil, fmt.Errorf("not found %v", key)
+	}
+	return v, nil
+}
+
+// Exists checks for existence
+func (s *kv) Exists(key interface{}) (bool, error) {
+	_, has := s.node.Get(s.prefix + s.Key(key))
+	return has, nil
+}
+
+// Delete deletes the object
+func (s *kv) Delete(key interface{}) error {
+	return s.node.Delete(s.prefix + s.Key(key))
+}
+
+// Entries returns the entries
+func (s *kv) Entries() (<-chan store.Pair, error) {
+	keys := s.node.Keys(s.prefix)
+	out := make(chan store.Pair)
+	go func() {
+		defer close(out)
+		for _, k := range keys {
+			v, has := s.node.Get(k)
+			if !has {
+				continue
+			}
+			out <- store.Pair{
+				Key:   strings.TrimPrefix(k, s.prefix+s.t+"-"),
+				Value: v,
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,108 @@
+package raft // import "github.com/docker/infrakit/pkg/store/raft"
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/docker/infrakit/pkg/util/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func startNode(t *testing.T, dir string) *raft.Node {
+	node, err := raft.NewNode(raft.Options{
+		ID:        "n1",
+		Dir:       dir,
+		Listen:    "127.0.0.1:0",
+		Bootstrap: true,
+		Timeout:   100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !node.IsLeader() {
+		require.True(t, time.Now().Before(deadline), "no leader")
+		time.Sleep(20 * time.Millisecond)
+	}
+	return node
+}
+
+func TestSnapshot(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "raft")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	node := startNode(t, dir)
+	defer node.Close()
+
+	snapshot, err := NewSnapshot(node, "specs")
+	require.NoError(t, err)
+
+	loaded := map[string]interface{}{}
+	require.NoError(t, snapshot.Load(&loaded))
+	require.Equal(t, 0, len(loaded))
+
+	saved := map[string]interface{}{"workers": map[string]interface{}{"Instance": "bar"}}
+	require.NoError(t, snapshot.Save(saved))
+	require.NoError(t, snapshot.Load(&loaded))
+	require.Equal(t, saved, loaded)
+
+	// fenced
+	require.True(t, store.IsFenced(snapshot))
+	require.NoError(t, store.Fence(snapshot, 1))
+	require.NoError(t, store.Fence(snapshot, 2))
+	err = store.WithToken(snapshot, func() store.Token { return 1 }).Save("stale")
+	require.Equal(t, store.ErrStaleToken{Token: 1, Current: 2}, err)
+	require.NoError(t, store.WithToken(snapshot, func() store.Token { return 2 }).Save("current"))
+
+	token, err := store.CurrentToken(snapshot)
+	require.NoError(t, err)
+	require.Equal(t, store.Token(2), token)
+
+	current := ""
+	require.NoError(t, snapshot.Load(&current))
+	require.Equal(t, "current", current)
+}
+
+func TestKV(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "raft")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	node := startNode(t, dir)
+	defer node.Close()
+
+	kv := NewKV(node, "instance")
+	other := NewKV(node, "route")
+
+	require.NoError(t, kv.Write("i-1", []byte("a")))
+	require.NoError(t, kv.Write("i-2", []byte("b")))
+	require.NoError(t, other.Write("r-1", []byte("c")))
+
+	exists, err := kv.Exists("i-1")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	v, err := kv.Read("i-2")
+	require.NoError(t, err)
+	require.Equal(t, "b", string(v))
+
+	require.NoError(t, kv.Delete("i-2"))
+	exists, err = kv.Exists("i-2")
+	require.NoError(t, err)
+	require.False(t, exists)
+	_, err = kv.Read("i-2")
+	require.Error(t, err)
+
+	entries, err := kv.Entries()
+	require.NoError(t, err)
+	found := []store.Pair{}
+	for entry := range entries {
+		found = append(found, entry)
+	}
+	require.Equal(t, []store.Pair{{Key: "i-1", Value: []byte("a")}}, found)
+}
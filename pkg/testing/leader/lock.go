@@ -0,0 +1,59 @@
+package leader // import "github.com/docker/infrakit/pkg/testing/leader"
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/docker/infrakit/pkg/leader"
+	"github.com/stretchr/testify/require"
+)
+
+// StepDown checks that the first detector becomes the leader and the second doesn't, and that the second,
+// of the identity second, takes over once the first stops, without waiting for the lock to expire.  The
+// holder returns the identity holding the lock in the backend, the empty string if none.  It's used with the
+// backends where the leader holds a lock, with leader.LockDetector.
+func StepDown(t *testing.T, detector1, detector2 leader.Detector, holder func() string, second string) {
+	events1, err := detector1.Start()
+	require.NoError(t, err)
+	require.Equal(t, leader.Leader, (<-events1).Status)
+
+	events2, err := detector2.Start()
+	require.NoError(t, err)
+	require.Equal(t, leader.NotLeader, (<-events2).Status)
+
+	// stopping releases the lock so the second takes over without waiting for it to expire
+	go func() {
+		for range events1 {
+		}
+	}()
+	detector1.Stop()
+
+	for event := range events2 {
+		if event.Status == leader.Leader {
+			break
+		}
+	}
+	require.Equal(t, second, holder())
+
+	go func() {
+		for range events2 {
+		}
+	}()
+	detector2.Stop()
+	require.Equal(t, "", holder())
+}
+
+// Store checks that the store has no location until one is updated.
+func Store(t *testing.T, store leader.Store) {
+	u, err := store.GetLocation()
+	require.NoError(t, err)
+	require.Nil(t, u)
+
+	loc, err := url.Parse("tcp://10.10.1.100:24864")
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateLocation(loc))
+
+	u, err = store.GetLocation()
+	require.NoError(t, err)
+	require.Equal(t, loc, u)
+}
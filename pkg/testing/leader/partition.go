@@ -0,0 +1,85 @@
+package leader // import "github.com/docker/infrakit/pkg/testing/leader"
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/docker/infrakit/pkg/leader"
+	"github.com/docker/infrakit/pkg/leader/file"
+)
+
+// Partitions simulates network partitions of nodes that detect the leader with the file leader.  Each node
+// has its own leader file, its view of who the leader is.  The views of the nodes that are not partitioned
+// follow the elections.  A partitioned node keeps the view it had when it was cut off, so a partitioned
+// leader keeps acting as the leader after another node is elected, until it's healed.
+type Partitions struct {
+	dir         string
+	lock        sync.Mutex
+	leader      string
+	nodes       map[string]bool
+	partitioned map[string]bool
+}
+
+// NewPartitions returns the partitions of the nodes whose leader files are in the directory
+func NewPartitions(dir string) *Partitions {
+	return &Partitions{
+		dir:         dir,
+		nodes:       map[string]bool{},
+		partitioned: map[string]bool{},
+	}
+}
+
+// Detector returns the file leader detector of the node
+func (p *Partitions) Detector(node string, pollInterval time.Duration) (*leader.Poller, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.nodes[node] = true
+	if err := p.write(node); err != nil {
+		return nil, err
+	}
+	return file.NewDetector(pollInterval, p.file(node), node)
+}
+
+// Elect makes the node the leader, as seen by the nodes that are not partitioned
+func (p *Partitions) Elect(node string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.leader = node
+	for n := range p.nodes {
+		if !p.partitioned[n] {
+			if err := p.write(n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Partition cuts the node off.  It keeps its view of the leader until healed.
+func (p *Partitions) Partition(node string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.partitioned[node] = true
+}
+
+// Heal reconnects the node.  It sees the current leader.
+func (p *Partitions) Heal(node string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.partitioned, node)
+	return p.write(node)
+}
+
+func (p *Partitions) file(node string) string {
+	return filepath.Join(p.dir, node+".leader")
+}
+
+func (p *Partitions) write(node string) error {
+	return ioutil.WriteFile(p.file(node), []byte(p.leader), 0644)
+}
@@ -3,6 +3,7 @@ package manager // import "github.com/docker/infrakit/pkg/testing/manager"
 import (
 	"net/url"
 
+	"github.com/docker/infrakit/pkg/spi/stack"
 	"github.com/docker/infrakit/pkg/types"
 )
 
@@ -26,6 +27,21 @@ type Plugin struct {
 
 	// DoTerminate destroys all resources associated with the specs
 	DoTerminate func(specs []types.Spec) error
+
+	// DoHistory returns the revisions of the specs
+	DoHistory func() ([]stack.Revision, error)
+
+	// DoRollback enforces the specs of the given revision
+	DoRollback func(revision int) error
+
+	// DoPlan returns the changes of enforcing the specs
+	DoPlan func(specs []types.Spec) (stack.Plan, error)
+
+	// DoFreeze stops the automated changes
+	DoFreeze func(reason string) error
+
+	// DoUnfreeze resumes the automated changes
+	DoUnfreeze func(reason string) error
 }
 
 // IsLeader returns true if manager is leader
@@ -57,3 +73,28 @@ func (t *Plugin) Inspect() ([]types.Object, error) {
 func (t *Plugin) Terminate(specs []types.Spec) error {
 	return t.DoTerminate(specs)
 }
+
+// History returns the revisions of the specs
+func (t *Plugin) History() ([]stack.Revision, error) {
+	return t.DoHistory()
+}
+
+// Rollback enforces the specs of the given revision
+func (t *Plugin) Rollback(revision int) error {
+	return t.DoRollback(revision)
+}
+
+// Plan returns the changes of enforcing the specs
+func (t *Plugin) Plan(specs []types.Spec) (stack.Plan, error) {
+	return t.DoPlan(specs)
+}
+
+// Freeze stops the automated changes
+func (t *Plugin) Freeze(reason string) error {
+	return t.DoFreeze(reason)
+}
+
+// Unfreeze resumes the automated changes
+func (t *Plugin) Unfreeze(reason string) error {
+	return t.DoUnfreeze(reason)
+}
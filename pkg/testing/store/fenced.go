@@ -0,0 +1,48 @@
+package store // import "github.com/docker/infrakit/pkg/testing/store"
+
+import (
+	"testing"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/stretchr/testify/require"
+)
+
+// Fencing checks that the snapshots, of two leaders of the same stored object, reject the writes of past
+// terms.  The old snapshot is of the leader of the term before the current one.  It's used with the
+// snapshots that implement store.Fenced.
+func Fencing(t *testing.T, old, current store.Snapshot) {
+	token, err := store.CurrentToken(old)
+	require.NoError(t, err)
+	require.Equal(t, store.Token(0), token)
+
+	require.NoError(t, store.Fence(old, 1))
+	require.NoError(t, store.WithToken(old, func() store.Token { return 1 }).Save("old"))
+
+	// the next term fences off the writes of the last
+	require.NoError(t, store.Fence(current, 2))
+	err = store.Fence(old, 2)
+	require.True(t, store.IsStaleToken(err))
+
+	err = store.WithToken(old, func() store.Token { return 1 }).Save("stale")
+	require.Error(t, err)
+	require.Equal(t, store.ErrStaleToken{Token: 1, Current: 2}, err)
+
+	require.NoError(t, store.WithToken(current, func() store.Token { return 2 }).Save("current"))
+
+	saved := ""
+	require.NoError(t, old.Load(&saved))
+	require.Equal(t, "current", saved)
+
+	// saves without a token are stale once a term started, unless not made with a token at all
+	err = store.WithToken(old, func() store.Token { return 0 }).Save("stale")
+	require.True(t, store.IsStaleToken(err))
+	require.NoError(t, old.Save("unfenced"))
+	require.True(t, store.IsFenced(store.WithToken(old, nil)))
+
+	require.NoError(t, current.Load(&saved))
+	require.Equal(t, "unfenced", saved)
+
+	token, err = store.CurrentToken(store.WithToken(old, nil))
+	require.NoError(t, err)
+	require.Equal(t, store.Token(2), token)
+}
@@ -132,10 +132,35 @@ type Metadata struct {
 	// Name is a user-friendly name.  It may or may not be unique.
 	Name string `json:"name"`
 
+	// Namespace is the namespace of the object, e.g. the team that owns it.  Names are unique
+	// within a namespace.  The default namespace is empty.
+	Namespace string `json:"namespace,omitempty" yaml:",omitempty"`
+
 	// Tags are a collection of labels, in key-value form, about the object
 	Tags map[string]string `json:"tags"`
 }
 
+// NamespaceSeparator separates the namespace and the name in a namespaced name, e.g. team-a::workers.  It marks
+// the name as namespaced, so that a name with a / like the ID of a group is not taken for a namespaced name.
+const NamespaceSeparator = "::"
+
+// Namespaced returns the name qualified by the namespace, or the name if the namespace is the default.
+func Namespaced(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + NamespaceSeparator + name
+}
+
+// SplitNamespaced returns the namespace and the name of a namespaced name.  The namespace is the default
+// if the name is not namespaced.
+func SplitNamespaced(namespaced string) (namespace, name string) {
+	if i := strings.Index(namespaced, NamespaceSeparator); i >= 0 {
+		return namespaced[:i], namespaced[i+len(NamespaceSeparator):]
+	}
+	return "", namespaced
+}
+
 // Fingerprint returns a unqiue key based on the content of this
 func (m Metadata) Fingerprint() string {
 	return Fingerprint(AnyValueMust(m))
@@ -147,6 +172,10 @@ func (m Metadata) Compare(other Metadata) int {
 		return m.Identity.Compare(*other.Identity)
 	}
 	switch {
+	case m.Namespace < other.Namespace:
+		return -1
+	case m.Namespace > other.Namespace:
+		return 1
 	case m.Name < other.Name:
 		return -1
 	case m.Name > other.Name:
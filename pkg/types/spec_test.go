@@ -235,3 +235,18 @@ func TestComparable(t *testing.T) {
 		}),
 	}))
 }
+
+func TestNamespaced(t *testing.T) {
+
+	require.Equal(t, "workers", Namespaced("", "workers"))
+	require.Equal(t, "team-a::workers", Namespaced("team-a", "workers"))
+
+	namespace, name := SplitNamespaced("team-a::workers")
+	require.Equal(t, "team-a", namespace)
+	require.Equal(t, "workers", name)
+
+	// a name with a / is not namespaced
+	namespace, name = SplitNamespaced("workers/a")
+	require.Equal(t, "", namespace)
+	require.Equal(t, "workers/a", name)
+}
@@ -12,17 +12,19 @@ type Specs []Spec
 // very different properties.  This is so we can compute the set difference not taking into account of actual
 // differences in embedded properties.
 type key struct {
-	kind    string
-	version string
-	name    string
-	id      string
+	kind      string
+	version   string
+	namespace string
+	name      string
+	id        string
 }
 
 func (s Spec) key() key {
 	k := key{
-		kind:    s.Kind,
-		version: s.Version,
-		name:    s.Metadata.Name,
+		kind:      s.Kind,
+		version:   s.Version,
+		namespace: s.Metadata.Namespace,
+		name:      s.Metadata.Name,
 	}
 	if s.Metadata.Identity != nil {
 		k.id = s.Metadata.Identity.ID
@@ -0,0 +1,83 @@
+package consul // import "github.com/docker/infrakit/pkg/util/consul"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultAddress is the address of the local Consul agent
+const DefaultAddress = "http://127.0.0.1:8500"
+
+// Options capture the Consul agent to connect to
+type Options struct {
+	// Address is the url of the Consul agent.  It's http if there's no scheme.
+	Address string
+
+	// Token is the ACL token, if ACLs are enabled
+	Token string
+}
+
+// Client is a minimal client of the HTTP API of the Consul agent
+type Client struct {
+	client  *http.Client
+	options Options
+}
+
+// NewClient returns a client of the agent of the options
+func NewClient(client *http.Client, options Options) *Client {
+	if options.Address == "" {
+		options.Address = DefaultAddress
+	}
+	if !strings.Contains(options.Address, "://") {
+		options.Address = "http://" + options.Address
+	}
+	options.Address = strings.TrimRight(options.Address, "/")
+	return &Client{client: client, options: options}
+}
+
+// Do sends the request to the path of the API and decodes the JSON response into out, if not nil.  If out
+// is a *[]byte, the response is copied as is.  It returns the status code, and an error if the status is
+// not 2xx.
+func (c *Client) Do(method, path string, query url.Values, body []byte, out interface{}) (int, error) {
+	u := c.options.Address + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	if c.options.Token != "" {
+		req.Header.Set("X-Consul-Token", c.options.Token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	buff, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message := strings.TrimSpace(string(buff))
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		return resp.StatusCode, fmt.Errorf("%v %v: %v", method, path, message)
+	}
+	if out == nil {
+		return resp.StatusCode, nil
+	}
+	if raw, is := out.(*[]byte); is {
+		*raw = buff
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.Unmarshal(buff, out)
+}
@@ -0,0 +1,60 @@
+package api // import "github.com/docker/infrakit/pkg/util/kubernetes/api"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// ObjectMeta is the metadata of the objects of the API server
+type ObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+// Do sends the object in, if not nil, as JSON to the url of the API server and decodes the response into out.
+// It returns the status code, and an error with the message of the status if the status is not 2xx.  This
+// package doesn't depend on client-go, so the http client comes from elsewhere, e.g. kubernetes.NewHTTPClient.
+func Do(client *http.Client, method, u string, in, out interface{}) (int, error) {
+	var body []byte
+	if in != nil {
+		buff, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = buff
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	buff, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		status := struct {
+			Message string `json:"message"`
+		}{}
+		if json.Unmarshal(buff, &status) != nil || status.Message == "" {
+			status.Message = http.StatusText(resp.StatusCode)
+		}
+		return resp.StatusCode, fmt.Errorf("%v %v: %v", method, u, status.Message)
+	}
+	return resp.StatusCode, json.Unmarshal(buff, out)
+}
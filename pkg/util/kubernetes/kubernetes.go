@@ -0,0 +1,40 @@
+package kubernetes // import "github.com/docker/infrakit/pkg/util/kubernetes"
+
+import (
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ConnectInfo holds the connection parameters for connecting to the Kubernetes API server.  If both are
+// empty, the in-cluster config of the pod's service account is used.
+type ConnectInfo struct {
+	// Master is the address of the API server
+	Master string
+
+	// Kubeconfig is the path to the kubeconfig file
+	Kubeconfig string
+}
+
+// NewHTTPClient returns the url of the API server and a http client that is authenticated to it.
+func NewHTTPClient(info ConnectInfo) (string, *http.Client, error) {
+	config, err := clientcmd.BuildConfigFromFlags(info.Master, info.Kubeconfig)
+	if err != nil {
+		return "", nil, err
+	}
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return "", nil, err
+	}
+	host := config.Host
+	if !strings.Contains(host, "://") {
+		if rest.IsConfigTransportTLS(*config) {
+			host = "https://" + host
+		} else {
+			host = "http://" + host
+		}
+	}
+	return host, &http.Client{Transport: transport, Timeout: config.Timeout}, nil
+}
@@ -0,0 +1,122 @@
+package raft // import "github.com/docker/infrakit/pkg/util/raft"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler returns the admin endpoint of the node, for the membership of the group:
+//
+//	GET /members        lists the members
+//	POST /members       adds the member in the body, as the Member json
+//	DELETE /members/id  removes the member
+//
+// Changes are made on the leader.  The other nodes return the conflict status and the leader's address.
+func (n *Node) Handler() http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/members", func(resp http.ResponseWriter, req *http.Request) {
+		members, err := n.Members()
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(resp).Encode(members)
+	}).Methods("GET")
+	router.HandleFunc("/members", func(resp http.ResponseWriter, req *http.Request) {
+		member := Member{}
+		if err := json.NewDecoder(req.Body).Decode(&member); err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if member.ID == "" || member.Address == "" {
+			http.Error(resp, "no id or address", http.StatusBadRequest)
+			return
+		}
+		writeError(resp, n.Join(member.ID, member.Address))
+	}).Methods("POST")
+	router.HandleFunc("/members/{id}", func(resp http.ResponseWriter, req *http.Request) {
+		writeError(resp, n.Remove(mux.Vars(req)["id"]))
+	}).Methods("DELETE")
+	return router
+}
+
+func writeError(resp http.ResponseWriter, err error) {
+	switch err.(type) {
+	case nil:
+		resp.WriteHeader(http.StatusOK)
+	case ErrNotLeader:
+		http.Error(resp, err.Error(), http.StatusConflict)
+	default:
+		http.Error(resp, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Admin is the client of the admin endpoint of a node
+type Admin struct {
+	// URL is the url of the admin endpoint, e.g. http://10.0.0.1:24866
+	URL string
+
+	// Client is the http client.  Defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// Members returns the members of the group
+func (a *Admin) Members() ([]Member, error) {
+	body, err := a.do("GET", "/members", nil)
+	if err != nil {
+		return nil, err
+	}
+	members := []Member{}
+	return members, json.Unmarshal(body, &members)
+}
+
+// Join adds the node as a voting member
+func (a *Admin) Join(id, address string) error {
+	body, err := json.Marshal(Member{ID: id, Address: address})
+	if err != nil {
+		return err
+	}
+	_, err = a.do("POST", "/members", body)
+	return err
+}
+
+// Remove removes the member
+func (a *Admin) Remove(id string) error {
+	_, err := a.do("DELETE", "/members/"+id, nil)
+	return err
+}
+
+func (a *Admin) do(method, path string, body []byte) ([]byte, error) {
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	url := strings.TrimRight(a.URL, "/") + path
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	buff, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s", method, url, strings.TrimSpace(string(buff)))
+	}
+	return buff, nil
+}
@@ -0,0 +1,160 @@
+package raft // import "github.com/docker/infrakit/pkg/util/raft"
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/raft"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	logsBucket   = []byte("logs")
+	stableBucket = []byte("stable")
+
+	// errKeyNotFound is the error raft expects of a stable store without the key
+	errKeyNotFound = errors.New("not found")
+)
+
+// boltStore keeps the log entries and the stable state (term, vote) of the node in a bolt database.
+// It implements raft.LogStore and raft.StableStore.
+type boltStore struct {
+	db *bolt.DB
+}
+
+func newBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(logsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(stableBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltStore{db: db}, nil
+}
+
+// Close closes the database
+func (b *boltStore) Close() error {
+	return b.db.Close()
+}
+
+func uint64Bytes(v uint64) []byte {
+	buff := make([]byte, 8)
+	binary.BigEndian.PutUint64(buff, v)
+	return buff
+}
+
+// FirstIndex implements raft.LogStore
+func (b *boltStore) FirstIndex() (index uint64, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket(logsBucket).Cursor().First(); k != nil {
+			index = binary.BigEndian.Uint64(k)
+		}
+		return nil
+	})
+	return
+}
+
+// LastIndex implements raft.LogStore
+func (b *boltStore) LastIndex() (index uint64, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket(logsBucket).Cursor().Last(); k != nil {
+			index = binary.BigEndian.Uint64(k)
+		}
+		return nil
+	})
+	return
+}
+
+// GetLog implements raft.LogStore
+func (b *boltStore) GetLog(index uint64, log *raft.Log) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(logsBucket).Get(uint64Bytes(index))
+		if v == nil {
+			return raft.ErrLogNotFound
+		}
+		return json.Unmarshal(v, log)
+	})
+}
+
+// StoreLog implements raft.LogStore
+func (b *boltStore) StoreLog(log *raft.Log) error {
+	return b.StoreLogs([]*raft.Log{log})
+}
+
+// StoreLogs implements raft.LogStore
+func (b *boltStore) StoreLogs(logs []*raft.Log) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(logsBucket)
+		for _, log := range logs {
+			v, err := json.Marshal(log)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(uint64Bytes(log.Index), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteRange implements raft.LogStore
+func (b *boltStore) DeleteRange(min, max uint64) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(logsBucket).Cursor()
+		for k, _ := cursor.Seek(uint64Bytes(min)); k != nil; k, _ = cursor.Next() {
+			if binary.BigEndian.Uint64(k) > max {
+				break
+			}
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Set implements raft.StableStore
+func (b *boltStore) Set(key []byte, val []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(stableBucket).Put(key, val)
+	})
+}
+
+// Get implements raft.StableStore
+func (b *boltStore) Get(key []byte) (val []byte, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(stableBucket).Get(key)
+		if v == nil {
+			return errKeyNotFound
+		}
+		val = append([]byte{}, v...)
+		return nil
+	})
+	return
+}
+
+// SetUint64 implements raft.StableStore
+func (b *boltStore) SetUint64(key []byte, val uint64) error {
+	return b.Set(key, uint64Bytes(val))
+}
+
+// GetUint64 implements raft.StableStore
+func (b *boltStore) GetUint64(key []byte) (uint64, error) {
+	v, err := b.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
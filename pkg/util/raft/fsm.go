@@ -0,0 +1,151 @@
+package raft // import "github.com/docker/infrakit/pkg/util/raft"
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/hashicorp/raft"
+)
+
+const (
+	opPut    = "put"
+	opDelete = "delete"
+	opFence  = "fence"
+)
+
+// command is a change to the key space, as the data of a log entry
+type command struct {
+	Op    string
+	Key   string
+	Value []byte `json:",omitempty"`
+
+	// TokenKey is the key of the fencing token that the write is checked against, if fenced
+	TokenKey string `json:",omitempty"`
+
+	// Token is the fencing token of the write
+	Token store.Token `json:",omitempty"`
+}
+
+// fsm is the key space replicated by the log.  It implements raft.FSM.
+type fsm struct {
+	lock sync.RWMutex
+	kv   map[string][]byte
+}
+
+func newFSM() *fsm {
+	return &fsm{kv: map[string][]byte{}}
+}
+
+// Apply implements raft.FSM.  It returns the error of the command, if any.
+func (f *fsm) Apply(entry *raft.Log) interface{} {
+	c := command{}
+	if err := json.Unmarshal(entry.Data, &c); err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch c.Op {
+	case opPut:
+		if c.TokenKey != "" {
+			current, err := f.token(c.TokenKey)
+			if err != nil {
+				return err
+			}
+			if c.Token < current {
+				return store.ErrStaleToken{Token: c.Token, Current: current}
+			}
+		}
+		f.kv[c.Key] = c.Value
+	case opDelete:
+		delete(f.kv, c.Key)
+	case opFence:
+		current, err := f.token(c.Key)
+		if err != nil {
+			return err
+		}
+		if c.Token <= current {
+			return store.ErrStaleToken{Token: c.Token, Current: current}
+		}
+		f.kv[c.Key] = []byte(strconv.FormatUint(uint64(c.Token), 10))
+	default:
+		return fmt.Errorf("unknown op %v", c.Op)
+	}
+	return nil
+}
+
+// token returns the fencing token at the key, 0 if none.  The lock must be held.
+func (f *fsm) token(key string) (store.Token, error) {
+	v, has := f.kv[key]
+	if !has {
+		return 0, nil
+	}
+	token, err := strconv.ParseUint(string(v), 10, 64)
+	return store.Token(token), err
+}
+
+func (f *fsm) get(key string) ([]byte, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	v, has := f.kv[key]
+	return v, has
+}
+
+// keys returns the keys with the prefix, sorted
+func (f *fsm) keys(prefix string) []string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	keys := []string{}
+	for k := range f.kv {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Snapshot implements raft.FSM
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	kv := map[string][]byte{}
+	for k, v := range f.kv {
+		kv[k] = v
+	}
+	return fsmSnapshot(kv), nil
+}
+
+// Restore implements raft.FSM
+func (f *fsm) Restore(snapshot io.ReadCloser) error {
+	defer snapshot.Close()
+	kv := map[string][]byte{}
+	if err := json.NewDecoder(snapshot).Decode(&kv); err != nil {
+		return err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.kv = kv
+	return nil
+}
+
+type fsmSnapshot map[string][]byte
+
+// Persist implements raft.FSMSnapshot
+func (s fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	if err := json.NewEncoder(sink).Encode(s); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+// Release implements raft.FSMSnapshot
+func (s fsmSnapshot) Release() {}
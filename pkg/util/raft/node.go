@@ -0,0 +1,373 @@
+package raft // import "github.com/docker/infrakit/pkg/util/raft"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	logutil "github.com/docker/infrakit/pkg/log"
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/hashicorp/raft"
+)
+
+var log = logutil.New("module", "util/raft")
+
+const (
+	// DefaultTimeout is the default heartbeat and election timeout
+	DefaultTimeout = 1 * time.Second
+
+	// DefaultRequestTimeout is the default timeout of writes and membership changes
+	DefaultRequestTimeout = 5 * time.Second
+)
+
+// Options is the configuration of a node
+type Options struct {
+	// ID is the id of the node in the group.  It must be unique and not change across restarts.
+	ID string
+
+	// Dir is the directory of the log, the snapshots and the stable state of the node
+	Dir string
+
+	// Listen is the address of the transport between the nodes, e.g. :24865
+	Listen string
+
+	// Advertise is the address that the other nodes reach this node at, e.g. 10.0.0.1:24865.
+	// Defaults to the address listened at, which must then not be unspecified.
+	Advertise string
+
+	// Bootstrap is set to start a new group of this node alone, if the node has no state yet.
+	// Other nodes join it with Join.
+	Bootstrap bool
+
+	// Join are the urls of the admin endpoints of members to ask to add this node, if it has no state yet
+	Join []string
+
+	// Timeout is the heartbeat and election timeout
+	Timeout time.Duration
+
+	// RequestTimeout is the timeout of writes and membership changes
+	RequestTimeout time.Duration
+}
+
+// Member is a member of the group
+type Member struct {
+	// ID is the id of the node
+	ID string
+
+	// Address is the address of the transport of the node
+	Address string
+
+	// Leader is true if the node is the leader
+	Leader bool
+}
+
+// ErrNotLeader is the error of the writes and membership changes made on a node that's not the leader
+type ErrNotLeader struct {
+	// Leader is the address of the leader, if known
+	Leader string
+}
+
+// Error implements error
+func (e ErrNotLeader) Error() string {
+	if e.Leader == "" {
+		return "not the leader, no leader is known"
+	}
+	return fmt.Sprintf("not the leader, the leader is at %s", e.Leader)
+}
+
+// Node is a member of a group of nodes that replicate a key space with Raft.
+type Node struct {
+	Options Options
+
+	raft      *raft.Raft
+	fsm       *fsm
+	logs      *boltStore
+	transport *raft.NetworkTransport
+	notify    chan bool
+	stop      chan struct{}
+
+	lock  sync.Mutex
+	term  int
+	ready bool
+}
+
+// NewNode starts the node.  Its state is recovered from the directory, if any.
+func NewNode(options Options) (*Node, error) {
+	if options.ID == "" {
+		return nil, fmt.Errorf("no id")
+	}
+	if options.Timeout == 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.RequestTimeout == 0 {
+		options.RequestTimeout = DefaultRequestTimeout
+	}
+	if err := os.MkdirAll(options.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var advertise net.Addr
+	if options.Advertise != "" {
+		addr, err := net.ResolveTCPAddr("tcp", options.Advertise)
+		if err != nil {
+			return nil, err
+		}
+		advertise = addr
+	}
+
+	logs, err := newBoltStore(filepath.Join(options.Dir, "raft.db"))
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := raft.NewFileSnapshotStore(options.Dir, 2, logWriter{})
+	if err != nil {
+		logs.Close()
+		return nil, err
+	}
+	transport, err := raft.NewTCPTransport(options.Listen, advertise, 3, 10*time.Second, logWriter{})
+	if err != nil {
+		logs.Close()
+		return nil, err
+	}
+
+	node := &Node{
+		Options:   options,
+		fsm:       newFSM(),
+		logs:      logs,
+		transport: transport,
+		notify:    make(chan bool, 1),
+		stop:      make(chan struct{}),
+	}
+
+	config := raft.DefaultConfig()
+	config.LocalID = raft.ServerID(options.ID)
+	config.HeartbeatTimeout = options.Timeout
+	config.ElectionTimeout = options.Timeout
+	config.LeaderLeaseTimeout = options.Timeout / 2
+	config.NotifyCh = node.notify
+	config.LogOutput = logWriter{}
+
+	existing, err := raft.HasExistingState(logs, logs, snapshots)
+	if err != nil {
+		node.close()
+		return nil, err
+	}
+
+	if options.Bootstrap && !existing {
+		log.Info("Bootstrapping", "id", options.ID, "address", transport.LocalAddr())
+		err := raft.BootstrapCluster(config, logs, logs, snapshots, transport, raft.Configuration{
+			Servers: []raft.Server{
+				{
+					Suffrage: raft.Voter,
+					ID:       config.LocalID,
+					Address:  transport.LocalAddr(),
+				},
+			},
+		})
+		if err != nil {
+			node.close()
+			return nil, err
+		}
+	}
+
+	r, err := raft.NewRaft(config, node.fsm, logs, logs, snapshots, transport)
+	if err != nil {
+		node.close()
+		return nil, err
+	}
+	node.raft = r
+
+	go node.watch()
+
+	if len(options.Join) > 0 && !existing {
+		go node.join()
+	}
+
+	log.Info("Started", "id", options.ID, "address", transport.LocalAddr(), "existing", existing)
+	return node, nil
+}
+
+// ID returns the id of the node
+func (n *Node) ID() string {
+	return n.Options.ID
+}
+
+// Address returns the address of the transport of the node
+func (n *Node) Address() string {
+	return string(n.transport.LocalAddr())
+}
+
+// watch follows the leadership of the node.  The node is reported as the leader only once it has applied the
+// entries committed in the past terms, so that the key space read on the leader is current.
+func (n *Node) watch() {
+	for {
+		select {
+		case <-n.stop:
+			return
+		case leader := <-n.notify:
+			n.lock.Lock()
+			n.term++
+			n.ready = false
+			term := n.term
+			n.lock.Unlock()
+
+			if leader {
+				go func() {
+					err := n.raft.Barrier(n.Options.RequestTimeout).Error()
+					n.lock.Lock()
+					defer n.lock.Unlock()
+					if err != nil {
+						log.Warn("Cannot apply the past entries as the leader", "id", n.ID(), "err", err)
+						return
+					}
+					n.ready = n.term == term
+				}()
+			}
+		}
+	}
+}
+
+// join asks the members to add this node, until one does
+func (n *Node) join() {
+	for {
+		for _, url := range n.Options.Join {
+			err := (&Admin{URL: url}).Join(n.ID(), n.Address())
+			if err == nil {
+				log.Info("Joined", "id", n.ID(), "url", url)
+				return
+			}
+			log.Warn("Cannot join", "id", n.ID(), "url", url, "err", err)
+		}
+		select {
+		case <-n.stop:
+			return
+		case <-time.After(n.Options.Timeout):
+		}
+	}
+}
+
+// IsLeader returns true if the node is the leader
+func (n *Node) IsLeader() bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.ready && n.raft.State() == raft.Leader
+}
+
+// Get returns the value at the key in the key space of the node.  On the leader, it's the current value.
+func (n *Node) Get(key string) ([]byte, bool) {
+	return n.fsm.get(key)
+}
+
+// Keys returns the keys with the prefix, sorted
+func (n *Node) Keys(prefix string) []string {
+	return n.fsm.keys(prefix)
+}
+
+// Token returns the fencing token at the key, 0 if none
+func (n *Node) Token(key string) (store.Token, error) {
+	n.fsm.lock.RLock()
+	defer n.fsm.lock.RUnlock()
+	return n.fsm.token(key)
+}
+
+// Put writes the value at the key.  It must be called on the leader.
+func (n *Node) Put(key string, value []byte) error {
+	return n.apply(command{Op: opPut, Key: key, Value: value})
+}
+
+// PutWithToken writes the value at the key, if the token is not less than the one at the token key.
+// Otherwise store.ErrStaleToken is returned.  It must be called on the leader.
+func (n *Node) PutWithToken(tokenKey string, token store.Token, key string, value []byte) error {
+	return n.apply(command{Op: opPut, Key: key, Value: value, TokenKey: tokenKey, Token: token})
+}
+
+// Fence writes the token at the key, if it's greater than the one there.  Otherwise store.ErrStaleToken
+// is returned.  It must be called on the leader.
+func (n *Node) Fence(key string, token store.Token) error {
+	return n.apply(command{Op: opFence, Key: key, Token: token})
+}
+
+// Delete deletes the key.  It must be called on the leader.
+func (n *Node) Delete(key string) error {
+	return n.apply(command{Op: opDelete, Key: key})
+}
+
+func (n *Node) apply(c command) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	future := n.raft.Apply(data, n.Options.RequestTimeout)
+	if err := n.checkLeader(future.Error()); err != nil {
+		return err
+	}
+	if err, is := future.Response().(error); is {
+		return err
+	}
+	return nil
+}
+
+func (n *Node) checkLeader(err error) error {
+	if err == raft.ErrNotLeader {
+		return ErrNotLeader{Leader: string(n.raft.Leader())}
+	}
+	return err
+}
+
+// Members returns the members of the group
+func (n *Node) Members() ([]Member, error) {
+	future := n.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return nil, err
+	}
+	leader := n.raft.Leader()
+	members := []Member{}
+	for _, server := range future.Configuration().Servers {
+		members = append(members, Member{
+			ID:      string(server.ID),
+			Address: string(server.Address),
+			Leader:  server.Address == leader,
+		})
+	}
+	return members, nil
+}
+
+// Join adds the node as a voting member.  It must be called on the leader.
+func (n *Node) Join(id, address string) error {
+	log.Info("Adding member", "id", id, "address", address)
+	return n.checkLeader(n.raft.AddVoter(raft.ServerID(id), raft.ServerAddress(address), 0,
+		n.Options.RequestTimeout).Error())
+}
+
+// Remove removes the member.  It must be called on the leader.
+func (n *Node) Remove(id string) error {
+	log.Info("Removing member", "id", id)
+	return n.checkLeader(n.raft.RemoveServer(raft.ServerID(id), 0, n.Options.RequestTimeout).Error())
+}
+
+// Close stops the node.  The node keeps its membership in the group.
+func (n *Node) Close() error {
+	close(n.stop)
+	err := n.raft.Shutdown().Error()
+	n.close()
+	return err
+}
+
+func (n *Node) close() {
+	n.transport.Close()
+	n.logs.Close()
+}
+
+// logWriter writes the logs of raft to the log of the package
+type logWriter struct{}
+
+// Write implements io.Writer
+func (logWriter) Write(p []byte) (int, error) {
+	log.Debug(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
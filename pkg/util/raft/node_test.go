@@ -0,0 +1,161 @@
+package raft // import "github.com/docker/infrakit/pkg/util/raft"
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/infrakit/pkg/store"
+	"github.com/stretchr/testify/require"
+)
+
+func startNode(t *testing.T, dir, id string, bootstrap bool, join ...string) *Node {
+	node, err := NewNode(Options{
+		ID:             id,
+		Dir:            filepath.Join(dir, id),
+		Listen:         "127.0.0.1:0",
+		Bootstrap:      bootstrap,
+		Join:           join,
+		Timeout:        100 * time.Millisecond,
+		RequestTimeout: 2 * time.Second,
+	})
+	require.NoError(t, err)
+	return node
+}
+
+func waitFor(t *testing.T, check func() bool) {
+	deadline := time.Now().Add(10 * time.Second)
+	for !check() {
+		if time.Now().After(deadline) {
+			require.FailNow(t, "timed out")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func waitForLeader(t *testing.T, nodes ...*Node) (leader *Node) {
+	waitFor(t, func() bool {
+		for _, n := range nodes {
+			if n.IsLeader() {
+				leader = n
+				return true
+			}
+		}
+		return false
+	})
+	return
+}
+
+func TestThreeNodes(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "raft")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	n1 := startNode(t, dir, "n1", true)
+	require.Equal(t, n1, waitForLeader(t, n1))
+
+	admin := httptest.NewServer(n1.Handler())
+	defer admin.Close()
+
+	// n2 joins with the admin endpoint of n1, n3 is added by the admin
+	n2 := startNode(t, dir, "n2", false, admin.URL)
+	n3 := startNode(t, dir, "n3", false)
+	require.NoError(t, (&Admin{URL: admin.URL}).Join("n3", n3.Address()))
+
+	waitFor(t, func() bool {
+		members, err := (&Admin{URL: admin.URL}).Members()
+		return err == nil && len(members) == 3
+	})
+
+	require.NoError(t, n1.Put("k", []byte("v1")))
+	waitFor(t, func() bool {
+		v2, _ := n2.Get("k")
+		v3, _ := n3.Get("k")
+		return string(v2) == "v1" && string(v3) == "v1"
+	})
+
+	// only the leader writes
+	err = n2.Put("k", []byte("v2"))
+	require.Equal(t, ErrNotLeader{Leader: n1.Address()}, err)
+
+	// the members are changed on the leader too
+	follower := httptest.NewServer(n2.Handler())
+	defer follower.Close()
+	err = (&Admin{URL: follower.URL}).Remove("n1")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not the leader")
+
+	// another leader is elected when the leader stops
+	require.NoError(t, n1.Close())
+	leader := waitForLeader(t, n2, n3)
+
+	v, has := leader.Get("k")
+	require.True(t, has)
+	require.Equal(t, "v1", string(v))
+
+	require.NoError(t, leader.Remove("n1"))
+	members, err := leader.Members()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(members))
+	for _, m := range members {
+		require.NotEqual(t, "n1", m.ID)
+		require.Equal(t, m.ID == leader.ID(), m.Leader)
+	}
+
+	require.NoError(t, leader.Put("k", []byte("v3")))
+	require.NoError(t, leader.Delete("k"))
+	require.Equal(t, []string{}, leader.Keys("k"))
+
+	require.NoError(t, n2.Close())
+	require.NoError(t, n3.Close())
+}
+
+func TestRestart(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "raft")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	n1 := startNode(t, dir, "n1", true)
+	waitForLeader(t, n1)
+	require.NoError(t, n1.Put("a/1", []byte("1")))
+	require.NoError(t, n1.Put("a/2", []byte("2")))
+	require.NoError(t, n1.Put("b/1", []byte("3")))
+	require.NoError(t, n1.Close())
+
+	// the state is recovered from the log, bootstrapping is skipped
+	n1 = startNode(t, dir, "n1", true)
+	defer n1.Close()
+	waitForLeader(t, n1)
+	require.Equal(t, []string{"a/1", "a/2"}, n1.Keys("a/"))
+}
+
+func TestFencing(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "raft")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	n1 := startNode(t, dir, "n1", true)
+	defer n1.Close()
+	waitForLeader(t, n1)
+
+	require.NoError(t, n1.Fence("k.token", 2))
+	err = n1.Fence("k.token", 2)
+	require.Equal(t, store.ErrStaleToken{Token: 2, Current: 2}, err)
+
+	require.NoError(t, n1.PutWithToken("k.token", 2, "k", []byte("v")))
+	err = n1.PutWithToken("k.token", 1, "k", []byte("stale"))
+	require.True(t, store.IsStaleToken(err))
+
+	v, _ := n1.Get("k")
+	require.Equal(t, "v", string(v))
+
+	token, err := n1.Token("k.token")
+	require.NoError(t, err)
+	require.Equal(t, store.Token(2), token)
+}